@@ -3,6 +3,7 @@ package main
 import (
 	"can-db-writer/internal/api"
 	"can-db-writer/internal/config"
+	"can-db-writer/internal/models"
 	"context"
 	"flag"
 	"log"
@@ -30,15 +31,40 @@ func main() {
 
 	// Create API server configuration
 	serverConfig := api.ServerConfig{
-		Port:         cfg.APIPort,
-		GRPCPort:     cfg.GRPCPort,
-		CHHost:       cfg.ClickHouseHost,
-		CHPort:       cfg.ClickHousePort,
-		CHDatabase:   cfg.ClickHouseDatabase,
-		CHUsername:   cfg.ClickHouseUsername,
-		CHPassword:   cfg.ClickHousePassword,
-		CHTable:      cfg.ClickHouseTable,
-		CHStatsTable: cfg.ClickHouseStatsTable,
+		Port:               cfg.APIPort,
+		GRPCPort:           cfg.GRPCPort,
+		CHHost:             cfg.ClickHouseHost,
+		CHPort:             cfg.ClickHousePort,
+		CHDatabase:         cfg.ClickHouseDatabase,
+		CHUsername:         cfg.ClickHouseUsername,
+		CHPassword:         cfg.ClickHousePassword,
+		CHTable:            cfg.ClickHouseTable,
+		CHStatsTable:       cfg.ClickHouseStatsTable,
+		CHEventsTable:      cfg.ClickHouseEventsTable,
+		CHUDSTable:         cfg.ClickHouseUDSTable,
+		CHProtocolTable:    cfg.ClickHouseProtocolTable,
+		CHSignalsTable:     cfg.ClickHouseSignalsTable,
+		CHCapturesTable:    cfg.ClickHouseCapturesTable,
+		CHAlertsTable:      cfg.ClickHouseAlertsTable,
+		CHRulesTable:       cfg.ClickHouseAlertRulesTable,
+		CHSessionsTable:    cfg.ClickHouseSessionsTable,
+		CHAnnotationsTable: cfg.ClickHouseAnnotationsTable,
+		CHReplicationTable: cfg.ClickHouseReplicationTable,
+		CHNMTAuditTable:    cfg.ClickHouseNMTAuditTable,
+		TxInterfaces:       cfg.CANTxInterfaces,
+		TxOperators:        cfg.CANTxOperators,
+		Producers:          cfg.GRPCProducers,
+		GRPCTLSCert:        cfg.GRPCTLSCert,
+		GRPCTLSKey:         cfg.GRPCTLSKey,
+		Device: models.Device{
+			DeviceID:   cfg.DeviceID,
+			Site:       cfg.DeviceSite,
+			HWRevision: cfg.DeviceHWRevision,
+		},
+		EMCYTables:     cfg.EMCYTables,
+		EDSDir:         cfg.EDSDir,
+		DriveNodes:     cfg.DriveNodes,
+		J1939Databases: cfg.J1939Databases,
 	}
 
 	// Create and start API server
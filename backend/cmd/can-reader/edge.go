@@ -0,0 +1,85 @@
+package main
+
+import (
+	"can-db-writer/internal/can"
+	"can-db-writer/internal/config"
+	"can-db-writer/internal/edge"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// runEdge runs the reader in edge mode: frames are appended to the local store and replicated
+// to the central API server in the background, without a ClickHouse connection
+// Only frames are stored: statistics, events, UDS, signals, alerts, captures, sessions and the live
+// stream need ClickHouse and are not set up, and transmitted frames are replicated as received.
+func runEdge(cfg *config.Config, canReader *can.Reader) error {
+	store, err := edge.OpenStore(cfg.EdgeStoreDir, int64(cfg.EdgeStoreMaxMB)*1024*1024)
+	if err != nil {
+		return fmt.Errorf("failed to open edge store: %w", err)
+	}
+	defer store.Close()
+
+	creds := edge.Credentials{Token: cfg.EdgeToken, TLS: cfg.EdgeTLS, CAFile: cfg.EdgeTLSCA}
+	if creds.Token == "" {
+		log.Printf("Warning: EDGE_TOKEN is not set, the central server rejects the replication")
+	} else if !creds.Secure() {
+		log.Printf("Warning: The replication token is sent without TLS, set EDGE_TLS or EDGE_TLS_CA")
+	}
+
+	replicator, err := edge.NewReplicator(store, cfg.EdgeCentralAddr, creds, deviceIdentity(cfg), cfg.EdgeBatchSize, cfg.EdgeBandwidthLimit*1024)
+	if err != nil {
+		return err
+	}
+	replicator.Start()
+	defer replicator.Stop()
+
+	log.Printf("Edge mode: storing frames in %s (store %s, %d pending), replicating to %s",
+		cfg.EdgeStoreDir, store.ID(), store.Pending(), cfg.EdgeCentralAddr)
+	log.Printf("Edge mode records frames only: statistics, events, UDS, signal decoding, alerts, captures, " +
+		"recording sessions and the live stream are disabled")
+
+	canReader.Start()
+	log.Println("Bridge started successfully. Press Ctrl+C to stop.")
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	// Buffered frames are synced to disk every second
+	flushTicker := time.NewTicker(time.Second)
+	defer flushTicker.Stop()
+
+	var messageCount uint64
+	var errorCount uint64
+	for {
+		select {
+		case msg := <-canReader.GetMessageChannel():
+			messageCount++
+			if _, err := store.Append(msg); err != nil {
+				errorCount++
+				log.Printf("Warning: Failed to store frame: %v", err)
+			}
+
+			if messageCount%1000 == 0 {
+				log.Printf("Stored %d messages (errors: %d, pending replication: %d)", messageCount, errorCount, store.Pending())
+			}
+
+		case err := <-canReader.GetErrorChannel():
+			errorCount++
+			log.Printf("CAN error: %v", err)
+
+		case <-flushTicker.C:
+			if err := store.Flush(); err != nil {
+				log.Printf("Warning: Failed to flush edge store: %v", err)
+			}
+
+		case <-sigChan:
+			log.Println("\nShutting down...")
+			log.Printf("Final statistics: %d messages stored, %d errors, %d pending replication", messageCount, errorCount, store.Pending())
+			return nil
+		}
+	}
+}
@@ -4,10 +4,16 @@ import (
 	"can-db-writer/internal/can"
 	"can-db-writer/internal/config"
 	"can-db-writer/internal/database/clickhouse"
+	"can-db-writer/internal/models"
+	"can-db-writer/internal/notify"
+	"can-db-writer/internal/stream"
+	"context"
 	"flag"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
 	"time"
 )
@@ -23,8 +29,17 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	// Session subcommand: can-reader [-env .env] session start|stop [flags]
+	if flag.Arg(0) == "session" {
+		if err := runSession(cfg, flag.Args()[1:]); err != nil {
+			log.Fatalf("Session command failed: %v", err)
+		}
+		return
+	}
+
 	log.Printf("Starting CAN to Database bridge...")
 	log.Printf("CAN Interface: %s", cfg.CANInterface)
+	log.Printf("Device: %s (site: %s, hardware revision: %s)", cfg.DeviceID, cfg.DeviceSite, cfg.DeviceHWRevision)
 	log.Printf("ClickHouse: %s:%d/%s.%s", cfg.ClickHouseHost, cfg.ClickHousePort, cfg.ClickHouseDatabase, cfg.ClickHouseTable)
 
 	// Create CAN reader
@@ -44,6 +59,14 @@ func main() {
 		}
 	}
 
+	// Edge mode: store frames locally and replicate them to the central server instead of ClickHouse
+	if cfg.EdgeStoreDir != "" {
+		if err := runEdge(cfg, canReader); err != nil {
+			log.Fatalf("Edge mode failed: %v", err)
+		}
+		return
+	}
+
 	// Create ClickHouse writer
 	chConfig := clickhouse.Config{
 		Host:     cfg.ClickHouseHost,
@@ -74,10 +97,224 @@ func main() {
 	statsCollector.Start()
 	defer statsCollector.Stop()
 
+	// Create events table and writer
+	err = clickhouse.CreateEventsTable(chWriter.GetConn(), cfg.ClickHouseEventsTable)
+	if err != nil {
+		log.Fatalf("Failed to create events table: %v", err)
+	}
+
+	eventWriter := clickhouse.NewEventWriter(chWriter.GetConn(), cfg.BatchSize/10)
+	defer eventWriter.Close()
+
+	// Create UDS transactions table and writer
+	err = clickhouse.CreateUDSTable(chWriter.GetConn(), cfg.ClickHouseUDSTable)
+	if err != nil {
+		log.Fatalf("Failed to create UDS transactions table: %v", err)
+	}
+
+	udsWriter := clickhouse.NewUDSWriter(chWriter.GetConn(), cfg.BatchSize/10)
+	defer udsWriter.Close()
+
+	// Create and start UDS monitor for the configured ISO-TP pairs
+	udsPairs := []models.ISOTPPair{}
+	for _, pairStr := range cfg.UDSPairs {
+		pair, err := models.ParseISOTPPair(pairStr)
+		if err != nil {
+			log.Fatalf("Invalid UDS_PAIRS: %v", err)
+		}
+		udsPairs = append(udsPairs, pair)
+		log.Printf("Decoding UDS diagnostics on request 0x%X / response 0x%X", pair.RequestID, pair.ResponseID)
+	}
+
+	udsMonitor := can.NewUDSMonitor(udsPairs)
+	udsMonitor.Start()
+	defer udsMonitor.Stop()
+
+	// Load EDS/DCF files for heartbeat consumer times and PDO signal mappings
+	edsRegistry, err := models.LoadEDSRegistry(cfg.EDSDir)
+	if err != nil {
+		log.Printf("Warning: Failed to load EDS/DCF files: %v", err)
+		edsRegistry = models.NewEDSRegistry()
+	}
+
+	// Heartbeat consumer times: DCF values (0x1016, or 0x1017 x tolerance), overridden by HEARTBEAT_CONSUMERS
+	consumerTimes := edsRegistry.HeartbeatConsumerTimes(cfg.HeartbeatTolerance)
+	for nodeID, ms := range cfg.HeartbeatConsumers {
+		consumerTimes[nodeID] = time.Duration(ms) * time.Millisecond
+	}
+	for nodeID, consumerTime := range consumerTimes {
+		log.Printf("Monitoring heartbeat of node %d (consumer time %v)", nodeID, consumerTime)
+	}
+
+	// Create and start heartbeat monitor
+	hbMonitor := can.NewHeartbeatMonitor(cfg.CANInterface, consumerTimes)
+	hbMonitor.Start()
+	defer hbMonitor.Stop()
+
+	// Create signals table, writer and decoder for decode-on-ingest
+	var signalDecoder *models.SignalDecoder
+	var signalWriter *clickhouse.SignalWriter
+	if cfg.SignalDecode {
+		err = clickhouse.CreateSignalsTable(chWriter.GetConn(), cfg.ClickHouseSignalsTable)
+		if err != nil {
+			log.Fatalf("Failed to create signals table: %v", err)
+		}
+
+		signalWriter = clickhouse.NewSignalWriter(chWriter.GetConn(), cfg.BatchSize)
+		defer signalWriter.Close()
+
+		// PDO mappings from the DCFs and the CiA 402 defaults of drives without one, then the signal files
+		pdoMap := models.NewPDOObjectMap()
+		for _, nodeID := range edsRegistry.Nodes() {
+			file, _ := edsRegistry.Get(nodeID)
+			pdoMap.AddEDS(nodeID, file)
+		}
+		for _, nodeID := range cfg.DriveNodes {
+			if _, ok := edsRegistry.Get(nodeID); !ok {
+				pdoMap.AddDS402Defaults(nodeID)
+			}
+		}
+
+		signalDecoder = models.NewSignalDecoder()
+		signalDecoder.AddPDOMap(pdoMap, edsRegistry)
+
+		definitions, err := models.LoadSignalMappings(cfg.SignalMappings)
+		if err != nil {
+			log.Fatalf("Failed to load signal mappings: %v", err)
+		}
+		for _, def := range definitions {
+			signalDecoder.Add(def)
+		}
+		log.Printf("Decoding %d signals on ingest", signalDecoder.Len())
+	}
+
+	// Create captures table and recorder for triggered capture
+	var captureRecorder *can.CaptureRecorder
+	switch cfg.CaptureMode {
+	case "continuous":
+	case "triggered":
+		err = clickhouse.CreateCapturesTable(chWriter.GetConn(), cfg.ClickHouseCapturesTable)
+		if err != nil {
+			log.Fatalf("Failed to create captures table: %v", err)
+		}
+
+		triggers, err := models.LoadCaptureTriggers(cfg.CaptureTriggers)
+		if err != nil {
+			log.Fatalf("Failed to load capture triggers: %v", err)
+		}
+		if len(triggers) == 0 {
+			log.Fatalf("CAPTURE_MODE=triggered requires at least one trigger in CAPTURE_TRIGGERS")
+		}
+
+		captureRecorder = can.NewCaptureRecorder(cfg.CANInterface, triggers,
+			time.Duration(cfg.CapturePreTrigger)*time.Second, time.Duration(cfg.CapturePostTrigger)*time.Second, cfg.CaptureBufferSize)
+		captureRecorder.Start()
+		defer captureRecorder.Stop()
+		log.Printf("Triggered capture with %d triggers (%ds pre-trigger, %ds post-trigger)",
+			len(triggers), cfg.CapturePreTrigger, cfg.CapturePostTrigger)
+	default:
+		log.Fatalf("Invalid CAPTURE_MODE '%s', must be continuous or triggered", cfg.CaptureMode)
+	}
+
+	// Create and start live stream server
+	hub := stream.NewHub()
+	if cfg.LiveStreamPort > 0 {
+		streamServer := stream.NewServer(cfg.LiveStreamPort, hub)
+		go func() {
+			if err := streamServer.Start(); err != nil {
+				log.Printf("Live stream server error: %v", err)
+			}
+		}()
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			streamServer.Stop(ctx)
+		}()
+	}
+
+	// Create and start payload change sniffer for live stream subscribers
+	var snifferMonitor *can.SnifferMonitor
+	if cfg.LiveStreamPort > 0 && cfg.SnifferInterval > 0 {
+		snifferMonitor = can.NewSnifferMonitor(time.Duration(cfg.SnifferInterval) * time.Millisecond)
+		snifferMonitor.Start()
+		defer snifferMonitor.Stop()
+
+		go func() {
+			for changes := range snifferMonitor.GetSnapshotChannel() {
+				hub.PublishSniffer(changes)
+			}
+		}()
+	}
+
+	// Create alert tables, engine and notifier
+	err = clickhouse.CreateAlertsTable(chWriter.GetConn(), cfg.ClickHouseAlertsTable)
+	if err != nil {
+		log.Fatalf("Failed to create alerts table: %v", err)
+	}
+	err = clickhouse.CreateAlertRulesTable(chWriter.GetConn(), cfg.ClickHouseAlertRulesTable)
+	if err != nil {
+		log.Fatalf("Failed to create alert rules table: %v", err)
+	}
+
+	fileRules, err := models.LoadAlertRules(cfg.AlertRules)
+	if err != nil {
+		log.Fatalf("Failed to load alert rules: %v", err)
+	}
+
+	var alertPublisher notify.Publisher
+	if cfg.LiveStreamPort > 0 {
+		alertPublisher = hub
+	}
+	notifier := notify.NewNotifier(notify.Config{
+		WebhookURL: cfg.AlertWebhookURL,
+		SMTPHost:   cfg.AlertSMTPHost,
+		SMTPPort:   cfg.AlertSMTPPort,
+		EmailFrom:  cfg.AlertEmailFrom,
+		EmailTo:    cfg.AlertEmailTo,
+	}, alertPublisher)
+
+	alertEngine := can.NewAlertEngine()
+	alertRules, err := loadAlertRules(chWriter, cfg, fileRules)
+	if err != nil {
+		log.Printf("Warning: Failed to load alert rules defined through the API: %v", err)
+		alertRules = fileRules
+	}
+	alertEngine.SetRules(alertRules)
+	alertEngine.Start()
+	defer alertEngine.Stop()
+	log.Printf("Evaluating %d alert rules (notify: %v)", len(alertRules), notifier.Channels())
+
+	// Track the active recording session of the interface, started through the API or CLI
+	err = clickhouse.CreateSessionsTable(chWriter.GetConn(), cfg.ClickHouseSessionsTable)
+	if err != nil {
+		log.Fatalf("Failed to create sessions table: %v", err)
+	}
+
+	var activeSession atomic.Pointer[models.Session]
+	refreshSession(chWriter, cfg, &activeSession)
+	if cfg.SessionRefresh > 0 {
+		go func() {
+			ticker := time.NewTicker(time.Duration(cfg.SessionRefresh) * time.Second)
+			defer ticker.Stop()
+
+			for range ticker.C {
+				refreshSession(chWriter, cfg, &activeSession)
+			}
+		}()
+	}
+
 	// Start readers and writers
 	canReader.Start()
 	chWriter.Start(cfg.ClickHouseTable)
 	statsWriter.Start(cfg.ClickHouseStatsTable)
+	eventWriter.Start(cfg.ClickHouseEventsTable)
+	udsWriter.Start(cfg.ClickHouseUDSTable)
+	if signalWriter != nil {
+		signalWriter.Start(cfg.ClickHouseSignalsTable)
+	}
+
+	// Record the start of the capture so recording gaps can be told apart from bus silence
+	writeReaderEvent(chWriter, cfg, models.EventReaderStarted, "CAN reader started", nil)
 
 	log.Println("Bridge started successfully. Press Ctrl+C to stop.")
 
@@ -85,6 +322,9 @@ func main() {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
+	// Device identity stamped on every row
+	device := deviceIdentity(cfg)
+
 	// Statistics
 	var messageCount uint64
 	var errorCount uint64
@@ -95,8 +335,36 @@ func main() {
 			select {
 			case msg := <-canReader.GetMessageChannel():
 				messageCount++
-			// Write to ClickHouse
-			chWriter.Write(msg)
+				msg.Device = device
+				if session := activeSession.Load(); session != nil {
+					msg.SessionID = session.SessionID
+				}
+				// Write to ClickHouse, only around triggers in triggered mode
+				persist := captureRecorder == nil || captureRecorder.Process(msg)
+				if persist {
+					chWriter.Write(msg)
+				}
+				hbMonitor.Process(msg)
+				alertEngine.Process(msg)
+				if len(udsPairs) > 0 {
+					udsMonitor.Process(msg)
+				}
+				if signalDecoder != nil {
+					for _, value := range signalDecoder.Decode(msg.Timestamp, msg.Interface, msg.Frame.ID, msg.Frame.Payload()) {
+						value.Device = msg.Device
+						if captureRecorder != nil {
+							captureRecorder.ProcessSignal(value)
+						}
+						alertEngine.ProcessSignal(value)
+						if persist {
+							signalWriter.Write(value)
+						}
+					}
+				}
+				hub.PublishFrame(msg)
+				if snifferMonitor != nil {
+					snifferMonitor.Process(msg)
+				}
 
 				// Log every 1000 messages
 				if messageCount%1000 == 0 {
@@ -113,14 +381,178 @@ func main() {
 	// Statistics collection loop
 	go func() {
 		for stat := range statsCollector.GetStatsChannel() {
+			stat.Device = device
 			statsWriter.Write(stat)
+			if captureRecorder != nil {
+				captureRecorder.ProcessStats(stat)
+			}
+			alertEngine.ProcessStats(stat)
 			log.Printf("Collected statistics for %s: RX packets=%d, TX packets=%d, Bus state=%s",
 				stat.Interface, stat.RXPackets, stat.TXPackets, stat.BusState)
 		}
 	}()
 
+	// Event processing loop
+	go func() {
+		for event := range hbMonitor.GetEventChannel() {
+			event.Device = device
+			eventWriter.Write(event)
+			hub.PublishEvent(event)
+			log.Printf("[%s] %s: %s", event.Severity, event.Interface, event.Message)
+		}
+	}()
+
+	// UDS transaction processing loop
+	go func() {
+		for tx := range udsMonitor.GetTransactionChannel() {
+			tx.DeviceID = device.DeviceID
+			udsWriter.Write(tx)
+			log.Printf("UDS %s 0x%X: %s %s -> %s %s", tx.Interface, tx.RequestID, tx.Service, tx.SubFunction, tx.Outcome, tx.NRCName)
+		}
+	}()
+
+	// Alert processing loop
+	go func() {
+		for alert := range alertEngine.GetAlertChannel() {
+			alert.DeviceID = device.DeviceID
+			writeAlert(chWriter, cfg, alert)
+			if err := notifier.Notify(alert, alert.Notify); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+			log.Printf("[alert %s] %s: %s", alert.Severity, alert.Status, alert.Message)
+		}
+	}()
+
+	// Reload the alert rules defined through the API
+	if cfg.AlertRulesRefresh > 0 {
+		go func() {
+			ticker := time.NewTicker(time.Duration(cfg.AlertRulesRefresh) * time.Second)
+			defer ticker.Stop()
+
+			for range ticker.C {
+				rules, err := loadAlertRules(chWriter, cfg, fileRules)
+				if err != nil {
+					log.Printf("Warning: Failed to reload alert rules: %v", err)
+					continue
+				}
+				alertEngine.SetRules(rules)
+			}
+		}()
+	}
+
+	// Triggered capture loops: pre-trigger frames bypass the writer queue, which they could overflow
+	if captureRecorder != nil {
+		go func() {
+			for frames := range captureRecorder.GetFlushChannel() {
+				ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+				if err := clickhouse.WriteMessages(ctx, chWriter.GetConn(), cfg.ClickHouseTable, frames); err != nil {
+					log.Printf("Warning: Failed to persist %d pre-trigger frames: %v", len(frames), err)
+				}
+				cancel()
+
+				if signalDecoder != nil {
+					for _, msg := range frames {
+						for _, value := range signalDecoder.Decode(msg.Timestamp, msg.Interface, msg.Frame.ID, msg.Frame.Payload()) {
+							value.Device = msg.Device
+							signalWriter.Write(value)
+						}
+					}
+				}
+			}
+		}()
+
+		go func() {
+			for capture := range captureRecorder.GetCaptureChannel() {
+				writeCapture(chWriter, cfg, capture)
+				log.Printf("Capture %s %s: trigger %s (%s), %d frames",
+					capture.RecordingID, capture.Status, capture.TriggerName, capture.TriggerDetail, capture.Frames)
+			}
+		}()
+	}
+
 	// Wait for termination signal
 	<-sigChan
 	log.Println("\nShutting down...")
+	if captureRecorder != nil {
+		if capture := captureRecorder.Finish(); capture != nil {
+			writeCapture(chWriter, cfg, *capture)
+		}
+	}
 	log.Printf("Final statistics: %d messages processed, %d errors", messageCount, errorCount)
+	writeReaderEvent(chWriter, cfg, models.EventReaderStopped, "CAN reader stopped", map[string]string{
+		"messages": fmt.Sprintf("%d", messageCount),
+		"errors":   fmt.Sprintf("%d", errorCount),
+	})
+}
+
+// writeReaderEvent records a reader lifecycle event directly in the events table
+func writeReaderEvent(chWriter *clickhouse.Writer, cfg *config.Config, eventType, message string, details map[string]string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	event := models.CANEvent{
+		Timestamp: time.Now(),
+		Interface: cfg.CANInterface,
+		EventType: eventType,
+		Severity:  models.SeverityInfo,
+		Message:   message,
+		Details:   details,
+		Device:    deviceIdentity(cfg),
+	}
+	if err := clickhouse.WriteEvents(ctx, chWriter.GetConn(), cfg.ClickHouseEventsTable, []models.CANEvent{event}); err != nil {
+		log.Printf("Warning: Failed to record %s event: %v", eventType, err)
+	}
+}
+
+// deviceIdentity returns the configured identity of the device running the reader
+func deviceIdentity(cfg *config.Config) models.Device {
+	return models.Device{
+		DeviceID:   cfg.DeviceID,
+		Site:       cfg.DeviceSite,
+		HWRevision: cfg.DeviceHWRevision,
+	}
+}
+
+// writeCapture records capture metadata directly in the captures table
+func writeCapture(chWriter *clickhouse.Writer, cfg *config.Config, capture models.Capture) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	capture.DeviceID = cfg.DeviceID
+	if err := clickhouse.WriteCapture(ctx, chWriter.GetConn(), cfg.ClickHouseCapturesTable, capture); err != nil {
+		log.Printf("Warning: Failed to record capture %s: %v", capture.RecordingID, err)
+	}
+}
+
+// loadAlertRules merges the rule files with the rules defined through the API, which replace file rules of the same id
+func loadAlertRules(chWriter *clickhouse.Writer, cfg *config.Config, fileRules []models.AlertRule) ([]models.AlertRule, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	apiRules, err := clickhouse.ReadAlertRules(ctx, chWriter.GetConn(), cfg.ClickHouseAlertRulesTable)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]models.AlertRule, 0, len(fileRules)+len(apiRules))
+	defined := make(map[string]bool, len(apiRules))
+	for _, rule := range apiRules {
+		defined[rule.ID] = true
+	}
+	for _, rule := range fileRules {
+		if !defined[rule.ID] {
+			rules = append(rules, rule)
+		}
+	}
+	return append(rules, apiRules...), nil
+}
+
+// writeAlert records an alert notification directly in the alert history
+func writeAlert(chWriter *clickhouse.Writer, cfg *config.Config, alert models.Alert) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := clickhouse.WriteAlert(ctx, chWriter.GetConn(), cfg.ClickHouseAlertsTable, alert); err != nil {
+		log.Printf("Warning: Failed to record alert of rule %s: %v", alert.RuleID, err)
+	}
 }
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"can-db-writer/internal/config"
+	"can-db-writer/internal/database/clickhouse"
+	"can-db-writer/internal/models"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// runSession starts or stops a recording session from the command line
+// Usage: can-reader [-env .env] session start [-name ...] [-operator ...] [-robot-serial ...]
+// [-software-version ...] [-test-case ...] [-tags a,b] [-notes ...] [-interface can0]
+// or: can-reader [-env .env] session stop [-id ...] [-interface can0]
+//
+// Sessions belong to the DEVICE_ID of the configuration. A running reader of the device picks up the
+// session within SESSION_REFRESH seconds.
+func runSession(cfg *config.Config, args []string) error {
+	if len(args) == 0 || (args[0] != "start" && args[0] != "stop") {
+		return fmt.Errorf("expected 'session start' or 'session stop'")
+	}
+
+	flags := flag.NewFlagSet("session "+args[0], flag.ExitOnError)
+	iface := flags.String("interface", cfg.CANInterface, "CAN interface of the session")
+	id := flags.String("id", "", "Session to stop (default: the active session of the interface)")
+	name := flags.String("name", "", "Session name")
+	operator := flags.String("operator", "", "Operator running the session")
+	robotSerial := flags.String("robot-serial", "", "Serial number of the robot under test")
+	softwareVersion := flags.String("software-version", "", "Software version under test")
+	testCase := flags.String("test-case", "", "Test case identifier")
+	tags := flags.String("tags", "", "Comma-separated tags")
+	notes := flags.String("notes", "", "Free-form notes")
+	flags.Parse(args[1:])
+
+	chWriter, err := clickhouse.New(clickhouse.Config{
+		Host:     cfg.ClickHouseHost,
+		Port:     cfg.ClickHousePort,
+		Database: cfg.ClickHouseDatabase,
+		Username: cfg.ClickHouseUsername,
+		Password: cfg.ClickHousePassword,
+		Table:    cfg.ClickHouseTable,
+	}, cfg.BatchSize)
+	if err != nil {
+		return err
+	}
+	defer chWriter.Close()
+
+	if err := clickhouse.CreateSessionsTable(chWriter.GetConn(), cfg.ClickHouseSessionsTable); err != nil {
+		return fmt.Errorf("failed to create sessions table: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if args[0] == "start" {
+		session := models.NewSession(cfg.DeviceID, *iface)
+		session.Name = *name
+		session.Operator = *operator
+		session.RobotSerial = *robotSerial
+		session.SoftwareVersion = *softwareVersion
+		session.TestCase = *testCase
+		session.Notes = *notes
+		session.UpdateTags(strings.Split(*tags, ","), nil)
+
+		if err := clickhouse.StartSession(ctx, chWriter.GetConn(), cfg.ClickHouseSessionsTable, &session); err != nil {
+			return err
+		}
+		fmt.Printf("Started session %s on %s\n", session.SessionID, session.Interface)
+		return nil
+	}
+
+	sessionID := *id
+	if sessionID == "" {
+		active, err := clickhouse.ReadActiveSession(ctx, chWriter.GetConn(), cfg.ClickHouseSessionsTable, cfg.DeviceID, *iface)
+		if err != nil {
+			return err
+		}
+		if active == nil {
+			return fmt.Errorf("no active session on %s of %s", *iface, cfg.DeviceID)
+		}
+		sessionID = active.SessionID
+	}
+
+	session, err := clickhouse.StopSession(ctx, chWriter.GetConn(), cfg.ClickHouseSessionsTable, sessionID, time.Now())
+	if err != nil {
+		return err
+	}
+	if session == nil {
+		return fmt.Errorf("session %s not found", sessionID)
+	}
+	fmt.Printf("Stopped session %s on %s\n", session.SessionID, session.Interface)
+	return nil
+}
+
+// refreshSession loads the active recording session of the device's interface, keeping the current one on errors
+func refreshSession(chWriter *clickhouse.Writer, cfg *config.Config, active *atomic.Pointer[models.Session]) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, err := clickhouse.ReadActiveSession(ctx, chWriter.GetConn(), cfg.ClickHouseSessionsTable, cfg.DeviceID, cfg.CANInterface)
+	if err != nil {
+		log.Printf("Warning: Failed to read the active session: %v", err)
+		return
+	}
+
+	previous := active.Swap(session)
+	if previous != nil && (session == nil || session.SessionID != previous.SessionID) {
+		log.Printf("Recording session %s ended", previous.SessionID)
+	}
+	if session != nil && (previous == nil || session.SessionID != previous.SessionID) {
+		log.Printf("Recording session %s started: %s", session.SessionID, session.Name)
+	}
+}
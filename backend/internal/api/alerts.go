@@ -0,0 +1,158 @@
+package api
+
+import (
+	"can-db-writer/internal/database/clickhouse"
+	"can-db-writer/internal/models"
+	"fmt"
+	"net/http"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// AlertAPI handles HTTP API requests for alert rules and the alert history
+type AlertAPI struct {
+	conn        driver.Conn
+	alertsTable string
+	rulesTable  string
+}
+
+// NewAlertAPI creates a new alert API handler
+func NewAlertAPI(conn driver.Conn, alertsTable, rulesTable string) *AlertAPI {
+	return &AlertAPI{
+		conn:        conn,
+		alertsTable: alertsTable,
+		rulesTable:  rulesTable,
+	}
+}
+
+// GetAlerts returns the alert history, newest first
+// GET /api/alerts?rule_id=bus_off&status=firing&severity=error&device_id=AMR-0042&interface=can0&start_time=2024-01-01T00:00:00Z&end_time=2024-01-02T00:00:00Z&limit=100&offset=0
+func (api *AlertAPI) GetAlerts(w http.ResponseWriter, r *http.Request) {
+	params, err := parseQueryParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	query := fmt.Sprintf(`
+		SELECT timestamp, rule_id, rule_name, severity, status, interface, subject, value, message, fired_at, device_id
+		FROM %s
+		WHERE 1=1`, api.alertsTable)
+	args := []any{}
+
+	if params.StartTime != nil {
+		query += " AND timestamp >= ?"
+		args = append(args, *params.StartTime)
+	}
+	if params.EndTime != nil {
+		query += " AND timestamp <= ?"
+		args = append(args, *params.EndTime)
+	}
+	if params.DeviceID != "" {
+		query += " AND device_id = ?"
+		args = append(args, params.DeviceID)
+	}
+	if params.Interface != "" {
+		query += " AND interface = ?"
+		args = append(args, params.Interface)
+	}
+	if ruleID := r.URL.Query().Get("rule_id"); ruleID != "" {
+		query += " AND rule_id = ?"
+		args = append(args, ruleID)
+	}
+	if status := r.URL.Query().Get("status"); status != "" {
+		query += " AND status = ?"
+		args = append(args, status)
+	}
+	if severity := r.URL.Query().Get("severity"); severity != "" {
+		query += " AND severity = ?"
+		args = append(args, severity)
+	}
+
+	query += " ORDER BY timestamp DESC"
+
+	if params.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, params.Limit)
+	}
+
+	if params.Offset > 0 {
+		query += " OFFSET ?"
+		args = append(args, params.Offset)
+	}
+
+	rows, err := api.conn.Query(r.Context(), query, args...)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+		return
+	}
+	defer rows.Close()
+
+	alerts := []models.Alert{}
+	for rows.Next() {
+		var alert models.Alert
+		err := rows.Scan(
+			&alert.Timestamp, &alert.RuleID, &alert.RuleName, &alert.Severity, &alert.Status,
+			&alert.Interface, &alert.Subject, &alert.Value, &alert.Message, &alert.FiredAt, &alert.DeviceID,
+		)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Scan failed: %v", err))
+			return
+		}
+		alerts = append(alerts, alert)
+	}
+
+	respondWithJSON(w, http.StatusOK, alerts)
+}
+
+// HandleRules lists or saves the alert rules defined through the API
+// GET /api/alerts/rules
+// POST /api/alerts/rules with an alert rule as body, replacing the rule of the same id
+//
+// The CAN reader reloads these rules periodically (ALERT_RULES_REFRESH); they replace
+// rules of the same id loaded from ALERT_RULES files.
+func (api *AlertAPI) HandleRules(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		rules, err := clickhouse.ReadAlertRules(r.Context(), api.conn, api.rulesTable)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+			return
+		}
+		respondWithJSON(w, http.StatusOK, rules)
+	case http.MethodPost:
+		var rule models.AlertRule
+		if err := parseJSONBody(r, &rule); err != nil {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+			return
+		}
+		if err := rule.Validate(); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := clickhouse.SaveAlertRule(r.Context(), api.conn, api.rulesTable, rule); err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to save rule: %v", err))
+			return
+		}
+		respondWithJSON(w, http.StatusOK, rule)
+	default:
+		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// DeleteRule deletes an alert rule defined through the API
+// DELETE /api/alerts/rules/{id}
+func (api *AlertAPI) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id := r.PathValue("id")
+	if err := clickhouse.DeleteAlertRule(r.Context(), api.conn, api.rulesTable, id); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete rule: %v", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"deleted": id})
+}
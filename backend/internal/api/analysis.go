@@ -0,0 +1,823 @@
+package api
+
+import (
+	"can-db-writer/internal/models"
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// analysisMaxFrames caps the number of frames read for an analysis
+const analysisMaxFrames = 1000000
+
+// analysisDefaultWindow is the capture window analysed when no start_time is given
+const analysisDefaultWindow = 60 * time.Second
+
+// analysisDefaultBuckets is the number of time buckets of an analysis without interval
+const analysisDefaultBuckets = 60
+
+// AnalysisAPI handles HTTP API requests for bus traffic analysis
+type AnalysisAPI struct {
+	conn         driver.Conn
+	tableName    string
+	statsTable   string
+	eventsTable  string
+	signalsTable string
+	emcyDecoder  *models.EMCYDecoder
+}
+
+// NewAnalysisAPI creates a new analysis API handler
+func NewAnalysisAPI(conn driver.Conn, tableName, statsTable, eventsTable, signalsTable string, emcyDecoder *models.EMCYDecoder) *AnalysisAPI {
+	return &AnalysisAPI{
+		conn:         conn,
+		tableName:    tableName,
+		statsTable:   statsTable,
+		eventsTable:  eventsTable,
+		signalsTable: signalsTable,
+		emcyDecoder:  emcyDecoder,
+	}
+}
+
+// GetTiming analyses the cycle time and jitter of a periodic CAN ID per interface
+// GET /api/analysis/timing?can_id=0x181&interface=can0&start_time=2024-01-01T00:00:00Z&end_time=2024-01-01T00:01:00Z&period=10ms&interval=1s
+//
+// Without start_time, the last window_sec seconds (default 60) before end_time or the latest frame are analysed.
+// period is the expected cycle time (default: the median inter-arrival time) used for missed cycles and bursts.
+// interval is the bucket size (default: the window split into 60 buckets, at least 1s). When SYNC (0x080)
+// frames are recorded, the latency of the first frame after each SYNC is included.
+func (api *AnalysisAPI) GetTiming(w http.ResponseWriter, r *http.Request) {
+	params, err := parseQueryParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if params.CANID == nil {
+		respondWithError(w, http.StatusBadRequest, "can_id is required")
+		return
+	}
+
+	window, err := parseWindowParam(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	period, err := parseDurationParam(r, "period")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	interval, err := parseDurationParam(r, "interval")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+	ok, err := api.resolveWindow(ctx, &params, window)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+		return
+	}
+	if !ok {
+		respondWithJSON(w, http.StatusOK, []models.TimingAnalysis{})
+		return
+	}
+
+	if interval == 0 {
+		interval = defaultInterval(params)
+	}
+
+	frames, truncated, err := api.timestamps(ctx, params, *params.CANID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	syncs, _, err := api.timestamps(ctx, params, 0x080)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	analyses := []models.TimingAnalysis{}
+	for _, iface := range slices.Sorted(maps.Keys(frames)) {
+		analysis := models.AnalyzeTiming(iface, *params.CANID, frames[iface], syncs[iface], period, interval)
+		analysis.Truncated = truncated[iface]
+		analyses = append(analyses, analysis)
+	}
+
+	respondWithJSON(w, http.StatusOK, analyses)
+}
+
+// GetBusLoad estimates the bus load per interface and time bucket with the top contributing CAN IDs
+// GET /api/analysis/busload?interface=can0&start_time=2024-01-01T00:00:00Z&end_time=2024-01-01T00:01:00Z&interval=1s&top=10&bitrate=500000&data_bitrate=2000000
+//
+// Each frame counts its worst-case length with bit stuffing (see models.FrameBits). The bitrate is the
+// latest one recorded in the statistics table for the interface up to end_time, unless bitrate is given;
+// data_bitrate is the CAN FD data phase bitrate (default: the bitrate). Frames count the payload of their
+// DLC; rows written before the dlc column existed count all 8 stored bytes. Window and interval as for timing.
+func (api *AnalysisAPI) GetBusLoad(w http.ResponseWriter, r *http.Request) {
+	params, err := parseQueryParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	window, err := parseWindowParam(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	interval, err := parseDurationParam(r, "interval")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	bitrate, err := parseUintParam(r, "bitrate", 32)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	dataBitrate, err := parseUintParam(r, "data_bitrate", 32)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	top := models.BusLoadDefaultTop
+	if topStr := r.URL.Query().Get("top"); topStr != "" {
+		top, err = strconv.Atoi(topStr)
+		if err != nil || top < 0 {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid top '%s', must be a non-negative number", topStr))
+			return
+		}
+	}
+
+	ctx := r.Context()
+	ok, err := api.resolveWindow(ctx, &params, window)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+		return
+	}
+	if !ok {
+		respondWithJSON(w, http.StatusOK, []models.BusLoad{})
+		return
+	}
+
+	if interval == 0 {
+		interval = defaultInterval(params)
+	}
+
+	bitrates, err := api.bitrates(ctx, params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+		return
+	}
+
+	micros := interval.Microseconds()
+	query := fmt.Sprintf(`
+		SELECT interface, intDiv(toUnixTimestamp64Micro(timestamp), %d) * %d AS bucket, can_id, least(dlc, length(data)) AS len, count()
+		FROM %s
+		WHERE timestamp >= ? AND timestamp <= ?`, micros, micros, api.tableName)
+	args := []any{*params.StartTime, *params.EndTime}
+
+	if params.CANID != nil {
+		query += " AND can_id = ?"
+		args = append(args, *params.CANID)
+	}
+	if params.Interface != "" {
+		query += " AND interface = ?"
+		args = append(args, params.Interface)
+	}
+	if params.DeviceID != "" {
+		query += " AND device_id = ?"
+		args = append(args, params.DeviceID)
+	}
+
+	query += " GROUP BY interface, bucket, can_id, len"
+
+	rows, err := api.conn.Query(ctx, query, args...)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+		return
+	}
+	defer rows.Close()
+
+	estimators := make(map[string]*models.BusLoadEstimator)
+	for rows.Next() {
+		var iface string
+		var bucket int64
+		var canID uint32
+		var dataLen, frames uint64
+		if err := rows.Scan(&iface, &bucket, &canID, &dataLen, &frames); err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Scan failed: %v", err))
+			return
+		}
+
+		estimator, ok := estimators[iface]
+		if !ok {
+			rate, source := bitrates[iface], "stats"
+			if bitrate != nil {
+				rate, source = uint32(*bitrate), "request"
+			} else if rate == 0 {
+				source = "unknown"
+			}
+
+			var dataRate uint32
+			if dataBitrate != nil {
+				dataRate = uint32(*dataBitrate)
+			}
+
+			estimator = models.NewBusLoadEstimator(iface, rate, dataRate, source, *params.StartTime, *params.EndTime, interval)
+			estimators[iface] = estimator
+		}
+		estimator.Add(bucket, canID, int(dataLen), frames)
+	}
+
+	loads := []models.BusLoad{}
+	for _, iface := range slices.Sorted(maps.Keys(estimators)) {
+		loads = append(loads, estimators[iface].Result(top))
+	}
+
+	respondWithJSON(w, http.StatusOK, loads)
+}
+
+// GetChanges summarises per CAN ID which payload bytes and bits changed over a window, like cansniffer
+// GET /api/analysis/changes?interface=can0&can_id=0x181&start_time=2024-01-01T00:00:00Z&end_time=2024-01-01T00:01:00Z
+//
+// Each byte reports its value range, the number of frames in which it changed and a toggle count per bit.
+// Window as for timing; at most analysisMaxFrames frames are read, oldest first.
+func (api *AnalysisAPI) GetChanges(w http.ResponseWriter, r *http.Request) {
+	params, err := parseQueryParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	window, err := parseWindowParam(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+	ok, err := api.resolveWindow(ctx, &params, window)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+		return
+	}
+	if !ok {
+		respondWithJSON(w, http.StatusOK, []models.PayloadChanges{})
+		return
+	}
+
+	query := fmt.Sprintf(`
+		SELECT timestamp, interface, can_id, data
+		FROM %s
+		WHERE timestamp >= ? AND timestamp <= ?`, api.tableName)
+	args := []any{*params.StartTime, *params.EndTime}
+
+	if params.CANID != nil {
+		query += " AND can_id = ?"
+		args = append(args, *params.CANID)
+	}
+	if params.Interface != "" {
+		query += " AND interface = ?"
+		args = append(args, params.Interface)
+	}
+	if params.DeviceID != "" {
+		query += " AND device_id = ?"
+		args = append(args, params.DeviceID)
+	}
+
+	query += " ORDER BY timestamp ASC LIMIT ?"
+	args = append(args, analysisMaxFrames)
+
+	rows, err := api.conn.Query(ctx, query, args...)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+		return
+	}
+	defer rows.Close()
+
+	sniffer := models.NewPayloadSniffer()
+	for rows.Next() {
+		var timestamp time.Time
+		var iface string
+		var canID uint32
+		var data []uint8
+		if err := rows.Scan(&timestamp, &iface, &canID, &data); err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Scan failed: %v", err))
+			return
+		}
+		sniffer.Add(timestamp, iface, canID, data)
+	}
+
+	respondWithJSON(w, http.StatusOK, sniffer.Result())
+}
+
+// bitrates returns the latest non-zero bitrate recorded per interface up to the end of the window
+func (api *AnalysisAPI) bitrates(ctx context.Context, params models.QueryParams) (map[string]uint32, error) {
+	query := fmt.Sprintf(`
+		SELECT interface, argMax(bitrate, timestamp)
+		FROM %s
+		WHERE bitrate > 0 AND timestamp <= ?`, api.statsTable)
+	args := []any{*params.EndTime}
+
+	if params.Interface != "" {
+		query += " AND interface = ?"
+		args = append(args, params.Interface)
+	}
+	if params.DeviceID != "" {
+		query += " AND device_id = ?"
+		args = append(args, params.DeviceID)
+	}
+
+	query += " GROUP BY interface"
+
+	rows, err := api.conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bitrates := make(map[string]uint32)
+	for rows.Next() {
+		var iface string
+		var bitrate uint32
+		if err := rows.Scan(&iface, &bitrate); err != nil {
+			return nil, err
+		}
+		bitrates[iface] = bitrate
+	}
+
+	return bitrates, nil
+}
+
+// GetGaps finds periods in which an interface, node or CAN ID was silent, longest first
+// GET /api/analysis/gaps?scope=can_id&can_id=0x181&interface=can0&start_time=2024-01-01T00:00:00Z&end_time=2024-01-02T00:00:00Z&threshold=500ms&factor=3&limit=100
+//
+// scope is interface (default), node (CANopen node ID of the COB-ID, filtered with node_id) or can_id
+// (filtered with can_id); node_id or can_id alone select their scope. A silence is a gap when it exceeds
+// threshold and factor times the learned period (the median inter-arrival time); factor defaults to 3
+// without a threshold. Silences from the start of the window until a key's first frame are reported as
+// leading and silences until the end of the window as ongoing; a key seen in the span before the window
+// without a frame in it is silent for the whole window. Keys with fewer than two frames in the window
+// use the period learned before it, and are skipped without one unless a threshold is given. Each gap
+// lists the reader start/stop events and DOWN, STOPPED or BUS-OFF statistics samples within it, and its cause.
+func (api *AnalysisAPI) GetGaps(w http.ResponseWriter, r *http.Request) {
+	params, err := parseQueryParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	nodeID, err := parseNodeID(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	scope := r.URL.Query().Get("scope")
+	if scope == "" {
+		switch {
+		case nodeID != nil:
+			scope = models.GapScopeNode
+		case params.CANID != nil:
+			scope = models.GapScopeCANID
+		default:
+			scope = models.GapScopeInterface
+		}
+	}
+
+	window, err := parseWindowParam(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	threshold, err := parseDurationParam(r, "threshold")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var factor float64
+	if threshold == 0 {
+		factor = models.GapDefaultFactor
+	}
+	if factorStr := r.URL.Query().Get("factor"); factorStr != "" {
+		factor, err = strconv.ParseFloat(factorStr, 64)
+		if err != nil || factor <= 0 {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid factor '%s', must be a positive number", factorStr))
+			return
+		}
+	}
+
+	// Frames are grouped by a UInt32 key per scope
+	var keyExpr string
+	switch scope {
+	case models.GapScopeInterface:
+		keyExpr = "toUInt32(0)"
+	case models.GapScopeCANID:
+		keyExpr = "can_id"
+	case models.GapScopeNode:
+		keyExpr = "toUInt32(bitAnd(can_id, 0x7F))"
+	default:
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid scope '%s', must be one of: interface, node, can_id", scope))
+		return
+	}
+
+	ctx := r.Context()
+	ok, err := api.resolveWindow(ctx, &params, window)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+		return
+	}
+	if !ok {
+		respondWithJSON(w, http.StatusOK, []models.Gap{})
+		return
+	}
+
+	where := " WHERE timestamp >= ? AND timestamp <= ?"
+	args := []any{*params.StartTime, *params.EndTime}
+
+	if scope == models.GapScopeNode {
+		// Node-specific COB-IDs of the predefined connection set, from EMCY to heartbeat
+		where += " AND can_id > 0x80 AND can_id < 0x780 AND bitAnd(can_id, 0x7F) != 0"
+	}
+	if nodeID != nil {
+		where += " AND can_id > 0x80 AND can_id < 0x780 AND bitAnd(can_id, 0x7F) = ?"
+		args = append(args, uint32(*nodeID))
+	}
+	if params.CANID != nil {
+		where += " AND can_id = ?"
+		args = append(args, *params.CANID)
+	}
+	if params.Interface != "" {
+		where += " AND interface = ?"
+		args = append(args, params.Interface)
+	}
+	if params.DeviceID != "" {
+		where += " AND device_id = ?"
+		args = append(args, params.DeviceID)
+	}
+
+	// Inter-arrival time of every frame after the first of its key
+	gapsCTE := fmt.Sprintf(`
+		WITH gaps AS (
+			SELECT interface, key, previous, timestamp,
+				toUnixTimestamp64Micro(timestamp) - toUnixTimestamp64Micro(previous) AS gap_us
+			FROM (
+				SELECT interface, key, timestamp,
+					lagInFrame(timestamp) OVER (PARTITION BY interface, key ORDER BY timestamp ROWS BETWEEN 1 PRECEDING AND CURRENT ROW) AS previous
+				FROM (SELECT interface, %s AS key, timestamp FROM %s%s)
+			)
+			WHERE toUnixTimestamp64Micro(previous) > 0
+		)`, keyExpr, api.tableName, where)
+
+	query := gapsCTE + `
+		SELECT g.interface, g.key, g.previous, g.timestamp, p.period_us
+		FROM gaps AS g
+		INNER JOIN (
+			SELECT interface, key, toFloat64(quantileExact(0.5)(gap_us)) AS period_us FROM gaps GROUP BY interface, key
+		) AS p ON g.interface = p.interface AND g.key = p.key
+		WHERE g.gap_us > greatest(?, ? * p.period_us)
+		ORDER BY g.gap_us DESC
+		LIMIT ?`
+	gapArgs := append(slices.Clone(args), threshold.Microseconds(), factor, params.Limit)
+
+	rows, err := api.conn.Query(ctx, query, gapArgs...)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+		return
+	}
+	defer rows.Close()
+
+	gaps := []models.Gap{}
+	for rows.Next() {
+		var iface string
+		var key uint32
+		var start, end time.Time
+		var periodUs float64
+		if err := rows.Scan(&iface, &key, &start, &end, &periodUs); err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Scan failed: %v", err))
+			return
+		}
+		gaps = append(gaps, models.NewGap(iface, scope, key, start, end, periodUs/1000))
+	}
+
+	// Silences at the window edges, with the period learned in the window or, for keys with fewer than
+	// two frames in it, in the same span before the window
+	edgesQuery := gapsCTE + fmt.Sprintf(`
+		SELECT f.interface, f.key, f.first, f.last, f.frames, p.period_us
+		FROM (
+			SELECT interface, %s AS key, min(timestamp) AS first, max(timestamp) AS last, count() AS frames
+			FROM %s%s
+			GROUP BY interface, key
+		) AS f
+		LEFT JOIN (
+			SELECT interface, key, toFloat64(quantileExact(0.5)(gap_us)) AS period_us FROM gaps GROUP BY interface, key
+		) AS p ON f.interface = p.interface AND f.key = p.key`, keyExpr, api.tableName, where)
+
+	inWindow, err := api.gapEdges(ctx, edgesQuery, append(slices.Clone(args), args...))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+		return
+	}
+
+	beforeArgs := slices.Clone(args)
+	beforeArgs[0] = params.StartTime.Add(-params.EndTime.Sub(*params.StartTime))
+	beforeArgs[1] = *params.StartTime
+	before, err := api.gapEdges(ctx, edgesQuery, append(slices.Clone(beforeArgs), beforeArgs...))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+		return
+	}
+
+	// Keys seen before the window without a frame in it were silent for the whole window
+	for k, e := range before {
+		if _, ok := inWindow[k]; !ok {
+			inWindow[k] = gapEdge{periodUs: e.periodUs}
+		}
+	}
+
+	for k, e := range inWindow {
+		periodUs := e.periodUs
+		if e.frames < 2 {
+			b, ok := before[k]
+			if !ok || b.frames < 2 {
+				// Without a learned period only a threshold tells a silence from a rare frame
+				if threshold == 0 {
+					continue
+				}
+				b.periodUs = 0
+			}
+			periodUs = b.periodUs
+		}
+		silent := func(start, end time.Time) bool {
+			return end.Sub(start).Microseconds() > max(threshold.Microseconds(), int64(factor*periodUs))
+		}
+
+		if e.frames == 0 {
+			if silent(*params.StartTime, *params.EndTime) {
+				gap := models.NewGap(k.iface, scope, k.key, *params.StartTime, *params.EndTime, periodUs/1000)
+				gap.Leading = true
+				gap.Ongoing = true
+				gaps = append(gaps, gap)
+			}
+			continue
+		}
+
+		// Silent from the start of the window until the first frame
+		if silent(*params.StartTime, e.first) {
+			gap := models.NewGap(k.iface, scope, k.key, *params.StartTime, e.first, periodUs/1000)
+			gap.Leading = true
+			gaps = append(gaps, gap)
+		}
+		// Silent from the last frame until the end of the window
+		if silent(e.last, *params.EndTime) {
+			gap := models.NewGap(k.iface, scope, k.key, e.last, *params.EndTime, periodUs/1000)
+			gap.Ongoing = true
+			gaps = append(gaps, gap)
+		}
+	}
+
+	evidence, err := api.gapEvidence(ctx, params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+		return
+	}
+	for i := range gaps {
+		gaps[i].Classify(evidence[gaps[i].Interface])
+	}
+
+	slices.SortStableFunc(gaps, func(a, b models.Gap) int {
+		return cmp.Compare(b.DurationMs, a.DurationMs)
+	})
+	if len(gaps) > params.Limit {
+		gaps = gaps[:params.Limit]
+	}
+
+	respondWithJSON(w, http.StatusOK, gaps)
+}
+
+// gapKey identifies the interface and scope key of a gap
+type gapKey struct {
+	iface string
+	key   uint32
+}
+
+// gapEdge is the first and last frame of a gap key in a span, with its learned period (0 below two frames)
+type gapEdge struct {
+	first, last time.Time
+	frames      uint64
+	periodUs    float64
+}
+
+// gapEdges runs an edges query of GetGaps and returns its rows per key
+func (api *AnalysisAPI) gapEdges(ctx context.Context, query string, args []any) (map[gapKey]gapEdge, error) {
+	rows, err := api.conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	edges := make(map[gapKey]gapEdge)
+	for rows.Next() {
+		var k gapKey
+		var e gapEdge
+		if err := rows.Scan(&k.iface, &k.key, &e.first, &e.last, &e.frames, &e.periodUs); err != nil {
+			return nil, err
+		}
+		edges[k] = e
+	}
+
+	return edges, nil
+}
+
+// gapEvidence returns the reader lifecycle events and the DOWN, STOPPED and BUS-OFF statistics samples
+// of the window per interface, in time order
+func (api *AnalysisAPI) gapEvidence(ctx context.Context, params models.QueryParams) (map[string][]models.GapEvidence, error) {
+	query := fmt.Sprintf(`
+		SELECT timestamp, interface, 'event', event_type
+		FROM %s
+		WHERE has(?, event_type) AND timestamp >= ? AND timestamp <= ?`, api.eventsTable)
+	args := []any{[]string{models.EventReaderStarted, models.EventReaderStopped}, *params.StartTime, *params.EndTime}
+
+	if params.Interface != "" {
+		query += " AND interface = ?"
+		args = append(args, params.Interface)
+	}
+	if params.DeviceID != "" {
+		query += " AND device_id = ?"
+		args = append(args, params.DeviceID)
+	}
+
+	query += fmt.Sprintf(`
+		UNION ALL
+		SELECT timestamp, interface, 'stats', if(state != 'UP', state, bus_state)
+		FROM %s
+		WHERE (state != 'UP' OR has(['BUS-OFF', 'STOPPED'], bus_state)) AND timestamp >= ? AND timestamp <= ?`, api.statsTable)
+	args = append(args, *params.StartTime, *params.EndTime)
+
+	if params.Interface != "" {
+		query += " AND interface = ?"
+		args = append(args, params.Interface)
+	}
+	if params.DeviceID != "" {
+		query += " AND device_id = ?"
+		args = append(args, params.DeviceID)
+	}
+
+	rows, err := api.conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	evidence := make(map[string][]models.GapEvidence)
+	for rows.Next() {
+		var e models.GapEvidence
+		var iface string
+		if err := rows.Scan(&e.Timestamp, &iface, &e.Source, &e.Type); err != nil {
+			return nil, err
+		}
+		evidence[iface] = append(evidence[iface], e)
+	}
+
+	for _, list := range evidence {
+		slices.SortFunc(list, func(a, b models.GapEvidence) int {
+			return a.Timestamp.Compare(b.Timestamp)
+		})
+	}
+
+	return evidence, nil
+}
+
+// resolveWindow fills a missing start_time with the window before end_time or the latest frame
+// ok is false when no frames are recorded.
+func (api *AnalysisAPI) resolveWindow(ctx context.Context, params *models.QueryParams, window time.Duration) (bool, error) {
+	if params.StartTime != nil {
+		if params.EndTime == nil {
+			end := time.Now().UTC()
+			params.EndTime = &end
+		}
+		return true, nil
+	}
+
+	end := params.EndTime
+	if end == nil {
+		query := fmt.Sprintf("SELECT max(timestamp), count() FROM %s WHERE 1=1", api.tableName)
+		args := []any{}
+		if params.Interface != "" {
+			query += " AND interface = ?"
+			args = append(args, params.Interface)
+		}
+		if params.DeviceID != "" {
+			query += " AND device_id = ?"
+			args = append(args, params.DeviceID)
+		}
+
+		var latest time.Time
+		var count uint64
+		if err := api.conn.QueryRow(ctx, query, args...).Scan(&latest, &count); err != nil {
+			return false, err
+		}
+		if count == 0 {
+			return false, nil
+		}
+		end = &latest
+	}
+
+	start := end.Add(-window)
+	params.StartTime = &start
+	params.EndTime = end
+	return true, nil
+}
+
+// timestamps reads the frame times of a CAN ID per interface, in time order, up to analysisMaxFrames
+func (api *AnalysisAPI) timestamps(ctx context.Context, params models.QueryParams, canID uint32) (map[string][]time.Time, map[string]bool, error) {
+	query := fmt.Sprintf(`
+		SELECT interface, timestamp
+		FROM %s
+		WHERE can_id = ? AND timestamp >= ? AND timestamp <= ?`, api.tableName)
+	args := []any{canID, *params.StartTime, *params.EndTime}
+
+	if params.Interface != "" {
+		query += " AND interface = ?"
+		args = append(args, params.Interface)
+	}
+	if params.DeviceID != "" {
+		query += " AND device_id = ?"
+		args = append(args, params.DeviceID)
+	}
+
+	query += " ORDER BY interface, timestamp LIMIT ?"
+	args = append(args, analysisMaxFrames+1)
+
+	rows, err := api.conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Query failed: %v", err)
+	}
+	defer rows.Close()
+
+	frames := make(map[string][]time.Time)
+	truncated := make(map[string]bool)
+	count := 0
+	for rows.Next() {
+		var iface string
+		var timestamp time.Time
+		if err := rows.Scan(&iface, &timestamp); err != nil {
+			return nil, nil, fmt.Errorf("Scan failed: %v", err)
+		}
+
+		count++
+		if count > analysisMaxFrames {
+			truncated[iface] = true
+			break
+		}
+		frames[iface] = append(frames[iface], timestamp)
+	}
+
+	return frames, truncated, nil
+}
+
+// defaultInterval splits the analysed window into analysisDefaultBuckets buckets of whole seconds
+func defaultInterval(params models.QueryParams) time.Duration {
+	return max(params.EndTime.Sub(*params.StartTime)/analysisDefaultBuckets, time.Second).Round(time.Second)
+}
+
+// parseWindowParam parses window_sec, the analysed window without start_time
+func parseWindowParam(r *http.Request) (time.Duration, error) {
+	windowStr := r.URL.Query().Get("window_sec")
+	if windowStr == "" {
+		return analysisDefaultWindow, nil
+	}
+
+	seconds, err := strconv.Atoi(windowStr)
+	if err != nil || seconds <= 0 {
+		return 0, fmt.Errorf("invalid window_sec '%s', must be a positive number of seconds", windowStr)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// parseDurationParam parses an optional positive Go duration (e.g. 10ms, 1s, 5m); 0 when not set
+func parseDurationParam(r *http.Request, name string) (time.Duration, error) {
+	valueStr := r.URL.Query().Get(name)
+	if valueStr == "" {
+		return 0, nil
+	}
+
+	value, err := time.ParseDuration(valueStr)
+	if err != nil || value < time.Microsecond {
+		return 0, fmt.Errorf("invalid %s '%s', expected a duration such as 10ms, 1s or 5m", name, valueStr)
+	}
+	return value, nil
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"can-db-writer/internal/database/clickhouse"
+	"can-db-writer/internal/models"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// annotationsInlineLimit caps the annotations returned inline with messages, signals and statistics
+const annotationsInlineLimit = 1000
+
+// AnnotationAPI handles HTTP API requests for timeline annotations
+type AnnotationAPI struct {
+	conn      driver.Conn
+	tableName string
+}
+
+// NewAnnotationAPI creates a new annotation API handler
+func NewAnnotationAPI(conn driver.Conn, tableName string) *AnnotationAPI {
+	return &AnnotationAPI{
+		conn:      conn,
+		tableName: tableName,
+	}
+}
+
+// HandleAnnotations lists or creates annotations
+// GET /api/annotations?device_id=AMR-0042&interface=can0&session_id=...&author=kim&tag=collision&q=e-stop&start_time=2024-01-01T00:00:00Z&end_time=2024-01-02T00:00:00Z&limit=100&offset=0
+// POST /api/annotations
+//
+// start_time and end_time select the annotations overlapping the range, device_id and interface also
+// match annotations of every device and interface and tag may be repeated.
+//
+// Request body:
+//
+//	{
+//	  "start_time": "2024-01-01T14:03:22Z",
+//	  "end_time": "2024-01-01T14:03:25Z" (optional, default: start_time),
+//	  "device_id": "AMR-0042" (optional, default: every device),
+//	  "interface": "can0" (optional, default: every interface),
+//	  "session_id": "..." (optional),
+//	  "author": "kim" (optional),
+//	  "text": "collision",
+//	  "tags": ["collision"] (optional)
+//	}
+func (api *AnnotationAPI) HandleAnnotations(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		params, err := parseQueryParams(r)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		annotations, err := clickhouse.ReadAnnotations(r.Context(), api.conn, api.tableName, clickhouse.AnnotationFilter{
+			StartTime: params.StartTime,
+			EndTime:   params.EndTime,
+			DeviceID:  params.DeviceID,
+			Interface: params.Interface,
+			SessionID: params.SessionID,
+			Author:    r.URL.Query().Get("author"),
+			Tags:      r.URL.Query()["tag"],
+			Search:    r.URL.Query().Get("q"),
+			Limit:     params.Limit,
+			Offset:    params.Offset,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+			return
+		}
+		respondWithJSON(w, http.StatusOK, annotations)
+	case http.MethodPost:
+		var annotation models.Annotation
+		if err := parseJSONBody(r, &annotation); err != nil {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+			return
+		}
+		if err := annotation.Validate(); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		annotation.AnnotationID = models.NewRecordingID()
+		annotation.CreatedAt = time.Now()
+		if err := clickhouse.WriteAnnotation(r.Context(), api.conn, api.tableName, &annotation); err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to save annotation: %v", err))
+			return
+		}
+		respondWithJSON(w, http.StatusOK, annotation)
+	default:
+		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// HandleAnnotation reads, updates or deletes an annotation
+// GET /api/annotations/{id}
+// PUT /api/annotations/{id} with the complete annotation as body
+// DELETE /api/annotations/{id}
+func (api *AnnotationAPI) HandleAnnotation(w http.ResponseWriter, r *http.Request) {
+	annotation, err := clickhouse.ReadAnnotation(r.Context(), api.conn, api.tableName, r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+		return
+	}
+	if annotation == nil {
+		respondWithError(w, http.StatusNotFound, "Annotation not found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		respondWithJSON(w, http.StatusOK, annotation)
+	case http.MethodPut:
+		var update models.Annotation
+		if err := parseJSONBody(r, &update); err != nil {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+			return
+		}
+		if err := update.Validate(); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		update.AnnotationID = annotation.AnnotationID
+		update.CreatedAt = annotation.CreatedAt
+		if err := clickhouse.WriteAnnotation(r.Context(), api.conn, api.tableName, &update); err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to save annotation: %v", err))
+			return
+		}
+		respondWithJSON(w, http.StatusOK, update)
+	case http.MethodDelete:
+		if err := clickhouse.DeleteAnnotation(r.Context(), api.conn, api.tableName, annotation); err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete annotation: %v", err))
+			return
+		}
+		respondWithJSON(w, http.StatusOK, map[string]string{"deleted": annotation.AnnotationID})
+	default:
+		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// respondWithAnnotations sends a query result, together with the annotations overlapping the
+// queried range when annotations=true is requested: {"<key>": result, "annotations": [...]}
+func respondWithAnnotations(w http.ResponseWriter, r *http.Request, conn driver.Conn, tableName string, params models.QueryParams, key string, payload any) {
+	if r.URL.Query().Get("annotations") != "true" {
+		respondWithJSON(w, http.StatusOK, payload)
+		return
+	}
+
+	// Without a time range, a session's annotations are those attached to it
+	filter := clickhouse.AnnotationFilter{
+		StartTime: params.StartTime,
+		EndTime:   params.EndTime,
+		DeviceID:  params.DeviceID,
+		Interface: params.Interface,
+		Limit:     annotationsInlineLimit,
+	}
+	if params.StartTime == nil && params.EndTime == nil {
+		filter.SessionID = params.SessionID
+	}
+
+	annotations, err := clickhouse.ReadAnnotations(r.Context(), conn, tableName, filter)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Annotation query failed: %v", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]any{
+		key:           payload,
+		"annotations": annotations,
+	})
+}
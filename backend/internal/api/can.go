@@ -0,0 +1,191 @@
+package api
+
+import (
+	"can-db-writer/internal/can"
+	"can-db-writer/internal/models"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// CANAPI handles HTTP API requests that act on the CAN bus
+type CANAPI struct {
+	transmitter *can.Transmitter
+	scheduler   *can.Scheduler
+	operators   []models.TxOperator
+}
+
+// NewCANAPI creates a new CAN API handler
+func NewCANAPI(transmitter *can.Transmitter, scheduler *can.Scheduler, operators []models.TxOperator) *CANAPI {
+	return &CANAPI{
+		transmitter: transmitter,
+		scheduler:   scheduler,
+		operators:   operators,
+	}
+}
+
+// SendFrame transmits a single frame and returns its echo or loopback confirmation
+// POST /api/can/send
+// Authorization: Bearer <token of an operator in CAN_TX_OPERATORS without a node list>
+//
+// Request body:
+//
+//	{
+//	  "interface": "can0" (must be listed in CAN_TX_INTERFACES),
+//	  "can_id": 1537 (with the EFF flag 0x80000000 for extended IDs, RTR flag 0x40000000 for remote frames),
+//	  "data": [64, 0, 24, 1, 0, 0, 0, 0] (up to 8 bytes),
+//	  "timeout_ms": 1000 (optional, wait for the confirmation, at most 10000)
+//	}
+//
+// The operator is recorded in a frame_sent event; the CAN Reader of the interface records the frame with direction tx.
+// A frame that is not confirmed in time (e.g. no node acknowledges it) answers 504 and may still be sent later.
+func (api *CANAPI) SendFrame(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req models.CANSendRequest
+	if err := parseJSONBody(r, &req); err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+	if req.Interface == "" {
+		respondWithError(w, http.StatusBadRequest, "interface is required")
+		return
+	}
+
+	operator, err := can.Authorize(api.operators, bearerToken(r), 0)
+	if err != nil {
+		respondWithError(w, txStatus(err), err.Error())
+		return
+	}
+	req.User = operator.User
+
+	result, err := api.transmitter.Send(r.Context(), req)
+	if err != nil {
+		respondWithError(w, txStatus(err), err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, result)
+}
+
+// HandleCyclicJobs lists or creates cyclic transmission jobs
+// GET /api/can/cyclic
+// POST /api/can/cyclic
+// Authorization: Bearer <token of an operator in CAN_TX_OPERATORS without a node list> (POST)
+//
+// Request body:
+//
+//	{
+//	  "interface": "can0" (must be listed in CAN_TX_INTERFACES),
+//	  "can_id": 128 (with the EFF flag 0x80000000 for extended IDs),
+//	  "data": [] (up to 8 bytes),
+//	  "period_ms": 1 (0.1 to 3600000),
+//	  "mode": "bcm|timer" (optional, default: bcm, the user-space timer when CAN_BCM is unavailable),
+//	  "paused": false (optional, create without transmitting)
+//	}
+//
+// Creating, updating and deleting a job is recorded in the events table with the operator.
+func (api *CANAPI) HandleCyclicJobs(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		respondWithJSON(w, http.StatusOK, api.scheduler.List())
+	case http.MethodPost:
+		var req models.CyclicJobRequest
+		if err := parseJSONBody(r, &req); err != nil {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+			return
+		}
+		if req.Interface == "" {
+			respondWithError(w, http.StatusBadRequest, "interface is required")
+			return
+		}
+		operator, err := can.Authorize(api.operators, bearerToken(r), 0)
+		if err != nil {
+			respondWithError(w, txStatus(err), err.Error())
+			return
+		}
+		req.User = operator.User
+
+		job, err := api.scheduler.Create(req)
+		if err != nil {
+			respondWithError(w, txStatus(err), err.Error())
+			return
+		}
+		respondWithJSON(w, http.StatusOK, job)
+	default:
+		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// HandleCyclicJob reads, updates or deletes a cyclic transmission job
+// GET|PUT|DELETE /api/can/cyclic/{id}
+// Authorization: Bearer <token of an operator in CAN_TX_OPERATORS without a node list> (PUT, DELETE)
+//
+// PUT body (omitted fields are kept):
+//
+//	{
+//	  "data": [1, 2, 3, 4] (applied from the next transmission without restarting the cycle),
+//	  "period_ms": 10,
+//	  "paused": true
+//	}
+//
+// The job reports its transmitted frames and the mean, standard deviation (jitter_ms) and largest
+// deviation of their period, measured on the frames looped back by the interface.
+func (api *CANAPI) HandleCyclicJob(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var operator models.TxOperator
+	var err error
+	if r.Method == http.MethodPut || r.Method == http.MethodDelete {
+		operator, err = can.Authorize(api.operators, bearerToken(r), 0)
+		if err != nil {
+			respondWithError(w, txStatus(err), err.Error())
+			return
+		}
+	}
+
+	var job models.CyclicJob
+	switch r.Method {
+	case http.MethodGet:
+		job, err = api.scheduler.Get(id)
+	case http.MethodPut:
+		var update models.CyclicJobUpdate
+		if err := parseJSONBody(r, &update); err != nil {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+			return
+		}
+		job, err = api.scheduler.Update(id, update, operator.User)
+	case http.MethodDelete:
+		job, err = api.scheduler.Delete(id, operator.User)
+	default:
+		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if err != nil {
+		respondWithError(w, txStatus(err), err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, job)
+}
+
+// txStatus returns the HTTP status of a transmission error
+func txStatus(err error) int {
+	switch {
+	case errors.Is(err, can.ErrTxDisabled), errors.Is(err, can.ErrTxForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, can.ErrTxUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, can.ErrTxInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, can.ErrTxTimeout):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, can.ErrCyclicNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,760 @@
+package api
+
+import (
+	"can-db-writer/internal/can"
+	"can-db-writer/internal/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// CANopenAPI handles HTTP API requests for decoded CANopen protocol data
+type CANopenAPI struct {
+	conn        driver.Conn
+	tableName   string
+	emcyDecoder *models.EMCYDecoder
+	edsRegistry *models.EDSRegistry
+	driveNodes  []uint8
+	sdoClient   *can.SDOClient
+	operators   []models.TxOperator
+}
+
+// NewCANopenAPI creates a new CANopen API handler
+func NewCANopenAPI(conn driver.Conn, tableName string, emcyDecoder *models.EMCYDecoder, edsRegistry *models.EDSRegistry, driveNodes []uint8, sdoClient *can.SDOClient, operators []models.TxOperator) *CANopenAPI {
+	return &CANopenAPI{
+		conn:        conn,
+		tableName:   tableName,
+		emcyDecoder: emcyDecoder,
+		edsRegistry: edsRegistry,
+		driveNodes:  driveNodes,
+		sdoClient:   sdoClient,
+		operators:   operators,
+	}
+}
+
+// GetEMCY retrieves decoded EMCY frames grouped into active and cleared errors per node
+// GET /api/canopen/emcy?start_time=2024-01-01T00:00:00Z&end_time=2024-01-02T00:00:00Z&interface=can0&node_id=3&history=true&limit=10000
+//
+// Error codes are resolved against the loaded vendor tables first, then CiA 402 and CiA 301.
+// An EMCY with error code 0x0000 (error reset) clears the errors whose error register bit is no longer set.
+// history=true also returns every decoded EMCY frame per node.
+// When more frames than limit match, the newest ones are used.
+func (api *CANopenAPI) GetEMCY(w http.ResponseWriter, r *http.Request) {
+	params, err := parseQueryParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if r.URL.Query().Get("limit") == "" {
+		params.Limit = 10000
+	}
+
+	nodeID, err := parseNodeID(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	query := fmt.Sprintf(`
+		SELECT timestamp, interface, can_id, data
+		FROM %s
+		WHERE can_id >= 0x081 AND can_id <= 0x0FF`, api.tableName)
+	args := []any{}
+
+	if params.StartTime != nil {
+		query += " AND timestamp >= ?"
+		args = append(args, *params.StartTime)
+	}
+	if params.EndTime != nil {
+		query += " AND timestamp <= ?"
+		args = append(args, *params.EndTime)
+	}
+	if params.Interface != "" {
+		query += " AND interface = ?"
+		args = append(args, params.Interface)
+	}
+	if params.DeviceID != "" {
+		query += " AND device_id = ?"
+		args = append(args, params.DeviceID)
+	}
+	if nodeID != nil {
+		query += " AND can_id = ?"
+		args = append(args, 0x080+uint32(*nodeID))
+	}
+
+	// Errors must be replayed in time order to derive the active/cleared state
+	query, args = replayQuery(query, args, params.Limit)
+
+	ctx := context.Background()
+	rows, err := api.conn.Query(ctx, query, args...)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+		return
+	}
+	defer rows.Close()
+
+	tracker := models.NewEMCYTracker(r.URL.Query().Get("history") == "true")
+	for rows.Next() {
+		var timestamp time.Time
+		var iface string
+		var canID uint32
+		var data []uint8
+
+		if err := rows.Scan(&timestamp, &iface, &canID, &data); err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Scan failed: %v", err))
+			return
+		}
+
+		tracker.Add(api.emcyDecoder.Decode(timestamp, iface, canID, data))
+	}
+
+	respondWithJSON(w, http.StatusOK, tracker.Nodes())
+}
+
+// GetNodes retrieves the current NMT state of every node that sent boot-up or heartbeat frames
+// GET /api/canopen/nodes?start_time=2024-01-01T00:00:00Z&end_time=2024-01-02T00:00:00Z&interface=can0
+//
+// Vendor information is included for nodes with a registered EDS/DCF file.
+func (api *CANopenAPI) GetNodes(w http.ResponseWriter, r *http.Request) {
+	params, err := parseQueryParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Bit 7 of the heartbeat state is the node guarding toggle bit
+	query := fmt.Sprintf(`
+		SELECT
+			interface,
+			toUInt8(can_id - 0x700) AS node_id,
+			argMax(bitAnd(data[1], 0x7F), timestamp) AS state,
+			min(timestamp) AS first_seen,
+			max(timestamp) AS last_seen,
+			count() AS heartbeats,
+			countIf(bitAnd(data[1], 0x7F) = 0) AS bootups,
+			maxIf(timestamp, bitAnd(data[1], 0x7F) = 0) AS last_bootup
+		FROM %s
+		WHERE can_id >= 0x701 AND can_id <= 0x77F`, api.tableName)
+	args := []any{}
+
+	if params.StartTime != nil {
+		query += " AND timestamp >= ?"
+		args = append(args, *params.StartTime)
+	}
+	if params.EndTime != nil {
+		query += " AND timestamp <= ?"
+		args = append(args, *params.EndTime)
+	}
+	if params.Interface != "" {
+		query += " AND interface = ?"
+		args = append(args, params.Interface)
+	}
+	if params.DeviceID != "" {
+		query += " AND device_id = ?"
+		args = append(args, params.DeviceID)
+	}
+
+	query += " GROUP BY interface, node_id ORDER BY interface, node_id"
+
+	ctx := context.Background()
+	rows, err := api.conn.Query(ctx, query, args...)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+		return
+	}
+	defer rows.Close()
+
+	nodes := []models.CANopenNodeStatus{}
+	for rows.Next() {
+		var node models.CANopenNodeStatus
+		var state uint8
+		var lastBootup time.Time
+
+		err := rows.Scan(&node.Interface, &node.NodeID, &state, &node.FirstSeen, &node.LastSeen,
+			&node.Heartbeats, &node.Bootups, &lastBootup)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Scan failed: %v", err))
+			return
+		}
+
+		node.State = models.NMTState(state)
+		if node.Bootups > 0 {
+			node.LastBootup = &lastBootup
+		}
+
+		if period, ok := api.edsRegistry.HeartbeatProducerTime(node.NodeID); ok {
+			node.HeartbeatPeriod = float64(period) / float64(time.Millisecond)
+		} else if node.Heartbeats > 1 {
+			node.HeartbeatPeriod = float64(node.LastSeen.Sub(node.FirstSeen)) / float64(time.Millisecond) / float64(node.Heartbeats-1)
+		}
+
+		if eds, ok := api.edsRegistry.Get(node.NodeID); ok {
+			node.EDSFile = eds.FileName
+			node.DeviceInfo = &eds.DeviceInfo
+		}
+
+		nodes = append(nodes, node)
+	}
+
+	respondWithJSON(w, http.StatusOK, nodes)
+}
+
+// GetNodeTimeline retrieves the NMT lifecycle of a node: state transitions with their cause,
+// the NMT commands addressed to it and gaps in its heartbeat
+// GET /api/canopen/nodes/{id}/timeline?start_time=2024-01-01T00:00:00Z&end_time=2024-01-02T00:00:00Z&interface=can0&gap_factor=3&limit=100000
+//
+// A heartbeat gap is reported when no heartbeat arrives within gap_factor times the producer time
+// (0x1017 from the node's DCF, or estimated from the recorded heartbeats), including a silence from the
+// last heartbeat until end_time (or now), reported as ongoing. Commands sent to all nodes are listed for
+// every node of the interface, also nodes whose first heartbeat follows them.
+// When more frames than limit match, the newest ones are used.
+func (api *CANopenAPI) GetNodeTimeline(w http.ResponseWriter, r *http.Request) {
+	params, err := parseQueryParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if r.URL.Query().Get("limit") == "" {
+		params.Limit = 100000
+	}
+
+	nodeID, err := strconv.ParseUint(r.PathValue("id"), 0, 8)
+	if err != nil || nodeID < 1 || nodeID > 127 {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid node id '%s', must be 1-127", r.PathValue("id")))
+		return
+	}
+
+	gapFactor := 3.0
+	if gapFactorStr := r.URL.Query().Get("gap_factor"); gapFactorStr != "" {
+		gapFactor, err = strconv.ParseFloat(gapFactorStr, 64)
+		if err != nil || gapFactor <= 1 {
+			respondWithError(w, http.StatusBadRequest, "invalid gap_factor, must be a number greater than 1")
+			return
+		}
+	}
+
+	query := fmt.Sprintf(`
+		SELECT timestamp, interface, can_id, data
+		FROM %s
+		WHERE (can_id = ? OR (can_id = 0x000 AND (data[2] = 0 OR data[2] = ?)))`, api.tableName)
+	args := []any{0x700 + uint32(nodeID), uint8(nodeID)}
+
+	if params.StartTime != nil {
+		query += " AND timestamp >= ?"
+		args = append(args, *params.StartTime)
+	}
+	if params.EndTime != nil {
+		query += " AND timestamp <= ?"
+		args = append(args, *params.EndTime)
+	}
+	if params.Interface != "" {
+		query += " AND interface = ?"
+		args = append(args, params.Interface)
+	}
+	if params.DeviceID != "" {
+		query += " AND device_id = ?"
+		args = append(args, params.DeviceID)
+	}
+
+	query, args = replayQuery(query, args, params.Limit)
+
+	ctx := context.Background()
+	rows, err := api.conn.Query(ctx, query, args...)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+		return
+	}
+	defer rows.Close()
+
+	tracker := models.NewNMTTracker(gapFactor)
+	if period, ok := api.edsRegistry.HeartbeatProducerTime(uint8(nodeID)); ok {
+		tracker.SetHeartbeatPeriod(uint8(nodeID), period)
+	}
+
+	for rows.Next() {
+		var timestamp time.Time
+		var iface string
+		var canID uint32
+		var data []uint8
+
+		if err := rows.Scan(&timestamp, &iface, &canID, &data); err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Scan failed: %v", err))
+			return
+		}
+
+		tracker.Add(timestamp, iface, canID, data)
+	}
+
+	end := time.Now().UTC()
+	if params.EndTime != nil && params.EndTime.Before(end) {
+		end = *params.EndTime
+	}
+	tracker.Finish(end)
+
+	respondWithJSON(w, http.StatusOK, tracker.Nodes())
+}
+
+// GetDrives retrieves the CiA 402 state history, mode of operation and fault summary of every drive axis
+// GET /api/canopen/drives?start_time=2024-01-01T00:00:00Z&end_time=2024-01-02T00:00:00Z&interface=can0&node_id=3&axis=1&limit=1000000
+//
+// Drives are the nodes whose EDS/DCF declares device profile 402 and the nodes in CANOPEN_DRIVE_NODES;
+// node_id selects a single node, which does not need to be configured.
+// Controlword, statusword and modes of operation are read from the PDOs mapped in the node's DCF
+// (CiA 402 default mapping without one) and from expedited SDO transfers.
+// When more frames than limit match, the newest ones are used.
+func (api *CANopenAPI) GetDrives(w http.ResponseWriter, r *http.Request) {
+	params, err := parseQueryParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if r.URL.Query().Get("limit") == "" {
+		params.Limit = 1000000
+	}
+
+	nodeID, err := parseNodeID(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	axisFilter := 0
+	if axisStr := r.URL.Query().Get("axis"); axisStr != "" {
+		axisFilter, err = strconv.Atoi(axisStr)
+		if err != nil || axisFilter < 1 || axisFilter > models.DS402MaxAxes {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid axis '%s', must be 1-%d", axisStr, models.DS402MaxAxes))
+			return
+		}
+	}
+
+	drives := api.drives(nodeID)
+	if len(drives) == 0 {
+		respondWithJSON(w, http.StatusOK, []*models.DS402Axis{})
+		return
+	}
+
+	pdoMap := models.NewPDOObjectMap()
+	for driveID := range drives {
+		if eds, ok := api.edsRegistry.Get(driveID); ok && pdoMap.AddEDS(driveID, eds) > 0 {
+			continue
+		}
+		pdoMap.AddDS402Defaults(driveID)
+	}
+
+	canIDs := pdoMap.CANIDs()
+	for driveID := range drives {
+		canIDs = append(canIDs, 0x580+uint32(driveID), 0x600+uint32(driveID))
+	}
+
+	query := fmt.Sprintf(`
+		SELECT timestamp, interface, can_id, data
+		FROM %s
+		WHERE has(?, can_id)`, api.tableName)
+	args := []any{canIDs}
+
+	if params.StartTime != nil {
+		query += " AND timestamp >= ?"
+		args = append(args, *params.StartTime)
+	}
+	if params.EndTime != nil {
+		query += " AND timestamp <= ?"
+		args = append(args, *params.EndTime)
+	}
+	if params.Interface != "" {
+		query += " AND interface = ?"
+		args = append(args, params.Interface)
+	}
+	if params.DeviceID != "" {
+		query += " AND device_id = ?"
+		args = append(args, params.DeviceID)
+	}
+
+	// State transitions must be replayed in time order
+	query, args = replayQuery(query, args, params.Limit)
+
+	ctx := context.Background()
+	rows, err := api.conn.Query(ctx, query, args...)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+		return
+	}
+	defer rows.Close()
+
+	tracker := models.NewDS402Tracker()
+	for rows.Next() {
+		var timestamp time.Time
+		var iface string
+		var canID uint32
+		var data []uint8
+
+		if err := rows.Scan(&timestamp, &iface, &canID, &data); err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Scan failed: %v", err))
+			return
+		}
+
+		tracker.AddFrame(timestamp, iface, canID, data, pdoMap, drives)
+	}
+
+	axes := []*models.DS402Axis{}
+	for _, axis := range tracker.Axes() {
+		if axisFilter == 0 || axis.Axis == axisFilter {
+			axes = append(axes, axis)
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, axes)
+}
+
+// HandleODEntry reads or writes an object dictionary entry of a node with an SDO transfer
+// GET /api/canopen/nodes/{id}/od/{index}/{subindex}?interface=can0&data_type=0x0007&transfer=block&timeout_ms=1000
+// PUT /api/canopen/nodes/{id}/od/{index}/{subindex}
+// Authorization: Bearer <token of an operator in CAN_TX_OPERATORS allowed to command the node>
+//
+// index and subindex are hexadecimal, with or without 0x (e.g. /od/1018/1 or /od/0x6060/0x00).
+// Values are encoded and decoded by the data type of the node's EDS/DCF, or data_type for objects
+// not in it. Transfers use the expedited protocol for up to 4 bytes and the segmented one otherwise;
+// transfer=block requests a block transfer, falling back when the node does not support it.
+//
+// PUT body:
+//
+//	{
+//	  "interface": "can0" (must be listed in CAN_TX_INTERFACES),
+//	  "value": 1000 (number, string or boolean, e.g. "0x3E8", "$NODEID+0x180", "01 02 0A" for octet strings),
+//	  "data": [232, 3, 0, 0] (optional, raw bytes instead of value),
+//	  "data_type": 7 (optional, overrides the EDS data type),
+//	  "transfer": "block" (optional),
+//	  "timeout_ms": 1000 (optional, wait for each SDO response, at most 10000)
+//	}
+//
+// An SDO abort by the node answers 502 with its abort code and description, no response 504.
+// Every write is recorded as an sdo_write event with the operator and its outcome.
+func (api *CANopenAPI) HandleODEntry(w http.ResponseWriter, r *http.Request) {
+	nodeID, err := strconv.ParseUint(r.PathValue("id"), 0, 8)
+	if err != nil || nodeID < 1 || nodeID > 127 {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid node id '%s', must be 1-127", r.PathValue("id")))
+		return
+	}
+	index, err := parseODIndex(r.PathValue("index"), 16)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	subIndex, err := parseODIndex(r.PathValue("subindex"), 8)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	operator, err := can.Authorize(api.operators, bearerToken(r), uint8(nodeID))
+	if err != nil {
+		respondWithError(w, txStatus(err), err.Error())
+		return
+	}
+
+	var entry *models.ODEntry
+	if eds, ok := api.edsRegistry.Get(uint8(nodeID)); ok {
+		entry, _ = eds.Entry(uint16(index), uint8(subIndex))
+	}
+	result := sdoResult(uint8(nodeID), uint16(index), uint8(subIndex), entry)
+
+	switch r.Method {
+	case http.MethodGet:
+		query := r.URL.Query()
+		result.Interface = query.Get("interface")
+		if result.Interface == "" {
+			respondWithError(w, http.StatusBadRequest, "interface is required")
+			return
+		}
+		dataType, err := parseUintParam(r, "data_type", 16)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if dataType != nil {
+			result.DataType = uint16(*dataType)
+		}
+		timeoutMs, err := strconv.Atoi(query.Get("timeout_ms"))
+		if err != nil {
+			timeoutMs = 0
+		}
+
+		transfer, err := api.sdoClient.Upload(result.Interface, result.NodeID, result.Index, result.SubIndex,
+			time.Duration(timeoutMs)*time.Millisecond, query.Get("transfer") == models.SDOTransferBlock)
+		if err != nil {
+			respondWithError(w, sdoStatus(err), err.Error())
+			return
+		}
+		setSDOTransfer(&result, transfer)
+		if result.DataType != 0 {
+			if result.Value, err = models.DecodeODValue(result.DataType, transfer.Data); err != nil {
+				result.Error = err.Error()
+			}
+		}
+	case http.MethodPut:
+		var req models.SDOWriteRequest
+		if err := parseJSONBody(r, &req); err != nil {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+			return
+		}
+		if req.Interface == "" {
+			respondWithError(w, http.StatusBadRequest, "interface is required")
+			return
+		}
+		req.User = operator.User
+		result.Interface = req.Interface
+		result.User = req.User
+		if req.DataType != 0 {
+			result.DataType = req.DataType
+		}
+
+		data := req.Data
+		if len(data) == 0 {
+			if len(req.Value) == 0 {
+				respondWithError(w, http.StatusBadRequest, "value or data is required")
+				return
+			}
+			if result.DataType == 0 {
+				respondWithError(w, http.StatusBadRequest, fmt.Sprintf("data_type is required, 0x%04Xsub%X is not in the EDS/DCF of node %d", index, subIndex, nodeID))
+				return
+			}
+			value := string(req.Value)
+			var text string
+			if json.Unmarshal(req.Value, &text) == nil {
+				value = text
+			}
+			if data, err = models.EncodeODValue(result.DataType, value, result.NodeID); err != nil {
+				respondWithError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+
+		transfer, err := api.sdoClient.Download(result.Interface, result.NodeID, result.Index, result.SubIndex, data,
+			time.Duration(req.TimeoutMs)*time.Millisecond, req.Transfer == models.SDOTransferBlock, req.User)
+		if err != nil {
+			respondWithError(w, sdoStatus(err), err.Error())
+			return
+		}
+		setSDOTransfer(&result, transfer)
+		if result.DataType != 0 {
+			result.Value, _ = models.DecodeODValue(result.DataType, data)
+		}
+	default:
+		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, result)
+}
+
+// ReadODSection reads every entry of a DCF section from a node and compares it with the configured value
+// GET /api/canopen/nodes/{id}/od/{section}?interface=can0&transfer=block&timeout_ms=1000
+// Authorization: Bearer <token of an operator in CAN_TX_OPERATORS allowed to command the node>
+//
+// section is an object list of the node's EDS/DCF (mandatory, optional or manufacturer), all for
+// every object, or a hexadecimal index for the subindexes of one object (e.g. 1018).
+// Entries are read one after the other; an abort is reported on the entry and the batch continues,
+// while a node that stops responding ends it and the remaining entries are skipped.
+// differs is set on the entries whose value does not match the DCF ParameterValue (or DefaultValue).
+func (api *CANopenAPI) ReadODSection(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	nodeID, err := strconv.ParseUint(r.PathValue("id"), 0, 8)
+	if err != nil || nodeID < 1 || nodeID > 127 {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid node id '%s', must be 1-127", r.PathValue("id")))
+		return
+	}
+	if _, err := can.Authorize(api.operators, bearerToken(r), uint8(nodeID)); err != nil {
+		respondWithError(w, txStatus(err), err.Error())
+		return
+	}
+	query := r.URL.Query()
+	iface := query.Get("interface")
+	if iface == "" {
+		respondWithError(w, http.StatusBadRequest, "interface is required")
+		return
+	}
+	timeoutMs, err := strconv.Atoi(query.Get("timeout_ms"))
+	if err != nil {
+		timeoutMs = 0
+	}
+	block := query.Get("transfer") == models.SDOTransferBlock
+
+	eds, ok := api.edsRegistry.Get(uint8(nodeID))
+	if !ok {
+		respondWithError(w, http.StatusNotFound, fmt.Sprintf("no EDS/DCF registered for node %d", nodeID))
+		return
+	}
+
+	section := strings.ToLower(r.PathValue("section"))
+	var indexes []uint16
+	switch section {
+	case "all":
+		for index := range eds.Objects {
+			indexes = append(indexes, index)
+		}
+		sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+	case "mandatory", "optional", "manufacturer":
+		if indexes, ok = eds.Section(section); !ok {
+			respondWithError(w, http.StatusNotFound, fmt.Sprintf("%s has no [%sObjects] section", eds.FileName, strings.ToUpper(section[:1])+section[1:]))
+			return
+		}
+	default:
+		index, err := parseODIndex(section, 16)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid section '%s', must be mandatory, optional, manufacturer, all or an index", section))
+			return
+		}
+		if _, ok := eds.Objects[uint16(index)]; !ok {
+			respondWithError(w, http.StatusNotFound, fmt.Sprintf("object 0x%04X is not in %s", index, eds.FileName))
+			return
+		}
+		indexes = []uint16{uint16(index)}
+	}
+
+	batch := models.SDOBatchResult{
+		NodeID:    uint8(nodeID),
+		Interface: iface,
+		EDSFile:   eds.FileName,
+		Section:   section,
+		Entries:   []models.SDOResult{},
+	}
+	start := time.Now()
+	timedOut := false
+	for _, index := range indexes {
+		for _, entry := range eds.Entries(index) {
+			// Arrays and records are read through their subindexes
+			if entry.DataType == 0 {
+				continue
+			}
+			result := sdoResult(batch.NodeID, entry.Index, entry.SubIndex, entry)
+			result.Interface = iface
+			result.ConfiguredValue = entry.RawValue()
+			if timedOut || entry.AccessType == "wo" {
+				batch.Skipped++
+				continue
+			}
+
+			transfer, err := api.sdoClient.Upload(iface, batch.NodeID, entry.Index, entry.SubIndex,
+				time.Duration(timeoutMs)*time.Millisecond, block)
+			if err != nil {
+				// Transmission not enabled or a socket error fails every entry
+				var abort *can.SDOAbortError
+				if !errors.As(err, &abort) && !errors.Is(err, can.ErrTxTimeout) {
+					respondWithError(w, sdoStatus(err), err.Error())
+					return
+				}
+				if abort != nil {
+					result.AbortCode = abort.Code
+				}
+				result.Error = err.Error()
+				timedOut = errors.Is(err, can.ErrTxTimeout)
+				batch.Failed++
+				batch.Entries = append(batch.Entries, result)
+				continue
+			}
+
+			setSDOTransfer(&result, transfer)
+			if result.Value, err = models.DecodeODValue(result.DataType, transfer.Data); err != nil {
+				result.Error = err.Error()
+			} else if result.ConfiguredValue != "" {
+				// Compared decoded, as the node may answer with more bytes than the type holds
+				if configured, err := models.EncodeODValue(result.DataType, result.ConfiguredValue, batch.NodeID); err == nil {
+					want, _ := models.DecodeODValue(result.DataType, configured)
+					result.Differs = fmt.Sprint(want) != fmt.Sprint(result.Value)
+				}
+			}
+			if result.Differs {
+				batch.Differs++
+			}
+			batch.Read++
+			batch.Entries = append(batch.Entries, result)
+		}
+	}
+	batch.DurationMs = float64(time.Since(start)) / float64(time.Millisecond)
+
+	respondWithJSON(w, http.StatusOK, batch)
+}
+
+// sdoResult creates the result of a transfer with the EDS/DCF description of the entry
+func sdoResult(nodeID uint8, index uint16, subIndex uint8, entry *models.ODEntry) models.SDOResult {
+	result := models.SDOResult{
+		NodeID:   nodeID,
+		Index:    index,
+		SubIndex: subIndex,
+		IndexHex: fmt.Sprintf("0x%04Xsub%X", index, subIndex),
+	}
+	if entry != nil {
+		result.ParameterName = entry.ParameterName
+		result.DataType = entry.DataType
+		result.AccessType = entry.AccessType
+	}
+	return result
+}
+
+// setSDOTransfer sets the data and protocol of a completed transfer on its result
+func setSDOTransfer(result *models.SDOResult, transfer can.SDOTransfer) {
+	result.Data = transfer.Data
+	result.DataHex = fmt.Sprintf("% X", transfer.Data)
+	result.Size = len(transfer.Data)
+	result.Transfer = transfer.Transfer
+	result.DurationMs = float64(transfer.Duration) / float64(time.Millisecond)
+}
+
+// sdoStatus returns the HTTP status of an SDO transfer error
+func sdoStatus(err error) int {
+	var abort *can.SDOAbortError
+	if errors.As(err, &abort) {
+		return http.StatusBadGateway
+	}
+	return txStatus(err)
+}
+
+// parseODIndex parses a hexadecimal object dictionary index or subindex, with or without 0x
+func parseODIndex(value string, bitSize int) (uint64, error) {
+	digits := strings.TrimPrefix(strings.ToLower(value), "0x")
+	n, err := strconv.ParseUint(digits, 16, bitSize)
+	if err != nil {
+		return 0, fmt.Errorf("invalid object dictionary index '%s', must be hexadecimal", value)
+	}
+	return n, nil
+}
+
+// drives returns the node IDs to decode as CiA 402 drives
+func (api *CANopenAPI) drives(nodeID *uint8) map[uint8]bool {
+	drives := make(map[uint8]bool)
+	if nodeID != nil {
+		drives[*nodeID] = true
+		return drives
+	}
+
+	for _, id := range api.edsRegistry.Nodes() {
+		if profile, ok := api.edsRegistry.DeviceProfile(id); ok && profile == 402 {
+			drives[id] = true
+		}
+	}
+	for _, id := range api.driveNodes {
+		drives[id] = true
+	}
+	return drives
+}
+
+// replayQuery sorts the frames of a query in time order for replaying them, keeping the newest frames
+// when more than limit match so the derived current state is not cut off at the oldest ones
+func replayQuery(query string, args []any, limit int) (string, []any) {
+	query += " ORDER BY timestamp DESC"
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+	return fmt.Sprintf("SELECT * FROM (%s) ORDER BY timestamp ASC", query), args
+}
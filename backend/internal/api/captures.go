@@ -0,0 +1,114 @@
+package api
+
+import (
+	"can-db-writer/internal/models"
+	"fmt"
+	"net/http"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// CaptureAPI handles HTTP API requests for triggered captures
+type CaptureAPI struct {
+	conn      driver.Conn
+	tableName string
+}
+
+// NewCaptureAPI creates a new capture API handler
+func NewCaptureAPI(conn driver.Conn, tableName string) *CaptureAPI {
+	return &CaptureAPI{
+		conn:      conn,
+		tableName: tableName,
+	}
+}
+
+// GetCaptures lists the triggered captures recorded by the CAN reader, newest first
+// GET /api/captures?recording_id=...&device_id=AMR-0042&interface=can0&trigger=drive_fault&trigger_type=emcy&status=complete&start_time=2024-01-01T00:00:00Z&end_time=2024-01-02T00:00:00Z&limit=100&offset=0
+//
+// start_time and end_time select the captures overlapping the range. The frames of a capture are
+// retrieved from /api/clickhouse/messages with its device_id, interface, start_time and end_time.
+func (api *CaptureAPI) GetCaptures(w http.ResponseWriter, r *http.Request) {
+	params, err := parseQueryParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			recording_id, interface, status, trigger_name, trigger_type, trigger_detail,
+			trigger_time, start_time, end_time, frames, triggers, pre_trigger_ms, post_trigger_ms, updated_at, device_id
+		FROM %s FINAL
+		WHERE 1=1`, api.tableName)
+	args := []any{}
+
+	if recordingID := r.URL.Query().Get("recording_id"); recordingID != "" {
+		query += " AND recording_id = ?"
+		args = append(args, recordingID)
+	}
+	if params.StartTime != nil {
+		query += " AND end_time >= ?"
+		args = append(args, *params.StartTime)
+	}
+	if params.EndTime != nil {
+		query += " AND start_time <= ?"
+		args = append(args, *params.EndTime)
+	}
+	if params.DeviceID != "" {
+		query += " AND device_id = ?"
+		args = append(args, params.DeviceID)
+	}
+	if params.Interface != "" {
+		query += " AND interface = ?"
+		args = append(args, params.Interface)
+	}
+	if trigger := r.URL.Query().Get("trigger"); trigger != "" {
+		query += " AND trigger_name = ?"
+		args = append(args, trigger)
+	}
+	if triggerType := r.URL.Query().Get("trigger_type"); triggerType != "" {
+		query += " AND trigger_type = ?"
+		args = append(args, triggerType)
+	}
+	if status := r.URL.Query().Get("status"); status != "" {
+		query += " AND status = ?"
+		args = append(args, status)
+	}
+
+	query += " ORDER BY trigger_time DESC"
+
+	if params.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, params.Limit)
+	}
+
+	if params.Offset > 0 {
+		query += " OFFSET ?"
+		args = append(args, params.Offset)
+	}
+
+	rows, err := api.conn.Query(r.Context(), query, args...)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+		return
+	}
+	defer rows.Close()
+
+	captures := []models.Capture{}
+	for rows.Next() {
+		var capture models.Capture
+		err := rows.Scan(
+			&capture.RecordingID, &capture.Interface, &capture.Status, &capture.TriggerName,
+			&capture.TriggerType, &capture.TriggerDetail, &capture.TriggerTime, &capture.StartTime,
+			&capture.EndTime, &capture.Frames, &capture.Triggers, &capture.PreTriggerMs,
+			&capture.PostTriggerMs, &capture.UpdatedAt, &capture.DeviceID,
+		)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Scan failed: %v", err))
+			return
+		}
+		captures = append(captures, capture)
+	}
+
+	respondWithJSON(w, http.StatusOK, captures)
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 	"path/filepath"
+	"sort"
 	"time"
 
 	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
@@ -14,22 +15,28 @@ import (
 
 // ClickHouseAPI handles HTTP API requests for ClickHouse data
 type ClickHouseAPI struct {
-	conn      driver.Conn
-	tableName string
-	writer    *clickhouse.Writer
+	conn             driver.Conn
+	tableName        string
+	eventsTable      string
+	sessionsTable    string
+	annotationsTable string
+	writer           *clickhouse.Writer
 }
 
 // NewClickHouseAPI creates a new ClickHouse API handler
-func NewClickHouseAPI(conn driver.Conn, tableName string, writer *clickhouse.Writer) *ClickHouseAPI {
+func NewClickHouseAPI(conn driver.Conn, tableName, eventsTable, sessionsTable, annotationsTable string, writer *clickhouse.Writer) *ClickHouseAPI {
 	return &ClickHouseAPI{
-		conn:      conn,
-		tableName: tableName,
-		writer:    writer,
+		conn:             conn,
+		tableName:        tableName,
+		eventsTable:      eventsTable,
+		sessionsTable:    sessionsTable,
+		annotationsTable: annotationsTable,
+		writer:           writer,
 	}
 }
 
 // GetCANopenMessages retrieves CAN messages classified by CANopen message type
-// GET /api/clickhouse/canopen/messages?message_type=pdo&start_time=2024-01-01T00:00:00Z&end_time=2024-01-02T00:00:00Z&interface=can0&limit=100&offset=0
+// GET /api/clickhouse/canopen/messages?message_type=pdo&start_time=2024-01-01T00:00:00Z&end_time=2024-01-02T00:00:00Z&interface=can0&session_id=...&limit=100&offset=0
 // message_type can be: nmt, sync, emcy, pdo, sdo, or empty for all
 // Multiple message types: message_type=pdo&message_type=sdo&message_type=nmt
 //
@@ -41,6 +48,8 @@ func NewClickHouseAPI(conn driver.Conn, tableName string, writer *clickhouse.Wri
 // Types: int8, uint8, int16, uint16, int32, uint32
 //
 // node_id filter: node_id=1 (filter by specific CANopen node ID)
+//
+// annotations=true returns {"messages": [...], "annotations": [...]} with the overlapping annotations
 func (api *ClickHouseAPI) GetCANopenMessages(w http.ResponseWriter, r *http.Request) {
 	params, err := parseQueryParams(r)
 	if err != nil {
@@ -190,6 +199,14 @@ func (api *ClickHouseAPI) GetCANopenMessages(w http.ResponseWriter, r *http.Requ
 		query += " AND interface = ?"
 		args = append(args, params.Interface)
 	}
+	if params.DeviceID != "" {
+		query += " AND device_id = ?"
+		args = append(args, params.DeviceID)
+	}
+	if params.SessionID != "" {
+		query += " AND session_id = ?"
+		args = append(args, params.SessionID)
+	}
 
 	// Add node_id filter if specified
 	if nodeIDFilter != nil {
@@ -278,7 +295,213 @@ func (api *ClickHouseAPI) GetCANopenMessages(w http.ResponseWriter, r *http.Requ
 		messages = append(messages, msg)
 	}
 
-	respondWithJSON(w, http.StatusOK, messages)
+	respondWithAnnotations(w, r, api.conn, api.annotationsTable, params, "messages", messages)
+}
+
+// GetMessages retrieves raw CAN messages without protocol decoding
+// GET /api/clickhouse/messages?start_time=2024-01-01T00:00:00Z&end_time=2024-01-02T00:00:00Z&can_id=0x123&interface=can0&session_id=...&direction=tx&limit=100&offset=0
+//
+// direction=tx selects the frames transmitted from the recording host, direction=rx the received frames.
+// Frames sent through the API are returned with the operator of their frame_sent event as user.
+//
+// With max_points (and optional downsample=minmax|lttb, value=int16:2) the frames of each CAN ID are
+// downsampled to at most that many, keeping the frames with the minimum and maximum value of every time
+// bucket. value is a little-endian TYPE:BYTE_OFFSET of the payload (default the whole payload as uint64);
+// limit and offset are ignored.
+//
+// With annotations=true the response is {"messages": [...], "annotations": [...]} with the annotations
+// overlapping the time range.
+func (api *ClickHouseAPI) GetMessages(w http.ResponseWriter, r *http.Request) {
+	params, err := parseQueryParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	downsample, err := parseDownsampleParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	where := " WHERE 1=1"
+	args := []any{}
+
+	if params.StartTime != nil {
+		where += " AND timestamp >= ?"
+		args = append(args, *params.StartTime)
+	}
+	if params.EndTime != nil {
+		where += " AND timestamp <= ?"
+		args = append(args, *params.EndTime)
+	}
+	if params.CANID != nil {
+		where += " AND can_id = ?"
+		args = append(args, *params.CANID)
+	}
+	if params.Interface != "" {
+		where += " AND interface = ?"
+		args = append(args, params.Interface)
+	}
+	if params.DeviceID != "" {
+		where += " AND device_id = ?"
+		args = append(args, params.DeviceID)
+	}
+	if params.SessionID != "" {
+		where += " AND session_id = ?"
+		args = append(args, params.SessionID)
+	}
+	switch direction := r.URL.Query().Get("direction"); direction {
+	case "":
+	case models.DirectionTX:
+		where += " AND direction = ?"
+		args = append(args, models.DirectionTX)
+	case models.DirectionRX:
+		// Frames written before transmission have no direction
+		where += " AND direction != ?"
+		args = append(args, models.DirectionTX)
+	default:
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid direction '%s', must be rx or tx", direction))
+		return
+	}
+
+	if downsample != nil {
+		valueExpr, err := parsePayloadValue(r)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		api.getDownsampledMessages(w, r, params, where, args, valueExpr, downsample)
+		return
+	}
+
+	// The API records its operator in a frame_sent event with the kernel timestamp of the looped back frame
+	query := fmt.Sprintf(`
+		SELECT timestamp, interface, can_id, data, session_id, device_id, direction, if(user != '', user, sent_by)
+		FROM %s
+		LEFT ANY JOIN (
+			SELECT timestamp AS sent_at, interface AS sent_on, details['user'] AS sent_by
+			FROM %s
+			WHERE event_type = '%s'
+		) AS sent ON timestamp = sent_at AND interface = sent_on`, api.tableName, api.eventsTable, models.EventFrameSent) + where + " ORDER BY timestamp DESC"
+
+	if params.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, params.Limit)
+	}
+	if params.Offset > 0 {
+		query += " OFFSET ?"
+		args = append(args, params.Offset)
+	}
+
+	ctx := context.Background()
+	rows, err := api.conn.Query(ctx, query, args...)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+		return
+	}
+	defer rows.Close()
+
+	messages := []models.CANMessageResponse{}
+	for rows.Next() {
+		var msg models.CANMessageResponse
+		if err := rows.Scan(&msg.Timestamp, &msg.Interface, &msg.CANID, &msg.Data, &msg.SessionID, &msg.DeviceID, &msg.Direction, &msg.User); err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Scan failed: %v", err))
+			return
+		}
+
+		messages = append(messages, formatMessage(msg))
+	}
+
+	respondWithAnnotations(w, r, api.conn, api.annotationsTable, params, "messages", messages)
+}
+
+// getDownsampledMessages responds with the frames at the minimum and maximum payload value of each
+// time bucket per interface and CAN ID, reduced with LTTB if requested, newest first
+func (api *ClickHouseAPI) getDownsampledMessages(w http.ResponseWriter, r *http.Request, params models.QueryParams, where string, args []any, valueExpr string, downsample *downsampleParams) {
+	start, end, ok, err := queryTimeRange(api.conn, api.tableName, params, where, args)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+		return
+	}
+	if !ok {
+		respondWithAnnotations(w, r, api.conn, api.annotationsTable, params, "messages", []models.CANMessageResponse{})
+		return
+	}
+
+	// Ties on the value are broken by timestamp so each extreme comes from a single frame
+	query := fmt.Sprintf(`
+		SELECT
+			interface, can_id, bucket,
+			argMin(timestamp, (value, timestamp)), argMin(data, (value, timestamp)), min(value),
+			argMax(timestamp, (value, timestamp)), argMax(data, (value, timestamp)), max(value)
+		FROM (
+			SELECT timestamp, interface, can_id, data, %s AS value,
+				intDiv(toUnixTimestamp64Micro(timestamp) - %d, %d) AS bucket
+			FROM %s`, valueExpr, start.UnixMicro(), downsample.bucketWidth(start, end), api.tableName) + where + `
+		)
+		GROUP BY interface, can_id, bucket ORDER BY interface, can_id, bucket`
+
+	ctx := context.Background()
+	rows, err := api.conn.Query(ctx, query, args...)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+		return
+	}
+	defer rows.Close()
+
+	candidates := []models.CANMessageResponse{}
+	messages := []models.CANMessageResponse{}
+	var points []models.DownsamplePoint
+
+	// flush reduces the points of the current CAN ID and keeps the selected frames
+	flush := func() {
+		for _, point := range downsample.reduce(points) {
+			messages = append(messages, formatMessage(candidates[point.Index]))
+		}
+		points = points[:0]
+	}
+
+	for rows.Next() {
+		var minMsg, maxMsg models.CANMessageResponse
+		var bucket int64
+		var minValue, maxValue float64
+		err := rows.Scan(&minMsg.Interface, &minMsg.CANID, &bucket,
+			&minMsg.Timestamp, &minMsg.Data, &minValue,
+			&maxMsg.Timestamp, &maxMsg.Data, &maxValue)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Scan failed: %v", err))
+			return
+		}
+		maxMsg.Interface, maxMsg.CANID = minMsg.Interface, minMsg.CANID
+
+		if len(points) > 0 {
+			last := candidates[points[len(points)-1].Index]
+			if last.Interface != minMsg.Interface || last.CANID != minMsg.CANID {
+				flush()
+			}
+		}
+
+		candidates = append(candidates, minMsg, maxMsg)
+		points = extremePoints(points,
+			models.DownsamplePoint{X: minMsg.Timestamp.UnixMicro(), Y: minValue, Index: len(candidates) - 2},
+			models.DownsamplePoint{X: maxMsg.Timestamp.UnixMicro(), Y: maxValue, Index: len(candidates) - 1})
+	}
+	flush()
+
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].Timestamp.After(messages[j].Timestamp)
+	})
+
+	respondWithAnnotations(w, r, api.conn, api.annotationsTable, params, "messages", messages)
+}
+
+// formatMessage fills the display fields of a raw CAN message
+func formatMessage(msg models.CANMessageResponse) models.CANMessageResponse {
+	msg.CANIDHex = fmt.Sprintf("0x%X", msg.CANID)
+	msg.DLC = uint8(len(msg.Data))
+	msg.DataHex = fmt.Sprintf("% X", msg.Data)
+	return msg
 }
 
 // ExportData exports CAN messages to Parquet or Iceberg format
@@ -287,8 +510,10 @@ func (api *ClickHouseAPI) GetCANopenMessages(w http.ResponseWriter, r *http.Requ
 // {
 //   "start_time": "2024-01-01T00:00:00Z",
 //   "end_time": "2024-01-02T00:00:00Z",
+//   "session_id": "..." (optional, exports the frames of a recording session instead of the time range),
+//   "device_id": "robot-01" (optional, exports the frames of a single device),
 //   "format": "parquet|iceberg" (optional, default: parquet),
-//   "filename": "export.parquet" (optional, default: can_messages_YYYYMMDD.parquet or .iceberg),
+//   "filename": "export.parquet" (optional, default: can_messages_YYYYMMDD.parquet or can_session_<id>.parquet),
 //   "compression": "snappy|lz4|brotli|zstd|gzip|none" (optional, default: zstd)
 // }
 // Response: File download in the requested format
@@ -301,6 +526,8 @@ func (api *ClickHouseAPI) ExportData(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		StartTime   string `json:"start_time"`
 		EndTime     string `json:"end_time"`
+		SessionID   string `json:"session_id"`
+		DeviceID    string `json:"device_id"`
 		Format      string `json:"format"`
 		Filename    string `json:"filename"`
 		Compression string `json:"compression"`
@@ -311,17 +538,39 @@ func (api *ClickHouseAPI) ExportData(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse times
-	startTime, err := time.Parse(time.RFC3339, req.StartTime)
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid start_time format: %v", err))
-		return
-	}
+	// Parse times, or look up the session
+	var startTime, endTime time.Time
+	var err error
+	filePrefix := ""
+	if req.SessionID != "" {
+		session, err := clickhouse.ReadSession(r.Context(), api.conn, api.sessionsTable, req.SessionID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+			return
+		}
+		if session == nil {
+			respondWithError(w, http.StatusNotFound, "Session not found")
+			return
+		}
+		startTime = session.StartTime
+		endTime = time.Now()
+		if session.EndTime != nil {
+			endTime = *session.EndTime
+		}
+		filePrefix = "can_session_" + session.SessionID
+	} else {
+		startTime, err = time.Parse(time.RFC3339, req.StartTime)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid start_time format: %v", err))
+			return
+		}
 
-	endTime, err := time.Parse(time.RFC3339, req.EndTime)
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid end_time format: %v", err))
-		return
+		endTime, err = time.Parse(time.RFC3339, req.EndTime)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid end_time format: %v", err))
+			return
+		}
+		filePrefix = "can_messages_" + startTime.Format("20060102")
 	}
 
 	// Set default format
@@ -366,7 +615,7 @@ func (api *ClickHouseAPI) ExportData(w http.ResponseWriter, r *http.Request) {
 	// Generate filename if not provided
 	filename := req.Filename
 	if filename == "" {
-		filename = fmt.Sprintf("can_messages_%s%s", startTime.Format("20060102"), defaultExt)
+		filename = filePrefix + defaultExt
 	}
 	// Ensure correct extension
 	if filepath.Ext(filename) != defaultExt {
@@ -375,10 +624,13 @@ func (api *ClickHouseAPI) ExportData(w http.ResponseWriter, r *http.Request) {
 
 	// Create export options
 	opts := clickhouse.ExportOptions{
-		Format:      exportFormat,
-		StartTime:   startTime,
-		EndTime:     endTime,
-		Compression: req.Compression,
+		Format:           exportFormat,
+		StartTime:        startTime,
+		EndTime:          endTime,
+		Compression:      req.Compression,
+		SessionID:        req.SessionID,
+		DeviceID:         req.DeviceID,
+		AnnotationsTable: api.annotationsTable,
 	}
 
 	// Set HTTP headers for file download
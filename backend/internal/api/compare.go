@@ -0,0 +1,240 @@
+package api
+
+import (
+	"can-db-writer/internal/models"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// GetCompare compares the traffic of two time windows, e.g. two recordings of the same machine
+// GET /api/analysis/compare?a_start=2024-01-01T00:00:00Z&a_end=2024-01-01T00:10:00Z&b_start=2024-01-02T00:00:00Z&b_end=2024-01-02T00:10:00Z&interface=can0&a_interface=can0&b_interface=can1&can_id=0x181&name=actual_velocity&tolerance=10
+//
+// Window A is the baseline. interface applies to both windows unless overridden by a_interface or b_interface.
+// The report lists the CAN IDs present in only one window, period and jitter changes, payload byte
+// distribution changes, distribution changes of the decoded signals given by name (repeatable), and
+// EMCY codes new in B or no longer seen in B. Changes below tolerance percent (default 10) are omitted.
+func (api *AnalysisAPI) GetCompare(w http.ResponseWriter, r *http.Request) {
+	params, err := parseQueryParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	windowA, err := parseCompareWindow(r, "a", params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	windowB, err := parseCompareWindow(r, "b", params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tolerance := models.CompareDefaultTolerance
+	if toleranceStr := r.URL.Query().Get("tolerance"); toleranceStr != "" {
+		tolerance, err = strconv.ParseFloat(toleranceStr, 64)
+		if err != nil || tolerance < 0 {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid tolerance '%s', must be a non-negative percentage", toleranceStr))
+			return
+		}
+	}
+
+	names := r.URL.Query()["name"]
+
+	ctx := r.Context()
+	a, err := api.compareWindow(ctx, windowA, names)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	b, err := api.compareWindow(ctx, windowB, names)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.CompareWindows(a, b, tolerance))
+}
+
+// parseCompareWindow parses the required <prefix>_start and <prefix>_end and the optional
+// <prefix>_interface and <prefix>_device_id of a compared window, inheriting interface,
+// device_id and can_id from params
+func parseCompareWindow(r *http.Request, prefix string, params models.QueryParams) (models.QueryParams, error) {
+	window := models.QueryParams{CANID: params.CANID, Interface: params.Interface, DeviceID: params.DeviceID}
+
+	for _, bound := range []struct {
+		name   string
+		target **time.Time
+	}{
+		{prefix + "_start", &window.StartTime},
+		{prefix + "_end", &window.EndTime},
+	} {
+		valueStr := r.URL.Query().Get(bound.name)
+		if valueStr == "" {
+			return window, fmt.Errorf("%s is required", bound.name)
+		}
+		t, err := time.Parse(time.RFC3339, valueStr)
+		if err != nil {
+			return window, fmt.Errorf("invalid %s format: %v", bound.name, err)
+		}
+		*bound.target = &t
+	}
+	if !window.EndTime.After(*window.StartTime) {
+		return window, fmt.Errorf("%s_end must be after %s_start", prefix, prefix)
+	}
+
+	if iface := r.URL.Query().Get(prefix + "_interface"); iface != "" {
+		window.Interface = iface
+	}
+	if deviceID := r.URL.Query().Get(prefix + "_device_id"); deviceID != "" {
+		window.DeviceID = deviceID
+	}
+
+	return window, nil
+}
+
+// compareWindow summarises the CAN IDs, payload bytes, signals and EMCY codes of a window
+func (api *AnalysisAPI) compareWindow(ctx context.Context, params models.QueryParams, names []string) (*models.CompareWindow, error) {
+	window := models.NewCompareWindow(params.Interface, *params.StartTime, *params.EndTime)
+
+	where := " WHERE timestamp >= ? AND timestamp <= ?"
+	args := []any{*params.StartTime, *params.EndTime}
+
+	if params.CANID != nil {
+		where += " AND can_id = ?"
+		args = append(args, *params.CANID)
+	}
+	if params.Interface != "" {
+		where += " AND interface = ?"
+		args = append(args, params.Interface)
+	}
+	if params.DeviceID != "" {
+		where += " AND device_id = ?"
+		args = append(args, params.DeviceID)
+	}
+
+	// Inter-arrival statistics of every CAN ID; the first frame of each has no predecessor
+	query := fmt.Sprintf(`
+		SELECT can_id, count(),
+			avgIf(gap_us, has_previous), stddevPopIf(gap_us, has_previous),
+			toFloat64(quantileExactIf(0.5)(gap_us, has_previous))
+		FROM (
+			SELECT can_id,
+				toUnixTimestamp64Micro(previous) > 0 AS has_previous,
+				toUnixTimestamp64Micro(timestamp) - toUnixTimestamp64Micro(previous) AS gap_us
+			FROM (
+				SELECT can_id, timestamp,
+					lagInFrame(timestamp) OVER (PARTITION BY interface, can_id ORDER BY timestamp ROWS BETWEEN 1 PRECEDING AND CURRENT ROW) AS previous
+				FROM %s%s
+			)
+		)
+		GROUP BY can_id`, api.tableName, where)
+
+	rows, err := api.conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Query failed: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var stats models.CANIDWindowStats
+		var periodUs, jitterUs, p50Us float64
+		if err := rows.Scan(&stats.CANID, &stats.Frames, &periodUs, &jitterUs, &p50Us); err != nil {
+			return nil, fmt.Errorf("Scan failed: %v", err)
+		}
+		if stats.Frames > 1 {
+			stats.PeriodMs, stats.JitterMs, stats.P50Ms = periodUs/1000, jitterUs/1000, p50Us/1000
+		}
+		window.AddCANID(stats)
+	}
+
+	// Value distribution of every payload byte, error frames excluded
+	query = fmt.Sprintf(`
+		SELECT can_id, toUInt32(position - 1), count(), min(value), max(value), avg(value), stddevPop(value), uniqExact(value)
+		FROM %s
+		ARRAY JOIN data AS value, arrayEnumerate(data) AS position`, api.tableName) + where + `
+			AND bitAnd(can_id, 0x20000000) = 0
+		GROUP BY can_id, position`
+
+	rows, err = api.conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Query failed: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var canID, index uint32
+		var minValue, maxValue uint8
+		var dist models.ValueDistribution
+		if err := rows.Scan(&canID, &index, &dist.Count, &minValue, &maxValue, &dist.Mean, &dist.StdDev, &dist.Distinct); err != nil {
+			return nil, fmt.Errorf("Scan failed: %v", err)
+		}
+		dist.Min, dist.Max = float64(minValue), float64(maxValue)
+		window.AddByte(canID, int(index), dist)
+	}
+
+	if len(names) > 0 {
+		signalWhere := " WHERE has(?, signal) AND timestamp >= ? AND timestamp <= ?"
+		signalArgs := []any{names, *params.StartTime, *params.EndTime}
+		if params.Interface != "" {
+			signalWhere += " AND interface = ?"
+			signalArgs = append(signalArgs, params.Interface)
+		}
+		if params.DeviceID != "" {
+			signalWhere += " AND device_id = ?"
+			signalArgs = append(signalArgs, params.DeviceID)
+		}
+
+		query = fmt.Sprintf(`
+			SELECT signal, node_id, any(unit), count(),
+				min(value_float), max(value_float), avg(value_float), stddevPop(value_float), uniqExact(value_float)
+			FROM %s`, api.signalsTable) + signalWhere + " GROUP BY signal, node_id"
+
+		rows, err = api.conn.Query(ctx, query, signalArgs...)
+		if err != nil {
+			return nil, fmt.Errorf("Query failed: %v", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var signal, unit string
+			var nodeID uint8
+			var dist models.ValueDistribution
+			if err := rows.Scan(&signal, &nodeID, &unit, &dist.Count, &dist.Min, &dist.Max, &dist.Mean, &dist.StdDev, &dist.Distinct); err != nil {
+				return nil, fmt.Errorf("Scan failed: %v", err)
+			}
+			window.AddSignal(signal, nodeID, unit, dist)
+		}
+	}
+
+	// EMCY error codes, without "error reset or no error"
+	query = fmt.Sprintf(`
+		SELECT can_id, toUInt16(data[1] + data[2] * 256) AS code, count(), min(timestamp)
+		FROM %s`, api.tableName) + where + `
+			AND can_id >= 0x081 AND can_id <= 0x0FF
+		GROUP BY can_id, code
+		HAVING code != 0`
+
+	rows, err = api.conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Query failed: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var canID uint32
+		var code models.EMCYCodeCount
+		if err := rows.Scan(&canID, &code.ErrorCode, &code.Count, &code.FirstSeen); err != nil {
+			return nil, fmt.Errorf("Scan failed: %v", err)
+		}
+		code.NodeID = uint8(canID - 0x080)
+		code.Description, _, _ = api.emcyDecoder.Describe(code.NodeID, code.ErrorCode)
+		window.AddEMCY(code)
+	}
+
+	return window, nil
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"can-db-writer/internal/models"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+const (
+	// devicesDefaultRange is the range searched for devices without start_time
+	devicesDefaultRange = 24 * time.Hour
+	// devicesDefaultRateWindow is the window the ingest rate is measured over without rate_window
+	devicesDefaultRateWindow = time.Minute
+)
+
+// DeviceAPI handles HTTP API requests for the devices writing to the database
+type DeviceAPI struct {
+	conn      driver.Conn
+	tableName string
+}
+
+// NewDeviceAPI creates a new device API handler
+func NewDeviceAPI(conn driver.Conn, tableName string) *DeviceAPI {
+	return &DeviceAPI{
+		conn:      conn,
+		tableName: tableName,
+	}
+}
+
+// GetDevices lists the devices that ingested frames in the range, with their last-seen time and ingest rate
+// GET /api/devices?device_id=robot-01&site=plant-a&interface=can0&start_time=2024-01-01T00:00:00Z&end_time=2024-01-02T00:00:00Z&rate_window=1m
+//
+// start_time defaults to 24 hours before end_time, end_time to now. The ingest rate is the frames
+// per second received during the rate_window (default: 1m) before end_time; 0 for a silent device.
+func (api *DeviceAPI) GetDevices(w http.ResponseWriter, r *http.Request) {
+	params, err := parseQueryParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rateWindow, err := parseDurationParam(r, "rate_window")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if rateWindow == 0 {
+		rateWindow = devicesDefaultRateWindow
+	}
+
+	endTime := time.Now()
+	if params.EndTime != nil {
+		endTime = *params.EndTime
+	}
+	startTime := endTime.Add(-devicesDefaultRange)
+	if params.StartTime != nil {
+		startTime = *params.StartTime
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			device_id,
+			argMax(site, timestamp),
+			argMax(hw_revision, timestamp),
+			arraySort(groupUniqArray(interface)),
+			min(timestamp),
+			max(timestamp),
+			count(),
+			countIf(timestamp > ?)
+		FROM %s
+		WHERE timestamp >= ? AND timestamp <= ?`, api.tableName)
+	args := []any{endTime.Add(-rateWindow), startTime, endTime}
+
+	if params.DeviceID != "" {
+		query += " AND device_id = ?"
+		args = append(args, params.DeviceID)
+	}
+	if site := r.URL.Query().Get("site"); site != "" {
+		query += " AND site = ?"
+		args = append(args, site)
+	}
+	if params.Interface != "" {
+		query += " AND interface = ?"
+		args = append(args, params.Interface)
+	}
+
+	query += " GROUP BY device_id ORDER BY device_id"
+
+	rows, err := api.conn.Query(r.Context(), query, args...)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+		return
+	}
+	defer rows.Close()
+
+	devices := []models.DeviceInfo{}
+	for rows.Next() {
+		var device models.DeviceInfo
+		var recent uint64
+		err := rows.Scan(
+			&device.DeviceID, &device.Site, &device.HWRevision, &device.Interfaces,
+			&device.FirstSeen, &device.LastSeen, &device.Frames, &recent,
+		)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Scan failed: %v", err))
+			return
+		}
+		device.IngestRate = float64(recent) / rateWindow.Seconds()
+		devices = append(devices, device)
+	}
+
+	respondWithJSON(w, http.StatusOK, devices)
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"can-db-writer/internal/models"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// downsampleParams holds the max_points and downsample query parameters
+type downsampleParams struct {
+	MaxPoints int
+	Method    string
+}
+
+// parseDownsampleParams parses max_points and downsample (minmax or lttb, default minmax)
+// Returns nil when max_points is not set.
+func parseDownsampleParams(r *http.Request) (*downsampleParams, error) {
+	maxPointsStr := r.URL.Query().Get("max_points")
+	if maxPointsStr == "" {
+		return nil, nil
+	}
+
+	maxPoints, err := strconv.Atoi(maxPointsStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid max_points '%s': %v", maxPointsStr, err)
+	}
+
+	method := r.URL.Query().Get("downsample")
+	if method == "" {
+		method = models.DownsampleMinMax
+	}
+
+	if err := models.ValidateDownsample(method, maxPoints); err != nil {
+		return nil, err
+	}
+
+	return &downsampleParams{MaxPoints: maxPoints, Method: method}, nil
+}
+
+// bucketWidth returns the bucket width in microseconds that splits [start, end] into the
+// per-series bucket count of the downsampling method
+func (p *downsampleParams) bucketWidth(start, end time.Time) int64 {
+	buckets := int64(models.DownsampleBuckets(p.Method, p.MaxPoints))
+	width := (end.Sub(start).Microseconds() + buckets) / buckets
+	return max(width, 1)
+}
+
+// reduce downsamples the per-bucket extremes of one series, ordered by time, to max_points
+func (p *downsampleParams) reduce(points []models.DownsamplePoint) []models.DownsamplePoint {
+	if p.Method == models.DownsampleLTTB {
+		return models.LTTB(points, p.MaxPoints)
+	}
+	return points
+}
+
+// queryTimeRange returns the requested time range, filling unset bounds from the first and last
+// rows matching the filter. ok is false when no rows match.
+func queryTimeRange(conn driver.Conn, tableName string, params models.QueryParams, where string, args []any) (start, end time.Time, ok bool, err error) {
+	if params.StartTime != nil && params.EndTime != nil {
+		return *params.StartTime, *params.EndTime, true, nil
+	}
+
+	var count uint64
+	query := fmt.Sprintf("SELECT count(), min(timestamp), max(timestamp) FROM %s", tableName) + where
+	if err := conn.QueryRow(context.Background(), query, args...).Scan(&count, &start, &end); err != nil {
+		return start, end, false, err
+	}
+	if count == 0 {
+		return start, end, false, nil
+	}
+
+	if params.StartTime != nil {
+		start = *params.StartTime
+	}
+	if params.EndTime != nil {
+		end = *params.EndTime
+	}
+	return start, end, true, nil
+}
+
+// extremePoints appends the minimum and maximum of a bucket in time order, once if they are the same row
+func extremePoints(points []models.DownsamplePoint, minPoint, maxPoint models.DownsamplePoint) []models.DownsamplePoint {
+	if minPoint.X == maxPoint.X {
+		return append(points, minPoint)
+	}
+	if maxPoint.X < minPoint.X {
+		minPoint, maxPoint = maxPoint, minPoint
+	}
+	return append(points, minPoint, maxPoint)
+}
+
+// payloadValueTypes maps the payload value types to their width in bytes and ClickHouse reinterpret function
+var payloadValueTypes = map[string]struct {
+	width    int
+	function string
+}{
+	"int8":   {1, "reinterpretAsInt8"},
+	"uint8":  {1, "reinterpretAsUInt8"},
+	"int16":  {2, "reinterpretAsInt16"},
+	"uint16": {2, "reinterpretAsUInt16"},
+	"int32":  {4, "reinterpretAsInt32"},
+	"uint32": {4, "reinterpretAsUInt32"},
+	"int64":  {8, "reinterpretAsInt64"},
+	"uint64": {8, "reinterpretAsUInt64"},
+}
+
+// parsePayloadValue parses the value parameter (TYPE:BYTE_OFFSET, little-endian, e.g. "int16:2") into
+// a ClickHouse expression over the data column. Defaults to the whole payload as uint64.
+func parsePayloadValue(r *http.Request) (string, error) {
+	valueStr := r.URL.Query().Get("value")
+	if valueStr == "" {
+		valueStr = "uint64:0"
+	}
+
+	parts := strings.Split(valueStr, ":")
+	if len(parts) != 2 {
+		return "", fmt.Errorf("invalid value '%s', expected format: type:offset", valueStr)
+	}
+
+	valueType, ok := payloadValueTypes[strings.TrimSpace(parts[0])]
+	if !ok {
+		return "", fmt.Errorf("invalid value type '%s', must be one of: int8, uint8, int16, uint16, int32, uint32, int64, uint64", parts[0])
+	}
+
+	offset, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil || offset < 0 || offset+valueType.width > 8 {
+		return "", fmt.Errorf("invalid value offset '%s', the value must fit in the 8 data bytes", parts[1])
+	}
+
+	// Payload bytes as a binary string; missing bytes of short frames read as zero
+	return fmt.Sprintf("toFloat64(%s(substring(arrayStringConcat(arrayMap(b -> char(b), data)), %d, %d)))",
+		valueType.function, offset+1, valueType.width), nil
+}
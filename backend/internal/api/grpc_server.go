@@ -6,10 +6,18 @@ import (
 	"log"
 	"net"
 
+	"can-db-writer/internal/can"
+	"can-db-writer/internal/database/clickhouse"
+	"can-db-writer/internal/models"
+
+	// Registers the gzip compressor used by edge replication clients
+	_ "google.golang.org/grpc/encoding/gzip"
+
 	cangrpc "can-db-writer/internal/grpc"
 
 	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/reflection"
 )
 
@@ -20,15 +28,25 @@ type GRPCServer struct {
 	canService *cangrpc.CANServer
 }
 
-// NewGRPCServer creates a new gRPC server
-func NewGRPCServer(port int, chConn driver.Conn, tableName string) (*GRPCServer, error) {
+// NewGRPCServer creates a new gRPC server, serving TLS with the certificate and key files when given
+func NewGRPCServer(port int, tlsCert, tlsKey string, chConn driver.Conn, tableName, replicationTable string, writer *clickhouse.Writer, transmitter *can.Transmitter, scheduler *can.Scheduler, operators []models.TxOperator, producers []models.Producer) (*GRPCServer, error) {
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
 	if err != nil {
 		return nil, fmt.Errorf("failed to listen on port %d: %w", port, err)
 	}
 
-	grpcServer := grpc.NewServer()
-	canService := cangrpc.NewCANServer(chConn, tableName)
+	var options []grpc.ServerOption
+	if tlsCert != "" || tlsKey != "" {
+		creds, err := credentials.NewServerTLSFromFile(tlsCert, tlsKey)
+		if err != nil {
+			lis.Close()
+			return nil, fmt.Errorf("failed to load gRPC TLS certificate: %w", err)
+		}
+		options = append(options, grpc.Creds(creds))
+	}
+
+	grpcServer := grpc.NewServer(options...)
+	canService := cangrpc.NewCANServer(chConn, tableName, replicationTable, writer, transmitter, scheduler, operators, producers)
 
 	// Register the service
 	pb.RegisterCanServiceServer(grpcServer, canService)
@@ -0,0 +1,278 @@
+package api
+
+import (
+	"can-db-writer/internal/models"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// j1939MaxFrames caps the number of raw frames replayed for a J1939 message query
+const j1939MaxFrames = 1000000
+
+// j1939PGNExpr extracts the PGN from a stored 29-bit CAN ID (PS is only part of the PGN for PDU2)
+const j1939PGNExpr = `if(bitAnd(bitShiftRight(can_id, 16), 0xFF) < 240,
+				bitAnd(bitShiftRight(can_id, 8), 0x3FF00),
+				bitAnd(bitShiftRight(can_id, 8), 0x3FFFF))`
+
+// J1939API handles HTTP API requests for SAE J1939 data
+type J1939API struct {
+	conn      driver.Conn
+	tableName string
+	database  *models.J1939Database
+}
+
+// NewJ1939API creates a new J1939 API handler
+func NewJ1939API(conn driver.Conn, tableName string, database *models.J1939Database) *J1939API {
+	return &J1939API{
+		conn:      conn,
+		tableName: tableName,
+		database:  database,
+	}
+}
+
+// GetMessages retrieves decoded J1939 messages, with TP.CM/TP.DT multi-packet messages reassembled
+// GET /api/j1939/messages?start_time=2024-01-01T00:00:00Z&end_time=2024-01-02T00:00:00Z&interface=can1&session_id=...&pgn=61444&sa=0x00&da=0xFF&limit=100&offset=0
+//
+// Only extended (29-bit) frames are considered. SPNs are decoded with the standard SAE J1939-71
+// definitions and the databases in J1939_SPN_DATABASES. Aborted or timed out transfers are
+// returned with an error and the data received so far.
+func (api *J1939API) GetMessages(w http.ResponseWriter, r *http.Request) {
+	params, err := parseQueryParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	pgn, err := parseUintParam(r, "pgn", 18)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	sa, err := parseUintParam(r, "sa", 8)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	da, err := parseUintParam(r, "da", 8)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	query := fmt.Sprintf(`
+		SELECT timestamp, interface, can_id, data
+		FROM %s
+		WHERE bitAnd(can_id, 0x80000000) != 0`, api.tableName)
+	args := []any{}
+
+	if params.StartTime != nil {
+		query += " AND timestamp >= ?"
+		args = append(args, *params.StartTime)
+	}
+	if params.EndTime != nil {
+		query += " AND timestamp <= ?"
+		args = append(args, *params.EndTime)
+	}
+	if params.Interface != "" {
+		query += " AND interface = ?"
+		args = append(args, params.Interface)
+	}
+	if params.DeviceID != "" {
+		query += " AND device_id = ?"
+		args = append(args, params.DeviceID)
+	}
+	if params.SessionID != "" {
+		query += " AND session_id = ?"
+		args = append(args, params.SessionID)
+	}
+	if sa != nil {
+		// Transport protocol frames are sent by the same source address
+		query += " AND bitAnd(can_id, 0xFF) = ?"
+		args = append(args, uint32(*sa))
+	}
+
+	// Multi-packet messages must be reassembled in time order
+	query += " ORDER BY timestamp ASC LIMIT ?"
+	args = append(args, j1939MaxFrames)
+
+	ctx := context.Background()
+	rows, err := api.conn.Query(ctx, query, args...)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+		return
+	}
+	defer rows.Close()
+
+	messages := []models.J1939Message{}
+	skipped := 0
+	collect := func(decoded []models.J1939Message) {
+		for _, msg := range decoded {
+			if (pgn != nil && msg.PGN != uint32(*pgn)) || (da != nil && msg.DA != uint8(*da)) {
+				continue
+			}
+			if skipped < params.Offset {
+				skipped++
+				continue
+			}
+			if params.Limit <= 0 || len(messages) < params.Limit {
+				messages = append(messages, msg)
+			}
+		}
+	}
+
+	reassembler := models.NewJ1939Reassembler(api.database)
+	for rows.Next() {
+		var timestamp time.Time
+		var iface string
+		var canID uint32
+		var data []uint8
+
+		if err := rows.Scan(&timestamp, &iface, &canID, &data); err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Scan failed: %v", err))
+			return
+		}
+
+		collect(reassembler.Add(timestamp, iface, canID, data))
+		if params.Limit > 0 && len(messages) >= params.Limit {
+			break
+		}
+	}
+	if params.Limit <= 0 || len(messages) < params.Limit {
+		collect(reassembler.Flush())
+	}
+
+	respondWithJSON(w, http.StatusOK, messages)
+}
+
+// GetNodes retrieves the J1939 controller applications seen on the bus with the parameter groups
+// they send and their address claims
+// GET /api/j1939/nodes?start_time=2024-01-01T00:00:00Z&end_time=2024-01-02T00:00:00Z&interface=can1
+//
+// conflict is set when different NAMEs claimed the same address in the time range.
+func (api *J1939API) GetNodes(w http.ResponseWriter, r *http.Request) {
+	params, err := parseQueryParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	where := " WHERE bitAnd(can_id, 0x80000000) != 0"
+	args := []any{}
+
+	if params.StartTime != nil {
+		where += " AND timestamp >= ?"
+		args = append(args, *params.StartTime)
+	}
+	if params.EndTime != nil {
+		where += " AND timestamp <= ?"
+		args = append(args, *params.EndTime)
+	}
+	if params.Interface != "" {
+		where += " AND interface = ?"
+		args = append(args, params.Interface)
+	}
+	if params.DeviceID != "" {
+		where += " AND device_id = ?"
+		args = append(args, params.DeviceID)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			interface,
+			toUInt8(bitAnd(can_id, 0xFF)) AS sa,
+			toUInt32(%s) AS pgn,
+			min(timestamp) AS first_seen,
+			max(timestamp) AS last_seen,
+			count() AS message_count
+		FROM %s`, j1939PGNExpr, api.tableName) + where + `
+		GROUP BY interface, sa, pgn
+		ORDER BY interface, sa, pgn`
+
+	ctx := context.Background()
+	rows, err := api.conn.Query(ctx, query, args...)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+		return
+	}
+	defer rows.Close()
+
+	nodes := []*models.J1939Node{}
+	var node *models.J1939Node
+	for rows.Next() {
+		var iface string
+		var sa uint8
+		var pgn uint32
+		var firstSeen, lastSeen time.Time
+		var count uint64
+
+		if err := rows.Scan(&iface, &sa, &pgn, &firstSeen, &lastSeen, &count); err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Scan failed: %v", err))
+			return
+		}
+
+		if node == nil || node.Interface != iface || node.Address != sa {
+			node = &models.J1939Node{
+				Interface: iface,
+				Address:   sa,
+				FirstSeen: firstSeen,
+				LastSeen:  lastSeen,
+				PGNs:      []models.J1939PGNInfo{},
+			}
+			nodes = append(nodes, node)
+		}
+
+		if firstSeen.Before(node.FirstSeen) {
+			node.FirstSeen = firstSeen
+		}
+		if lastSeen.After(node.LastSeen) {
+			node.LastSeen = lastSeen
+		}
+		node.MessageCount += count
+		node.PGNs = append(node.PGNs, models.J1939PGNInfo{
+			PGN:    pgn,
+			PGNHex: fmt.Sprintf("0x%04X", pgn),
+			Name:   api.database.PGNName(pgn),
+			Count:  count,
+		})
+	}
+
+	// Address claims in time order
+	claimQuery := fmt.Sprintf(`
+		SELECT timestamp, interface, can_id, data
+		FROM %s`, api.tableName) + where + fmt.Sprintf(`
+		AND %s = ?
+		ORDER BY timestamp ASC
+		LIMIT 100000`, j1939PGNExpr)
+
+	claimRows, err := api.conn.Query(ctx, claimQuery, append(args, models.J1939PGNAddressClaim)...)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+		return
+	}
+	defer claimRows.Close()
+
+	claims := models.NewJ1939ClaimTracker()
+	for claimRows.Next() {
+		var timestamp time.Time
+		var iface string
+		var canID uint32
+		var data []uint8
+
+		if err := claimRows.Scan(&timestamp, &iface, &canID, &data); err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Scan failed: %v", err))
+			return
+		}
+
+		claims.Add(timestamp, iface, canID, data)
+	}
+
+	for _, node := range nodes {
+		claims.Apply(node)
+	}
+
+	respondWithJSON(w, http.StatusOK, nodes)
+}
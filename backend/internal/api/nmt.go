@@ -0,0 +1,249 @@
+package api
+
+import (
+	"can-db-writer/internal/can"
+	"can-db-writer/internal/database/clickhouse"
+	"can-db-writer/internal/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// NMTAPI handles HTTP API requests that send NMT commands and the audit log of these commands
+type NMTAPI struct {
+	conn        driver.Conn
+	auditTable  string
+	master      *can.NMTMaster
+	operators   []models.TxOperator
+	edsRegistry *models.EDSRegistry
+	device      models.Device
+}
+
+// NewNMTAPI creates a new NMT API handler
+func NewNMTAPI(conn driver.Conn, auditTable string, master *can.NMTMaster, operators []models.TxOperator, edsRegistry *models.EDSRegistry, device models.Device) *NMTAPI {
+	return &NMTAPI{
+		conn:        conn,
+		auditTable:  auditTable,
+		master:      master,
+		operators:   operators,
+		edsRegistry: edsRegistry,
+		device:      device,
+	}
+}
+
+// SendCommand sends an NMT command to a node or to all nodes and waits for the expected state
+// POST /api/canopen/nmt
+// Authorization: Bearer <token of an operator in CAN_TX_OPERATORS allowed to command the node>
+//
+// Request body:
+//
+//	{
+//	  "interface": "can0" (must be listed in CAN_TX_INTERFACES),
+//	  "command": "start|stop|pre_operational|reset_node|reset_communication",
+//	  "node_id": 3 (0 to broadcast to all nodes, only for operators without a node list),
+//	  "nodes": [3, 4] (optional, nodes expected to answer a broadcast, default: the nodes with an EDS/DCF),
+//	  "timeout_ms": 3000 (optional, wait for the expected state, at most 20000)
+//	}
+//
+// Start, stop and pre_operational are confirmed by the node's heartbeat, the resets by its boot-up.
+// A node that does not report the expected state in time answers 504 with the per-node results.
+// Every attempt, including invalid (rejected), denied and failed ones, is recorded in the audit log.
+func (api *NMTAPI) SendCommand(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// The operator is looked up for the audit of invalid requests, authorized once the node is known
+	var user string
+	if token := bearerToken(r); token != "" {
+		if operator, ok := models.FindTxOperator(api.operators, token); ok {
+			user = operator.User
+		}
+	}
+
+	var req models.NMTCommandRequest
+	if err := parseJSONBody(r, &req); err != nil {
+		api.reject(w, r, req, user, models.NMTOutcomeRejected, http.StatusBadRequest, fmt.Errorf("Invalid request body: %v", err))
+		return
+	}
+	if req.Interface == "" {
+		api.reject(w, r, req, user, models.NMTOutcomeRejected, http.StatusBadRequest, errors.New("interface is required"))
+		return
+	}
+
+	operator, err := can.Authorize(api.operators, bearerToken(r), req.NodeID)
+	if err != nil {
+		api.reject(w, r, req, operator.User, models.NMTOutcomeDenied, txStatus(err), err)
+		return
+	}
+
+	if req.NodeID == 0 && len(req.Nodes) == 0 {
+		req.Nodes = api.edsRegistry.Nodes()
+	}
+
+	result, err := api.master.Command(r.Context(), req, operator.User)
+	if err != nil {
+		// Nothing is sent for an invalid request
+		if errors.Is(err, can.ErrTxInvalid) {
+			api.reject(w, r, req, operator.User, models.NMTOutcomeRejected, http.StatusBadRequest, err)
+			return
+		}
+		result.Error = err.Error()
+		api.audit(r, &result)
+		respondWithError(w, txStatus(err), err.Error())
+		return
+	}
+
+	api.audit(r, &result)
+	if result.Outcome == models.NMTOutcomeTimeout {
+		respondWithJSON(w, http.StatusGatewayTimeout, result)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, result)
+}
+
+// GetAudit retrieves the audit log of the NMT commands, newest first
+// GET /api/canopen/nmt/audit?start_time=2024-01-01T00:00:00Z&end_time=2024-01-02T00:00:00Z&interface=can0&node_id=3&user=kim&outcome=denied&limit=100&offset=0
+//
+// node_id also returns the broadcasts, which addressed the node as well.
+func (api *NMTAPI) GetAudit(w http.ResponseWriter, r *http.Request) {
+	params, err := parseQueryParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	nodeID, err := parseNodeID(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, timestamp, device_id, interface, command, node_id, expected_state, user, remote_addr,
+			outcome, nodes, duration_ms, error
+		FROM %s
+		WHERE 1=1`, api.auditTable)
+	args := []any{}
+
+	if params.StartTime != nil {
+		query += " AND timestamp >= ?"
+		args = append(args, *params.StartTime)
+	}
+	if params.EndTime != nil {
+		query += " AND timestamp <= ?"
+		args = append(args, *params.EndTime)
+	}
+	if params.Interface != "" {
+		query += " AND interface = ?"
+		args = append(args, params.Interface)
+	}
+	if params.DeviceID != "" {
+		query += " AND device_id = ?"
+		args = append(args, params.DeviceID)
+	}
+	if nodeID != nil {
+		query += " AND (node_id = ? OR node_id = 0)"
+		args = append(args, *nodeID)
+	}
+	if user := r.URL.Query().Get("user"); user != "" {
+		query += " AND user = ?"
+		args = append(args, user)
+	}
+	if outcome := r.URL.Query().Get("outcome"); outcome != "" {
+		query += " AND outcome = ?"
+		args = append(args, outcome)
+	}
+
+	query += " ORDER BY timestamp DESC"
+
+	if params.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, params.Limit)
+	}
+
+	if params.Offset > 0 {
+		query += " OFFSET ?"
+		args = append(args, params.Offset)
+	}
+
+	rows, err := api.conn.Query(r.Context(), query, args...)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+		return
+	}
+	defer rows.Close()
+
+	results := []models.NMTCommandResult{}
+	for rows.Next() {
+		var result models.NMTCommandResult
+		var command, expectedState, nodes string
+		err := rows.Scan(
+			&result.ID, &result.Timestamp, &result.DeviceID, &result.Interface, &command, &result.NodeID,
+			&expectedState, &result.User, &result.RemoteAddr, &result.Outcome, &nodes, &result.DurationMs, &result.Error,
+		)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Scan failed: %v", err))
+			return
+		}
+
+		result.Broadcast = result.NodeID == 0
+		result.Audited = true
+		if command != "" {
+			result.Command.UnmarshalText([]byte(command))
+		}
+		result.ExpectedState.UnmarshalText([]byte(expectedState))
+		if err := json.Unmarshal([]byte(nodes), &result.Nodes); err != nil {
+			result.Nodes = []models.NMTNodeResult{}
+		}
+		results = append(results, result)
+	}
+
+	respondWithJSON(w, http.StatusOK, results)
+}
+
+// reject records a command attempt that was not sent in the audit log and answers with its error
+func (api *NMTAPI) reject(w http.ResponseWriter, r *http.Request, req models.NMTCommandRequest, user, outcome string, status int, err error) {
+	result := models.NMTCommandResult{
+		Timestamp:     time.Now().UTC(),
+		Interface:     req.Interface,
+		NodeID:        req.NodeID,
+		Broadcast:     req.NodeID == 0,
+		ExpectedState: models.NMTStateUnknown,
+		User:          user,
+		Outcome:       outcome,
+		Nodes:         []models.NMTNodeResult{},
+		Error:         err.Error(),
+	}
+	if command, err := models.ParseNMTCommand(req.Command); err == nil {
+		result.Command = command
+		result.ExpectedState = command.ExpectedState()
+	}
+	api.audit(r, &result)
+	respondWithError(w, status, err.Error())
+}
+
+// audit records an NMT command attempt in the audit log and the server log
+func (api *NMTAPI) audit(r *http.Request, result *models.NMTCommandResult) {
+	result.ID = models.NewRecordingID()
+	result.DeviceID = api.device.DeviceID
+	result.RemoteAddr = r.RemoteAddr
+
+	if result.Outcome != models.NMTOutcomeSuccess && result.Outcome != models.NMTOutcomeTimeout {
+		log.Printf("NMT %s to node %d on %s %s for '%s' from %s: %s",
+			result.Command, result.NodeID, result.Interface, result.Outcome, result.User, result.RemoteAddr, result.Error)
+	}
+
+	// Recorded even when the requester went away
+	if err := clickhouse.WriteNMTAudit(context.Background(), api.conn, api.auditTable, *result); err != nil {
+		log.Printf("Warning: Failed to record NMT audit %s: %v", result.ID, err)
+		return
+	}
+	result.Audited = true
+}
@@ -0,0 +1,264 @@
+package api
+
+import (
+	"can-db-writer/internal/database/clickhouse"
+	"can-db-writer/internal/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// protocolMaxFrames caps the number of frames analysed for a protocol detection
+const protocolMaxFrames = 500000
+
+// protocolDefaultWindow is the capture window analysed when no start_time is given
+const protocolDefaultWindow = 60 * time.Second
+
+// ProtocolAPI detects the protocol used on each interface and routes message queries to the matching decoder
+type ProtocolAPI struct {
+	conn         driver.Conn
+	tableName    string
+	profileTable string
+	edsRegistry  *models.EDSRegistry
+	database     *models.J1939Database
+	decoders     map[string]http.HandlerFunc
+}
+
+// NewProtocolAPI creates a new protocol API handler; decoders maps each protocol to the handler
+// serving its decoded messages
+func NewProtocolAPI(conn driver.Conn, tableName, profileTable string, edsRegistry *models.EDSRegistry, database *models.J1939Database, decoders map[string]http.HandlerFunc) *ProtocolAPI {
+	return &ProtocolAPI{
+		conn:         conn,
+		tableName:    tableName,
+		profileTable: profileTable,
+		edsRegistry:  edsRegistry,
+		database:     database,
+		decoders:     decoders,
+	}
+}
+
+// DetectProtocols analyses a capture window, stores the resulting profile of each interface and returns them
+// POST /api/protocols/detect?interface=can0&start_time=2024-01-01T00:00:00Z&end_time=2024-01-01T00:01:00Z
+//
+// Without start_time, the last window_sec seconds (default 60) before end_time or the latest frame are analysed.
+func (api *ProtocolAPI) DetectProtocols(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	params, err := parseQueryParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	window := protocolDefaultWindow
+	if windowStr := r.URL.Query().Get("window_sec"); windowStr != "" {
+		seconds, err := strconv.Atoi(windowStr)
+		if err != nil || seconds <= 0 {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid window_sec '%s', must be a positive number of seconds", windowStr))
+			return
+		}
+		window = time.Duration(seconds) * time.Second
+	}
+
+	profiles, err := api.detect(r.Context(), params, window)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, profiles)
+}
+
+// GetProfiles retrieves the latest stored protocol profile of each interface
+// GET /api/protocols?interface=can0
+func (api *ProtocolAPI) GetProfiles(w http.ResponseWriter, r *http.Request) {
+	profiles, err := api.latest(r.Context(), r.URL.Query().Get("interface"))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, profiles)
+}
+
+// GetDecodedMessages returns the messages of an interface decoded with the decoder of its detected protocol
+// GET /api/messages/decoded?interface=can0&start_time=2024-01-01T00:00:00Z&limit=100
+//
+// canopen is served as /api/clickhouse/canopen/messages, j1939 as /api/j1939/messages and raw as
+// /api/clickhouse/messages, with the same query parameters. The protocol is taken from the latest
+// stored profile; an interface without one is detected first. protocol=canopen|j1939|raw overrides
+// the profile. The selected protocol is returned in the X-CAN-Protocol header.
+func (api *ProtocolAPI) GetDecodedMessages(w http.ResponseWriter, r *http.Request) {
+	iface := r.URL.Query().Get("interface")
+	if iface == "" {
+		respondWithError(w, http.StatusBadRequest, "interface is required")
+		return
+	}
+
+	protocol := r.URL.Query().Get("protocol")
+	if protocol == "" {
+		profiles, err := api.latest(r.Context(), iface)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if len(profiles) == 0 {
+			profiles, err = api.detect(r.Context(), models.QueryParams{Interface: iface}, protocolDefaultWindow)
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+		}
+		if len(profiles) == 0 {
+			respondWithError(w, http.StatusNotFound, fmt.Sprintf("No frames recorded on interface '%s'", iface))
+			return
+		}
+		protocol = profiles[0].Protocol
+	}
+
+	decoder, ok := api.decoders[protocol]
+	if !ok {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid protocol '%s', must be one of: canopen, j1939, raw", protocol))
+		return
+	}
+
+	w.Header().Set("X-CAN-Protocol", protocol)
+	decoder(w, r)
+}
+
+// detect runs the protocol detector over a capture window and stores the profiles
+func (api *ProtocolAPI) detect(ctx context.Context, params models.QueryParams, window time.Duration) ([]models.ProtocolProfile, error) {
+	if params.StartTime == nil {
+		end := params.EndTime
+		if end == nil {
+			query := fmt.Sprintf("SELECT max(timestamp), count() FROM %s WHERE 1=1", api.tableName)
+			args := []any{}
+			if params.Interface != "" {
+				query += " AND interface = ?"
+				args = append(args, params.Interface)
+			}
+			if params.DeviceID != "" {
+				query += " AND device_id = ?"
+				args = append(args, params.DeviceID)
+			}
+
+			var latest time.Time
+			var count uint64
+			if err := api.conn.QueryRow(ctx, query, args...).Scan(&latest, &count); err != nil {
+				return nil, fmt.Errorf("Query failed: %v", err)
+			}
+			if count == 0 {
+				return []models.ProtocolProfile{}, nil
+			}
+			end = &latest
+		}
+		start := end.Add(-window)
+		params.StartTime = &start
+		params.EndTime = end
+	}
+
+	query := fmt.Sprintf(`
+		SELECT timestamp, interface, can_id, data
+		FROM %s
+		WHERE timestamp >= ?`, api.tableName)
+	args := []any{*params.StartTime}
+
+	if params.EndTime != nil {
+		query += " AND timestamp <= ?"
+		args = append(args, *params.EndTime)
+	}
+	if params.Interface != "" {
+		query += " AND interface = ?"
+		args = append(args, params.Interface)
+	}
+	if params.DeviceID != "" {
+		query += " AND device_id = ?"
+		args = append(args, params.DeviceID)
+	}
+
+	// SDO requests are paired with their responses in time order
+	query += " ORDER BY timestamp ASC LIMIT ?"
+	args = append(args, protocolMaxFrames)
+
+	rows, err := api.conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Query failed: %v", err)
+	}
+	defer rows.Close()
+
+	detector := models.NewProtocolDetector(api.database)
+	detector.AddEDSRegistry(api.edsRegistry)
+	for rows.Next() {
+		var timestamp time.Time
+		var iface string
+		var canID uint32
+		var data []uint8
+
+		if err := rows.Scan(&timestamp, &iface, &canID, &data); err != nil {
+			return nil, fmt.Errorf("Scan failed: %v", err)
+		}
+		detector.Add(timestamp, iface, canID, data)
+	}
+
+	profiles := detector.Profiles(time.Now().UTC())
+	if err := clickhouse.WriteProtocolProfiles(ctx, api.conn, api.profileTable, profiles); err != nil {
+		return nil, fmt.Errorf("Failed to store protocol profiles: %v", err)
+	}
+	return profiles, nil
+}
+
+// latest returns the most recent stored profile of each interface, or of a single interface
+func (api *ProtocolAPI) latest(ctx context.Context, iface string) ([]models.ProtocolProfile, error) {
+	query := fmt.Sprintf(`
+		SELECT
+			detected_at, interface, protocol, confidence, scores,
+			window_start, window_end, frame_count, standard_frames, extended_frames,
+			unique_ids, evidence, issues
+		FROM %s
+		WHERE 1=1`, api.profileTable)
+	args := []any{}
+
+	if iface != "" {
+		query += " AND interface = ?"
+		args = append(args, iface)
+	}
+
+	query += " ORDER BY interface, detected_at DESC LIMIT 1 BY interface"
+
+	rows, err := api.conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Query failed: %v", err)
+	}
+	defer rows.Close()
+
+	profiles := []models.ProtocolProfile{}
+	for rows.Next() {
+		var profile models.ProtocolProfile
+		var issues string
+
+		err := rows.Scan(
+			&profile.DetectedAt, &profile.Interface, &profile.Protocol, &profile.Confidence, &profile.Scores,
+			&profile.WindowStart, &profile.WindowEnd, &profile.FrameCount, &profile.StandardFrames, &profile.ExtendedFrames,
+			&profile.UniqueIDs, &profile.Evidence, &issues,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("Scan failed: %v", err)
+		}
+
+		profile.Issues = []models.ProtocolIssue{}
+		if err := json.Unmarshal([]byte(issues), &profile.Issues); err != nil {
+			return nil, fmt.Errorf("Failed to decode issues: %v", err)
+		}
+		profiles = append(profiles, profile)
+	}
+
+	return profiles, nil
+}
@@ -1,7 +1,9 @@
 package api
 
 import (
+	"can-db-writer/internal/can"
 	"can-db-writer/internal/database/clickhouse"
+	"can-db-writer/internal/models"
 	"context"
 	"fmt"
 	"log"
@@ -17,19 +19,55 @@ type Server struct {
 	grpcServer    *GRPCServer
 	clickhouseAPI *ClickHouseAPI
 	statsAPI      *StatsAPI
+	canopenAPI    *CANopenAPI
+	j1939API      *J1939API
+	udsAPI        *UDSAPI
+	protocolAPI   *ProtocolAPI
+	signalsAPI    *SignalsAPI
+	analysisAPI   *AnalysisAPI
+	captureAPI    *CaptureAPI
+	alertAPI      *AlertAPI
+	sessionAPI    *SessionAPI
+	annotationAPI *AnnotationAPI
+	deviceAPI     *DeviceAPI
+	canAPI        *CANAPI
+	nmtAPI        *NMTAPI
+	transmitter   *can.Transmitter
+	scheduler     *can.Scheduler
 }
 
 // ServerConfig holds API server configuration
 type ServerConfig struct {
-	Port             int
-	GRPCPort         int
-	CHHost           string
-	CHPort           int
-	CHDatabase       string
-	CHUsername       string
-	CHPassword       string
-	CHTable          string
-	CHStatsTable     string
+	Port               int
+	GRPCPort           int
+	CHHost             string
+	CHPort             int
+	CHDatabase         string
+	CHUsername         string
+	CHPassword         string
+	CHTable            string
+	CHStatsTable       string
+	CHEventsTable      string
+	CHUDSTable         string
+	CHProtocolTable    string
+	CHSignalsTable     string
+	CHCapturesTable    string
+	CHAlertsTable      string
+	CHRulesTable       string
+	CHSessionsTable    string
+	CHAnnotationsTable string
+	CHReplicationTable string
+	CHNMTAuditTable    string
+	TxInterfaces       []string
+	TxOperators        []string      // USER:TOKEN[:NODES] entries allowed to transmit
+	Producers          []string      // DEVICE:TOKEN entries allowed to ingest and replicate frames through gRPC
+	GRPCTLSCert        string        // Certificate and key of the gRPC server, TLS is disabled without them
+	GRPCTLSKey         string
+	Device             models.Device // Identity of the frames transmitted through the API and default device of sessions
+	EMCYTables         []string
+	EDSDir             string
+	DriveNodes         []uint8
+	J1939Databases     []string
 }
 
 // NewServer creates a new API server instance
@@ -75,22 +113,125 @@ func NewServer(config ServerConfig) (*Server, error) {
 	}
 
 	// Create API handlers
-	clickhouseAPI := NewClickHouseAPI(chConn, config.CHTable, writer)
-	statsAPI := NewStatsAPI(chConn, config.CHStatsTable)
+	// The reader's tables are created here too so that they carry the device columns queried by the API
+	if err := clickhouse.CreateStatsTable(chConn, config.CHStatsTable); err != nil {
+		return nil, fmt.Errorf("failed to create stats table: %w", err)
+	}
+	if err := clickhouse.CreateEventsTable(chConn, config.CHEventsTable); err != nil {
+		return nil, fmt.Errorf("failed to create events table: %w", err)
+	}
+	if err := clickhouse.CreateSignalsTable(chConn, config.CHSignalsTable); err != nil {
+		return nil, fmt.Errorf("failed to create signals table: %w", err)
+	}
+	if err := clickhouse.CreateSessionsTable(chConn, config.CHSessionsTable); err != nil {
+		return nil, fmt.Errorf("failed to create sessions table: %w", err)
+	}
+	if err := clickhouse.CreateAnnotationsTable(chConn, config.CHAnnotationsTable); err != nil {
+		return nil, fmt.Errorf("failed to create annotations table: %w", err)
+	}
+	clickhouseAPI := NewClickHouseAPI(chConn, config.CHTable, config.CHEventsTable, config.CHSessionsTable, config.CHAnnotationsTable, writer)
+	sessionAPI := NewSessionAPI(chConn, config.CHSessionsTable, config.Device.DeviceID)
+	annotationAPI := NewAnnotationAPI(chConn, config.CHAnnotationsTable)
+	deviceAPI := NewDeviceAPI(chConn, config.CHTable)
+
+	txOperators, err := models.ParseTxOperators(config.TxOperators)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse transmission operators: %w", err)
+	}
+
+	// Transmitted frames are recorded directly, confirming them to the requester
+	recordEvent := func(ctx context.Context, event models.CANEvent) error {
+		return clickhouse.WriteEvents(ctx, chConn, config.CHEventsTable, []models.CANEvent{event})
+	}
+	transmitter := can.NewTransmitter(config.TxInterfaces, config.Device, recordEvent)
+	scheduler := can.NewScheduler(config.TxInterfaces, config.Device, recordEvent)
+	canAPI := NewCANAPI(transmitter, scheduler, txOperators)
+	statsAPI := NewStatsAPI(chConn, config.CHStatsTable, config.CHAnnotationsTable)
+
+	emcyDecoder, err := models.LoadEMCYDecoder(config.EMCYTables)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load EMCY code tables: %w", err)
+	}
+	edsRegistry, err := models.LoadEDSRegistry(config.EDSDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load EDS files: %w", err)
+	}
+	canopenAPI := NewCANopenAPI(chConn, config.CHTable, emcyDecoder, edsRegistry, config.DriveNodes,
+		can.NewSDOClient(config.TxInterfaces, config.Device, recordEvent), txOperators)
+
+	j1939Database, err := models.LoadJ1939Database(config.J1939Databases)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load J1939 SPN databases: %w", err)
+	}
+	j1939API := NewJ1939API(chConn, config.CHTable, j1939Database)
+	udsAPI := NewUDSAPI(chConn, config.CHUDSTable)
+	signalsAPI := NewSignalsAPI(chConn, config.CHSignalsTable, config.CHAnnotationsTable)
+	analysisAPI := NewAnalysisAPI(chConn, config.CHTable, config.CHStatsTable, config.CHEventsTable, config.CHSignalsTable, emcyDecoder)
+
+	if err := clickhouse.CreateProtocolProfilesTable(chConn, config.CHProtocolTable); err != nil {
+		return nil, fmt.Errorf("failed to create protocol profiles table: %w", err)
+	}
+	if err := clickhouse.CreateCapturesTable(chConn, config.CHCapturesTable); err != nil {
+		return nil, fmt.Errorf("failed to create captures table: %w", err)
+	}
+	captureAPI := NewCaptureAPI(chConn, config.CHCapturesTable)
+	if err := clickhouse.CreateAlertsTable(chConn, config.CHAlertsTable); err != nil {
+		return nil, fmt.Errorf("failed to create alerts table: %w", err)
+	}
+	if err := clickhouse.CreateAlertRulesTable(chConn, config.CHRulesTable); err != nil {
+		return nil, fmt.Errorf("failed to create alert rules table: %w", err)
+	}
+	alertAPI := NewAlertAPI(chConn, config.CHAlertsTable, config.CHRulesTable)
+
+	if err := clickhouse.CreateNMTAuditTable(chConn, config.CHNMTAuditTable); err != nil {
+		return nil, fmt.Errorf("failed to create NMT audit table: %w", err)
+	}
+	nmtAPI := NewNMTAPI(chConn, config.CHNMTAuditTable, can.NewNMTMaster(transmitter), txOperators, edsRegistry, config.Device)
+
+	protocolAPI := NewProtocolAPI(chConn, config.CHTable, config.CHProtocolTable, edsRegistry, j1939Database, map[string]http.HandlerFunc{
+		models.ProtocolCANopen: clickhouseAPI.GetCANopenMessages,
+		models.ProtocolJ1939:   j1939API.GetMessages,
+		models.ProtocolRaw:     clickhouseAPI.GetMessages,
+	})
 
 	// Create gRPC server if port is specified
 	var grpcServer *GRPCServer
 	if config.GRPCPort > 0 {
 		var err error
-		grpcServer, err = NewGRPCServer(config.GRPCPort, chConn, config.CHTable)
+		if err := clickhouse.CreateReplicationTable(chConn, config.CHReplicationTable); err != nil {
+			return nil, fmt.Errorf("failed to create replication table: %w", err)
+		}
+		producers, err := models.ParseProducers(config.Producers)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse gRPC producers: %w", err)
+		}
+		grpcServer, err = NewGRPCServer(config.GRPCPort, config.GRPCTLSCert, config.GRPCTLSKey, chConn, config.CHTable, config.CHReplicationTable, writer, transmitter, scheduler, txOperators, producers)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create gRPC server: %w", err)
 		}
+
+		// Frames ingested through gRPC are batched by the export writer
+		writer.Start(config.CHTable)
 	}
 
 	server := &Server{
 		clickhouseAPI: clickhouseAPI,
 		statsAPI:      statsAPI,
+		canopenAPI:    canopenAPI,
+		j1939API:      j1939API,
+		udsAPI:        udsAPI,
+		protocolAPI:   protocolAPI,
+		signalsAPI:    signalsAPI,
+		analysisAPI:   analysisAPI,
+		captureAPI:    captureAPI,
+		alertAPI:      alertAPI,
+		sessionAPI:    sessionAPI,
+		annotationAPI: annotationAPI,
+		deviceAPI:     deviceAPI,
+		canAPI:        canAPI,
+		nmtAPI:        nmtAPI,
+		transmitter:   transmitter,
+		scheduler:     scheduler,
 		grpcServer:    grpcServer,
 	}
 
@@ -117,9 +258,69 @@ func (s *Server) setupRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/health", s.handleHealth)
 
 	// ClickHouse endpoints
+	mux.HandleFunc("/api/clickhouse/messages", s.clickhouseAPI.GetMessages)
 	mux.HandleFunc("/api/clickhouse/canopen/messages", s.clickhouseAPI.GetCANopenMessages)
 	mux.HandleFunc("/api/clickhouse/export", s.clickhouseAPI.ExportData)
 
+	// CANopen protocol endpoints
+	mux.HandleFunc("/api/canopen/emcy", s.canopenAPI.GetEMCY)
+	mux.HandleFunc("/api/canopen/nodes", s.canopenAPI.GetNodes)
+	mux.HandleFunc("/api/canopen/nodes/{id}/timeline", s.canopenAPI.GetNodeTimeline)
+	mux.HandleFunc("/api/canopen/nodes/{id}/od/{section}", s.canopenAPI.ReadODSection)
+	mux.HandleFunc("/api/canopen/nodes/{id}/od/{index}/{subindex}", s.canopenAPI.HandleODEntry)
+	mux.HandleFunc("/api/canopen/drives", s.canopenAPI.GetDrives)
+	mux.HandleFunc("/api/canopen/nmt", s.nmtAPI.SendCommand)
+	mux.HandleFunc("/api/canopen/nmt/audit", s.nmtAPI.GetAudit)
+
+	// J1939 API routes
+	mux.HandleFunc("/api/j1939/messages", s.j1939API.GetMessages)
+	mux.HandleFunc("/api/j1939/nodes", s.j1939API.GetNodes)
+
+	// UDS API routes
+	mux.HandleFunc("/api/uds/transactions", s.udsAPI.GetTransactions)
+
+	// Decoded signal routes
+	mux.HandleFunc("/api/signals", s.signalsAPI.GetSignals)
+	mux.HandleFunc("/api/signals/names", s.signalsAPI.GetSignalNames)
+
+	// Traffic analysis routes
+	mux.HandleFunc("/api/analysis/timing", s.analysisAPI.GetTiming)
+	mux.HandleFunc("/api/analysis/busload", s.analysisAPI.GetBusLoad)
+	mux.HandleFunc("/api/analysis/gaps", s.analysisAPI.GetGaps)
+	mux.HandleFunc("/api/analysis/changes", s.analysisAPI.GetChanges)
+	mux.HandleFunc("/api/analysis/compare", s.analysisAPI.GetCompare)
+
+	// Triggered capture routes
+	mux.HandleFunc("/api/captures", s.captureAPI.GetCaptures)
+
+	// Recording session routes
+	mux.HandleFunc("/api/sessions", s.sessionAPI.GetSessions)
+	mux.HandleFunc("/api/sessions/start", s.sessionAPI.StartSession)
+	mux.HandleFunc("/api/sessions/{id}/stop", s.sessionAPI.StopSession)
+	mux.HandleFunc("/api/sessions/{id}/tags", s.sessionAPI.TagSession)
+
+	// Device routes
+	mux.HandleFunc("/api/devices", s.deviceAPI.GetDevices)
+
+	// CAN bus routes
+	mux.HandleFunc("/api/can/send", s.canAPI.SendFrame)
+	mux.HandleFunc("/api/can/cyclic", s.canAPI.HandleCyclicJobs)
+	mux.HandleFunc("/api/can/cyclic/{id}", s.canAPI.HandleCyclicJob)
+
+	// Annotation routes
+	mux.HandleFunc("/api/annotations", s.annotationAPI.HandleAnnotations)
+	mux.HandleFunc("/api/annotations/{id}", s.annotationAPI.HandleAnnotation)
+
+	// Alert routes
+	mux.HandleFunc("/api/alerts", s.alertAPI.GetAlerts)
+	mux.HandleFunc("/api/alerts/rules", s.alertAPI.HandleRules)
+	mux.HandleFunc("/api/alerts/rules/{id}", s.alertAPI.DeleteRule)
+
+	// Protocol detection routes
+	mux.HandleFunc("/api/protocols", s.protocolAPI.GetProfiles)
+	mux.HandleFunc("/api/protocols/detect", s.protocolAPI.DetectProtocols)
+	mux.HandleFunc("/api/messages/decoded", s.protocolAPI.GetDecodedMessages)
+
 	// SocketCAN statistics endpoints
 	mux.HandleFunc("/api/stats/latest", s.statsAPI.GetLatestStats)
 	mux.HandleFunc("/api/stats/history", s.statsAPI.GetStatsHistory)
@@ -139,15 +340,78 @@ func (s *Server) handleRoot(w http.ResponseWriter, r *http.Request) {
 		"endpoints": map[string]any{
 			"health": "/health",
 			"clickhouse": map[string]string{
-				"messages": "/api/clickhouse/messages?start_time=2024-01-01T00:00:00Z&end_time=2024-01-02T00:00:00Z&can_id=0x123&interface=can0&limit=100&offset=0",
-				"count":    "/api/clickhouse/count?start_time=2024-01-01T00:00:00Z&can_id=0x123",
-				"can_ids":  "/api/clickhouse/can_ids",
-				"stats":    "/api/clickhouse/stats?limit=10",
-				"export":   "POST /api/clickhouse/export (body: {start_time, end_time, format?: 'parquet'|'iceberg', filename?, compression?}) - Downloads file in requested format",
+				"messages":             "/api/clickhouse/messages?start_time=2024-01-01T00:00:00Z&end_time=2024-01-02T00:00:00Z&can_id=0x123&interface=can0&session_id=...&direction=tx&limit=100&offset=0",
+				"messages_downsampled": "/api/clickhouse/messages?can_id=0x183&start_time=2024-01-01T00:00:00Z&end_time=2024-01-02T00:00:00Z&max_points=1000&downsample=minmax&value=int16:2",
+				"count":                "/api/clickhouse/count?start_time=2024-01-01T00:00:00Z&can_id=0x123",
+				"can_ids":              "/api/clickhouse/can_ids",
+				"stats":                "/api/clickhouse/stats?limit=10",
+				"export":               "POST /api/clickhouse/export (body: {start_time, end_time | session_id, format?: 'parquet'|'iceberg', filename?, compression?}) - Downloads file in requested format",
 			},
 			"canopen": map[string]string{
-				"messages": "/api/clickhouse/canopen/messages?message_type=pdo&start_time=2024-01-01T00:00:00Z&interface=can0&limit=100",
-				"stats":    "/api/clickhouse/canopen/stats?start_time=2024-01-01T00:00:00Z&interface=can0",
+				"messages":  "/api/clickhouse/canopen/messages?message_type=pdo&start_time=2024-01-01T00:00:00Z&interface=can0&limit=100",
+				"stats":     "/api/clickhouse/canopen/stats?start_time=2024-01-01T00:00:00Z&interface=can0",
+				"emcy":      "/api/canopen/emcy?start_time=2024-01-01T00:00:00Z&interface=can0&node_id=3&history=true",
+				"nodes":     "/api/canopen/nodes?interface=can0",
+				"timeline":  "/api/canopen/nodes/3/timeline?start_time=2024-01-01T00:00:00Z&interface=can0&gap_factor=3",
+				"od":        "/api/canopen/nodes/3/od/1018/1?interface=can0 (GET, PUT, Authorization: Bearer <token>)",
+				"od_batch":  "/api/canopen/nodes/3/od/optional?interface=can0 (Authorization: Bearer <token>)",
+				"drives":    "/api/canopen/drives?start_time=2024-01-01T00:00:00Z&interface=can0&node_id=3&axis=1",
+				"nmt":       "POST /api/canopen/nmt (Authorization: Bearer <token>, body: {interface, command, node_id, nodes?, timeout_ms?}) - Sends an NMT command and waits for the expected state",
+				"nmt_audit": "/api/canopen/nmt/audit?start_time=2024-01-01T00:00:00Z&node_id=3&user=kim&outcome=denied",
+			},
+			"j1939": map[string]string{
+				"messages": "/api/j1939/messages?start_time=2024-01-01T00:00:00Z&interface=can1&pgn=61444&sa=0x00&limit=100",
+				"nodes":    "/api/j1939/nodes?start_time=2024-01-01T00:00:00Z&interface=can1",
+			},
+			"uds": map[string]string{
+				"transactions": "/api/uds/transactions?start_time=2024-01-01T00:00:00Z&interface=can0&request_id=0x7E0&service_id=0x22&outcome=negative&limit=100",
+			},
+			"signals": map[string]string{
+				"series":      "/api/signals?name=actual_velocity&name=target_velocity&node_id=3&start_time=2024-01-01T00:00:00Z&interval=100ms&limit=1000",
+				"downsampled": "/api/signals?name=actual_velocity&node_id=3&start_time=2024-01-01T00:00:00Z&end_time=2024-01-02T00:00:00Z&max_points=2000&downsample=lttb",
+				"names":       "/api/signals/names?interface=can0&node_id=3",
+			},
+			"analysis": map[string]string{
+				"timing":  "/api/analysis/timing?can_id=0x181&interface=can0&start_time=2024-01-01T00:00:00Z&end_time=2024-01-01T00:01:00Z&period=10ms&interval=1s",
+				"busload": "/api/analysis/busload?interface=can0&start_time=2024-01-01T00:00:00Z&end_time=2024-01-01T00:01:00Z&interval=1s&top=10&bitrate=500000&data_bitrate=2000000",
+				"changes": "/api/analysis/changes?interface=can0&can_id=0x181&start_time=2024-01-01T00:00:00Z&end_time=2024-01-01T00:01:00Z",
+				"compare": "/api/analysis/compare?a_start=2024-01-01T00:00:00Z&a_end=2024-01-01T00:10:00Z&b_start=2024-01-02T00:00:00Z&b_end=2024-01-02T00:10:00Z&interface=can0&name=actual_velocity&tolerance=10",
+				"gaps":    "/api/analysis/gaps?scope=can_id&can_id=0x181&interface=can0&start_time=2024-01-01T00:00:00Z&end_time=2024-01-02T00:00:00Z&threshold=500ms&factor=3&limit=100",
+			},
+			"captures": map[string]string{
+				"list": "/api/captures?interface=can0&trigger=drive_fault&status=complete&start_time=2024-01-01T00:00:00Z&limit=100",
+			},
+			"sessions": map[string]string{
+				"list":  "/api/sessions?interface=can0&operator=kim&robot_serial=AMR-0042&tag=regression&q=brake&start_time=2024-01-01T00:00:00Z&limit=100",
+				"start": "POST /api/sessions/start (body: {interface, name?, operator?, robot_serial?, software_version?, test_case?, tags?, notes?})",
+				"stop":  "POST /api/sessions/{id}/stop",
+				"tags":  "POST /api/sessions/{id}/tags (body: {add?, remove?})",
+			},
+			"devices": map[string]string{
+				"list":   "/api/devices?site=plant-a&start_time=2024-01-01T00:00:00Z&rate_window=1m",
+				"filter": "device_id=robot-01 on every message, signal, stats, CANopen, J1939 and analysis query",
+			},
+			"can": map[string]string{
+				"send":   "POST /api/can/send (Authorization: Bearer <token>, body: {interface, can_id, data, timeout_ms?}), interface listed in CAN_TX_INTERFACES",
+				"cyclic": "GET|POST /api/can/cyclic (body: {interface, can_id, data, period_ms, mode?, paused?, user?})",
+				"job":    "GET|PUT|DELETE /api/can/cyclic/{id} (PUT body: {data?, period_ms?, paused?})",
+			},
+			"annotations": map[string]string{
+				"list":   "/api/annotations?interface=can0&tag=collision&q=e-stop&start_time=2024-01-01T00:00:00Z&end_time=2024-01-02T00:00:00Z&limit=100",
+				"create": "POST /api/annotations (body: {start_time, end_time?, interface?, session_id?, author?, text, tags?})",
+				"item":   "GET|PUT|DELETE /api/annotations/{id}",
+				"inline": "annotations=true on message, signal and stats history queries returns {<messages|signals|stats>, annotations}",
+			},
+			"alerts": map[string]string{
+				"history":     "/api/alerts?rule_id=bus_off&status=firing&severity=error&interface=can0&start_time=2024-01-01T00:00:00Z&limit=100",
+				"rules":       "/api/alerts/rules",
+				"save_rule":   "POST /api/alerts/rules (alert rule JSON, replaces the rule of the same id)",
+				"delete_rule": "DELETE /api/alerts/rules/{id}",
+			},
+			"protocols": map[string]string{
+				"profiles": "/api/protocols?interface=can0",
+				"detect":   "POST /api/protocols/detect?interface=can0&start_time=2024-01-01T00:00:00Z&end_time=2024-01-01T00:01:00Z",
+				"decoded":  "/api/messages/decoded?interface=can0&start_time=2024-01-01T00:00:00Z&limit=100 (decoder chosen by the interface's protocol profile)",
 			},
 			"socketcan_stats": map[string]string{
 				"latest":     "/api/stats/latest?interface=can0",
@@ -197,6 +461,8 @@ func (s *Server) Stop(ctx context.Context) error {
 	if s.grpcServer != nil {
 		s.grpcServer.Stop()
 	}
+	s.scheduler.Close()
+	s.transmitter.Close()
 
 	return s.server.Shutdown(ctx)
 }
@@ -0,0 +1,241 @@
+package api
+
+import (
+	"can-db-writer/internal/database/clickhouse"
+	"can-db-writer/internal/models"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// SessionAPI handles HTTP API requests for recording sessions
+type SessionAPI struct {
+	conn      driver.Conn
+	tableName string
+	deviceID  string // Device of sessions started without one
+}
+
+// NewSessionAPI creates a new session API handler
+func NewSessionAPI(conn driver.Conn, tableName, deviceID string) *SessionAPI {
+	return &SessionAPI{
+		conn:      conn,
+		tableName: tableName,
+		deviceID:  deviceID,
+	}
+}
+
+// GetSessions lists and searches recording sessions, newest first
+// GET /api/sessions?session_id=...&device_id=AMR-0042&interface=can0&status=stopped&operator=kim&robot_serial=AMR-0042&software_version=2.3.1&test_case=TC-17&tag=regression&q=brake&start_time=2024-01-01T00:00:00Z&end_time=2024-01-02T00:00:00Z&limit=100&offset=0
+//
+// tag may be repeated; sessions must carry every given tag. q searches the name, notes, operator,
+// robot serial, software version, test case and tags. start_time and end_time select the sessions
+// overlapping the range. The frames of a session are retrieved with the session_id parameter of
+// the message APIs.
+func (api *SessionAPI) GetSessions(w http.ResponseWriter, r *http.Request) {
+	params, err := parseQueryParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s FINAL WHERE 1=1", clickhouse.SessionColumns, api.tableName)
+	args := []any{}
+
+	if params.SessionID != "" {
+		query += " AND session_id = ?"
+		args = append(args, params.SessionID)
+	}
+	if params.StartTime != nil {
+		query += " AND (end_time IS NULL OR end_time >= ?)"
+		args = append(args, *params.StartTime)
+	}
+	if params.EndTime != nil {
+		query += " AND start_time <= ?"
+		args = append(args, *params.EndTime)
+	}
+	if params.DeviceID != "" {
+		query += " AND device_id = ?"
+		args = append(args, params.DeviceID)
+	}
+	if params.Interface != "" {
+		query += " AND interface = ?"
+		args = append(args, params.Interface)
+	}
+	for _, column := range []string{"status", "operator", "robot_serial", "software_version", "test_case"} {
+		if value := r.URL.Query().Get(column); value != "" {
+			query += fmt.Sprintf(" AND %s = ?", column)
+			args = append(args, value)
+		}
+	}
+	if tags := r.URL.Query()["tag"]; len(tags) > 0 {
+		query += " AND hasAll(tags, ?)"
+		args = append(args, tags)
+	}
+	if search := r.URL.Query().Get("q"); search != "" {
+		query += ` AND (positionCaseInsensitiveUTF8(concat(name, ' ', notes, ' ', operator, ' ', robot_serial, ' ',
+			software_version, ' ', test_case, ' ', arrayStringConcat(tags, ' ')), ?) > 0)`
+		args = append(args, search)
+	}
+
+	query += " ORDER BY start_time DESC"
+
+	if params.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, params.Limit)
+	}
+
+	if params.Offset > 0 {
+		query += " OFFSET ?"
+		args = append(args, params.Offset)
+	}
+
+	rows, err := api.conn.Query(r.Context(), query, args...)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+		return
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		session, err := clickhouse.ScanSession(rows)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Scan failed: %v", err))
+			return
+		}
+		sessions = append(sessions, session)
+	}
+
+	respondWithJSON(w, http.StatusOK, sessions)
+}
+
+// StartSession starts a recording session on an interface of a device, stopping the session active on it
+// POST /api/sessions/start
+//
+// Request body:
+//
+//	{
+//	  "device_id": "AMR-0042" (optional, default: DEVICE_ID of the API server),
+//	  "interface": "can0",
+//	  "name": "brake endurance run 3" (optional),
+//	  "operator": "kim" (optional),
+//	  "robot_serial": "AMR-0042" (optional),
+//	  "software_version": "2.3.1" (optional),
+//	  "test_case": "TC-17" (optional),
+//	  "tags": ["regression", "brake"] (optional),
+//	  "notes": "..." (optional)
+//	}
+func (api *SessionAPI) StartSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req struct {
+		DeviceID        string   `json:"device_id"`
+		Interface       string   `json:"interface"`
+		Name            string   `json:"name"`
+		Operator        string   `json:"operator"`
+		RobotSerial     string   `json:"robot_serial"`
+		SoftwareVersion string   `json:"software_version"`
+		TestCase        string   `json:"test_case"`
+		Tags            []string `json:"tags"`
+		Notes           string   `json:"notes"`
+	}
+
+	if err := parseJSONBody(r, &req); err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+	if req.Interface == "" {
+		respondWithError(w, http.StatusBadRequest, "interface is required")
+		return
+	}
+
+	if req.DeviceID == "" {
+		req.DeviceID = api.deviceID
+	}
+
+	session := models.NewSession(req.DeviceID, req.Interface)
+	session.Name = req.Name
+	session.Operator = req.Operator
+	session.RobotSerial = req.RobotSerial
+	session.SoftwareVersion = req.SoftwareVersion
+	session.TestCase = req.TestCase
+	session.Notes = req.Notes
+	session.UpdateTags(req.Tags, nil)
+
+	if err := clickhouse.StartSession(r.Context(), api.conn, api.tableName, &session); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to start session: %v", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, session)
+}
+
+// StopSession stops a recording session
+// POST /api/sessions/{id}/stop
+func (api *SessionAPI) StopSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	session, err := clickhouse.StopSession(r.Context(), api.conn, api.tableName, r.PathValue("id"), time.Now())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to stop session: %v", err))
+		return
+	}
+	if session == nil {
+		respondWithError(w, http.StatusNotFound, "Session not found")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, session)
+}
+
+// TagSession adds and removes tags of a recording session
+// POST /api/sessions/{id}/tags
+//
+// Request body:
+//
+//	{
+//	  "add": ["anomaly"] (optional),
+//	  "remove": ["regression"] (optional)
+//	}
+func (api *SessionAPI) TagSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req struct {
+		Add    []string `json:"add"`
+		Remove []string `json:"remove"`
+	}
+
+	if err := parseJSONBody(r, &req); err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+
+	session, err := clickhouse.ReadSession(r.Context(), api.conn, api.tableName, r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+		return
+	}
+	if session == nil {
+		respondWithError(w, http.StatusNotFound, "Session not found")
+		return
+	}
+
+	session.UpdateTags(req.Add, req.Remove)
+	if err := clickhouse.WriteSession(r.Context(), api.conn, api.tableName, session); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to update session: %v", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, session)
+}
@@ -0,0 +1,332 @@
+package api
+
+import (
+	"can-db-writer/internal/models"
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// SignalsAPI handles HTTP API requests for signals decoded on ingest
+type SignalsAPI struct {
+	conn             driver.Conn
+	tableName        string
+	annotationsTable string
+}
+
+// NewSignalsAPI creates a new signals API handler
+func NewSignalsAPI(conn driver.Conn, tableName, annotationsTable string) *SignalsAPI {
+	return &SignalsAPI{
+		conn:             conn,
+		tableName:        tableName,
+		annotationsTable: annotationsTable,
+	}
+}
+
+// GetSignals retrieves the time series of one or more decoded signals, oldest first
+// GET /api/signals?name=actual_velocity&name=target_velocity&node_id=3&interface=can0&start_time=2024-01-01T00:00:00Z&end_time=2024-01-02T00:00:00Z&interval=100ms&max_points=2000&downsample=lttb&limit=1000&offset=0
+//
+// Without interval the raw samples are returned. With interval (a Go duration such as 10ms, 1s, 5m)
+// the samples are grouped into time buckets with count, min, max, avg, first and last values.
+// With max_points each series is downsampled to at most that many raw samples (minmax or lttb),
+// keeping the extremes of every bucket so spikes stay visible; limit and offset are ignored.
+// With annotations=true the response is {"signals": [...], "annotations": [...]} with the annotations
+// overlapping the time range.
+func (api *SignalsAPI) GetSignals(w http.ResponseWriter, r *http.Request) {
+	params, err := parseQueryParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	names := r.URL.Query()["name"]
+	if len(names) == 0 {
+		respondWithError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	nodeID, err := parseUintParam(r, "node_id", 8)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var interval time.Duration
+	if intervalStr := r.URL.Query().Get("interval"); intervalStr != "" {
+		interval, err = time.ParseDuration(intervalStr)
+		if err != nil || interval < time.Microsecond {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid interval '%s', expected a duration such as 100ms, 1s or 5m", intervalStr))
+			return
+		}
+	}
+
+	downsample, err := parseDownsampleParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if downsample != nil && interval > 0 {
+		respondWithError(w, http.StatusBadRequest, "max_points and interval cannot be combined")
+		return
+	}
+
+	where := " WHERE has(?, signal)"
+	args := []any{names}
+
+	if nodeID != nil {
+		where += " AND node_id = ?"
+		args = append(args, uint8(*nodeID))
+	}
+	if params.StartTime != nil {
+		where += " AND timestamp >= ?"
+		args = append(args, *params.StartTime)
+	}
+	if params.EndTime != nil {
+		where += " AND timestamp <= ?"
+		args = append(args, *params.EndTime)
+	}
+	if params.Interface != "" {
+		where += " AND interface = ?"
+		args = append(args, params.Interface)
+	}
+	if params.DeviceID != "" {
+		where += " AND device_id = ?"
+		args = append(args, params.DeviceID)
+	}
+
+	if interval > 0 {
+		api.getBuckets(w, r, params, where, args, interval)
+		return
+	}
+	if downsample != nil {
+		api.getDownsampled(w, r, params, where, args, downsample)
+		return
+	}
+
+	query := fmt.Sprintf(`
+		SELECT timestamp, interface, node_id, signal, value_float, value_int, unit
+		FROM %s`, api.tableName) + where + " ORDER BY timestamp ASC"
+
+	if params.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, params.Limit)
+	}
+	if params.Offset > 0 {
+		query += " OFFSET ?"
+		args = append(args, params.Offset)
+	}
+
+	ctx := context.Background()
+	rows, err := api.conn.Query(ctx, query, args...)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+		return
+	}
+	defer rows.Close()
+
+	values := []models.SignalValue{}
+	for rows.Next() {
+		var value models.SignalValue
+		err := rows.Scan(&value.Timestamp, &value.Interface, &value.NodeID, &value.Signal,
+			&value.ValueFloat, &value.ValueInt, &value.Unit)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Scan failed: %v", err))
+			return
+		}
+		values = append(values, value)
+	}
+
+	respondWithAnnotations(w, r, api.conn, api.annotationsTable, params, "signals", values)
+}
+
+// getBuckets responds with the signal samples aggregated into time buckets
+func (api *SignalsAPI) getBuckets(w http.ResponseWriter, r *http.Request, params models.QueryParams, where string, args []any, interval time.Duration) {
+	micros := interval.Microseconds()
+
+	// Buckets are aligned to multiples of the interval since the Unix epoch
+	query := fmt.Sprintf(`
+		SELECT
+			fromUnixTimestamp64Micro(intDiv(toUnixTimestamp64Micro(timestamp), %d) * %d) AS time_bucket,
+			interface, node_id, signal, any(unit),
+			count(), min(value_float), max(value_float), avg(value_float),
+			argMin(value_float, timestamp), argMax(value_float, timestamp)
+		FROM %s`, micros, micros, api.tableName) + where +
+		" GROUP BY time_bucket, interface, node_id, signal ORDER BY time_bucket ASC, signal, node_id"
+
+	if params.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, params.Limit)
+	}
+	if params.Offset > 0 {
+		query += " OFFSET ?"
+		args = append(args, params.Offset)
+	}
+
+	ctx := context.Background()
+	rows, err := api.conn.Query(ctx, query, args...)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+		return
+	}
+	defer rows.Close()
+
+	buckets := []models.SignalBucket{}
+	for rows.Next() {
+		var bucket models.SignalBucket
+		err := rows.Scan(&bucket.TimeBucket, &bucket.Interface, &bucket.NodeID, &bucket.Signal, &bucket.Unit,
+			&bucket.Count, &bucket.Min, &bucket.Max, &bucket.Avg, &bucket.First, &bucket.Last)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Scan failed: %v", err))
+			return
+		}
+		buckets = append(buckets, bucket)
+	}
+
+	respondWithAnnotations(w, r, api.conn, api.annotationsTable, params, "signals", buckets)
+}
+
+// getDownsampled responds with the samples at the minimum and maximum of each time bucket per series,
+// reduced with LTTB if requested, oldest first
+func (api *SignalsAPI) getDownsampled(w http.ResponseWriter, r *http.Request, params models.QueryParams, where string, args []any, downsample *downsampleParams) {
+	start, end, ok, err := queryTimeRange(api.conn, api.tableName, params, where, args)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+		return
+	}
+	if !ok {
+		respondWithAnnotations(w, r, api.conn, api.annotationsTable, params, "signals", []models.SignalValue{})
+		return
+	}
+
+	// Ties on the value are broken by timestamp so each extreme comes from a single row
+	query := fmt.Sprintf(`
+		SELECT
+			interface, node_id, signal, any(unit),
+			intDiv(toUnixTimestamp64Micro(timestamp) - %d, %d) AS bucket,
+			argMin(timestamp, (value_float, timestamp)), argMin(value_int, (value_float, timestamp)), min(value_float),
+			argMax(timestamp, (value_float, timestamp)), argMax(value_int, (value_float, timestamp)), max(value_float)
+		FROM %s`, start.UnixMicro(), downsample.bucketWidth(start, end), api.tableName) + where +
+		" GROUP BY interface, node_id, signal, bucket ORDER BY interface, node_id, signal, bucket"
+
+	ctx := context.Background()
+	rows, err := api.conn.Query(ctx, query, args...)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+		return
+	}
+	defer rows.Close()
+
+	candidates := []models.SignalValue{}
+	values := []models.SignalValue{}
+	var points []models.DownsamplePoint
+
+	// flush reduces the points of the current series and keeps the selected samples
+	flush := func() {
+		for _, point := range downsample.reduce(points) {
+			values = append(values, candidates[point.Index])
+		}
+		points = points[:0]
+	}
+
+	for rows.Next() {
+		var minValue, maxValue models.SignalValue
+		var bucket int64
+		err := rows.Scan(&minValue.Interface, &minValue.NodeID, &minValue.Signal, &minValue.Unit, &bucket,
+			&minValue.Timestamp, &minValue.ValueInt, &minValue.ValueFloat,
+			&maxValue.Timestamp, &maxValue.ValueInt, &maxValue.ValueFloat)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Scan failed: %v", err))
+			return
+		}
+		maxValue.Interface, maxValue.NodeID, maxValue.Signal, maxValue.Unit = minValue.Interface, minValue.NodeID, minValue.Signal, minValue.Unit
+
+		if len(points) > 0 {
+			last := candidates[points[len(points)-1].Index]
+			if last.Interface != minValue.Interface || last.NodeID != minValue.NodeID || last.Signal != minValue.Signal {
+				flush()
+			}
+		}
+
+		candidates = append(candidates, minValue, maxValue)
+		points = extremePoints(points,
+			models.DownsamplePoint{X: minValue.Timestamp.UnixMicro(), Y: minValue.ValueFloat, Index: len(candidates) - 2},
+			models.DownsamplePoint{X: maxValue.Timestamp.UnixMicro(), Y: maxValue.ValueFloat, Index: len(candidates) - 1})
+	}
+	flush()
+
+	sort.SliceStable(values, func(i, j int) bool {
+		return values[i].Timestamp.Before(values[j].Timestamp)
+	})
+
+	respondWithAnnotations(w, r, api.conn, api.annotationsTable, params, "signals", values)
+}
+
+// GetSignalNames lists the recorded signals per node and interface
+// GET /api/signals/names?interface=can0&node_id=3&start_time=2024-01-01T00:00:00Z&end_time=2024-01-02T00:00:00Z
+func (api *SignalsAPI) GetSignalNames(w http.ResponseWriter, r *http.Request) {
+	params, err := parseQueryParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	nodeID, err := parseUintParam(r, "node_id", 8)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	query := fmt.Sprintf(`
+		SELECT signal, node_id, interface, any(unit), count(), min(timestamp), max(timestamp)
+		FROM %s
+		WHERE 1=1`, api.tableName)
+	args := []any{}
+
+	if nodeID != nil {
+		query += " AND node_id = ?"
+		args = append(args, uint8(*nodeID))
+	}
+	if params.StartTime != nil {
+		query += " AND timestamp >= ?"
+		args = append(args, *params.StartTime)
+	}
+	if params.EndTime != nil {
+		query += " AND timestamp <= ?"
+		args = append(args, *params.EndTime)
+	}
+	if params.Interface != "" {
+		query += " AND interface = ?"
+		args = append(args, params.Interface)
+	}
+	if params.DeviceID != "" {
+		query += " AND device_id = ?"
+		args = append(args, params.DeviceID)
+	}
+
+	query += " GROUP BY signal, node_id, interface ORDER BY signal, node_id, interface"
+
+	ctx := context.Background()
+	rows, err := api.conn.Query(ctx, query, args...)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+		return
+	}
+	defer rows.Close()
+
+	signals := []models.SignalInfo{}
+	for rows.Next() {
+		var info models.SignalInfo
+		err := rows.Scan(&info.Signal, &info.NodeID, &info.Interface, &info.Unit, &info.Count, &info.FirstSeen, &info.LastSeen)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Scan failed: %v", err))
+			return
+		}
+		signals = append(signals, info)
+	}
+
+	respondWithJSON(w, http.StatusOK, signals)
+}
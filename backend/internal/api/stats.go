@@ -12,15 +12,17 @@ import (
 
 // StatsAPI handles HTTP API requests for SocketCAN statistics
 type StatsAPI struct {
-	conn      driver.Conn
-	tableName string
+	conn             driver.Conn
+	tableName        string
+	annotationsTable string
 }
 
 // NewStatsAPI creates a new Statistics API handler
-func NewStatsAPI(conn driver.Conn, tableName string) *StatsAPI {
+func NewStatsAPI(conn driver.Conn, tableName, annotationsTable string) *StatsAPI {
 	return &StatsAPI{
-		conn:      conn,
-		tableName: tableName,
+		conn:             conn,
+		tableName:        tableName,
+		annotationsTable: annotationsTable,
 	}
 }
 
@@ -28,6 +30,7 @@ func NewStatsAPI(conn driver.Conn, tableName string) *StatsAPI {
 // GET /api/stats/latest?interface=can0
 func (api *StatsAPI) GetLatestStats(w http.ResponseWriter, r *http.Request) {
 	interfaceName := r.URL.Query().Get("interface")
+	deviceID := r.URL.Query().Get("device_id")
 
 	query := fmt.Sprintf(`
 		SELECT
@@ -41,7 +44,8 @@ func (api *StatsAPI) GetLatestStats(w http.ResponseWriter, r *http.Request) {
 			tx_carrier_errors, tx_fifo_errors, tx_heartbeat_errors, tx_window_errors,
 			tx_aborted_restarts, tx_bus_error_restarts,
 			collisions, carrier_changes, bus_off_restarts, arbitration_lost,
-			error_warning, error_passive, bus_off
+			error_warning, error_passive, bus_off,
+			device_id, site, hw_revision
 		FROM %s
 		WHERE 1=1`, api.tableName)
 
@@ -51,6 +55,10 @@ func (api *StatsAPI) GetLatestStats(w http.ResponseWriter, r *http.Request) {
 		query += " AND interface = ?"
 		args = append(args, interfaceName)
 	}
+	if deviceID != "" {
+		query += " AND device_id = ?"
+		args = append(args, deviceID)
+	}
 
 	query += " ORDER BY timestamp DESC LIMIT 1"
 
@@ -70,6 +78,7 @@ func (api *StatsAPI) GetLatestStats(w http.ResponseWriter, r *http.Request) {
 		&stat.TXAbortedRestarts, &stat.TXBusErrorRestarts,
 		&stat.Collisions, &stat.CarrierChanges, &stat.BusOffRestarts, &stat.ArbitrationLost,
 		&stat.ErrorWarning, &stat.ErrorPassive, &stat.BusOff,
+		&stat.DeviceID, &stat.Site, &stat.HWRevision,
 	)
 
 	if err != nil {
@@ -81,7 +90,7 @@ func (api *StatsAPI) GetLatestStats(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetStatsHistory retrieves historical statistics
-// GET /api/stats/history?interface=can0&start_time=2024-01-01T00:00:00Z&end_time=2024-01-02T00:00:00Z&limit=100
+// GET /api/stats/history?interface=can0&start_time=2024-01-01T00:00:00Z&end_time=2024-01-02T00:00:00Z&limit=100&annotations=true
 func (api *StatsAPI) GetStatsHistory(w http.ResponseWriter, r *http.Request) {
 	params, err := parseQueryParams(r)
 	if err != nil {
@@ -101,7 +110,8 @@ func (api *StatsAPI) GetStatsHistory(w http.ResponseWriter, r *http.Request) {
 			tx_carrier_errors, tx_fifo_errors, tx_heartbeat_errors, tx_window_errors,
 			tx_aborted_restarts, tx_bus_error_restarts,
 			collisions, carrier_changes, bus_off_restarts, arbitration_lost,
-			error_warning, error_passive, bus_off
+			error_warning, error_passive, bus_off,
+			device_id, site, hw_revision
 		FROM %s
 		WHERE 1=1`, api.tableName)
 
@@ -119,6 +129,10 @@ func (api *StatsAPI) GetStatsHistory(w http.ResponseWriter, r *http.Request) {
 		query += " AND interface = ?"
 		args = append(args, params.Interface)
 	}
+	if params.DeviceID != "" {
+		query += " AND device_id = ?"
+		args = append(args, params.DeviceID)
+	}
 
 	query += " ORDER BY timestamp DESC"
 
@@ -157,6 +171,7 @@ func (api *StatsAPI) GetStatsHistory(w http.ResponseWriter, r *http.Request) {
 			&stat.TXAbortedRestarts, &stat.TXBusErrorRestarts,
 			&stat.Collisions, &stat.CarrierChanges, &stat.BusOffRestarts, &stat.ArbitrationLost,
 			&stat.ErrorWarning, &stat.ErrorPassive, &stat.BusOff,
+			&stat.DeviceID, &stat.Site, &stat.HWRevision,
 		)
 
 		if err != nil {
@@ -167,11 +182,11 @@ func (api *StatsAPI) GetStatsHistory(w http.ResponseWriter, r *http.Request) {
 		stats = append(stats, stat)
 	}
 
-	respondWithJSON(w, http.StatusOK, stats)
+	respondWithAnnotations(w, r, api.conn, api.annotationsTable, params, "stats", stats)
 }
 
 // GetStatsAggregated retrieves aggregated statistics over a time period
-// GET /api/stats/aggregated?interface=can0&start_time=2024-01-01T00:00:00Z&end_time=2024-01-02T00:00:00Z&interval=1h
+// GET /api/stats/aggregated?interface=can0&start_time=2024-01-01T00:00:00Z&end_time=2024-01-02T00:00:00Z&interval=1h&annotations=true
 func (api *StatsAPI) GetStatsAggregated(w http.ResponseWriter, r *http.Request) {
 	params, err := parseQueryParams(r)
 	if err != nil {
@@ -233,6 +248,10 @@ func (api *StatsAPI) GetStatsAggregated(w http.ResponseWriter, r *http.Request)
 		query += " AND interface = ?"
 		args = append(args, params.Interface)
 	}
+	if params.DeviceID != "" {
+		query += " AND device_id = ?"
+		args = append(args, params.DeviceID)
+	}
 
 	query += " GROUP BY time_bucket, interface ORDER BY time_bucket DESC"
 
@@ -287,5 +306,5 @@ func (api *StatsAPI) GetStatsAggregated(w http.ResponseWriter, r *http.Request)
 		aggregated = append(aggregated, agg)
 	}
 
-	respondWithJSON(w, http.StatusOK, aggregated)
+	respondWithAnnotations(w, r, api.conn, api.annotationsTable, params, "stats", aggregated)
 }
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"can-db-writer/internal/models"
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// UDSAPI handles HTTP API requests for decoded UDS diagnostic transactions
+type UDSAPI struct {
+	conn      driver.Conn
+	tableName string
+}
+
+// NewUDSAPI creates a new UDS API handler
+func NewUDSAPI(conn driver.Conn, tableName string) *UDSAPI {
+	return &UDSAPI{
+		conn:      conn,
+		tableName: tableName,
+	}
+}
+
+// GetTransactions retrieves UDS transactions recorded by the CAN reader
+// GET /api/uds/transactions?start_time=2024-01-01T00:00:00Z&end_time=2024-01-02T00:00:00Z&device_id=AMR-0042&interface=can0&request_id=0x7E0&service_id=0x22&outcome=negative&nrc=0x31&limit=100&offset=0
+//
+// outcome is one of positive, negative, no_response, suppressed, unsolicited, transport_error.
+func (api *UDSAPI) GetTransactions(w http.ResponseWriter, r *http.Request) {
+	params, err := parseQueryParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	requestID, err := parseUintParam(r, "request_id", 32)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	responseID, err := parseUintParam(r, "response_id", 32)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	serviceID, err := parseUintParam(r, "service_id", 8)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	nrc, err := parseUintParam(r, "nrc", 8)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			timestamp, response_time, interface, request_id, response_id,
+			service_id, service, sub_function, outcome, nrc, nrc_name,
+			latency_ms, pending_count, request, response, details, device_id
+		FROM %s
+		WHERE 1=1`, api.tableName)
+	args := []any{}
+
+	if params.StartTime != nil {
+		query += " AND timestamp >= ?"
+		args = append(args, *params.StartTime)
+	}
+	if params.EndTime != nil {
+		query += " AND timestamp <= ?"
+		args = append(args, *params.EndTime)
+	}
+	if params.DeviceID != "" {
+		query += " AND device_id = ?"
+		args = append(args, params.DeviceID)
+	}
+	if params.Interface != "" {
+		query += " AND interface = ?"
+		args = append(args, params.Interface)
+	}
+	if requestID != nil {
+		query += " AND request_id = ?"
+		args = append(args, uint32(*requestID))
+	}
+	if responseID != nil {
+		query += " AND response_id = ?"
+		args = append(args, uint32(*responseID))
+	}
+	if serviceID != nil {
+		query += " AND service_id = ?"
+		args = append(args, uint8(*serviceID))
+	}
+	if outcome := r.URL.Query().Get("outcome"); outcome != "" {
+		query += " AND outcome = ?"
+		args = append(args, outcome)
+	}
+	if nrc != nil {
+		query += " AND nrc = ?"
+		args = append(args, uint8(*nrc))
+	}
+
+	query += " ORDER BY timestamp DESC"
+
+	if params.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, params.Limit)
+	}
+
+	if params.Offset > 0 {
+		query += " OFFSET ?"
+		args = append(args, params.Offset)
+	}
+
+	ctx := context.Background()
+	rows, err := api.conn.Query(ctx, query, args...)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Query failed: %v", err))
+		return
+	}
+	defer rows.Close()
+
+	transactions := []models.UDSTransaction{}
+	for rows.Next() {
+		var tx models.UDSTransaction
+		err := rows.Scan(
+			&tx.Timestamp, &tx.ResponseTime, &tx.Interface, &tx.RequestID, &tx.ResponseID,
+			&tx.ServiceID, &tx.Service, &tx.SubFunction, &tx.Outcome, &tx.NRC, &tx.NRCName,
+			&tx.LatencyMs, &tx.PendingCount, &tx.Request, &tx.Response, &tx.Details, &tx.DeviceID,
+		)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Scan failed: %v", err))
+			return
+		}
+
+		tx.RequestHex = fmt.Sprintf("%X", tx.Request)
+		tx.ResponseHex = fmt.Sprintf("%X", tx.Response)
+		transactions = append(transactions, tx)
+	}
+
+	respondWithJSON(w, http.StatusOK, transactions)
+}
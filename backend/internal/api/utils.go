@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -52,6 +53,12 @@ func parseQueryParams(r *http.Request) (models.QueryParams, error) {
 	// Parse interface
 	params.Interface = r.URL.Query().Get("interface")
 
+	// Parse session_id
+	params.SessionID = r.URL.Query().Get("session_id")
+
+	// Parse device_id
+	params.DeviceID = r.URL.Query().Get("device_id")
+
 	// Parse limit
 	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
 		limit, err := strconv.Atoi(limitStr)
@@ -73,6 +80,52 @@ func parseQueryParams(r *http.Request) (models.QueryParams, error) {
 	return params, nil
 }
 
+// parseNodeID parses the optional node_id query parameter (1-127)
+func parseNodeID(r *http.Request) (*uint8, error) {
+	nodeIDStr := r.URL.Query().Get("node_id")
+	if nodeIDStr == "" {
+		return nil, nil
+	}
+
+	nodeID, err := strconv.ParseUint(nodeIDStr, 0, 8)
+	if err != nil || nodeID < 1 || nodeID > 127 {
+		return nil, fmt.Errorf("invalid node_id '%s', must be 1-127", nodeIDStr)
+	}
+
+	n := uint8(nodeID)
+	return &n, nil
+}
+
+// parseUintParam parses an optional unsigned integer query parameter (decimal or 0x-prefixed hex)
+func parseUintParam(r *http.Request, name string, bitSize int) (*uint64, error) {
+	valueStr := r.URL.Query().Get(name)
+	if valueStr == "" {
+		return nil, nil
+	}
+
+	value, err := strconv.ParseUint(valueStr, 0, bitSize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s '%s': %v", name, valueStr, err)
+	}
+	return &value, nil
+}
+
+// bearerToken returns the token of the Authorization header, empty without one
+func bearerToken(r *http.Request) string {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return ""
+	}
+	return token
+}
+
+// parseJSONBody decodes the JSON request body into v
+func parseJSONBody(r *http.Request, v any) error {
+	defer r.Body.Close()
+
+	return json.NewDecoder(r.Body).Decode(v)
+}
+
 // respondWithError sends an error response
 func respondWithError(w http.ResponseWriter, code int, message string) {
 	respondWithJSON(w, code, map[string]string{"error": message})
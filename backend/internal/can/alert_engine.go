@@ -0,0 +1,253 @@
+package can
+
+import (
+	"can-db-writer/internal/models"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// alertCheckInterval is how often pending conditions and silent period rules are re-evaluated
+const alertCheckInterval = 100 * time.Millisecond
+
+// alertKey identifies a rule instance: a rule applies separately to every node or interface it matches
+type alertKey struct {
+	rule    string
+	subject string
+}
+
+// alertState tracks the condition of a rule instance
+type alertState struct {
+	rule      *models.AlertRule
+	subject   string
+	iface     string
+	since     time.Time // Start of the current holding period, zero when the condition does not hold
+	firing    bool
+	firedAt   time.Time
+	value     string
+	lastFrame time.Time // Period rules: last frame of the CAN ID
+}
+
+// AlertEngine evaluates alert rules against live frames, decoded signals and interface statistics
+// A rule instance fires once when its condition has held for the rule's for duration, and sends a
+// resolve notification when the condition stops holding; repeated matches while firing are deduplicated.
+type AlertEngine struct {
+	mu        sync.Mutex
+	rules     []models.AlertRule
+	states    map[alertKey]*alertState
+	alertChan chan models.Alert
+	stopChan  chan struct{}
+}
+
+// NewAlertEngine creates an alert engine without rules
+func NewAlertEngine() *AlertEngine {
+	return &AlertEngine{
+		states:    make(map[alertKey]*alertState),
+		alertChan: make(chan models.Alert, 100),
+		stopChan:  make(chan struct{}),
+	}
+}
+
+// Start begins re-evaluating pending conditions and silent period rules
+func (e *AlertEngine) Start() {
+	go e.checkLoop()
+}
+
+// Stop stops the engine
+func (e *AlertEngine) Stop() {
+	close(e.stopChan)
+}
+
+// GetAlertChannel returns the channel for receiving firing and resolve notifications
+func (e *AlertEngine) GetAlertChannel() <-chan models.Alert {
+	return e.alertChan
+}
+
+// SetRules replaces the rules, keeping the state of unchanged rules
+// Instances of removed or changed rules are dropped without a resolve notification.
+func (e *AlertEngine) SetRules(rules []models.AlertRule) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	previous := make(map[string]models.AlertRule, len(e.rules))
+	for _, rule := range e.rules {
+		previous[rule.ID] = rule
+	}
+
+	e.rules = make([]models.AlertRule, 0, len(rules))
+	kept := make(map[string]*models.AlertRule, len(rules))
+	for _, rule := range rules {
+		if rule.Disabled {
+			continue
+		}
+		e.rules = append(e.rules, rule)
+	}
+	for i := range e.rules {
+		if old, ok := previous[e.rules[i].ID]; ok && reflect.DeepEqual(old, e.rules[i]) {
+			kept[e.rules[i].ID] = &e.rules[i]
+		}
+	}
+
+	for key, state := range e.states {
+		rule, ok := kept[key.rule]
+		if !ok {
+			delete(e.states, key)
+			continue
+		}
+		state.rule = rule
+	}
+}
+
+// Process evaluates the period and payload rules against a received frame
+func (e *AlertEngine) Process(msg models.CANMessage) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for i := range e.rules {
+		rule := &e.rules[i]
+		switch rule.Type {
+		case models.AlertRulePeriod:
+			if msg.Frame.ID != rule.CANID {
+				continue
+			}
+			state := e.state(rule, fmt.Sprintf("0x%X", msg.Frame.ArbitrationID()), msg.Interface)
+			if !state.lastFrame.IsZero() {
+				periodMs := float64(msg.Timestamp.Sub(state.lastFrame).Microseconds()) / 1000
+				e.update(state, rule.Compare(periodMs), strconv.FormatFloat(periodMs, 'f', 3, 64)+"ms", msg.Timestamp)
+			}
+			state.lastFrame = msg.Timestamp
+		case models.AlertRulePayload:
+			holds, observed, applies := rule.EvalPayload(msg.Frame.ID, msg.Frame.Payload())
+			if applies {
+				e.update(e.state(rule, fmt.Sprintf("0x%X", msg.Frame.ArbitrationID()), msg.Interface), holds, observed, msg.Timestamp)
+			}
+		}
+	}
+}
+
+// ProcessSignal evaluates the signal rules against a decoded value
+func (e *AlertEngine) ProcessSignal(value models.SignalValue) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for i := range e.rules {
+		rule := &e.rules[i]
+		holds, observed, applies := rule.EvalSignal(value)
+		if applies {
+			e.update(e.state(rule, fmt.Sprintf("node %d", value.NodeID), value.Interface), holds, observed, value.Timestamp)
+		}
+	}
+}
+
+// ProcessStats evaluates the stats rules against interface statistics
+func (e *AlertEngine) ProcessStats(stats models.SocketCANStats) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for i := range e.rules {
+		rule := &e.rules[i]
+		holds, observed, applies := rule.EvalStats(stats)
+		if applies {
+			e.update(e.state(rule, stats.Interface, stats.Interface), holds, observed, stats.Timestamp)
+		}
+	}
+}
+
+// state returns the state of a rule instance
+func (e *AlertEngine) state(rule *models.AlertRule, subject, iface string) *alertState {
+	key := alertKey{rule: rule.ID, subject: subject}
+	state, ok := e.states[key]
+	if !ok {
+		state = &alertState{rule: rule, subject: subject}
+		e.states[key] = state
+	}
+	state.iface = iface
+	return state
+}
+
+// update applies an evaluation of a rule instance's condition
+func (e *AlertEngine) update(state *alertState, holds bool, observed string, ts time.Time) {
+	state.value = observed
+	if !holds {
+		state.since = time.Time{}
+		if state.firing {
+			state.firing = false
+			e.publish(state, models.AlertResolved, ts)
+		}
+		return
+	}
+
+	if state.since.IsZero() {
+		state.since = ts
+	}
+	e.promote(state, ts)
+}
+
+// promote fires a rule instance whose condition has held for the rule's for duration
+func (e *AlertEngine) promote(state *alertState, now time.Time) {
+	if state.firing || state.since.IsZero() || now.Sub(state.since) < state.rule.ForDuration() {
+		return
+	}
+	state.firing = true
+	state.firedAt = now
+	e.publish(state, models.AlertFiring, now)
+}
+
+// publish sends a notification of a rule instance
+func (e *AlertEngine) publish(state *alertState, status string, ts time.Time) {
+	rule := state.rule
+	message := fmt.Sprintf("%s: %s on %s (value %s)", rule.Name, rule.Condition(), state.subject, state.value)
+	if status == models.AlertResolved {
+		message = fmt.Sprintf("%s resolved on %s (value %s)", rule.Name, state.subject, state.value)
+	}
+
+	alert := models.Alert{
+		Timestamp: ts,
+		RuleID:    rule.ID,
+		RuleName:  rule.Name,
+		Severity:  rule.Severity,
+		Status:    status,
+		Interface: state.iface,
+		Subject:   state.subject,
+		Value:     state.value,
+		Message:   message,
+		FiredAt:   state.firedAt,
+		Notify:    rule.Notify,
+	}
+
+	select {
+	case e.alertChan <- alert:
+	default:
+		fmt.Println("Warning: alert channel full, dropping alert")
+	}
+}
+
+// checkLoop fires pending conditions whose for duration has passed without a new sample and
+// evaluates period rules against the silence since the last frame
+func (e *AlertEngine) checkLoop() {
+	ticker := time.NewTicker(alertCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			e.mu.Lock()
+			for _, state := range e.states {
+				rule := state.rule
+				if rule.Type == models.AlertRulePeriod && !state.lastFrame.IsZero() && (rule.Op == ">" || rule.Op == ">=") {
+					silenceMs := float64(now.Sub(state.lastFrame).Microseconds()) / 1000
+					if rule.Compare(silenceMs) {
+						e.update(state, true, strconv.FormatFloat(silenceMs, 'f', 3, 64)+"ms", now)
+						continue
+					}
+				}
+				e.promote(state, now)
+			}
+			e.mu.Unlock()
+		case <-e.stopChan:
+			return
+		}
+	}
+}
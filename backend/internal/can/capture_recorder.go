@@ -0,0 +1,263 @@
+package can
+
+import (
+	"can-db-writer/internal/models"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// captureCheckInterval is how often an open capture is checked for its post-trigger deadline
+const captureCheckInterval = 100 * time.Millisecond
+
+// triggerKey identifies the level state of a signal or bus_off trigger for a node
+type triggerKey struct {
+	trigger int
+	nodeID  uint8
+}
+
+// CaptureRecorder keeps recent frames in a ring buffer and persists them only around triggers
+// When a trigger fires, the frames of the pre-trigger window are flushed and every frame until
+// the post-trigger deadline is persisted; triggers firing meanwhile extend the same capture.
+// Signal and bus_off triggers fire when their condition starts to hold, not on every sample.
+type CaptureRecorder struct {
+	mu          sync.Mutex
+	iface       string
+	triggers    []models.CaptureTrigger
+	pre         time.Duration
+	post        time.Duration
+	ring        []models.CANMessage
+	head        int
+	size        int
+	active      map[triggerKey]bool
+	capture     *models.Capture
+	flushChan   chan []models.CANMessage
+	captureChan chan models.Capture
+	stopChan    chan struct{}
+}
+
+// NewCaptureRecorder creates a recorder buffering up to bufferSize frames of the pre-trigger window
+func NewCaptureRecorder(iface string, triggers []models.CaptureTrigger, pre, post time.Duration, bufferSize int) *CaptureRecorder {
+	return &CaptureRecorder{
+		iface:       iface,
+		triggers:    triggers,
+		pre:         pre,
+		post:        post,
+		ring:        make([]models.CANMessage, max(bufferSize, 1)),
+		active:      make(map[triggerKey]bool),
+		flushChan:   make(chan []models.CANMessage, 10),
+		captureChan: make(chan models.Capture, 100),
+		stopChan:    make(chan struct{}),
+	}
+}
+
+// Start begins closing captures whose post-trigger window has passed
+func (r *CaptureRecorder) Start() {
+	go r.checkLoop()
+}
+
+// Stop stops the recorder
+func (r *CaptureRecorder) Stop() {
+	close(r.stopChan)
+}
+
+// GetFlushChannel returns the channel for receiving the pre-trigger frames of each new capture
+func (r *CaptureRecorder) GetFlushChannel() <-chan []models.CANMessage {
+	return r.flushChan
+}
+
+// GetCaptureChannel returns the channel for receiving capture metadata when a capture starts and completes
+func (r *CaptureRecorder) GetCaptureChannel() <-chan models.Capture {
+	return r.captureChan
+}
+
+// Process evaluates the frame triggers and reports whether the frame is to be persisted
+// Frames outside a capture are kept in the ring buffer.
+func (r *CaptureRecorder) Process(msg models.CANMessage) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.expire(msg.Timestamp)
+
+	for i := range r.triggers {
+		if detail, ok := r.triggers[i].MatchFrame(msg.Frame.ID, msg.Frame.Payload()); ok {
+			r.fire(&r.triggers[i], detail, msg.Timestamp)
+		}
+	}
+
+	if r.capture != nil {
+		r.capture.Frames++
+		return true
+	}
+
+	r.push(msg)
+	return false
+}
+
+// ProcessSignal evaluates the signal triggers against a decoded value
+func (r *CaptureRecorder) ProcessSignal(value models.SignalValue) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.triggers {
+		if r.triggers[i].Type != models.TriggerSignal {
+			continue
+		}
+		detail, ok := r.triggers[i].MatchSignal(value)
+		key := triggerKey{trigger: i, nodeID: value.NodeID}
+		if ok && !r.active[key] {
+			r.fire(&r.triggers[i], detail, value.Timestamp)
+		}
+		r.active[key] = ok
+	}
+}
+
+// ProcessStats evaluates the bus_off triggers against interface statistics
+func (r *CaptureRecorder) ProcessStats(stats models.SocketCANStats) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.triggers {
+		if r.triggers[i].Type != models.TriggerBusOff {
+			continue
+		}
+		detail, ok := r.triggers[i].MatchStats(stats)
+		key := triggerKey{trigger: i}
+		if ok && !r.active[key] {
+			r.fire(&r.triggers[i], detail, stats.Timestamp)
+		}
+		r.active[key] = ok
+	}
+}
+
+// Finish completes the open capture, if any, at shutdown
+func (r *CaptureRecorder) Finish() *models.Capture {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.capture == nil {
+		return nil
+	}
+
+	now := time.Now()
+	capture := *r.capture
+	capture.Status = models.CaptureComplete
+	capture.EndTime = minTime(capture.EndTime, now)
+	capture.UpdatedAt = now
+	r.capture = nil
+	return &capture
+}
+
+// fire starts a capture with the buffered pre-trigger frames, or extends the open one
+func (r *CaptureRecorder) fire(trigger *models.CaptureTrigger, detail string, ts time.Time) {
+	if r.capture != nil {
+		r.capture.Triggers++
+		if end := ts.Add(r.post); end.After(r.capture.EndTime) {
+			r.capture.EndTime = end
+		}
+		return
+	}
+
+	frames := r.drain(ts.Add(-r.pre))
+	r.capture = &models.Capture{
+		RecordingID:   models.NewRecordingID(),
+		Interface:     r.iface,
+		Status:        models.CaptureRecording,
+		TriggerName:   trigger.Name,
+		TriggerType:   trigger.Type,
+		TriggerDetail: detail,
+		TriggerTime:   ts,
+		StartTime:     ts.Add(-r.pre),
+		EndTime:       ts.Add(r.post),
+		Frames:        uint64(len(frames)),
+		Triggers:      1,
+		PreTriggerMs:  uint32(r.pre.Milliseconds()),
+		PostTriggerMs: uint32(r.post.Milliseconds()),
+		UpdatedAt:     time.Now(),
+	}
+
+	if len(frames) > 0 {
+		select {
+		case r.flushChan <- frames:
+		default:
+			fmt.Println("Warning: capture flush channel full, dropping pre-trigger frames")
+		}
+	}
+	r.publish(*r.capture)
+}
+
+// expire completes the open capture once its post-trigger deadline has passed
+func (r *CaptureRecorder) expire(now time.Time) {
+	if r.capture == nil || !now.After(r.capture.EndTime) {
+		return
+	}
+
+	r.capture.Status = models.CaptureComplete
+	r.capture.UpdatedAt = time.Now()
+	r.publish(*r.capture)
+	r.capture = nil
+}
+
+// publish sends capture metadata to the capture channel
+func (r *CaptureRecorder) publish(capture models.Capture) {
+	select {
+	case r.captureChan <- capture:
+	default:
+		fmt.Println("Warning: capture channel full, dropping capture metadata")
+	}
+}
+
+// push appends a frame to the ring buffer, dropping the oldest frame when full and
+// frames older than the pre-trigger window
+func (r *CaptureRecorder) push(msg models.CANMessage) {
+	cutoff := msg.Timestamp.Add(-r.pre)
+	for r.size > 0 && r.ring[r.head].Timestamp.Before(cutoff) {
+		r.head = (r.head + 1) % len(r.ring)
+		r.size--
+	}
+
+	if r.size == len(r.ring) {
+		r.head = (r.head + 1) % len(r.ring)
+		r.size--
+	}
+	r.ring[(r.head+r.size)%len(r.ring)] = msg
+	r.size++
+}
+
+// drain empties the ring buffer, returning the frames not older than cutoff in time order
+func (r *CaptureRecorder) drain(cutoff time.Time) []models.CANMessage {
+	frames := make([]models.CANMessage, 0, r.size)
+	for i := range r.size {
+		msg := r.ring[(r.head+i)%len(r.ring)]
+		if !msg.Timestamp.Before(cutoff) {
+			frames = append(frames, msg)
+		}
+	}
+	r.head, r.size = 0, 0
+	return frames
+}
+
+// checkLoop closes captures when no frame arrives after their deadline, e.g. on a silent bus
+func (r *CaptureRecorder) checkLoop() {
+	ticker := time.NewTicker(captureCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			r.mu.Lock()
+			r.expire(now)
+			r.mu.Unlock()
+		case <-r.stopChan:
+			return
+		}
+	}
+}
+
+// minTime returns the earlier of two times
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
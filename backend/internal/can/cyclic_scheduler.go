@@ -0,0 +1,441 @@
+package can
+
+import (
+	"can-db-writer/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// Bounds of the period of a cyclic job
+	minCyclicPeriod = 100 * time.Microsecond
+	maxCyclicPeriod = time.Hour
+	// monitorPollInterval is how often the monitor of a job checks for its deletion
+	monitorPollInterval = 200 * time.Millisecond
+)
+
+// ErrCyclicNotFound is returned for an unknown cyclic job
+var ErrCyclicNotFound = errors.New("cyclic job not found")
+
+// Scheduler runs the cyclic transmission jobs of the interfaces enabled for transmission
+// Every creation, update and deletion is recorded as an event with the requesting operator.
+type Scheduler struct {
+	mu         sync.Mutex
+	interfaces map[string]bool
+	jobs       map[string]*cyclicJob
+	device     models.Device
+	record     func(ctx context.Context, event models.CANEvent) error
+}
+
+// cyclicJob is a scheduled frame and the timing measured on its looped back transmissions
+type cyclicJob struct {
+	mu      sync.Mutex
+	job     models.CyclicJob
+	frame   models.CANFrame
+	sender  cyclicSender
+	monitor int // Raw socket receiving the transmitted frames
+	stop    chan struct{}
+	done    chan struct{}
+
+	// Period statistics (Welford)
+	last      time.Time // Previous frame, zero after a pause or period change
+	intervals float64
+	mean      float64
+	m2        float64
+}
+
+// NewScheduler creates a scheduler for the interfaces; record stores the job changes
+func NewScheduler(interfaces []string, device models.Device, record func(ctx context.Context, event models.CANEvent) error) *Scheduler {
+	s := &Scheduler{
+		interfaces: make(map[string]bool),
+		jobs:       make(map[string]*cyclicJob),
+		device:     device,
+		record:     record,
+	}
+	for _, iface := range interfaces {
+		s.interfaces[iface] = true
+	}
+	return s
+}
+
+// Create starts a cyclic job, with BCM unless the timer mode is requested or BCM is unavailable
+func (s *Scheduler) Create(req models.CyclicJobRequest) (models.CyclicJob, error) {
+	job, err := s.create(req)
+	if err != nil {
+		return models.CyclicJob{}, err
+	}
+
+	log.Printf("Started cyclic job %s: 0x%X every %gms on %s (%s) for '%s'",
+		job.ID, job.CANID, job.PeriodMs, job.Interface, job.Mode, req.User)
+	s.recordEvent(models.EventCyclicJobCreated, job, req.User)
+	return job, nil
+}
+
+// create starts and registers a cyclic job
+func (s *Scheduler) create(req models.CyclicJobRequest) (models.CyclicJob, error) {
+	if !s.interfaces[req.Interface] {
+		return models.CyclicJob{}, fmt.Errorf("%w on interface '%s'", ErrTxDisabled, req.Interface)
+	}
+
+	if err := rejectNMT(req.CANID); err != nil {
+		return models.CyclicJob{}, err
+	}
+	frame, err := txFrame(req.CANID, req.Data)
+	if err != nil {
+		return models.CyclicJob{}, err
+	}
+	period, err := cyclicPeriod(req.PeriodMs)
+	if err != nil {
+		return models.CyclicJob{}, err
+	}
+	if req.Mode != "" && req.Mode != models.CyclicModeBCM && req.Mode != models.CyclicModeTimer {
+		return models.CyclicJob{}, fmt.Errorf("%w: invalid mode '%s', must be bcm or timer", ErrTxInvalid, req.Mode)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The transmissions of a job are told apart by their CAN ID
+	for _, other := range s.jobs {
+		if other.job.Interface == req.Interface && other.frame.ID == frame.ID {
+			return models.CyclicJob{}, fmt.Errorf("%w: cyclic job %s already transmits 0x%X on %s", ErrTxInvalid, other.job.ID, frame.ID, req.Interface)
+		}
+	}
+
+	job := &cyclicJob{
+		job: models.CyclicJob{
+			ID:        models.NewRecordingID(),
+			Interface: req.Interface,
+			CANID:     frame.ID,
+			PeriodMs:  req.PeriodMs,
+			Mode:      models.CyclicModeTimer,
+			State:     models.CyclicRunning,
+			User:      req.User,
+			CreatedAt: time.Now().UTC(),
+		},
+		frame: frame,
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+
+	if req.Mode != models.CyclicModeTimer {
+		if sender, err := newBCMSender(req.Interface); err == nil {
+			job.sender = sender
+			job.job.Mode = models.CyclicModeBCM
+		} else {
+			log.Printf("Warning: CAN_BCM unavailable on %s, using the user-space timer: %v", req.Interface, err)
+		}
+	}
+	if job.sender == nil {
+		sender, err := newTimerSender(req.Interface)
+		if err != nil {
+			return models.CyclicJob{}, err
+		}
+		job.sender = sender
+	}
+
+	job.monitor, err = openMonitorSocket(req.Interface, frame.ID)
+	if err != nil {
+		job.sender.close()
+		return models.CyclicJob{}, err
+	}
+	go job.monitorLoop()
+
+	if req.Paused {
+		job.job.State = models.CyclicPaused
+		err = job.sender.pause(frame)
+	} else {
+		err = job.sender.start(frame, period)
+	}
+	if err != nil {
+		job.close()
+		return models.CyclicJob{}, err
+	}
+
+	s.jobs[job.job.ID] = job
+	return job.snapshot(), nil
+}
+
+// Update changes the payload, period or pause state of a job for a user
+// A new payload is applied from the next transmission without restarting the cycle.
+func (s *Scheduler) Update(id string, update models.CyclicJobUpdate, user string) (models.CyclicJob, error) {
+	job, err := s.job(id)
+	if err != nil {
+		return models.CyclicJob{}, err
+	}
+	snapshot, err := job.update(update)
+	if err != nil {
+		return models.CyclicJob{}, err
+	}
+
+	log.Printf("Updated cyclic job %s: 0x%X [% X] every %gms on %s (%s) for '%s'",
+		id, snapshot.CANID, snapshot.Data, snapshot.PeriodMs, snapshot.Interface, snapshot.State, user)
+	s.recordEvent(models.EventCyclicJobUpdated, snapshot, user)
+	return snapshot, nil
+}
+
+// update applies a change to the transmission of the job
+func (j *cyclicJob) update(update models.CyclicJobUpdate) (models.CyclicJob, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var err error
+	frame := j.frame
+	if update.Data != nil {
+		if frame, err = txFrame(frame.ID, *update.Data); err != nil {
+			return models.CyclicJob{}, err
+		}
+	}
+	periodMs := j.job.PeriodMs
+	if update.PeriodMs != nil {
+		periodMs = *update.PeriodMs
+	}
+	period, err := cyclicPeriod(periodMs)
+	if err != nil {
+		return models.CyclicJob{}, err
+	}
+	state := j.job.State
+	if update.Paused != nil {
+		state = models.CyclicRunning
+		if *update.Paused {
+			state = models.CyclicPaused
+		}
+	}
+
+	switch {
+	case state == models.CyclicPaused:
+		err = j.sender.pause(frame)
+		j.last = time.Time{}
+	case j.job.State == models.CyclicPaused || periodMs != j.job.PeriodMs:
+		err = j.sender.start(frame, period)
+		j.last = time.Time{}
+	default:
+		err = j.sender.update(frame)
+	}
+	if err != nil {
+		return models.CyclicJob{}, err
+	}
+
+	// The statistics are measured against the current period
+	if periodMs != j.job.PeriodMs {
+		j.intervals, j.mean, j.m2, j.job.MaxDeviationMs = 0, 0, 0, 0
+	}
+	j.frame = frame
+	j.job.PeriodMs = periodMs
+	j.job.State = state
+	return j.snapshotLocked(), nil
+}
+
+// Delete stops and removes a job for a user, returning its final counts
+func (s *Scheduler) Delete(id string, user string) (models.CyclicJob, error) {
+	s.mu.Lock()
+	job, ok := s.jobs[id]
+	delete(s.jobs, id)
+	s.mu.Unlock()
+	if !ok {
+		return models.CyclicJob{}, fmt.Errorf("%w: %s", ErrCyclicNotFound, id)
+	}
+
+	job.close()
+	snapshot := job.snapshot()
+	log.Printf("Deleted cyclic job %s: 0x%X on %s after %d frames for '%s'", id, snapshot.CANID, snapshot.Interface, snapshot.Frames, user)
+	s.recordEvent(models.EventCyclicJobDeleted, snapshot, user)
+	return snapshot, nil
+}
+
+// Get returns a job
+func (s *Scheduler) Get(id string) (models.CyclicJob, error) {
+	job, err := s.job(id)
+	if err != nil {
+		return models.CyclicJob{}, err
+	}
+	return job.snapshot(), nil
+}
+
+// List returns the jobs, oldest first
+func (s *Scheduler) List() []models.CyclicJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := make([]models.CyclicJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job.snapshot())
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+	})
+	return jobs
+}
+
+// Close stops every job
+func (s *Scheduler) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, job := range s.jobs {
+		job.close()
+		delete(s.jobs, id)
+	}
+}
+
+// recordEvent records a change of a job with the user requesting it
+func (s *Scheduler) recordEvent(eventType string, job models.CyclicJob, user string) {
+	if s.record == nil {
+		return
+	}
+
+	event := models.CANEvent{
+		Timestamp: time.Now().UTC(),
+		Interface: job.Interface,
+		EventType: eventType,
+		Severity:  models.SeverityInfo,
+		Message: fmt.Sprintf("Cyclic job %s %s by '%s': 0x%X every %gms (%s)",
+			job.ID, strings.TrimPrefix(eventType, "cyclic_job_"), user, job.CANID, job.PeriodMs, job.State),
+		Details: map[string]string{
+			"job_id":    job.ID,
+			"can_id":    job.CANIDHex,
+			"data":      job.DataHex,
+			"period_ms": fmt.Sprintf("%g", job.PeriodMs),
+			"mode":      job.Mode,
+			"state":     job.State,
+			"frames":    fmt.Sprintf("%d", job.Frames),
+			"user":      user,
+		},
+		Device: s.device,
+	}
+	if err := s.record(context.Background(), event); err != nil {
+		log.Printf("Warning: Failed to record %s event of %s: %v", eventType, job.ID, err)
+	}
+}
+
+// job returns a job by ID
+func (s *Scheduler) job(id string) (*cyclicJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrCyclicNotFound, id)
+	}
+	return job, nil
+}
+
+// close stops the transmission and the monitor
+func (j *cyclicJob) close() {
+	j.mu.Lock()
+	j.sender.close()
+	j.mu.Unlock()
+
+	close(j.stop)
+	<-j.done
+	unix.Close(j.monitor)
+}
+
+// monitorLoop records the frames of the job transmitted from this host
+func (j *cyclicJob) monitorLoop() {
+	defer close(j.done)
+
+	buf := make([]byte, 16)
+	oob := make([]byte, 64)
+	for {
+		select {
+		case <-j.stop:
+			return
+		default:
+		}
+
+		fds := []unix.PollFd{{Fd: int32(j.monitor), Events: unix.POLLIN}}
+		n, err := unix.Poll(fds, int(monitorPollInterval.Milliseconds()))
+		if err == unix.EINTR || n == 0 {
+			continue
+		}
+		if err != nil {
+			log.Printf("Warning: Cyclic job %s monitor failed: %v", j.job.ID, err)
+			return
+		}
+
+		n, oobn, flags, _, err := unix.Recvmsg(j.monitor, buf, oob, 0)
+		// MSG_DONTROUTE marks the frames sent from this host, not by other nodes
+		if err != nil || n < 16 || flags&unix.MSG_DONTROUTE == 0 {
+			continue
+		}
+		j.record(receiveTimestamp(oob[:oobn]))
+	}
+}
+
+// record adds a transmitted frame to the counts and period statistics
+func (j *cyclicJob) record(timestamp time.Time) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.job.Frames++
+	if !j.last.IsZero() && j.job.State == models.CyclicRunning {
+		interval := float64(timestamp.Sub(j.last)) / float64(time.Millisecond)
+		j.intervals++
+		delta := interval - j.mean
+		j.mean += delta / j.intervals
+		j.m2 += delta * (interval - j.mean)
+		j.job.MaxDeviationMs = max(j.job.MaxDeviationMs, math.Abs(interval-j.job.PeriodMs))
+	}
+	j.last = timestamp
+	j.job.LastTx = &timestamp
+}
+
+// snapshot returns the job with its current statistics
+func (j *cyclicJob) snapshot() models.CyclicJob {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.snapshotLocked()
+}
+
+func (j *cyclicJob) snapshotLocked() models.CyclicJob {
+	job := j.job
+	job.CANIDHex = fmt.Sprintf("0x%X", j.frame.ID)
+	job.DLC = j.frame.DLC
+	job.Data = append([]uint8{}, j.frame.Payload()...)
+	job.DataHex = fmt.Sprintf("% X", j.frame.Payload())
+	job.MeanPeriodMs = j.mean
+	if j.intervals > 1 {
+		job.JitterMs = math.Sqrt(j.m2 / j.intervals)
+	}
+	return job
+}
+
+// cyclicPeriod validates the period of a job
+func cyclicPeriod(periodMs float64) (time.Duration, error) {
+	period := time.Duration(periodMs * float64(time.Millisecond))
+	if period < minCyclicPeriod || period > maxCyclicPeriod {
+		return 0, fmt.Errorf("%w: period_ms %g out of range, must be between %g and %.0f",
+			ErrTxInvalid, periodMs, float64(minCyclicPeriod)/float64(time.Millisecond), float64(maxCyclicPeriod)/float64(time.Millisecond))
+	}
+	return period, nil
+}
+
+// openMonitorSocket creates a raw socket receiving the frames of a CAN ID with their timestamps
+func openMonitorSocket(ifname string, canID uint32) (int, error) {
+	socket, err := openRawSocket(ifname)
+	if err != nil {
+		return -1, err
+	}
+
+	filter := []unix.CanFilter{{Id: canID, Mask: models.CANEffFlag | models.CANRtrFlag | models.CANEffMask}}
+	if err := unix.SetsockoptCanRawFilter(socket, unix.SOL_CAN_RAW, unix.CAN_RAW_FILTER, filter); err != nil {
+		unix.Close(socket)
+		return -1, fmt.Errorf("failed to set filter: %w", err)
+	}
+	if err := unix.SetsockoptInt(socket, unix.SOL_SOCKET, unix.SO_TIMESTAMPNS, 1); err != nil {
+		unix.Close(socket)
+		return -1, fmt.Errorf("failed to enable timestamps: %w", err)
+	}
+
+	return socket, nil
+}
@@ -0,0 +1,199 @@
+package can
+
+import (
+	"can-db-writer/internal/models"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// Broadcast Manager constants (linux/can/bcm.h)
+const (
+	CAN_BCM = 2
+
+	bcmTxSetup    = 1
+	bcmSetTimer   = 0x0001
+	bcmStartTimer = 0x0002
+)
+
+// cyclicSender transmits the frame of a cyclic job
+type cyclicSender interface {
+	// start (re)starts transmitting a frame every period
+	start(frame models.CANFrame, period time.Duration) error
+	// update replaces the frame from the next transmission without restarting the cycle
+	update(frame models.CANFrame) error
+	// pause stops transmitting, keeping the frame
+	pause(frame models.CANFrame) error
+	close() error
+}
+
+// bcmSender transmits through the kernel Broadcast Manager, which times the frames with hrtimers
+// Each job has its own BCM socket; closing it deletes the TX operation.
+type bcmSender struct {
+	socket int
+}
+
+// newBCMSender creates a BCM socket connected to an interface
+func newBCMSender(ifname string) (*bcmSender, error) {
+	socket, err := unix.Socket(unix.AF_CAN, unix.SOCK_DGRAM, CAN_BCM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create BCM socket: %w", err)
+	}
+
+	ifindex, err := interfaceIndex(socket, ifname)
+	if err != nil {
+		unix.Close(socket)
+		return nil, err
+	}
+
+	if err := unix.Connect(socket, &unix.SockaddrCAN{Ifindex: ifindex}); err != nil {
+		unix.Close(socket)
+		return nil, fmt.Errorf("failed to connect BCM socket: %w", err)
+	}
+
+	return &bcmSender{socket: socket}, nil
+}
+
+func (s *bcmSender) start(frame models.CANFrame, period time.Duration) error {
+	return s.write(bcmTxSetup, bcmSetTimer|bcmStartTimer, period, frame)
+}
+
+func (s *bcmSender) update(frame models.CANFrame) error {
+	return s.write(bcmTxSetup, 0, 0, frame)
+}
+
+func (s *bcmSender) pause(frame models.CANFrame) error {
+	// SETTIMER with both intervals zero stops the timer
+	return s.write(bcmTxSetup, bcmSetTimer, 0, frame)
+}
+
+func (s *bcmSender) close() error {
+	return unix.Close(s.socket)
+}
+
+// write sends a BCM message: struct bcm_msg_head followed by one struct can_frame
+// The head holds two struct bcm_timeval of C longs, whose size is the platform's int size.
+func (s *bcmSender) write(opcode, flags uint32, ival2 time.Duration, frame models.CANFrame) error {
+	long := strconv.IntSize / 8
+	ival1 := 12
+	if long == 8 {
+		ival1 = 16 // Aligned to the 64-bit tv_sec
+	}
+	canID := ival1 + 4*long
+	head := (canID + 8 + 7) &^ 7 // Frames are aligned to 8 bytes
+
+	buf := make([]byte, head+16)
+	binary.NativeEndian.PutUint32(buf[0:], opcode)
+	binary.NativeEndian.PutUint32(buf[4:], flags)
+	putLong(buf[ival1+2*long:], long, int64(ival2/time.Second))
+	putLong(buf[ival1+3*long:], long, int64(ival2%time.Second/time.Microsecond))
+	binary.NativeEndian.PutUint32(buf[canID:], frame.ID)
+	binary.NativeEndian.PutUint32(buf[canID+4:], 1)
+
+	binary.NativeEndian.PutUint32(buf[head:], frame.ID)
+	buf[head+4] = frame.DLC
+	copy(buf[head+8:], frame.Data[:])
+
+	if _, err := unix.Write(s.socket, buf); err != nil {
+		return fmt.Errorf("BCM write error: %w", err)
+	}
+	return nil
+}
+
+// putLong stores a C long of the given size
+func putLong(buf []byte, size int, v int64) {
+	if size == 8 {
+		binary.NativeEndian.PutUint64(buf, uint64(v))
+	} else {
+		binary.NativeEndian.PutUint32(buf, uint32(v))
+	}
+}
+
+// timerSender transmits from a user-space ticker on a raw socket, when BCM is not available
+type timerSender struct {
+	mu     sync.Mutex
+	socket int
+	ifname string
+	buf    []byte
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// newTimerSender creates a raw socket sender for an interface
+func newTimerSender(ifname string) (*timerSender, error) {
+	socket, err := openRawSocket(ifname)
+	if err != nil {
+		return nil, err
+	}
+	return &timerSender{socket: socket, ifname: ifname, buf: make([]byte, 16)}, nil
+}
+
+func (s *timerSender) start(frame models.CANFrame, period time.Duration) error {
+	s.pause(frame)
+
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go s.run(period, s.stop, s.done)
+	return nil
+}
+
+func (s *timerSender) update(frame models.CANFrame) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	binary.NativeEndian.PutUint32(s.buf[0:4], frame.ID)
+	s.buf[4] = frame.DLC
+	copy(s.buf[8:16], frame.Data[:])
+	return nil
+}
+
+func (s *timerSender) pause(frame models.CANFrame) error {
+	if s.stop != nil {
+		close(s.stop)
+		<-s.done
+		s.stop = nil
+	}
+	return s.update(frame)
+}
+
+func (s *timerSender) close() error {
+	if s.stop != nil {
+		close(s.stop)
+		<-s.done
+		s.stop = nil
+	}
+	return unix.Close(s.socket)
+}
+
+// run writes the frame on every tick, sending the first one immediately like BCM
+func (s *timerSender) run(period time.Duration, stop, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	failing := false
+	for {
+		s.mu.Lock()
+		_, err := unix.Write(s.socket, s.buf)
+		canID := binary.NativeEndian.Uint32(s.buf[0:4])
+		s.mu.Unlock()
+
+		// Failures such as a full TX queue are logged once until a write succeeds
+		if err != nil && !failing {
+			log.Printf("Warning: Cyclic transmission of 0x%X on %s failed: %v", canID, s.ifname, err)
+		}
+		failing = err != nil
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
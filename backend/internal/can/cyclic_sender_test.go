@@ -0,0 +1,102 @@
+package can
+
+import (
+	"bytes"
+	"can-db-writer/internal/models"
+	"encoding/binary"
+	"strconv"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// bcmLayout is the offset of each field of struct bcm_msg_head and of its frame (linux/can/bcm.h)
+type bcmLayout struct {
+	ival2, canID, nframes, frame, size int
+}
+
+func TestBCMSenderHead(t *testing.T) {
+	layouts := map[int]bcmLayout{
+		// u32 opcode, flags, count; 4 padding bytes; 2 x {s64 tv_sec, s64 tv_usec}; u32 can_id, nframes
+		64: {ival2: 32, canID: 48, nframes: 52, frame: 56, size: 72},
+		// u32 opcode, flags, count; 2 x {s32 tv_sec, s32 tv_usec}; u32 can_id, nframes; frame aligned to 8
+		32: {ival2: 20, canID: 28, nframes: 32, frame: 40, size: 56},
+	}
+	layout, ok := layouts[strconv.IntSize]
+	if !ok {
+		t.Skipf("no BCM layout for %d-bit platforms", strconv.IntSize)
+	}
+	long := strconv.IntSize / 8
+
+	frame := models.CANFrame{ID: 0x12345 | models.CANEffFlag, DLC: 3, Data: [8]byte{0xDE, 0xAD, 0xBE}}
+
+	tests := []struct {
+		name      string
+		write     func(s *bcmSender) error
+		wantFlags uint32
+		wantSec   int64
+		wantUsec  int64
+	}{
+		{
+			name:      "start",
+			write:     func(s *bcmSender) error { return s.start(frame, 1500*time.Millisecond) },
+			wantFlags: bcmSetTimer | bcmStartTimer,
+			wantSec:   1,
+			wantUsec:  500000,
+		},
+		{
+			name:      "start below a second",
+			write:     func(s *bcmSender) error { return s.start(frame, 10*time.Millisecond) },
+			wantFlags: bcmSetTimer | bcmStartTimer,
+			wantUsec:  10000,
+		},
+		{
+			name:  "update",
+			write: func(s *bcmSender) error { return s.update(frame) },
+		},
+		{
+			name:      "pause",
+			write:     func(s *bcmSender) error { return s.pause(frame) },
+			wantFlags: bcmSetTimer,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_SEQPACKET, 0)
+			if err != nil {
+				t.Skipf("socketpair unavailable: %v", err)
+			}
+			defer unix.Close(fds[1])
+
+			sender := &bcmSender{socket: fds[0]}
+			defer sender.close()
+			if err := tt.write(sender); err != nil {
+				t.Fatal(err)
+			}
+
+			got := make([]byte, 128)
+			n, err := unix.Read(fds[1], got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = got[:n]
+
+			want := make([]byte, layout.size)
+			binary.NativeEndian.PutUint32(want[0:], bcmTxSetup)
+			binary.NativeEndian.PutUint32(want[4:], tt.wantFlags)
+			putLong(want[layout.ival2:], long, tt.wantSec)
+			putLong(want[layout.ival2+long:], long, tt.wantUsec)
+			binary.NativeEndian.PutUint32(want[layout.canID:], frame.ID)
+			binary.NativeEndian.PutUint32(want[layout.nframes:], 1)
+			binary.NativeEndian.PutUint32(want[layout.frame:], frame.ID)
+			want[layout.frame+4] = frame.DLC
+			copy(want[layout.frame+8:], frame.Data[:])
+
+			if !bytes.Equal(got, want) {
+				t.Errorf("BCM message\n got % X\nwant % X", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package can
+
+import (
+	"can-db-writer/internal/models"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// bootupResetWindow is how long after a reset command a boot-up is considered expected
+const bootupResetWindow = 5 * time.Second
+
+// nodeWatch is the watchdog state of a single node
+type nodeWatch struct {
+	consumerTime  time.Duration
+	lastHeartbeat time.Time
+	state         models.NMTState
+	timedOut      bool
+	guarded       bool // Node guarding RTR requests were seen for this node
+	lastToggle    int  // Last toggle bit of a guarding response, -1 = none yet
+	lastReset     time.Time
+}
+
+// HeartbeatMonitor keeps live heartbeat watchdogs per node and raises events for
+// missed heartbeats, node guarding toggle-bit errors and unexpected boot-ups
+type HeartbeatMonitor struct {
+	interfaceName string
+	mu            sync.Mutex
+	nodes         map[uint8]*nodeWatch
+	eventChan     chan models.CANEvent
+	stopChan      chan struct{}
+}
+
+// NewHeartbeatMonitor creates a monitor with the consumer heartbeat time of each monitored node
+// Monitoring of a node starts with its first received heartbeat, as for a CiA 301 heartbeat consumer.
+func NewHeartbeatMonitor(interfaceName string, consumerTimes map[uint8]time.Duration) *HeartbeatMonitor {
+	nodes := make(map[uint8]*nodeWatch, len(consumerTimes))
+	for nodeID, consumerTime := range consumerTimes {
+		nodes[nodeID] = &nodeWatch{
+			consumerTime: consumerTime,
+			state:        models.NMTStateUnknown,
+			lastToggle:   -1,
+		}
+	}
+
+	return &HeartbeatMonitor{
+		interfaceName: interfaceName,
+		nodes:         nodes,
+		eventChan:     make(chan models.CANEvent, 100),
+		stopChan:      make(chan struct{}),
+	}
+}
+
+// Start begins checking the watchdogs
+func (m *HeartbeatMonitor) Start() {
+	go m.checkLoop()
+}
+
+// Stop stops the monitor
+func (m *HeartbeatMonitor) Stop() {
+	close(m.stopChan)
+}
+
+// GetEventChannel returns the channel for receiving events
+func (m *HeartbeatMonitor) GetEventChannel() <-chan models.CANEvent {
+	return m.eventChan
+}
+
+// Process feeds a received frame to the monitor
+func (m *HeartbeatMonitor) Process(msg models.CANMessage) {
+	if msg.Frame.IsExtended() || msg.Frame.IsError() {
+		return
+	}
+
+	canID := msg.Frame.ArbitrationID()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch {
+	case canID == 0x000:
+		// NMT reset commands make the following boot-up expected
+		command := models.NMTCommand(msg.Frame.Data[0])
+		if command != models.NMTCommandResetNode && command != models.NMTCommandResetCommunication {
+			return
+		}
+		target := msg.Frame.Data[1]
+		for nodeID, node := range m.nodes {
+			if target == 0 || target == nodeID {
+				node.lastReset = msg.Timestamp
+			}
+		}
+
+	case canID >= 0x701 && canID <= 0x77F:
+		node, ok := m.nodes[uint8(canID-0x700)]
+		if !ok {
+			return
+		}
+		nodeID := uint8(canID - 0x700)
+
+		if msg.Frame.IsRTR() {
+			// Node guarding request from the NMT master
+			node.guarded = true
+			return
+		}
+
+		m.processHeartbeat(nodeID, node, msg)
+	}
+}
+
+// processHeartbeat handles a boot-up, heartbeat or node guarding response
+func (m *HeartbeatMonitor) processHeartbeat(nodeID uint8, node *nodeWatch, msg models.CANMessage) {
+	state := models.NMTState(msg.Frame.Data[0] & 0x7F)
+	toggle := int(msg.Frame.Data[0] >> 7)
+
+	if node.timedOut {
+		node.timedOut = false
+		m.emit(models.CANEvent{
+			Timestamp: msg.Timestamp,
+			NodeID:    nodeID,
+			EventType: models.EventHeartbeatRecovered,
+			Severity:  models.SeverityInfo,
+			Message:   fmt.Sprintf("Node %d heartbeat recovered after %v (state %s)", nodeID, msg.Timestamp.Sub(node.lastHeartbeat).Round(time.Millisecond), state),
+			Details: map[string]string{
+				"silent_ms": fmt.Sprintf("%d", msg.Timestamp.Sub(node.lastHeartbeat).Milliseconds()),
+				"state":     state.String(),
+			},
+		})
+	}
+
+	if state == models.NMTStateBootup {
+		// A boot-up is expected on the first contact and after a reset command
+		if !node.lastHeartbeat.IsZero() && msg.Timestamp.Sub(node.lastReset) > bootupResetWindow {
+			m.emit(models.CANEvent{
+				Timestamp: msg.Timestamp,
+				NodeID:    nodeID,
+				EventType: models.EventUnexpectedBootup,
+				Severity:  models.SeverityWarning,
+				Message:   fmt.Sprintf("Node %d sent an unexpected boot-up (previous state %s)", nodeID, node.state),
+				Details: map[string]string{
+					"previous_state": node.state.String(),
+				},
+			})
+		}
+		node.lastToggle = -1
+	} else if node.guarded || toggle == 1 {
+		// Node guarding responses alternate the toggle bit
+		node.guarded = true
+		if node.lastToggle >= 0 && toggle == node.lastToggle {
+			m.emit(models.CANEvent{
+				Timestamp: msg.Timestamp,
+				NodeID:    nodeID,
+				EventType: models.EventGuardingToggleError,
+				Severity:  models.SeverityError,
+				Message:   fmt.Sprintf("Node %d node guarding toggle bit did not alternate (toggle %d)", nodeID, toggle),
+				Details: map[string]string{
+					"toggle": fmt.Sprintf("%d", toggle),
+					"state":  state.String(),
+				},
+			})
+		}
+		node.lastToggle = toggle
+	}
+
+	node.state = state
+	node.lastHeartbeat = msg.Timestamp
+}
+
+// checkLoop periodically checks the watchdogs for expired consumer times
+func (m *HeartbeatMonitor) checkLoop() {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			m.check(now.UTC())
+		case <-m.stopChan:
+			return
+		}
+	}
+}
+
+// check raises a timeout event for every node whose consumer time expired
+func (m *HeartbeatMonitor) check(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for nodeID, node := range m.nodes {
+		if node.lastHeartbeat.IsZero() || node.timedOut {
+			continue
+		}
+		if now.Sub(node.lastHeartbeat) <= node.consumerTime {
+			continue
+		}
+
+		node.timedOut = true
+		m.emit(models.CANEvent{
+			Timestamp: now,
+			NodeID:    nodeID,
+			EventType: models.EventHeartbeatTimeout,
+			Severity:  models.SeverityError,
+			Message:   fmt.Sprintf("Node %d missed its heartbeat (consumer time %v, last state %s)", nodeID, node.consumerTime, node.state),
+			Details: map[string]string{
+				"consumer_time_ms": fmt.Sprintf("%d", node.consumerTime.Milliseconds()),
+				"last_heartbeat":   node.lastHeartbeat.Format(time.RFC3339Nano),
+				"last_state":       node.state.String(),
+			},
+		})
+	}
+}
+
+// emit queues an event; the caller must hold the lock
+func (m *HeartbeatMonitor) emit(event models.CANEvent) {
+	event.Interface = m.interfaceName
+
+	select {
+	case m.eventChan <- event:
+	default:
+		fmt.Println("Warning: event channel full, dropping event")
+	}
+}
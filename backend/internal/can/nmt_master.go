@@ -0,0 +1,199 @@
+package can
+
+import (
+	"can-db-writer/internal/models"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// defaultNMTTimeout is the wait for the expected state without timeout_ms
+	defaultNMTTimeout = 3 * time.Second
+	// maxNMTTimeout bounds the requested wait, a reset node may take several seconds to boot
+	// (kept below the write timeout of the HTTP server)
+	maxNMTTimeout = 20 * time.Second
+	// nmtPollInterval is how often the wait checks for a cancelled request
+	nmtPollInterval = 100 * time.Millisecond
+)
+
+// NMTMaster sends NMT commands through the transmitter and waits for the nodes to report the expected state
+// Start, stop and pre-operational are confirmed by the heartbeat, resets by the boot-up message.
+type NMTMaster struct {
+	transmitter *Transmitter
+}
+
+// NewNMTMaster creates an NMT master transmitting through the transmitter
+func NewNMTMaster(transmitter *Transmitter) *NMTMaster {
+	return &NMTMaster{transmitter: transmitter}
+}
+
+// Command sends an NMT command to a node, or to every node with node ID 0, and waits for the expected state
+// A broadcast waits for req.Nodes, or for the whole timeout and reports every node that answered.
+// The result is filled as far as the command got, also when an error is returned.
+func (m *NMTMaster) Command(ctx context.Context, req models.NMTCommandRequest, user string) (models.NMTCommandResult, error) {
+	result := models.NMTCommandResult{
+		Timestamp: time.Now().UTC(),
+		Interface: req.Interface,
+		NodeID:    req.NodeID,
+		Broadcast: req.NodeID == 0,
+		User:      user,
+		Outcome:   models.NMTOutcomeFailed,
+		Nodes:     []models.NMTNodeResult{},
+	}
+
+	command, err := models.ParseNMTCommand(req.Command)
+	if err != nil {
+		return result, fmt.Errorf("%w: %v", ErrTxInvalid, err)
+	}
+	result.Command = command
+	result.ExpectedState = command.ExpectedState()
+
+	if req.NodeID > 127 {
+		return result, fmt.Errorf("%w: node ID %d, must be 1-127 or 0 for all nodes", ErrTxInvalid, req.NodeID)
+	}
+	expected := []uint8{req.NodeID}
+	if req.NodeID == 0 {
+		expected = req.Nodes
+		for _, nodeID := range expected {
+			if nodeID < 1 || nodeID > 127 {
+				return result, fmt.Errorf("%w: node ID %d in nodes, must be 1-127", ErrTxInvalid, nodeID)
+			}
+		}
+	}
+	if !m.transmitter.interfaces[req.Interface] {
+		return result, fmt.Errorf("%w on interface '%s'", ErrTxDisabled, req.Interface)
+	}
+
+	timeout := defaultNMTTimeout
+	if req.TimeoutMs > 0 {
+		timeout = min(time.Duration(req.TimeoutMs)*time.Millisecond, maxNMTTimeout)
+	}
+
+	// Listen before sending, a node may answer within microseconds
+	monitor, err := openHeartbeatSocket(req.Interface)
+	if err != nil {
+		return result, err
+	}
+	defer unix.Close(monitor)
+
+	start := time.Now()
+	sent, err := m.transmitter.send(ctx, models.CANSendRequest{
+		Interface: req.Interface,
+		CANID:     0x000,
+		Data:      []byte{byte(command), req.NodeID},
+		User:      user,
+	})
+	if err != nil {
+		return result, err
+	}
+	result.Timestamp = sent.TxTimestamp
+
+	nodes := make(map[uint8]*models.NMTNodeResult)
+	for _, nodeID := range expected {
+		nodes[nodeID] = &models.NMTNodeResult{NodeID: nodeID, State: models.NMTStateUnknown}
+	}
+	waitNodes(ctx, monitor, sent.TxTimestamp, start.Add(timeout), result.ExpectedState, nodes, len(expected) == 0)
+
+	result.Outcome = models.NMTOutcomeSuccess
+	if len(nodes) == 0 {
+		result.Outcome = models.NMTOutcomeTimeout
+	}
+	for _, node := range nodes {
+		if !node.Reached {
+			result.Outcome = models.NMTOutcomeTimeout
+		}
+		result.Nodes = append(result.Nodes, *node)
+	}
+	sort.Slice(result.Nodes, func(i, j int) bool { return result.Nodes[i].NodeID < result.Nodes[j].NodeID })
+	result.DurationMs = float64(time.Since(start)) / float64(time.Millisecond)
+
+	log.Printf("NMT %s sent to node %d on %s for '%s': %s after %.0fms",
+		command, req.NodeID, req.Interface, user, result.Outcome, result.DurationMs)
+	return result, nil
+}
+
+// waitNodes applies the boot-up/heartbeat frames received after the command until every node reached
+// the expected state or the deadline; any answering node is added when discover is set
+func waitNodes(ctx context.Context, monitor int, sent, deadline time.Time, expected models.NMTState, nodes map[uint8]*models.NMTNodeResult, discover bool) {
+	buf := make([]byte, 16)
+	oob := make([]byte, 64)
+	for {
+		pending := discover
+		for _, node := range nodes {
+			if !node.Reached {
+				pending = true
+			}
+		}
+		remaining := time.Until(deadline)
+		if !pending || remaining <= 0 || ctx.Err() != nil {
+			return
+		}
+
+		fds := []unix.PollFd{{Fd: int32(monitor), Events: unix.POLLIN}}
+		n, err := unix.Poll(fds, int(min(remaining, nmtPollInterval).Milliseconds())+1)
+		if err == unix.EINTR || n == 0 {
+			continue
+		}
+		if err != nil {
+			log.Printf("Warning: NMT state wait failed: %v", err)
+			return
+		}
+
+		n, oobn, _, _, err := unix.Recvmsg(monitor, buf, oob, 0)
+		if err != nil || n < 16 || buf[4] < 1 {
+			continue
+		}
+		// Heartbeats sent before the command still report the previous state
+		timestamp := receiveTimestamp(oob[:oobn])
+		if timestamp.Before(sent) {
+			continue
+		}
+
+		nodeID := uint8(binary.NativeEndian.Uint32(buf[0:4]) - 0x700)
+		node, ok := nodes[nodeID]
+		if !ok {
+			if !discover || nodeID == 0 {
+				continue
+			}
+			node = &models.NMTNodeResult{NodeID: nodeID}
+			nodes[nodeID] = node
+		}
+		if node.Reached {
+			continue
+		}
+
+		// Bit 7 is the node guarding toggle bit
+		node.State = models.NMTState(buf[8] & 0x7F)
+		if node.State == expected {
+			latency := float64(timestamp.Sub(sent)) / float64(time.Millisecond)
+			node.Reached = true
+			node.LatencyMs = &latency
+		}
+	}
+}
+
+// openHeartbeatSocket creates a raw socket receiving the boot-up/heartbeat frames (0x701-0x77F) with their timestamps
+func openHeartbeatSocket(ifname string) (int, error) {
+	socket, err := openRawSocket(ifname)
+	if err != nil {
+		return -1, err
+	}
+
+	filter := []unix.CanFilter{{Id: 0x700, Mask: models.CANEffFlag | models.CANRtrFlag | 0x780}}
+	if err := unix.SetsockoptCanRawFilter(socket, unix.SOL_CAN_RAW, unix.CAN_RAW_FILTER, filter); err != nil {
+		unix.Close(socket)
+		return -1, fmt.Errorf("failed to set filter: %w", err)
+	}
+	if err := unix.SetsockoptInt(socket, unix.SOL_SOCKET, unix.SO_TIMESTAMPNS, 1); err != nil {
+		unix.Close(socket)
+		return -1, fmt.Errorf("failed to enable timestamps: %w", err)
+	}
+
+	return socket, nil
+}
@@ -5,7 +5,6 @@ import (
 	"encoding/binary"
 	"fmt"
 	"syscall"
-	"time"
 	"unsafe"
 
 	"golang.org/x/sys/unix"
@@ -27,36 +26,15 @@ type Reader struct {
 
 // NewReader creates a new CAN reader for the specified interface
 func NewReader(ifname string) (*Reader, error) {
-	// Create a CAN socket
-	socket, err := unix.Socket(unix.AF_CAN, unix.SOCK_RAW, CAN_RAW)
+	socket, err := openRawSocket(ifname)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create CAN socket: %w", err)
+		return nil, err
 	}
 
-	// Get interface index
-	ifreq, err := unix.NewIfreq(ifname)
-	if err != nil {
+	// Frames are stamped by the kernel on reception rather than when read from the socket
+	if err := unix.SetsockoptInt(socket, unix.SOL_SOCKET, unix.SO_TIMESTAMPNS, 1); err != nil {
 		unix.Close(socket)
-		return nil, fmt.Errorf("failed to create ifreq: %w", err)
-	}
-
-	err = unix.IoctlIfreq(socket, unix.SIOCGIFINDEX, ifreq)
-	if err != nil {
-		unix.Close(socket)
-		return nil, fmt.Errorf("failed to get interface index: %w", err)
-	}
-
-	ifindex := ifreq.Uint32()
-
-	// Bind socket to CAN interface
-	addr := &unix.SockaddrCAN{
-		Ifindex: int(ifindex),
-	}
-
-	err = unix.Bind(socket, addr)
-	if err != nil {
-		unix.Close(socket)
-		return nil, fmt.Errorf("failed to bind socket: %w", err)
+		return nil, fmt.Errorf("failed to enable timestamps: %w", err)
 	}
 
 	return &Reader{
@@ -75,9 +53,10 @@ func (r *Reader) Start() {
 // readLoop continuously reads CAN frames from the socket
 func (r *Reader) readLoop() {
 	buf := make([]byte, 16) // CAN frame is 16 bytes
+	oob := make([]byte, unix.CmsgSpace(int(unsafe.Sizeof(unix.Timespec{}))))
 
 	for {
-		n, err := unix.Read(r.socket, buf)
+		n, oobn, flags, _, err := unix.Recvmsg(r.socket, buf, oob, 0)
 		if err != nil {
 			r.errorChan <- fmt.Errorf("read error: %w", err)
 			continue
@@ -90,16 +69,20 @@ func (r *Reader) readLoop() {
 
 		// Parse CAN frame
 		frame := models.CANFrame{
-			ID:  binary.LittleEndian.Uint32(buf[0:4]),
+			ID:  binary.NativeEndian.Uint32(buf[0:4]),
 			DLC: buf[4],
 		}
 		copy(frame.Data[:], buf[8:16])
 
 		msg := models.CANMessage{
 			Frame:     frame,
-			Timestamp: time.Now().UTC(),
+			Timestamp: receiveTimestamp(oob[:oobn]),
 			Interface: r.ifname,
 		}
+		// MSG_DONTROUTE marks frames looped back from a socket of this host, such as the API server
+		if flags&unix.MSG_DONTROUTE != 0 {
+			msg.Direction = models.DirectionTX
+		}
 
 		select {
 		case r.msgChan <- msg:
@@ -143,8 +126,8 @@ func (r *Reader) SetFilter(filters []uint32) error {
 	filterBuf := make([]byte, len(filters)*8)
 	for i, id := range filters {
 		offset := i * 8
-		binary.LittleEndian.PutUint32(filterBuf[offset:], id)
-		binary.LittleEndian.PutUint32(filterBuf[offset+4:], 0xFFFFFFFF) // exact match
+		binary.NativeEndian.PutUint32(filterBuf[offset:], id)
+		binary.NativeEndian.PutUint32(filterBuf[offset+4:], 0xFFFFFFFF) // exact match
 	}
 
 	_, _, errno := syscall.Syscall6(
@@ -0,0 +1,676 @@
+package can
+
+import (
+	"can-db-writer/internal/models"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// defaultSDOTimeout is the wait for each SDO response without timeout_ms
+	defaultSDOTimeout = time.Second
+	// maxSDOSize bounds the data of a segmented or block upload
+	maxSDOSize = 1 << 20
+	// sdoBlockSize is the number of segments per block requested from the server
+	sdoBlockSize = 127
+)
+
+// SDO abort codes sent by the client (CiA 301)
+const (
+	sdoAbortToggle           = 0x05030000
+	sdoAbortTimeout          = 0x05040000
+	sdoAbortCommandSpecifier = 0x05040001
+	sdoAbortBlockSize        = 0x05040002
+	sdoAbortCRC              = 0x05040004
+	sdoAbortOutOfMemory      = 0x05040005
+	sdoAbortLength           = 0x06070010
+	sdoAbortGeneral          = 0x08000000
+)
+
+// SDO segment and block transfer command specifiers, bits 5-7 of the first data byte
+const (
+	sdoClientSegmentDownload = 0
+	sdoClientSegmentUpload   = 3
+	sdoClientBlockUpload     = 5
+	sdoClientBlockDownload   = 6
+	sdoServerSegmentUpload   = 0
+	sdoServerSegmentDownload = 1
+	sdoServerBlockDownload   = 5
+	sdoServerBlockUpload     = 6
+)
+
+// SDO block transfer subcommands, the low bits of the first data byte
+const (
+	sdoBlockInitiate    = 0
+	sdoBlockEnd         = 1
+	sdoBlockAck         = 2
+	sdoBlockUploadStart = 3
+)
+
+// SDOAbortError is an SDO transfer aborted by the node or, on a protocol error, by the client
+type SDOAbortError struct {
+	Code   uint32
+	Server bool   // Aborted by the node
+	Reason string // Protocol error detected by the client
+}
+
+func (e *SDOAbortError) Error() string {
+	if e.Server {
+		return fmt.Sprintf("SDO abort 0x%08X by node: %s", e.Code, models.SDOAbortDescription(e.Code))
+	}
+	return fmt.Sprintf("SDO protocol error, aborted with 0x%08X (%s): %s", e.Code, models.SDOAbortDescription(e.Code), e.Reason)
+}
+
+// errBlockUnsupported is returned when the server rejects the initiation of a block transfer
+var errBlockUnsupported = errors.New("block transfer not supported")
+
+// SDOClient reads and writes the object dictionary of CANopen nodes on the interfaces enabled for transmission
+// Transfers to the same node are serialized, as a node has a single default SDO server.
+type SDOClient struct {
+	mu         sync.Mutex
+	interfaces map[string]bool
+	channels   map[string]*sync.Mutex
+	device     models.Device
+	record     func(ctx context.Context, event models.CANEvent) error
+}
+
+// SDOTransfer is a completed SDO transfer
+type SDOTransfer struct {
+	Data     []byte
+	Transfer string // models.SDOTransferExpedited, SDOTransferSegmented or SDOTransferBlock
+	Duration time.Duration
+}
+
+// NewSDOClient creates an SDO client for the interfaces; record stores every download attempt as an event
+func NewSDOClient(interfaces []string, device models.Device, record func(ctx context.Context, event models.CANEvent) error) *SDOClient {
+	c := &SDOClient{
+		interfaces: make(map[string]bool),
+		channels:   make(map[string]*sync.Mutex),
+		device:     device,
+		record:     record,
+	}
+	for _, iface := range interfaces {
+		c.interfaces[iface] = true
+	}
+	return c
+}
+
+// Upload reads an object dictionary entry from a node
+// A block upload falls back to a segmented one when the node does not support block transfers.
+func (c *SDOClient) Upload(iface string, nodeID uint8, index uint16, subIndex uint8, timeout time.Duration, block bool) (SDOTransfer, error) {
+	session, unlock, err := c.open(iface, nodeID, index, subIndex, timeout)
+	if err != nil {
+		return SDOTransfer{}, err
+	}
+	defer unlock()
+	defer unix.Close(session.socket)
+
+	start := time.Now()
+	transfer := SDOTransfer{}
+	if block {
+		transfer.Data, err = session.blockUpload()
+		transfer.Transfer = models.SDOTransferBlock
+		if errors.Is(err, errBlockUnsupported) {
+			block = false
+		}
+	}
+	if !block {
+		transfer.Data, transfer.Transfer, err = session.upload()
+	}
+	if err != nil {
+		return SDOTransfer{}, session.fail(err)
+	}
+
+	transfer.Duration = time.Since(start)
+	return transfer, nil
+}
+
+// Download writes an object dictionary entry of a node
+// Up to 4 bytes are sent expedited unless a block download is requested.
+// Every attempt is recorded as an sdo_write event with its outcome.
+func (c *SDOClient) Download(iface string, nodeID uint8, index uint16, subIndex uint8, data []byte, timeout time.Duration, block bool, user string) (SDOTransfer, error) {
+	transfer, err := c.download(iface, nodeID, index, subIndex, data, timeout, block, user)
+	c.recordDownload(iface, nodeID, index, subIndex, data, user, transfer, err)
+	return transfer, err
+}
+
+// download writes an object dictionary entry of a node, see Download
+func (c *SDOClient) download(iface string, nodeID uint8, index uint16, subIndex uint8, data []byte, timeout time.Duration, block bool, user string) (SDOTransfer, error) {
+	if len(data) == 0 || len(data) > maxSDOSize {
+		return SDOTransfer{}, fmt.Errorf("%w: %d data bytes, must be 1 to %d", ErrTxInvalid, len(data), maxSDOSize)
+	}
+
+	session, unlock, err := c.open(iface, nodeID, index, subIndex, timeout)
+	if err != nil {
+		return SDOTransfer{}, err
+	}
+	defer unlock()
+	defer unix.Close(session.socket)
+
+	start := time.Now()
+	transfer := SDOTransfer{Data: data}
+	if block {
+		transfer.Transfer = models.SDOTransferBlock
+		err = session.blockDownload(data)
+		if errors.Is(err, errBlockUnsupported) {
+			block = false
+		}
+	}
+	if !block {
+		transfer.Transfer, err = session.download(data)
+	}
+	if err != nil {
+		return SDOTransfer{}, session.fail(err)
+	}
+
+	transfer.Duration = time.Since(start)
+	log.Printf("SDO download 0x%04Xsub%X [% X] to node %d on %s for '%s' (%s)",
+		index, subIndex, data, nodeID, iface, user, transfer.Transfer)
+	return transfer, nil
+}
+
+// recordDownload records a download attempt as an sdo_write event
+func (c *SDOClient) recordDownload(iface string, nodeID uint8, index uint16, subIndex uint8, data []byte, user string, transfer SDOTransfer, err error) {
+	if c.record == nil {
+		return
+	}
+
+	event := models.CANEvent{
+		Timestamp: time.Now().UTC(),
+		Interface: iface,
+		NodeID:    nodeID,
+		EventType: models.EventSDOWrite,
+		Severity:  models.SeverityInfo,
+		Message: fmt.Sprintf("SDO write 0x%04Xsub%X [% X] to node %d by '%s' (%s)",
+			index, subIndex, data, nodeID, user, transfer.Transfer),
+		Details: map[string]string{
+			"index":    fmt.Sprintf("0x%04X", index),
+			"subindex": fmt.Sprintf("0x%02X", subIndex),
+			"data":     fmt.Sprintf("% X", data),
+			"user":     user,
+			"outcome":  "success",
+		},
+		Device: c.device,
+	}
+	if err != nil {
+		var abort *SDOAbortError
+		event.Severity = models.SeverityWarning
+		event.Message = fmt.Sprintf("SDO write 0x%04Xsub%X [% X] to node %d by '%s' failed: %v",
+			index, subIndex, data, nodeID, user, err)
+		event.Details["outcome"] = "failed"
+		event.Details["error"] = err.Error()
+		if errors.As(err, &abort) {
+			event.Details["outcome"] = "aborted"
+			event.Details["abort_code"] = fmt.Sprintf("0x%08X", abort.Code)
+		}
+	} else {
+		event.Details["transfer"] = transfer.Transfer
+	}
+
+	if err := c.record(context.Background(), event); err != nil {
+		log.Printf("Warning: Failed to record SDO write 0x%04Xsub%X to node %d: %v", index, subIndex, nodeID, err)
+	}
+}
+
+// open validates a transfer, takes the SDO channel of the node and opens its socket
+func (c *SDOClient) open(iface string, nodeID uint8, index uint16, subIndex uint8, timeout time.Duration) (*sdoSession, func(), error) {
+	if !c.interfaces[iface] {
+		return nil, nil, fmt.Errorf("%w on interface '%s'", ErrTxDisabled, iface)
+	}
+	if nodeID < 1 || nodeID > 127 {
+		return nil, nil, fmt.Errorf("%w: node ID %d, must be 1-127", ErrTxInvalid, nodeID)
+	}
+	if timeout <= 0 {
+		timeout = defaultSDOTimeout
+	}
+	timeout = min(timeout, maxTxTimeout)
+
+	c.mu.Lock()
+	key := fmt.Sprintf("%s/%d", iface, nodeID)
+	channel, ok := c.channels[key]
+	if !ok {
+		channel = &sync.Mutex{}
+		c.channels[key] = channel
+	}
+	c.mu.Unlock()
+
+	channel.Lock()
+	socket, err := openRawSocket(iface)
+	if err != nil {
+		channel.Unlock()
+		return nil, nil, err
+	}
+	// Only the responses of the node's SDO server (0x580 + node ID) are received
+	filter := []unix.CanFilter{{Id: 0x580 + uint32(nodeID), Mask: models.CANEffFlag | models.CANRtrFlag | models.CANSffMask}}
+	if err := unix.SetsockoptCanRawFilter(socket, unix.SOL_CAN_RAW, unix.CAN_RAW_FILTER, filter); err != nil {
+		unix.Close(socket)
+		channel.Unlock()
+		return nil, nil, fmt.Errorf("failed to set filter: %w", err)
+	}
+
+	session := &sdoSession{
+		socket:   socket,
+		ifname:   iface,
+		nodeID:   nodeID,
+		index:    index,
+		subIndex: subIndex,
+		timeout:  timeout,
+	}
+	return session, channel.Unlock, nil
+}
+
+// sdoSession is a transfer between the client and the SDO server of a node
+type sdoSession struct {
+	socket   int
+	ifname   string
+	nodeID   uint8
+	index    uint16
+	subIndex uint8
+	timeout  time.Duration
+}
+
+// upload reads the entry with an expedited or segmented transfer
+func (s *sdoSession) upload() ([]byte, string, error) {
+	resp, err := s.request(s.initiate(models.SDOClientUploadInitiate << 5))
+	if err != nil {
+		return nil, "", err
+	}
+	if resp[0]>>5 != models.SDOServerUploadInitiate {
+		return nil, "", s.unexpected(resp)
+	}
+	if err := s.checkMultiplexer(resp); err != nil {
+		return nil, "", err
+	}
+
+	// Expedited: up to 4 bytes in the response, all 4 if the size is not indicated
+	if resp[0]&0x02 != 0 {
+		size := 4
+		if resp[0]&0x01 != 0 {
+			size = 4 - int(resp[0]>>2&0x03)
+		}
+		return append([]byte{}, resp[4:4+size]...), models.SDOTransferExpedited, nil
+	}
+
+	size := -1
+	if resp[0]&0x01 != 0 {
+		size = int(binary.LittleEndian.Uint32(resp[4:8]))
+		if size > maxSDOSize {
+			return nil, "", protocolError(sdoAbortOutOfMemory, "node announced %d bytes, at most %d", size, maxSDOSize)
+		}
+	}
+
+	var data []byte
+	var toggle byte
+	for {
+		resp, err := s.request([8]byte{sdoClientSegmentUpload<<5 | toggle<<4})
+		if err != nil {
+			return nil, "", err
+		}
+		if resp[0]>>5 != sdoServerSegmentUpload {
+			return nil, "", s.unexpected(resp)
+		}
+		if resp[0]>>4&0x01 != toggle {
+			return nil, "", protocolError(sdoAbortToggle, "segment toggle bit %d, expected %d", resp[0]>>4&0x01, toggle)
+		}
+
+		unused := int(resp[0] >> 1 & 0x07)
+		data = append(data, resp[1:8-unused]...)
+		if len(data) > maxSDOSize {
+			return nil, "", protocolError(sdoAbortOutOfMemory, "more than %d bytes uploaded", maxSDOSize)
+		}
+		if resp[0]&0x01 != 0 {
+			break
+		}
+		toggle ^= 1
+	}
+
+	if size >= 0 && len(data) != size {
+		return nil, "", protocolError(sdoAbortLength, "node sent %d bytes, announced %d", len(data), size)
+	}
+	return data, models.SDOTransferSegmented, nil
+}
+
+// download writes the entry with an expedited or segmented transfer
+func (s *sdoSession) download(data []byte) (string, error) {
+	if len(data) <= 4 {
+		frame := s.initiate(models.SDOClientDownloadInitiate<<5 | byte(4-len(data))<<2 | 0x03)
+		copy(frame[4:], data)
+		resp, err := s.request(frame)
+		if err != nil {
+			return "", err
+		}
+		if resp[0]>>5 != models.SDOServerDownloadInitiate {
+			return "", s.unexpected(resp)
+		}
+		return models.SDOTransferExpedited, s.checkMultiplexer(resp)
+	}
+
+	frame := s.initiate(models.SDOClientDownloadInitiate<<5 | 0x01)
+	binary.LittleEndian.PutUint32(frame[4:8], uint32(len(data)))
+	resp, err := s.request(frame)
+	if err != nil {
+		return "", err
+	}
+	if resp[0]>>5 != models.SDOServerDownloadInitiate {
+		return "", s.unexpected(resp)
+	}
+	if err := s.checkMultiplexer(resp); err != nil {
+		return "", err
+	}
+
+	var toggle byte
+	for offset := 0; offset < len(data); offset += 7 {
+		segment := data[offset:min(offset+7, len(data))]
+		frame := [8]byte{sdoClientSegmentDownload<<5 | toggle<<4 | byte(7-len(segment))<<1}
+		if offset+7 >= len(data) {
+			frame[0] |= 0x01
+		}
+		copy(frame[1:], segment)
+
+		resp, err := s.request(frame)
+		if err != nil {
+			return "", err
+		}
+		if resp[0]>>5 != sdoServerSegmentDownload {
+			return "", s.unexpected(resp)
+		}
+		if resp[0]>>4&0x01 != toggle {
+			return "", protocolError(sdoAbortToggle, "segment toggle bit %d, expected %d", resp[0]>>4&0x01, toggle)
+		}
+		toggle ^= 1
+	}
+
+	return models.SDOTransferSegmented, nil
+}
+
+// blockUpload reads the entry with a block transfer, verifying its CRC when the node supports it
+func (s *sdoSession) blockUpload() ([]byte, error) {
+	frame := s.initiate(sdoClientBlockUpload<<5 | 0x04 | sdoBlockInitiate) // CRC supported
+	frame[4] = sdoBlockSize
+	resp, err := s.request(frame)
+	if err != nil {
+		return nil, blockInitiateError(err)
+	}
+	if resp[0]>>5 != sdoServerBlockUpload || resp[0]&0x01 != sdoBlockInitiate {
+		return nil, s.unexpected(resp)
+	}
+	if err := s.checkMultiplexer(resp); err != nil {
+		return nil, err
+	}
+	crc := resp[0]&0x04 != 0
+	size := -1
+	if resp[0]&0x02 != 0 {
+		size = int(binary.LittleEndian.Uint32(resp[4:8]))
+		if size > maxSDOSize {
+			return nil, protocolError(sdoAbortOutOfMemory, "node announced %d bytes, at most %d", size, maxSDOSize)
+		}
+	}
+
+	if err := s.send([8]byte{sdoClientBlockUpload<<5 | sdoBlockUploadStart}); err != nil {
+		return nil, err
+	}
+
+	// Segments after a lost one are dropped; the acknowledgement makes the node send them again
+	var data []byte
+	for last := false; !last; {
+		var ackseq byte
+		for {
+			resp, err := s.receive()
+			if err != nil {
+				return nil, err
+			}
+			seq := resp[0] & 0x7F
+			if seq == ackseq+1 {
+				ackseq = seq
+				data = append(data, resp[1:8]...)
+				last = resp[0]&0x80 != 0
+			}
+			if last || seq == sdoBlockSize || (resp[0]&0x80 != 0 && seq != ackseq) {
+				break
+			}
+		}
+		if len(data) > maxSDOSize+7 {
+			return nil, protocolError(sdoAbortOutOfMemory, "more than %d bytes uploaded", maxSDOSize)
+		}
+		if err := s.send([8]byte{sdoClientBlockUpload<<5 | sdoBlockAck, ackseq, sdoBlockSize}); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err = s.receive()
+	if err != nil {
+		return nil, err
+	}
+	if resp[0]>>5 != sdoServerBlockUpload || resp[0]&0x03 != sdoBlockEnd {
+		return nil, s.unexpected(resp)
+	}
+	unused := int(resp[0] >> 2 & 0x07)
+	if unused > len(data) {
+		return nil, protocolError(sdoAbortLength, "%d unused bytes in the last segment of %d bytes", unused, len(data))
+	}
+	data = data[:len(data)-unused]
+	if crc {
+		if expected, got := binary.LittleEndian.Uint16(resp[1:3]), sdoCRC(data); expected != got {
+			return nil, protocolError(sdoAbortCRC, "CRC 0x%04X, node sent 0x%04X", got, expected)
+		}
+	}
+	if size >= 0 && len(data) != size {
+		return nil, protocolError(sdoAbortLength, "node sent %d bytes, announced %d", len(data), size)
+	}
+
+	if err := s.send([8]byte{sdoClientBlockUpload<<5 | sdoBlockEnd}); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// blockDownload writes the entry with a block transfer, sending its CRC when the node supports it
+func (s *sdoSession) blockDownload(data []byte) error {
+	frame := s.initiate(sdoClientBlockDownload<<5 | 0x04 | 0x02 | sdoBlockInitiate) // CRC supported, size indicated
+	binary.LittleEndian.PutUint32(frame[4:8], uint32(len(data)))
+	resp, err := s.request(frame)
+	if err != nil {
+		return blockInitiateError(err)
+	}
+	if resp[0]>>5 != sdoServerBlockDownload || resp[0]&0x03 != sdoBlockInitiate {
+		return s.unexpected(resp)
+	}
+	if err := s.checkMultiplexer(resp); err != nil {
+		return err
+	}
+	crc := resp[0]&0x04 != 0
+	blockSize := resp[4]
+
+	for offset := 0; offset < len(data); {
+		if blockSize < 1 || blockSize > sdoBlockSize {
+			return protocolError(sdoAbortBlockSize, "block size %d, must be 1-%d", blockSize, sdoBlockSize)
+		}
+
+		start := offset
+		var seq byte
+		for seq = 1; seq <= blockSize && offset < len(data); seq++ {
+			segment := data[offset:min(offset+7, len(data))]
+			offset += len(segment)
+			frame := [8]byte{seq}
+			if offset == len(data) {
+				frame[0] |= 0x80
+			}
+			copy(frame[1:], segment)
+			if err := s.send(frame); err != nil {
+				return err
+			}
+		}
+		sent := seq - 1
+
+		resp, err := s.receive()
+		if err != nil {
+			return err
+		}
+		if resp[0]>>5 != sdoServerBlockDownload || resp[0]&0x03 != sdoBlockAck {
+			return s.unexpected(resp)
+		}
+		// Segments after the acknowledged sequence number are sent again in the next block
+		if ackseq := resp[1]; ackseq < sent {
+			offset = start + 7*int(ackseq)
+		}
+		blockSize = resp[2]
+	}
+
+	unused := (7 - len(data)%7) % 7
+	end := [8]byte{sdoClientBlockDownload<<5 | byte(unused)<<2 | sdoBlockEnd}
+	if crc {
+		binary.LittleEndian.PutUint16(end[1:3], sdoCRC(data))
+	}
+	resp, err = s.request(end)
+	if err != nil {
+		return err
+	}
+	if resp[0]>>5 != sdoServerBlockDownload || resp[0]&0x03 != sdoBlockEnd {
+		return s.unexpected(resp)
+	}
+	return nil
+}
+
+// initiate builds an initiate request addressing the entry
+func (s *sdoSession) initiate(command byte) [8]byte {
+	frame := [8]byte{command}
+	binary.LittleEndian.PutUint16(frame[1:3], s.index)
+	frame[3] = s.subIndex
+	return frame
+}
+
+// request sends a frame and waits for the response of the node
+func (s *sdoSession) request(frame [8]byte) ([8]byte, error) {
+	if err := s.send(frame); err != nil {
+		return [8]byte{}, err
+	}
+	return s.receive()
+}
+
+// send transmits a request on 0x600 + node ID, retrying while the transmit queue is full
+func (s *sdoSession) send(frame [8]byte) error {
+	buf := make([]byte, 16)
+	binary.NativeEndian.PutUint32(buf[0:4], 0x600+uint32(s.nodeID))
+	buf[4] = 8
+	copy(buf[8:16], frame[:])
+
+	deadline := time.Now().Add(s.timeout)
+	for {
+		_, err := unix.Write(s.socket, buf)
+		if err != unix.ENOBUFS || time.Now().After(deadline) {
+			if err != nil {
+				return fmt.Errorf("write error: %w", err)
+			}
+			return nil
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// receive waits for the next response of the node, returning its abort as an error
+func (s *sdoSession) receive() ([8]byte, error) {
+	deadline := time.Now().Add(s.timeout)
+	buf := make([]byte, 16)
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return [8]byte{}, fmt.Errorf("%w: no SDO response from node %d on %s within %v", ErrTxTimeout, s.nodeID, s.ifname, s.timeout)
+		}
+
+		fds := []unix.PollFd{{Fd: int32(s.socket), Events: unix.POLLIN}}
+		n, err := unix.Poll(fds, int(remaining.Milliseconds())+1)
+		if err == unix.EINTR || n == 0 {
+			continue
+		}
+		if err != nil {
+			return [8]byte{}, fmt.Errorf("poll error: %w", err)
+		}
+
+		n, err = unix.Read(s.socket, buf)
+		if err != nil {
+			return [8]byte{}, fmt.Errorf("read error: %w", err)
+		}
+		if n < 16 {
+			continue
+		}
+
+		var resp [8]byte
+		copy(resp[:], buf[8:16])
+		if resp[0] == models.SDOAbort<<5 {
+			return resp, &SDOAbortError{Code: binary.LittleEndian.Uint32(resp[4:8]), Server: true}
+		}
+		return resp, nil
+	}
+}
+
+// fail aborts the transfer on a timeout or protocol error and returns the error
+func (s *sdoSession) fail(err error) error {
+	var abort *SDOAbortError
+	switch {
+	case errors.As(err, &abort) && !abort.Server:
+		s.abort(abort.Code)
+	case errors.Is(err, ErrTxTimeout):
+		s.abort(sdoAbortTimeout)
+	}
+	return err
+}
+
+// abort sends an abort transfer request
+func (s *sdoSession) abort(code uint32) {
+	frame := s.initiate(models.SDOAbort << 5)
+	binary.LittleEndian.PutUint32(frame[4:8], code)
+	if err := s.send(frame); err != nil {
+		log.Printf("Warning: Failed to abort SDO transfer to node %d on %s: %v", s.nodeID, s.ifname, err)
+	}
+}
+
+// checkMultiplexer checks that a response addresses the entry of the transfer
+func (s *sdoSession) checkMultiplexer(resp [8]byte) error {
+	index, subIndex := binary.LittleEndian.Uint16(resp[1:3]), resp[3]
+	if index != s.index || subIndex != s.subIndex {
+		return protocolError(sdoAbortGeneral, "response for 0x%04Xsub%X, expected 0x%04Xsub%X", index, subIndex, s.index, s.subIndex)
+	}
+	return nil
+}
+
+// unexpected returns the protocol error of a response with an unexpected command specifier
+func (s *sdoSession) unexpected(resp [8]byte) error {
+	return protocolError(sdoAbortCommandSpecifier, "unexpected response command 0x%02X", resp[0])
+}
+
+// protocolError returns a protocol error detected by the client, aborting the transfer with code
+func protocolError(code uint32, format string, args ...any) error {
+	return &SDOAbortError{Code: code, Reason: fmt.Sprintf(format, args...)}
+}
+
+// blockInitiateError tells a node rejecting block transfers apart from other initiate errors
+func blockInitiateError(err error) error {
+	var abort *SDOAbortError
+	if errors.As(err, &abort) && abort.Server && abort.Code == sdoAbortCommandSpecifier {
+		return errBlockUnsupported
+	}
+	return err
+}
+
+// sdoCRC computes the CRC of a block transfer (CRC-16-CCITT, polynomial 0x1021, initial value 0)
+func sdoCRC(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
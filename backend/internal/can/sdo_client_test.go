@@ -0,0 +1,491 @@
+package can
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	sdoTestNode     = 5
+	sdoTestIndex    = 0x1018
+	sdoTestSubIndex = 1
+)
+
+// sdoStep is a frame of a scripted SDO exchange, sent by the client or by the server
+type sdoStep struct {
+	client bool
+	frame  [8]byte
+}
+
+func fromClient(data ...byte) sdoStep {
+	step := sdoStep{client: true}
+	copy(step.frame[:], data)
+	return step
+}
+
+func fromServer(data ...byte) sdoStep {
+	step := sdoStep{}
+	copy(step.frame[:], data)
+	return step
+}
+
+// entry builds an initiate or abort frame addressing the entry of the test
+func entry(command byte, rest ...byte) []byte {
+	return append([]byte{command, sdoTestIndex & 0xFF, sdoTestIndex >> 8, sdoTestSubIndex}, rest...)
+}
+
+func le32(v uint32) []byte {
+	return binary.LittleEndian.AppendUint32(nil, v)
+}
+
+func le16(v uint16) []byte {
+	return binary.LittleEndian.AppendUint16(nil, v)
+}
+
+// abortFrame is the abort transfer request of the client
+func abortFrame(code uint32) sdoStep {
+	return fromClient(entry(0x80, le32(code)...)...)
+}
+
+// segments returns the 7 byte segments of data with block sequence numbers from 1, the last one flagged
+func segments(data []byte, last bool) []sdoStep {
+	var steps []sdoStep
+	for i := 0; i*7 < len(data); i++ {
+		seq := byte(i + 1)
+		if last && (i+1)*7 >= len(data) {
+			seq |= 0x80
+		}
+		steps = append(steps, fromServer(append([]byte{seq}, data[i*7:min((i+1)*7, len(data))]...)...))
+	}
+	return steps
+}
+
+// asClient turns server steps into client steps
+func asClient(steps []sdoStep) []sdoStep {
+	for i := range steps {
+		steps[i].client = true
+	}
+	return steps
+}
+
+// script concatenates steps
+func script(parts ...any) []sdoStep {
+	var steps []sdoStep
+	for _, part := range parts {
+		switch part := part.(type) {
+		case sdoStep:
+			steps = append(steps, part)
+		case []sdoStep:
+			steps = append(steps, part...)
+		}
+	}
+	return steps
+}
+
+// testData returns n bytes counting from 1
+func testData(n int) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(i + 1)
+	}
+	return data
+}
+
+// runSDOServer plays the server side of a script on a socket, reporting the first deviation
+func runSDOServer(socket int, steps []sdoStep) error {
+	buf := make([]byte, 16)
+	for i, step := range steps {
+		if !step.client {
+			binary.NativeEndian.PutUint32(buf[0:4], 0x580+sdoTestNode)
+			buf[4] = 8
+			copy(buf[8:16], step.frame[:])
+			if _, err := unix.Write(socket, buf); err != nil {
+				return fmt.Errorf("step %d: client gone before response % X: %v", i, step.frame, err)
+			}
+			continue
+		}
+
+		fds := []unix.PollFd{{Fd: int32(socket), Events: unix.POLLIN}}
+		if n, err := unix.Poll(fds, 2000); err != nil || n == 0 {
+			return fmt.Errorf("step %d: no request, expected % X", i, step.frame)
+		}
+		n, err := unix.Read(socket, buf)
+		if err != nil || n == 0 {
+			return fmt.Errorf("step %d: client done, expected request % X", i, step.frame)
+		}
+		if id := binary.NativeEndian.Uint32(buf[0:4]); n != 16 || id != 0x600+sdoTestNode || buf[4] != 8 {
+			return fmt.Errorf("step %d: request of %d bytes on 0x%X with DLC %d", i, n, id, buf[4])
+		}
+		if !bytes.Equal(buf[8:16], step.frame[:]) {
+			return fmt.Errorf("step %d: request % X, expected % X", i, buf[8:16], step.frame)
+		}
+	}
+
+	// The client must not send anything after the script
+	fds := []unix.PollFd{{Fd: int32(socket), Events: unix.POLLIN}}
+	if n, _ := unix.Poll(fds, 2000); n > 0 {
+		if n, _ := unix.Read(socket, buf); n > 0 {
+			return fmt.Errorf("unexpected request % X after the script", buf[8:16])
+		}
+	}
+	return nil
+}
+
+func TestSDOSession(t *testing.T) {
+	data10 := testData(10)
+	data21 := testData(21)
+	data28 := testData(28)
+	data892 := testData(127*7 + 3)
+
+	upload := func(s *sdoSession) ([]byte, string, error) {
+		return s.upload()
+	}
+	blockUpload := func(s *sdoSession) ([]byte, string, error) {
+		data, err := s.blockUpload()
+		return data, "block", err
+	}
+	download := func(data []byte) func(s *sdoSession) ([]byte, string, error) {
+		return func(s *sdoSession) ([]byte, string, error) {
+			transfer, err := s.download(data)
+			return nil, transfer, err
+		}
+	}
+	blockDownload := func(data []byte) func(s *sdoSession) ([]byte, string, error) {
+		return func(s *sdoSession) ([]byte, string, error) {
+			return nil, "block", s.blockDownload(data)
+		}
+	}
+
+	tests := []struct {
+		name         string
+		run          func(s *sdoSession) ([]byte, string, error)
+		steps        []sdoStep
+		wantData     []byte
+		wantTransfer string
+		wantAbort    uint32 // Abort code of the error, sent by the client unless wantServer
+		wantServer   bool
+		wantErr      error
+	}{
+		{
+			name: "expedited upload without size",
+			run:  upload,
+			steps: script(
+				fromClient(entry(0x40)...),
+				fromServer(entry(0x42, 1, 2, 3, 4)...),
+			),
+			wantData:     []byte{1, 2, 3, 4},
+			wantTransfer: "expedited",
+		},
+		{
+			name: "expedited upload of 2 bytes",
+			run:  upload,
+			steps: script(
+				fromClient(entry(0x40)...),
+				fromServer(entry(0x4B, 1, 2, 0xEE, 0xEE)...),
+			),
+			wantData:     []byte{1, 2},
+			wantTransfer: "expedited",
+		},
+		{
+			name: "segmented upload",
+			run:  upload,
+			steps: script(
+				fromClient(entry(0x40)...),
+				fromServer(entry(0x41, le32(10)...)...),
+				fromClient(0x60),
+				fromServer(append([]byte{0x00}, data10[:7]...)...),
+				fromClient(0x70),
+				fromServer(append([]byte{0x10 | 4<<1 | 0x01}, data10[7:]...)...),
+			),
+			wantData:     data10,
+			wantTransfer: "segmented",
+		},
+		{
+			name: "segmented upload with a repeated toggle bit",
+			run:  upload,
+			steps: script(
+				fromClient(entry(0x40)...),
+				fromServer(entry(0x41, le32(10)...)...),
+				fromClient(0x60),
+				fromServer(append([]byte{0x00}, data10[:7]...)...),
+				fromClient(0x70),
+				fromServer(append([]byte{4<<1 | 0x01}, data10[7:]...)...),
+				abortFrame(sdoAbortToggle),
+			),
+			wantAbort: sdoAbortToggle,
+		},
+		{
+			name: "segmented upload longer than announced",
+			run:  upload,
+			steps: script(
+				fromClient(entry(0x40)...),
+				fromServer(entry(0x41, le32(8)...)...),
+				fromClient(0x60),
+				fromServer(append([]byte{0x00}, data10[:7]...)...),
+				fromClient(0x70),
+				fromServer(append([]byte{0x10 | 4<<1 | 0x01}, data10[7:]...)...),
+				abortFrame(sdoAbortLength),
+			),
+			wantAbort: sdoAbortLength,
+		},
+		{
+			name: "upload aborted by the node",
+			run:  upload,
+			steps: script(
+				fromClient(entry(0x40)...),
+				fromServer(entry(0x80, le32(0x06020000)...)...),
+			),
+			wantAbort:  0x06020000,
+			wantServer: true,
+		},
+		{
+			name: "upload response for another entry",
+			run:  upload,
+			steps: script(
+				fromClient(entry(0x40)...),
+				fromServer(0x43, 0x00, 0x10, 0x00, 1, 2, 3, 4),
+				abortFrame(sdoAbortGeneral),
+			),
+			wantAbort: sdoAbortGeneral,
+		},
+		{
+			name: "upload with an unexpected command",
+			run:  upload,
+			steps: script(
+				fromClient(entry(0x40)...),
+				fromServer(entry(0x60)...),
+				abortFrame(sdoAbortCommandSpecifier),
+			),
+			wantAbort: sdoAbortCommandSpecifier,
+		},
+		{
+			name: "upload timed out",
+			run:  upload,
+			steps: script(
+				fromClient(entry(0x40)...),
+				abortFrame(sdoAbortTimeout),
+			),
+			wantErr: ErrTxTimeout,
+		},
+		{
+			name: "block upload with CRC",
+			run:  blockUpload,
+			steps: script(
+				fromClient(entry(0xA4, sdoBlockSize)...),
+				fromServer(entry(0xC6, le32(10)...)...),
+				fromClient(0xA3),
+				segments(data10, true),
+				fromClient(0xA2, 2, sdoBlockSize),
+				fromServer(append([]byte{0xC1 | 4<<2}, le16(sdoCRC(data10))...)...),
+				fromClient(0xA1),
+			),
+			wantData: data10,
+		},
+		{
+			name: "block upload acknowledging a full block before the end",
+			run:  blockUpload,
+			steps: script(
+				fromClient(entry(0xA4, sdoBlockSize)...),
+				fromServer(entry(0xC6, le32(uint32(len(data892)))...)...),
+				fromClient(0xA3),
+				segments(data892[:127*7], false),
+				fromClient(0xA2, 127, sdoBlockSize),
+				segments(data892[127*7:], true),
+				fromClient(0xA2, 1, sdoBlockSize),
+				fromServer(append([]byte{0xC1 | 4<<2}, le16(sdoCRC(data892))...)...),
+				fromClient(0xA1),
+			),
+			wantData: data892,
+		},
+		{
+			name: "block upload resending after a lost segment",
+			run:  blockUpload,
+			steps: script(
+				fromClient(entry(0xA4, sdoBlockSize)...),
+				fromServer(entry(0xC6, le32(21)...)...),
+				fromClient(0xA3),
+				fromServer(append([]byte{1}, data21[:7]...)...),
+				fromServer(append([]byte{0x83}, data21[14:]...)...), // Segment 2 lost
+				fromClient(0xA2, 1, sdoBlockSize),
+				segments(data21[7:], true),
+				fromClient(0xA2, 2, sdoBlockSize),
+				fromServer(append([]byte{0xC1}, le16(sdoCRC(data21))...)...),
+				fromClient(0xA1),
+			),
+			wantData: data21,
+		},
+		{
+			name: "block upload with a wrong CRC",
+			run:  blockUpload,
+			steps: script(
+				fromClient(entry(0xA4, sdoBlockSize)...),
+				fromServer(entry(0xC6, le32(10)...)...),
+				fromClient(0xA3),
+				segments(data10, true),
+				fromClient(0xA2, 2, sdoBlockSize),
+				fromServer(append([]byte{0xC1 | 4<<2}, le16(sdoCRC(data10)^1)...)...),
+				abortFrame(sdoAbortCRC),
+			),
+			wantAbort: sdoAbortCRC,
+		},
+		{
+			name: "block upload not supported by the node",
+			run:  blockUpload,
+			steps: script(
+				fromClient(entry(0xA4, sdoBlockSize)...),
+				fromServer(entry(0x80, le32(sdoAbortCommandSpecifier)...)...),
+			),
+			wantErr: errBlockUnsupported,
+		},
+		{
+			name: "expedited download",
+			run:  download([]byte{0xAA, 0xBB}),
+			steps: script(
+				fromClient(entry(0x2B, 0xAA, 0xBB)...),
+				fromServer(entry(0x60)...),
+			),
+			wantTransfer: "expedited",
+		},
+		{
+			name: "segmented download",
+			run:  download(data10),
+			steps: script(
+				fromClient(entry(0x21, le32(10)...)...),
+				fromServer(entry(0x60)...),
+				fromClient(append([]byte{0x00}, data10[:7]...)...),
+				fromServer(0x20),
+				fromClient(append([]byte{0x10 | 4<<1 | 0x01}, data10[7:]...)...),
+				fromServer(0x30),
+			),
+			wantTransfer: "segmented",
+		},
+		{
+			name: "segmented download with a repeated toggle bit",
+			run:  download(data10),
+			steps: script(
+				fromClient(entry(0x21, le32(10)...)...),
+				fromServer(entry(0x60)...),
+				fromClient(append([]byte{0x00}, data10[:7]...)...),
+				fromServer(0x20),
+				fromClient(append([]byte{0x10 | 4<<1 | 0x01}, data10[7:]...)...),
+				fromServer(0x20),
+				abortFrame(sdoAbortToggle),
+			),
+			wantAbort: sdoAbortToggle,
+		},
+		{
+			name: "block download with CRC",
+			run:  blockDownload(data10),
+			steps: script(
+				fromClient(entry(0xC6, le32(10)...)...),
+				fromServer(entry(0xA4, sdoBlockSize)...),
+				asClient(segments(data10, true)),
+				fromServer(0xA2, 2, sdoBlockSize),
+				fromClient(append([]byte{0xC1 | 4<<2}, le16(sdoCRC(data10))...)...),
+				fromServer(0xA1),
+			),
+		},
+		{
+			name: "block download resending from the acknowledged segment",
+			run:  blockDownload(data28),
+			steps: script(
+				fromClient(entry(0xC6, le32(28)...)...),
+				fromServer(entry(0xA0, 2)...),
+				asClient(segments(data28[:14], false)),
+				fromServer(0xA2, 2, 2),
+				asClient(segments(data28[14:], true)),
+				fromServer(0xA2, 1, 2), // Segment 4 lost: resent from offset 14+7*1
+				asClient(segments(data28[21:], true)),
+				fromServer(0xA2, 1, 2),
+				fromClient(0xC1),
+				fromServer(0xA1),
+			),
+		},
+		{
+			name: "block download with an invalid block size",
+			run:  blockDownload(data10),
+			steps: script(
+				fromClient(entry(0xC6, le32(10)...)...),
+				fromServer(entry(0xA4, 0)...),
+				abortFrame(sdoAbortBlockSize),
+			),
+			wantAbort: sdoAbortBlockSize,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_SEQPACKET, 0)
+			if err != nil {
+				t.Skipf("socketpair unavailable: %v", err)
+			}
+			defer unix.Close(fds[1])
+
+			server := make(chan error, 1)
+			go func() { server <- runSDOServer(fds[1], tt.steps) }()
+
+			session := &sdoSession{
+				socket:   fds[0],
+				ifname:   "vcan0",
+				nodeID:   sdoTestNode,
+				index:    sdoTestIndex,
+				subIndex: sdoTestSubIndex,
+				timeout:  100 * time.Millisecond,
+			}
+			data, transfer, err := tt.run(session)
+			if err != nil {
+				err = session.fail(err)
+			}
+			unix.Close(fds[0])
+
+			if err := <-server; err != nil {
+				t.Error(err)
+			}
+
+			var abort *SDOAbortError
+			switch {
+			case tt.wantAbort != 0:
+				if !errors.As(err, &abort) || abort.Code != tt.wantAbort || abort.Server != tt.wantServer {
+					t.Fatalf("got error %v, want abort 0x%08X (by node %v)", err, tt.wantAbort, tt.wantServer)
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+			case err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			default:
+				if !bytes.Equal(data, tt.wantData) {
+					t.Errorf("got data % X, want % X", data, tt.wantData)
+				}
+				if tt.wantTransfer != "" && transfer != tt.wantTransfer {
+					t.Errorf("got %s transfer, want %s", transfer, tt.wantTransfer)
+				}
+			}
+		})
+	}
+}
+
+func TestSDOCRC(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want uint16
+	}{
+		{nil, 0x0000},
+		{[]byte("123456789"), 0x31C3}, // CRC-16/XMODEM check value
+		{[]byte{0x00}, 0x0000},
+		{[]byte{0xFF}, 0x1EF0},
+	}
+
+	for _, tt := range tests {
+		if got := sdoCRC(tt.data); got != tt.want {
+			t.Errorf("sdoCRC(% X) = 0x%04X, want 0x%04X", tt.data, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package can
+
+import (
+	"can-db-writer/internal/models"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SnifferMonitor publishes the payload changes of every CAN ID at a fixed interval, like cansniffer
+type SnifferMonitor struct {
+	mu       sync.Mutex
+	sniffer  *models.PayloadSniffer
+	interval time.Duration
+	snapChan chan []models.PayloadChanges
+	stopChan chan struct{}
+}
+
+// NewSnifferMonitor creates a monitor that reports the changes of each interval
+func NewSnifferMonitor(interval time.Duration) *SnifferMonitor {
+	return &SnifferMonitor{
+		sniffer:  models.NewPayloadSniffer(),
+		interval: interval,
+		snapChan: make(chan []models.PayloadChanges, 10),
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start begins publishing snapshots
+func (m *SnifferMonitor) Start() {
+	go m.snapshotLoop()
+}
+
+// Stop stops the monitor
+func (m *SnifferMonitor) Stop() {
+	close(m.stopChan)
+}
+
+// GetSnapshotChannel returns the channel for receiving the changes of each interval
+func (m *SnifferMonitor) GetSnapshotChannel() <-chan []models.PayloadChanges {
+	return m.snapChan
+}
+
+// Process feeds a received frame to the monitor
+func (m *SnifferMonitor) Process(msg models.CANMessage) {
+	if msg.Frame.IsRTR() || msg.Frame.IsError() {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sniffer.Add(msg.Timestamp, msg.Interface, msg.Frame.ID, msg.Frame.Payload())
+}
+
+// snapshotLoop emits the changes of the past interval and starts a new one
+func (m *SnifferMonitor) snapshotLoop() {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.mu.Lock()
+			changes := m.sniffer.Result()
+			m.sniffer.Reset()
+			m.mu.Unlock()
+
+			if len(changes) == 0 {
+				continue
+			}
+			select {
+			case m.snapChan <- changes:
+			default:
+				fmt.Println("Warning: sniffer snapshot channel full, dropping snapshot")
+			}
+		case <-m.stopChan:
+			return
+		}
+	}
+}
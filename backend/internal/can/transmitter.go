@@ -0,0 +1,221 @@
+package can
+
+import (
+	"can-db-writer/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultTxTimeout is the wait for the confirmation of a transmitted frame without timeout_ms
+	defaultTxTimeout = time.Second
+	// maxTxTimeout bounds the requested wait for a confirmation
+	maxTxTimeout = 10 * time.Second
+)
+
+// Transmission errors, told apart by the API to answer with the matching status
+var (
+	ErrTxDisabled     = errors.New("transmission is not enabled")
+	ErrTxInvalid      = errors.New("invalid transmission")
+	ErrTxTimeout      = errors.New("transmission not confirmed")
+	ErrTxUnauthorized = errors.New("transmission requires an operator token")
+	ErrTxForbidden    = errors.New("transmission not permitted")
+)
+
+// Authorize returns the operator of a bearer token if it may act on a CANopen node,
+// 0 for broadcasts and raw frames, which need an operator without a node list
+func Authorize(operators []models.TxOperator, token string, nodeID uint8) (models.TxOperator, error) {
+	if len(operators) == 0 {
+		return models.TxOperator{}, fmt.Errorf("%w, set CAN_TX_OPERATORS", ErrTxDisabled)
+	}
+	if token == "" {
+		return models.TxOperator{}, fmt.Errorf("%w: missing bearer token", ErrTxUnauthorized)
+	}
+
+	operator, ok := models.FindTxOperator(operators, token)
+	if !ok {
+		return models.TxOperator{}, fmt.Errorf("%w: invalid bearer token", ErrTxUnauthorized)
+	}
+	if !operator.Allows(nodeID) {
+		if nodeID == 0 {
+			return operator, fmt.Errorf("%w: operator '%s' is restricted to nodes %v", ErrTxForbidden, operator.User, operator.Nodes)
+		}
+		return operator, fmt.Errorf("%w: operator '%s' may not command node %d", ErrTxForbidden, operator.User, nodeID)
+	}
+	return operator, nil
+}
+
+// Transmitter sends frames on the interfaces enabled for transmission and records them
+// A writer is opened per interface on its first frame and reopened after a socket error.
+type Transmitter struct {
+	mu         sync.Mutex
+	interfaces map[string]bool
+	writers    map[string]*Writer
+	device     models.Device
+	record     func(ctx context.Context, event models.CANEvent) error
+}
+
+// NewTransmitter creates a transmitter for the interfaces; record stores a frame_sent event per confirmed frame
+func NewTransmitter(interfaces []string, device models.Device, record func(ctx context.Context, event models.CANEvent) error) *Transmitter {
+	t := &Transmitter{
+		interfaces: make(map[string]bool),
+		writers:    make(map[string]*Writer),
+		device:     device,
+		record:     record,
+	}
+	for _, iface := range interfaces {
+		t.interfaces[iface] = true
+	}
+	return t
+}
+
+// Send transmits a frame, waits for its confirmation and records the operator in a frame_sent event
+// The frame row is written by the CAN Reader of the interface, which receives it back with direction tx
+// and the kernel timestamp of the confirmation. A frame whose event fails is still reported as sent,
+// with Logged false.
+// NMT commands are rejected, they are sent by the NMT master with its permission check and audit.
+func (t *Transmitter) Send(ctx context.Context, req models.CANSendRequest) (models.CANSendResult, error) {
+	if err := rejectNMT(req.CANID); err != nil {
+		return models.CANSendResult{}, err
+	}
+	return t.send(ctx, req)
+}
+
+// send transmits a frame of any CAN ID, see Send
+func (t *Transmitter) send(ctx context.Context, req models.CANSendRequest) (models.CANSendResult, error) {
+	if !t.interfaces[req.Interface] {
+		return models.CANSendResult{}, fmt.Errorf("%w on interface '%s'", ErrTxDisabled, req.Interface)
+	}
+
+	frame, err := txFrame(req.CANID, req.Data)
+	if err != nil {
+		return models.CANSendResult{}, err
+	}
+
+	timeout := defaultTxTimeout
+	if req.TimeoutMs > 0 {
+		timeout = min(time.Duration(req.TimeoutMs)*time.Millisecond, maxTxTimeout)
+	}
+
+	writer, err := t.writer(req.Interface)
+	if err != nil {
+		return models.CANSendResult{}, err
+	}
+	confirmation, err := writer.Send(frame, timeout)
+	if err != nil {
+		if !errors.Is(err, ErrTxTimeout) {
+			t.closeWriter(req.Interface, writer)
+		}
+		return models.CANSendResult{}, err
+	}
+
+	result := models.CANSendResult{
+		Interface:    req.Interface,
+		CANID:        frame.ID,
+		CANIDHex:     fmt.Sprintf("0x%X", frame.ID),
+		DLC:          frame.DLC,
+		Data:         frame.Payload(),
+		DataHex:      fmt.Sprintf("% X", frame.Payload()),
+		User:         req.User,
+		Confirmation: confirmation.Confirmation,
+		TxTimestamp:  confirmation.Timestamp,
+		LatencyUs:    confirmation.Latency.Microseconds(),
+	}
+
+	event := models.CANEvent{
+		Timestamp: confirmation.Timestamp,
+		Interface: req.Interface,
+		EventType: models.EventFrameSent,
+		Severity:  models.SeverityInfo,
+		Message:   fmt.Sprintf("Frame 0x%X [% X] sent by '%s'", frame.ID, frame.Payload(), req.User),
+		Details: map[string]string{
+			"can_id":       fmt.Sprintf("0x%X", frame.ID),
+			"data":         fmt.Sprintf("% X", frame.Payload()),
+			"user":         req.User,
+			"confirmation": confirmation.Confirmation,
+		},
+		Device: t.device,
+	}
+	if t.record != nil {
+		if err := t.record(ctx, event); err != nil {
+			log.Printf("Warning: Failed to record transmitted frame 0x%X on %s: %v", frame.ID, req.Interface, err)
+		} else {
+			result.Logged = true
+		}
+	}
+
+	log.Printf("Transmitted 0x%X [% X] on %s for '%s' (%s after %v)",
+		frame.ID, frame.Payload(), req.Interface, req.User, confirmation.Confirmation, confirmation.Latency)
+	return result, nil
+}
+
+// Close closes the writers
+func (t *Transmitter) Close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for iface, writer := range t.writers {
+		writer.Close()
+		delete(t.writers, iface)
+	}
+}
+
+// writer returns the writer of an interface, opening it on first use
+func (t *Transmitter) writer(iface string) (*Writer, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if writer, ok := t.writers[iface]; ok {
+		return writer, nil
+	}
+	writer, err := NewWriter(iface)
+	if err != nil {
+		return nil, err
+	}
+	t.writers[iface] = writer
+	return writer, nil
+}
+
+// closeWriter closes the writer of an interface after a socket error, unless already replaced
+func (t *Transmitter) closeWriter(iface string, writer *Writer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.writers[iface] == writer {
+		writer.Close()
+		delete(t.writers, iface)
+	}
+}
+
+// txFrame validates a frame to transmit
+func txFrame(canID uint32, data []byte) (models.CANFrame, error) {
+	frame := models.CANFrame{ID: canID, DLC: uint8(len(data))}
+
+	switch {
+	case len(data) > 8:
+		return frame, fmt.Errorf("%w: %d data bytes, at most 8", ErrTxInvalid, len(data))
+	case frame.IsError():
+		return frame, fmt.Errorf("%w: error frames (0x20000000) cannot be sent", ErrTxInvalid)
+	case frame.IsRTR() && len(data) > 0:
+		return frame, fmt.Errorf("%w: remote frames carry no data", ErrTxInvalid)
+	case !frame.IsExtended() && canID&^(models.CANEffFlag|models.CANRtrFlag) > models.CANSffMask:
+		return frame, fmt.Errorf("%w: standard CAN ID 0x%X exceeds 11 bits, set the EFF flag (0x80000000) for extended IDs",
+			ErrTxInvalid, canID&^models.CANRtrFlag)
+	}
+
+	copy(frame.Data[:], data)
+	return frame, nil
+}
+
+// rejectNMT rejects the NMT CAN ID (standard 0x000) for raw and cyclic frames
+func rejectNMT(canID uint32) error {
+	frame := models.CANFrame{ID: canID}
+	if !frame.IsExtended() && canID&models.CANSffMask == 0 {
+		return fmt.Errorf("%w: NMT commands (0x000) must be sent through POST /api/canopen/nmt", ErrTxInvalid)
+	}
+	return nil
+}
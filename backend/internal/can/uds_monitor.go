@@ -0,0 +1,83 @@
+package can
+
+import (
+	"can-db-writer/internal/models"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// UDSMonitor decodes the diagnostic traffic of the configured ISO-TP request/response pairs
+// into UDS transactions
+type UDSMonitor struct {
+	mu       sync.Mutex
+	tracker  *models.UDSTracker
+	txChan   chan models.UDSTransaction
+	stopChan chan struct{}
+}
+
+// NewUDSMonitor creates a monitor for the given request/response CAN ID pairs
+func NewUDSMonitor(pairs []models.ISOTPPair) *UDSMonitor {
+	return &UDSMonitor{
+		tracker:  models.NewUDSTracker(pairs),
+		txChan:   make(chan models.UDSTransaction, 100),
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start begins expiring requests without a response
+func (m *UDSMonitor) Start() {
+	go m.expireLoop()
+}
+
+// Stop stops the monitor
+func (m *UDSMonitor) Stop() {
+	close(m.stopChan)
+}
+
+// GetTransactionChannel returns the channel for receiving completed transactions
+func (m *UDSMonitor) GetTransactionChannel() <-chan models.UDSTransaction {
+	return m.txChan
+}
+
+// Process feeds a received frame to the monitor
+func (m *UDSMonitor) Process(msg models.CANMessage) {
+	if msg.Frame.IsRTR() || msg.Frame.IsError() {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, tx := range m.tracker.Add(msg.Timestamp, msg.Interface, msg.Frame.ArbitrationID(), msg.Frame.Payload()) {
+		m.emit(tx)
+	}
+}
+
+// expireLoop periodically ends requests whose response timeout elapsed
+func (m *UDSMonitor) expireLoop() {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			m.mu.Lock()
+			for _, tx := range m.tracker.Expire(now.UTC()) {
+				m.emit(tx)
+			}
+			m.mu.Unlock()
+		case <-m.stopChan:
+			return
+		}
+	}
+}
+
+// emit queues a transaction; the caller must hold the lock
+func (m *UDSMonitor) emit(tx models.UDSTransaction) {
+	select {
+	case m.txChan <- tx:
+	default:
+		fmt.Println("Warning: UDS transaction channel full, dropping transaction")
+	}
+}
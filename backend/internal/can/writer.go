@@ -0,0 +1,205 @@
+package can
+
+import (
+	"can-db-writer/internal/models"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Writer transmits CAN frames on a raw socket and waits for their confirmation
+// The socket receives its own frames back (CAN_RAW_RECV_OWN_MSGS): from the driver once sent on
+// the bus when the interface echoes (IFF_ECHO), otherwise from the kernel loopback when queued.
+type Writer struct {
+	mu     sync.Mutex
+	socket int
+	ifname string
+	echo   bool
+}
+
+// TxConfirmation is the confirmation of a transmitted frame
+type TxConfirmation struct {
+	Timestamp    time.Time     // Kernel receive timestamp of the echo
+	Latency      time.Duration // From the write to the echo
+	Confirmation string        // models.TxConfirmEcho or models.TxConfirmLoopback
+}
+
+// NewWriter creates a new CAN writer for the specified interface
+func NewWriter(ifname string) (*Writer, error) {
+	socket, err := openRawSocket(ifname)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := unix.SetsockoptInt(socket, unix.SOL_CAN_RAW, unix.CAN_RAW_RECV_OWN_MSGS, 1); err != nil {
+		unix.Close(socket)
+		return nil, fmt.Errorf("failed to enable own message reception: %w", err)
+	}
+	if err := unix.SetsockoptInt(socket, unix.SOL_SOCKET, unix.SO_TIMESTAMPNS, 1); err != nil {
+		unix.Close(socket)
+		return nil, fmt.Errorf("failed to enable timestamps: %w", err)
+	}
+
+	return &Writer{
+		socket: socket,
+		ifname: ifname,
+		echo:   interfaceEchoes(ifname),
+	}, nil
+}
+
+// Send transmits a frame and waits up to timeout for its echo
+// Frames are sent one at a time; a frame that is not confirmed in time may still be sent later.
+func (w *Writer) Send(frame models.CANFrame, timeout time.Duration) (TxConfirmation, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// Only frames of the CAN ID are received, i.e. the echo and the same ID sent by other sockets
+	filter := []unix.CanFilter{{Id: frame.ID, Mask: models.CANEffFlag | models.CANRtrFlag | models.CANEffMask}}
+	if err := unix.SetsockoptCanRawFilter(w.socket, unix.SOL_CAN_RAW, unix.CAN_RAW_FILTER, filter); err != nil {
+		return TxConfirmation{}, fmt.Errorf("failed to set filter: %w", err)
+	}
+	w.drain()
+
+	buf := make([]byte, 16) // CAN frame is 16 bytes
+	binary.NativeEndian.PutUint32(buf[0:4], frame.ID)
+	buf[4] = frame.DLC
+	copy(buf[8:16], frame.Data[:])
+
+	sent := time.Now()
+	if _, err := unix.Write(w.socket, buf); err != nil {
+		return TxConfirmation{}, fmt.Errorf("write error: %w", err)
+	}
+
+	deadline := sent.Add(timeout)
+	rx := make([]byte, 16)
+	oob := make([]byte, unix.CmsgSpace(int(unsafe.Sizeof(unix.Timespec{}))))
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return TxConfirmation{}, fmt.Errorf("%w: frame 0x%X not confirmed on %s within %v", ErrTxTimeout, frame.ID, w.ifname, timeout)
+		}
+
+		fds := []unix.PollFd{{Fd: int32(w.socket), Events: unix.POLLIN}}
+		n, err := unix.Poll(fds, int(remaining.Milliseconds())+1)
+		if err == unix.EINTR || n == 0 {
+			continue
+		}
+		if err != nil {
+			return TxConfirmation{}, fmt.Errorf("poll error: %w", err)
+		}
+
+		n, oobn, flags, _, err := unix.Recvmsg(w.socket, rx, oob, 0)
+		if err != nil {
+			return TxConfirmation{}, fmt.Errorf("read error: %w", err)
+		}
+		// MSG_CONFIRM marks the frames sent by this socket
+		if n < 16 || flags&unix.MSG_CONFIRM == 0 || !sameFrame(rx, buf) {
+			continue
+		}
+
+		confirmation := TxConfirmation{
+			Timestamp:    receiveTimestamp(oob[:oobn]),
+			Latency:      time.Since(sent),
+			Confirmation: models.TxConfirmLoopback,
+		}
+		if w.echo {
+			confirmation.Confirmation = models.TxConfirmEcho
+		}
+		return confirmation, nil
+	}
+}
+
+// drain discards the frames received before a send, such as the late echo of a timed out frame
+func (w *Writer) drain() {
+	buf := make([]byte, 16)
+	for {
+		if _, _, _, _, err := unix.Recvmsg(w.socket, buf, nil, unix.MSG_DONTWAIT); err != nil {
+			return
+		}
+	}
+}
+
+// Close closes the CAN socket
+func (w *Writer) Close() error {
+	return unix.Close(w.socket)
+}
+
+// sameFrame compares the ID, DLC and data of two raw frames
+func sameFrame(a, b []byte) bool {
+	return string(a[0:5]) == string(b[0:5]) && string(a[8:16]) == string(b[8:16])
+}
+
+// receiveTimestamp returns the SO_TIMESTAMPNS timestamp of a received frame, or the current time
+func receiveTimestamp(oob []byte) time.Time {
+	msgs, err := unix.ParseSocketControlMessage(oob)
+	if err == nil {
+		for _, msg := range msgs {
+			if msg.Header.Level == unix.SOL_SOCKET && msg.Header.Type == unix.SCM_TIMESTAMPNS &&
+				len(msg.Data) >= int(unsafe.Sizeof(unix.Timespec{})) {
+				ts := (*unix.Timespec)(unsafe.Pointer(&msg.Data[0]))
+				return time.Unix(ts.Unix()).UTC()
+			}
+		}
+	}
+	return time.Now().UTC()
+}
+
+// interfaceEchoes reports whether the driver of an interface echoes frames once sent (IFF_ECHO)
+// The flag is not reported by SIOCGIFFLAGS, whose flags are 16 bits.
+func interfaceEchoes(ifname string) bool {
+	data, err := os.ReadFile("/sys/class/net/" + ifname + "/flags")
+	if err != nil {
+		return false
+	}
+	flags, err := strconv.ParseUint(strings.TrimSpace(string(data)), 0, 32)
+	return err == nil && flags&unix.IFF_ECHO != 0
+}
+
+// openRawSocket creates a raw CAN socket bound to an interface
+func openRawSocket(ifname string) (int, error) {
+	// Create a CAN socket
+	socket, err := unix.Socket(unix.AF_CAN, unix.SOCK_RAW, CAN_RAW)
+	if err != nil {
+		return -1, fmt.Errorf("failed to create CAN socket: %w", err)
+	}
+
+	ifindex, err := interfaceIndex(socket, ifname)
+	if err != nil {
+		unix.Close(socket)
+		return -1, err
+	}
+
+	// Bind socket to CAN interface
+	addr := &unix.SockaddrCAN{
+		Ifindex: ifindex,
+	}
+
+	err = unix.Bind(socket, addr)
+	if err != nil {
+		unix.Close(socket)
+		return -1, fmt.Errorf("failed to bind socket: %w", err)
+	}
+
+	return socket, nil
+}
+
+// interfaceIndex returns the index of an interface
+func interfaceIndex(socket int, ifname string) (int, error) {
+	ifreq, err := unix.NewIfreq(ifname)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create ifreq: %w", err)
+	}
+
+	if err := unix.IoctlIfreq(socket, unix.SIOCGIFINDEX, ifreq); err != nil {
+		return 0, fmt.Errorf("failed to get interface index: %w", err)
+	}
+
+	return int(ifreq.Uint32()), nil
+}
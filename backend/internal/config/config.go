@@ -15,6 +15,11 @@ type Config struct {
 	CANFilters     []uint32
 	StatsInterval  int
 
+	// Device identity stamped on every row, telling apart the robots writing to one database
+	DeviceID         string // Default: host name
+	DeviceSite       string
+	DeviceHWRevision string
+
 	// ClickHouse
 	ClickHouseHost     string
 	ClickHousePort     int
@@ -23,6 +28,78 @@ type Config struct {
 	ClickHousePassword string
 	ClickHouseTable    string
 	ClickHouseStatsTable string
+	ClickHouseEventsTable string
+	ClickHouseUDSTable    string
+	ClickHouseProtocolTable string
+	ClickHouseSignalsTable string
+	ClickHouseCapturesTable string
+	ClickHouseAlertsTable string
+	ClickHouseAlertRulesTable string
+	ClickHouseSessionsTable string
+	ClickHouseAnnotationsTable string
+	ClickHouseReplicationTable string
+	ClickHouseNMTAuditTable string
+
+	// CANopen
+	EMCYTables []string
+	EDSDir     string
+	DriveNodes []uint8 // CiA 402 drives without a DCF declaring profile 402
+
+	// J1939
+	J1939Databases []string
+
+	// Decode-on-ingest
+	SignalDecode   bool
+	SignalMappings []string // YAML signal definition files
+
+	// Triggered capture
+	CaptureMode        string   // "continuous" (every frame) or "triggered"
+	CaptureTriggers    []string // YAML capture trigger files
+	CapturePreTrigger  int      // Seconds persisted before a trigger
+	CapturePostTrigger int      // Seconds persisted after the last trigger
+	CaptureBufferSize  int      // Maximum frames held in the pre-trigger ring buffer
+
+	// Alerting
+	AlertRules        []string // YAML alert rule files
+	AlertRulesRefresh int      // Seconds between reloads of the rules defined through the API
+	AlertWebhookURL   string
+	AlertSMTPHost     string
+	AlertSMTPPort     int
+	AlertEmailFrom    string
+	AlertEmailTo      []string
+
+	// Frame transmission
+	CANTxInterfaces []string // Interfaces the API server may transmit on, none by default
+	CANTxOperators  []string // USER:TOKEN[:NODES] entries allowed to transmit, none by default
+
+	// Recording sessions
+	SessionRefresh int // Seconds between checks for the active session of the interface
+
+	// Edge store-and-forward
+	EdgeStoreDir       string // Local frame store, enables edge mode instead of writing to ClickHouse
+	EdgeStoreMaxMB     int    // Store size limit, the oldest frames are dropped beyond it
+	EdgeCentralAddr    string // gRPC address of the central API server
+	EdgeBatchSize      int    // Frames per replicated batch
+	EdgeBandwidthLimit int    // Replication bandwidth limit in KB/s, 0 = unlimited
+	EdgeToken          string // Producer token of the device on the central server
+	EdgeTLS            bool   // Replicate over TLS, verifying the server with EdgeTLSCA or the system roots
+	EdgeTLSCA          string // CA certificate of the central server, implies TLS
+
+	// Remote producers
+	GRPCProducers []string // DEVICE:TOKEN entries allowed to ingest and replicate frames through gRPC, none by default
+	GRPCTLSCert   string   // Certificate and key of the gRPC server, TLS is disabled without them
+	GRPCTLSKey    string
+
+	// UDS diagnostics
+	UDSPairs []string // ISO-TP request/response CAN ID pairs, e.g. "7E0:7E8"
+
+	// Heartbeat monitor
+	HeartbeatConsumers map[uint8]int // Node ID -> consumer time in ms
+	HeartbeatTolerance float64
+
+	// Live stream
+	LiveStreamPort  int
+	SnifferInterval int // Payload change snapshot interval in ms, 0 = disabled
 
 	// General
 	BatchSize int
@@ -33,8 +110,10 @@ type Config struct {
 // LoadConfig loads configuration from .env file
 func LoadConfig(envFile string) (*Config, error) {
 	// Set default values
+	hostname, _ := os.Hostname()
 	config := &Config{
 		CANInterface:         "vcan0",
+		DeviceID:             hostname,
 		StatsInterval:        10,
 		ClickHouseHost:       "localhost",
 		ClickHousePort:       9000,
@@ -43,6 +122,31 @@ func LoadConfig(envFile string) (*Config, error) {
 		ClickHousePassword:   "",
 		ClickHouseTable:      "can_messages",
 		ClickHouseStatsTable: "can_interface_stats",
+		ClickHouseEventsTable: "can_events",
+		ClickHouseUDSTable:    "uds_transactions",
+		ClickHouseProtocolTable: "can_protocol_profiles",
+		ClickHouseSignalsTable: "can_signals",
+		ClickHouseCapturesTable: "can_captures",
+		ClickHouseAlertsTable: "can_alerts",
+		ClickHouseAlertRulesTable: "can_alert_rules",
+		ClickHouseSessionsTable: "can_sessions",
+		ClickHouseAnnotationsTable: "can_annotations",
+		ClickHouseReplicationTable: "can_replication_checkpoints",
+		ClickHouseNMTAuditTable: "can_nmt_audit",
+		EdgeStoreMaxMB:       1024,
+		EdgeCentralAddr:      "localhost:50051",
+		EdgeBatchSize:        1000,
+		SessionRefresh:       1,
+		AlertRulesRefresh:    30,
+		AlertSMTPPort:        25,
+		AlertEmailFrom:       "can-reader@localhost",
+		CaptureMode:          "continuous",
+		CapturePreTrigger:    5,
+		CapturePostTrigger:   5,
+		CaptureBufferSize:    100000,
+		HeartbeatTolerance:   1.5,
+		LiveStreamPort:       8081,
+		SnifferInterval:      1000,
 		BatchSize:            1000,
 		APIPort:              8080,
 		GRPCPort:             50051,
@@ -93,6 +197,12 @@ func LoadConfig(envFile string) (*Config, error) {
 			config.CANFilters = parseFilters(value)
 		case "STATS_INTERVAL":
 			config.StatsInterval, _ = strconv.Atoi(value)
+		case "DEVICE_ID":
+			config.DeviceID = value
+		case "DEVICE_SITE":
+			config.DeviceSite = value
+		case "DEVICE_HW_REVISION":
+			config.DeviceHWRevision = value
 		case "CLICKHOUSE_HOST":
 			config.ClickHouseHost = value
 		case "CLICKHOUSE_PORT":
@@ -113,6 +223,102 @@ func LoadConfig(envFile string) (*Config, error) {
 			config.APIPort, _ = strconv.Atoi(value)
 		case "GRPC_PORT":
 			config.GRPCPort, _ = strconv.Atoi(value)
+		case "CANOPEN_EMCY_TABLES":
+			config.EMCYTables = parseList(value)
+		case "CANOPEN_EDS_DIR":
+			config.EDSDir = value
+		case "CANOPEN_DRIVE_NODES":
+			config.DriveNodes = parseNodeIDs(value)
+		case "J1939_SPN_DATABASES":
+			config.J1939Databases = parseList(value)
+		case "CLICKHOUSE_EVENTS_TABLE":
+			config.ClickHouseEventsTable = value
+		case "CLICKHOUSE_UDS_TABLE":
+			config.ClickHouseUDSTable = value
+		case "CLICKHOUSE_PROTOCOL_TABLE":
+			config.ClickHouseProtocolTable = value
+		case "CLICKHOUSE_SIGNALS_TABLE":
+			config.ClickHouseSignalsTable = value
+		case "CLICKHOUSE_CAPTURES_TABLE":
+			config.ClickHouseCapturesTable = value
+		case "CAPTURE_MODE":
+			config.CaptureMode = value
+		case "CAPTURE_TRIGGERS":
+			config.CaptureTriggers = parseList(value)
+		case "CAPTURE_PRE_TRIGGER":
+			config.CapturePreTrigger, _ = strconv.Atoi(value)
+		case "CAPTURE_POST_TRIGGER":
+			config.CapturePostTrigger, _ = strconv.Atoi(value)
+		case "CAPTURE_BUFFER_SIZE":
+			config.CaptureBufferSize, _ = strconv.Atoi(value)
+		case "CLICKHOUSE_ALERTS_TABLE":
+			config.ClickHouseAlertsTable = value
+		case "CLICKHOUSE_ALERT_RULES_TABLE":
+			config.ClickHouseAlertRulesTable = value
+		case "ALERT_RULES":
+			config.AlertRules = parseList(value)
+		case "ALERT_RULES_REFRESH":
+			config.AlertRulesRefresh, _ = strconv.Atoi(value)
+		case "ALERT_WEBHOOK_URL":
+			config.AlertWebhookURL = value
+		case "ALERT_SMTP_HOST":
+			config.AlertSMTPHost = value
+		case "ALERT_SMTP_PORT":
+			config.AlertSMTPPort, _ = strconv.Atoi(value)
+		case "ALERT_EMAIL_FROM":
+			config.AlertEmailFrom = value
+		case "ALERT_EMAIL_TO":
+			config.AlertEmailTo = parseList(value)
+		case "CLICKHOUSE_SESSIONS_TABLE":
+			config.ClickHouseSessionsTable = value
+		case "CLICKHOUSE_ANNOTATIONS_TABLE":
+			config.ClickHouseAnnotationsTable = value
+		case "CAN_TX_INTERFACES":
+			config.CANTxInterfaces = parseList(value)
+		case "CAN_TX_OPERATORS":
+			config.CANTxOperators = parseList(value)
+		case "SESSION_REFRESH":
+			config.SessionRefresh, _ = strconv.Atoi(value)
+		case "CLICKHOUSE_REPLICATION_TABLE":
+			config.ClickHouseReplicationTable = value
+		case "CLICKHOUSE_NMT_AUDIT_TABLE":
+			config.ClickHouseNMTAuditTable = value
+		case "EDGE_STORE_DIR":
+			config.EdgeStoreDir = value
+		case "EDGE_STORE_MAX_MB":
+			config.EdgeStoreMaxMB, _ = strconv.Atoi(value)
+		case "EDGE_CENTRAL_ADDR":
+			config.EdgeCentralAddr = value
+		case "EDGE_BATCH_SIZE":
+			config.EdgeBatchSize, _ = strconv.Atoi(value)
+		case "EDGE_BANDWIDTH_LIMIT":
+			config.EdgeBandwidthLimit, _ = strconv.Atoi(value)
+		case "EDGE_TOKEN":
+			config.EdgeToken = value
+		case "EDGE_TLS":
+			config.EdgeTLS, _ = strconv.ParseBool(value)
+		case "EDGE_TLS_CA":
+			config.EdgeTLSCA = value
+		case "GRPC_PRODUCERS":
+			config.GRPCProducers = parseList(value)
+		case "GRPC_TLS_CERT":
+			config.GRPCTLSCert = value
+		case "GRPC_TLS_KEY":
+			config.GRPCTLSKey = value
+		case "SIGNAL_DECODE":
+			config.SignalDecode, _ = strconv.ParseBool(value)
+		case "SIGNAL_MAPPINGS":
+			config.SignalMappings = parseList(value)
+		case "UDS_PAIRS":
+			config.UDSPairs = parseList(value)
+		case "HEARTBEAT_CONSUMERS":
+			config.HeartbeatConsumers = parseHeartbeatConsumers(value)
+		case "HEARTBEAT_TOLERANCE":
+			config.HeartbeatTolerance, _ = strconv.ParseFloat(value, 64)
+		case "LIVE_STREAM_PORT":
+			config.LiveStreamPort, _ = strconv.Atoi(value)
+		case "SNIFFER_INTERVAL":
+			config.SnifferInterval, _ = strconv.Atoi(value)
 		}
 	}
 
@@ -149,3 +355,50 @@ func parseFilters(filterStr string) []uint32 {
 
 	return filters
 }
+
+// parseList parses a comma-separated list of values
+func parseList(listStr string) []string {
+	values := []string{}
+	for _, part := range strings.Split(listStr, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+// parseNodeIDs parses comma-separated CANopen node IDs (1-127)
+func parseNodeIDs(nodesStr string) []uint8 {
+	nodes := []uint8{}
+	for _, part := range parseList(nodesStr) {
+		nodeID, err := strconv.ParseUint(part, 0, 8)
+		if err != nil || nodeID < 1 || nodeID > 127 {
+			continue
+		}
+		nodes = append(nodes, uint8(nodeID))
+	}
+	return nodes
+}
+
+// parseHeartbeatConsumers parses comma-separated NODE_ID:CONSUMER_TIME_MS pairs (e.g. "3:300,4:500")
+func parseHeartbeatConsumers(consumersStr string) map[uint8]int {
+	consumers := make(map[uint8]int)
+	for _, part := range parseList(consumersStr) {
+		pair := strings.SplitN(part, ":", 2)
+		if len(pair) != 2 {
+			continue
+		}
+
+		nodeID, err := strconv.ParseUint(strings.TrimSpace(pair[0]), 0, 8)
+		if err != nil || nodeID < 1 || nodeID > 127 {
+			continue
+		}
+		ms, err := strconv.Atoi(strings.TrimSpace(pair[1]))
+		if err != nil || ms <= 0 {
+			continue
+		}
+
+		consumers[uint8(nodeID)] = ms
+	}
+	return consumers
+}
@@ -0,0 +1,150 @@
+package clickhouse
+
+import (
+	"can-db-writer/internal/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// CreateAlertsTable creates the alert history table in ClickHouse
+func CreateAlertsTable(conn driver.Conn, tableName string) error {
+	query := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			timestamp DateTime64(6),
+			rule_id String,
+			rule_name String,
+			severity LowCardinality(String),
+			status LowCardinality(String),
+			interface String,
+			subject String,
+			value String,
+			message String,
+			fired_at DateTime64(6),
+			device_id LowCardinality(String)
+		) ENGINE = MergeTree()
+		ORDER BY (timestamp, rule_id)
+		PARTITION BY toYYYYMM(timestamp)
+		SETTINGS index_granularity = 8192
+	`, tableName)
+
+	if err := conn.Exec(context.Background(), query); err != nil {
+		return err
+	}
+
+	// Tables created before fleets have no device column
+	return conn.Exec(context.Background(), fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS device_id LowCardinality(String)", tableName))
+}
+
+// WriteAlert inserts an alert notification into the alert history
+func WriteAlert(ctx context.Context, conn driver.Conn, tableName string, alert models.Alert) error {
+	batch, err := conn.PrepareBatch(ctx, fmt.Sprintf("INSERT INTO %s", tableName))
+	if err != nil {
+		return fmt.Errorf("failed to prepare batch: %w", err)
+	}
+
+	err = batch.Append(
+		alert.Timestamp,
+		alert.RuleID,
+		alert.RuleName,
+		alert.Severity,
+		alert.Status,
+		alert.Interface,
+		alert.Subject,
+		alert.Value,
+		alert.Message,
+		alert.FiredAt,
+		alert.DeviceID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to append to batch: %w", err)
+	}
+
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("failed to send batch: %w", err)
+	}
+
+	return nil
+}
+
+// CreateAlertRulesTable creates the table of alert rules defined through the API
+// Rules are stored as JSON; the latest row per id is kept and deleted rules are marked.
+func CreateAlertRulesTable(conn driver.Conn, tableName string) error {
+	query := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id String,
+			rule String,
+			deleted UInt8,
+			updated_at DateTime64(6)
+		) ENGINE = ReplacingMergeTree(updated_at)
+		ORDER BY id
+		SETTINGS index_granularity = 8192
+	`, tableName)
+
+	return conn.Exec(context.Background(), query)
+}
+
+// ReadAlertRules returns the alert rules defined through the API, ordered by id
+func ReadAlertRules(ctx context.Context, conn driver.Conn, tableName string) ([]models.AlertRule, error) {
+	query := fmt.Sprintf("SELECT id, rule FROM %s FINAL WHERE deleted = 0 ORDER BY id", tableName)
+
+	rows, err := conn.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []models.AlertRule{}
+	for rows.Next() {
+		var id, content string
+		if err := rows.Scan(&id, &content); err != nil {
+			return nil, err
+		}
+
+		var rule models.AlertRule
+		if err := json.Unmarshal([]byte(content), &rule); err != nil {
+			return nil, fmt.Errorf("invalid alert rule '%s': %w", id, err)
+		}
+		if err := rule.Validate(); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// SaveAlertRule creates or replaces an alert rule
+func SaveAlertRule(ctx context.Context, conn driver.Conn, tableName string, rule models.AlertRule) error {
+	content, err := json.Marshal(rule)
+	if err != nil {
+		return err
+	}
+	return writeAlertRule(ctx, conn, tableName, rule.ID, string(content), 0)
+}
+
+// DeleteAlertRule marks an alert rule as deleted
+func DeleteAlertRule(ctx context.Context, conn driver.Conn, tableName, id string) error {
+	return writeAlertRule(ctx, conn, tableName, id, "", 1)
+}
+
+// writeAlertRule inserts a new version of an alert rule row
+func writeAlertRule(ctx context.Context, conn driver.Conn, tableName, id, content string, deleted uint8) error {
+	batch, err := conn.PrepareBatch(ctx, fmt.Sprintf("INSERT INTO %s", tableName))
+	if err != nil {
+		return fmt.Errorf("failed to prepare batch: %w", err)
+	}
+
+	if err := batch.Append(id, content, deleted, time.Now()); err != nil {
+		return fmt.Errorf("failed to append to batch: %w", err)
+	}
+
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("failed to send batch: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,186 @@
+package clickhouse
+
+import (
+	"can-db-writer/internal/models"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// AnnotationFilter selects annotations; zero fields do not filter
+type AnnotationFilter struct {
+	AnnotationID string
+	StartTime    *time.Time // Annotations ending at or after
+	EndTime      *time.Time // Annotations starting at or before
+	DeviceID     string     // Also matches annotations of every device
+	Interface    string     // Also matches annotations of every interface
+	SessionID    string
+	Author       string
+	Tags         []string // Annotations carrying every tag
+	Search       string   // Case-insensitive search of the text
+	Limit        int
+	Offset       int
+}
+
+// CreateAnnotationsTable creates the timeline annotations table in ClickHouse
+// Every change inserts a new row; the latest row per annotation is kept and deleted annotations are marked.
+func CreateAnnotationsTable(conn driver.Conn, tableName string) error {
+	query := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			annotation_id String,
+			start_time DateTime64(6),
+			end_time DateTime64(6),
+			interface String,
+			session_id String,
+			author String,
+			text String,
+			tags Array(String),
+			deleted UInt8,
+			created_at DateTime64(6),
+			updated_at DateTime64(6),
+			device_id LowCardinality(String)
+		) ENGINE = ReplacingMergeTree(updated_at)
+		ORDER BY annotation_id
+		SETTINGS index_granularity = 8192
+	`, tableName)
+
+	if err := conn.Exec(context.Background(), query); err != nil {
+		return err
+	}
+
+	// Tables created before fleets have no device column
+	return conn.Exec(context.Background(), fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS device_id LowCardinality(String)", tableName))
+}
+
+// WriteAnnotation inserts the current state of an annotation and sets its update time
+func WriteAnnotation(ctx context.Context, conn driver.Conn, tableName string, annotation *models.Annotation) error {
+	return writeAnnotation(ctx, conn, tableName, annotation, 0)
+}
+
+// DeleteAnnotation marks an annotation as deleted
+func DeleteAnnotation(ctx context.Context, conn driver.Conn, tableName string, annotation *models.Annotation) error {
+	return writeAnnotation(ctx, conn, tableName, annotation, 1)
+}
+
+// writeAnnotation inserts a new version of an annotation row
+func writeAnnotation(ctx context.Context, conn driver.Conn, tableName string, annotation *models.Annotation, deleted uint8) error {
+	annotation.UpdatedAt = time.Now()
+
+	batch, err := conn.PrepareBatch(ctx, fmt.Sprintf("INSERT INTO %s", tableName))
+	if err != nil {
+		return fmt.Errorf("failed to prepare batch: %w", err)
+	}
+
+	err = batch.Append(
+		annotation.AnnotationID,
+		annotation.StartTime,
+		annotation.EndTime,
+		annotation.Interface,
+		annotation.SessionID,
+		annotation.Author,
+		annotation.Text,
+		annotation.Tags,
+		deleted,
+		annotation.CreatedAt,
+		annotation.UpdatedAt,
+		annotation.DeviceID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to append to batch: %w", err)
+	}
+
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("failed to send batch: %w", err)
+	}
+
+	return nil
+}
+
+// ReadAnnotations returns the annotations selected by a filter, ordered by start time
+func ReadAnnotations(ctx context.Context, conn driver.Conn, tableName string, filter AnnotationFilter) ([]models.Annotation, error) {
+	query := fmt.Sprintf(`
+		SELECT annotation_id, start_time, end_time, interface, session_id, author, text, tags, created_at, updated_at, device_id
+		FROM %s FINAL
+		WHERE deleted = 0`, tableName)
+	args := []any{}
+
+	if filter.AnnotationID != "" {
+		query += " AND annotation_id = ?"
+		args = append(args, filter.AnnotationID)
+	}
+	if filter.StartTime != nil {
+		query += " AND end_time >= ?"
+		args = append(args, *filter.StartTime)
+	}
+	if filter.EndTime != nil {
+		query += " AND start_time <= ?"
+		args = append(args, *filter.EndTime)
+	}
+	if filter.DeviceID != "" {
+		query += " AND (device_id = '' OR device_id = ?)"
+		args = append(args, filter.DeviceID)
+	}
+	if filter.Interface != "" {
+		query += " AND (interface = '' OR interface = ?)"
+		args = append(args, filter.Interface)
+	}
+	if filter.SessionID != "" {
+		query += " AND session_id = ?"
+		args = append(args, filter.SessionID)
+	}
+	if filter.Author != "" {
+		query += " AND author = ?"
+		args = append(args, filter.Author)
+	}
+	if len(filter.Tags) > 0 {
+		query += " AND hasAll(tags, ?)"
+		args = append(args, filter.Tags)
+	}
+	if filter.Search != "" {
+		query += " AND positionCaseInsensitiveUTF8(text, ?) > 0"
+		args = append(args, filter.Search)
+	}
+
+	query += " ORDER BY start_time"
+
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query += " OFFSET ?"
+		args = append(args, filter.Offset)
+	}
+
+	rows, err := conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	annotations := []models.Annotation{}
+	for rows.Next() {
+		var a models.Annotation
+		err := rows.Scan(
+			&a.AnnotationID, &a.StartTime, &a.EndTime, &a.Interface, &a.SessionID,
+			&a.Author, &a.Text, &a.Tags, &a.CreatedAt, &a.UpdatedAt, &a.DeviceID,
+		)
+		if err != nil {
+			return nil, err
+		}
+		annotations = append(annotations, a)
+	}
+
+	return annotations, nil
+}
+
+// ReadAnnotation returns an annotation by id, or nil when it does not exist
+func ReadAnnotation(ctx context.Context, conn driver.Conn, tableName, annotationID string) (*models.Annotation, error) {
+	annotations, err := ReadAnnotations(ctx, conn, tableName, AnnotationFilter{AnnotationID: annotationID})
+	if err != nil || len(annotations) == 0 {
+		return nil, err
+	}
+	return &annotations[0], nil
+}
@@ -0,0 +1,78 @@
+package clickhouse
+
+import (
+	"can-db-writer/internal/models"
+	"context"
+	"fmt"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// CreateCapturesTable creates the triggered capture metadata table in ClickHouse
+// A capture is written when it starts and again when it completes; the latest row per
+// recording_id is kept.
+func CreateCapturesTable(conn driver.Conn, tableName string) error {
+	query := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			recording_id String,
+			interface String,
+			status LowCardinality(String),
+			trigger_name String,
+			trigger_type LowCardinality(String),
+			trigger_detail String,
+			trigger_time DateTime64(6),
+			start_time DateTime64(6),
+			end_time DateTime64(6),
+			frames UInt64,
+			triggers UInt64,
+			pre_trigger_ms UInt32,
+			post_trigger_ms UInt32,
+			updated_at DateTime64(6),
+			device_id LowCardinality(String)
+		) ENGINE = ReplacingMergeTree(updated_at)
+		ORDER BY recording_id
+		SETTINGS index_granularity = 8192
+	`, tableName)
+
+	if err := conn.Exec(context.Background(), query); err != nil {
+		return err
+	}
+
+	// Tables created before fleets have no device column
+	return conn.Exec(context.Background(), fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS device_id LowCardinality(String)", tableName))
+}
+
+// WriteCapture inserts a capture metadata row
+func WriteCapture(ctx context.Context, conn driver.Conn, tableName string, capture models.Capture) error {
+	batch, err := conn.PrepareBatch(ctx, fmt.Sprintf("INSERT INTO %s", tableName))
+	if err != nil {
+		return fmt.Errorf("failed to prepare batch: %w", err)
+	}
+
+	err = batch.Append(
+		capture.RecordingID,
+		capture.Interface,
+		capture.Status,
+		capture.TriggerName,
+		capture.TriggerType,
+		capture.TriggerDetail,
+		capture.TriggerTime,
+		capture.StartTime,
+		capture.EndTime,
+		capture.Frames,
+		capture.Triggers,
+		capture.PreTriggerMs,
+		capture.PostTriggerMs,
+		capture.UpdatedAt,
+		capture.DeviceID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to append to batch: %w", err)
+	}
+
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("failed to send batch: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// deviceColumns are the device identity columns ending the messages, statistics, events and signals tables
+const deviceColumns = `device_id LowCardinality(String),
+			site LowCardinality(String),
+			hw_revision LowCardinality(String)`
+
+// addDeviceColumns adds the device identity columns to a table created before they existed
+// The sorting key of such a table cannot be changed and does not start with the device, which is logged
+// as per-device queries then read the rows of every device.
+func addDeviceColumns(conn driver.Conn, tableName string) error {
+	for _, column := range []string{"device_id", "site", "hw_revision"} {
+		query := fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s LowCardinality(String)", tableName, column)
+		if err := conn.Exec(context.Background(), query); err != nil {
+			return err
+		}
+	}
+
+	var sortingKey string
+	err := conn.QueryRow(context.Background(),
+		"SELECT sorting_key FROM system.tables WHERE database = currentDatabase() AND name = ?", tableName,
+	).Scan(&sortingKey)
+	if err != nil {
+		log.Printf("Warning: Failed to read the sorting key of %s: %v", tableName, err)
+		return nil
+	}
+	if !strings.HasPrefix(sortingKey, "device_id") {
+		log.Printf("Warning: Table %s is sorted by (%s), not by device first; recreate it to speed up per-device queries (see README)",
+			tableName, sortingKey)
+	}
+	return nil
+}
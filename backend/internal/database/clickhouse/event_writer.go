@@ -0,0 +1,163 @@
+package clickhouse
+
+import (
+	"can-db-writer/internal/models"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// EventWriter handles writing bus events (heartbeat timeouts, boot-ups, ...) to ClickHouse
+type EventWriter struct {
+	conn       driver.Conn
+	batchSize  int
+	batch      []models.CANEvent
+	batchChan  chan models.CANEvent
+	ctx        context.Context
+	cancel     context.CancelFunc
+	flushTimer *time.Ticker
+}
+
+// NewEventWriter creates a new ClickHouse event writer
+func NewEventWriter(conn driver.Conn, batchSize int) *EventWriter {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	writer := &EventWriter{
+		conn:       conn,
+		batchSize:  batchSize,
+		batch:      make([]models.CANEvent, 0, batchSize),
+		batchChan:  make(chan models.CANEvent, batchSize*2),
+		ctx:        ctx,
+		cancel:     cancel,
+		flushTimer: time.NewTicker(1 * time.Second), // Flush every second
+	}
+
+	return writer
+}
+
+// CreateEventsTable creates the bus events table in ClickHouse
+func CreateEventsTable(conn driver.Conn, tableName string) error {
+	query := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			timestamp DateTime64(6),
+			interface String,
+			node_id UInt8,
+			event_type LowCardinality(String),
+			severity LowCardinality(String),
+			message String,
+			details Map(String, String),
+			%s
+		) ENGINE = MergeTree()
+		ORDER BY (device_id, timestamp, interface, node_id)
+		PARTITION BY toYYYYMM(timestamp)
+		SETTINGS index_granularity = 8192
+	`, tableName, deviceColumns)
+
+	if err := conn.Exec(context.Background(), query); err != nil {
+		return err
+	}
+	return addDeviceColumns(conn, tableName)
+}
+
+// Start begins processing and writing events
+func (w *EventWriter) Start(tableName string) {
+	go w.writeLoop(tableName)
+}
+
+// writeLoop processes events and writes them in batches
+func (w *EventWriter) writeLoop(tableName string) {
+	for {
+		select {
+		case <-w.ctx.Done():
+			// Flush remaining events before exiting
+			if len(w.batch) > 0 {
+				w.flush(tableName)
+			}
+			return
+
+		case event := <-w.batchChan:
+			w.batch = append(w.batch, event)
+			if len(w.batch) >= w.batchSize {
+				w.flush(tableName)
+			}
+
+		case <-w.flushTimer.C:
+			if len(w.batch) > 0 {
+				w.flush(tableName)
+			}
+		}
+	}
+}
+
+// flush writes the current batch to ClickHouse
+func (w *EventWriter) flush(tableName string) error {
+	if len(w.batch) == 0 {
+		return nil
+	}
+
+	if err := WriteEvents(w.ctx, w.conn, tableName, w.batch); err != nil {
+		return err
+	}
+
+	fmt.Printf("Flushed %d events to ClickHouse\n", len(w.batch))
+	w.batch = w.batch[:0] // Clear batch
+
+	return nil
+}
+
+// WriteEvents inserts events directly, e.g. reader lifecycle events that must not wait for a batch
+func WriteEvents(ctx context.Context, conn driver.Conn, tableName string, events []models.CANEvent) error {
+	batch, err := conn.PrepareBatch(ctx, fmt.Sprintf("INSERT INTO %s", tableName))
+	if err != nil {
+		return fmt.Errorf("failed to prepare batch: %w", err)
+	}
+
+	for _, event := range events {
+		details := event.Details
+		if details == nil {
+			details = map[string]string{}
+		}
+
+		err = batch.Append(
+			event.Timestamp,
+			event.Interface,
+			event.NodeID,
+			event.EventType,
+			event.Severity,
+			event.Message,
+			details,
+			event.DeviceID,
+			event.Site,
+			event.HWRevision,
+		)
+
+		if err != nil {
+			return fmt.Errorf("failed to append to batch: %w", err)
+		}
+	}
+
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("failed to send batch: %w", err)
+	}
+
+	return nil
+}
+
+// Write queues an event for writing
+func (w *EventWriter) Write(event models.CANEvent) {
+	select {
+	case w.batchChan <- event:
+	default:
+		fmt.Println("Warning: event batch channel full, dropping event")
+	}
+}
+
+// Close closes the event writer
+func (w *EventWriter) Close() error {
+	w.cancel()
+	w.flushTimer.Stop()
+	close(w.batchChan)
+	return nil
+}
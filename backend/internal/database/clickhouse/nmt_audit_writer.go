@@ -0,0 +1,81 @@
+package clickhouse
+
+import (
+	"can-db-writer/internal/models"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// CreateNMTAuditTable creates the audit log of the NMT commands sent through the API
+// Denied and failed attempts are recorded as well; the node results are stored as JSON.
+func CreateNMTAuditTable(conn driver.Conn, tableName string) error {
+	query := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id String,
+			timestamp DateTime64(9),
+			device_id LowCardinality(String),
+			interface LowCardinality(String),
+			command LowCardinality(String),
+			node_id UInt8,
+			expected_state LowCardinality(String),
+			user String,
+			remote_addr String,
+			outcome LowCardinality(String),
+			nodes String,
+			duration_ms Float64,
+			error String
+		) ENGINE = MergeTree()
+		ORDER BY (timestamp, id)
+		PARTITION BY toYYYYMM(timestamp)
+		SETTINGS index_granularity = 8192
+	`, tableName)
+
+	return conn.Exec(context.Background(), query)
+}
+
+// WriteNMTAudit inserts an NMT command into the audit log
+func WriteNMTAudit(ctx context.Context, conn driver.Conn, tableName string, result models.NMTCommandResult) error {
+	nodes, err := json.Marshal(result.Nodes)
+	if err != nil {
+		return err
+	}
+
+	batch, err := conn.PrepareBatch(ctx, fmt.Sprintf("INSERT INTO %s", tableName))
+	if err != nil {
+		return fmt.Errorf("failed to prepare batch: %w", err)
+	}
+
+	// A denied request may name an unknown command, which is recorded empty
+	command := ""
+	if result.Command != 0 {
+		command = result.Command.String()
+	}
+
+	err = batch.Append(
+		result.ID,
+		result.Timestamp,
+		result.DeviceID,
+		result.Interface,
+		command,
+		result.NodeID,
+		result.ExpectedState.String(),
+		result.User,
+		result.RemoteAddr,
+		result.Outcome,
+		string(nodes),
+		result.DurationMs,
+		result.Error,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to append to batch: %w", err)
+	}
+
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("failed to send batch: %w", err)
+	}
+
+	return nil
+}
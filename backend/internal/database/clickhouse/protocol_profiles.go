@@ -0,0 +1,79 @@
+package clickhouse
+
+import (
+	"can-db-writer/internal/models"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// CreateProtocolProfilesTable creates the per-interface protocol profile table in ClickHouse
+func CreateProtocolProfilesTable(conn driver.Conn, tableName string) error {
+	query := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			detected_at DateTime64(6),
+			interface String,
+			protocol LowCardinality(String),
+			confidence Float64,
+			scores Map(String, Float64),
+			window_start DateTime64(6),
+			window_end DateTime64(6),
+			frame_count UInt64,
+			standard_frames UInt64,
+			extended_frames UInt64,
+			unique_ids UInt32,
+			evidence Map(String, String),
+			issues String
+		) ENGINE = MergeTree()
+		ORDER BY (interface, detected_at)
+		PARTITION BY toYYYYMM(detected_at)
+		SETTINGS index_granularity = 8192
+	`, tableName)
+
+	return conn.Exec(context.Background(), query)
+}
+
+// WriteProtocolProfiles stores detected protocol profiles; issues are stored as JSON
+func WriteProtocolProfiles(ctx context.Context, conn driver.Conn, tableName string, profiles []models.ProtocolProfile) error {
+	if len(profiles) == 0 {
+		return nil
+	}
+
+	batch, err := conn.PrepareBatch(ctx, fmt.Sprintf("INSERT INTO %s", tableName))
+	if err != nil {
+		return fmt.Errorf("failed to prepare batch: %w", err)
+	}
+
+	for _, profile := range profiles {
+		issues, err := json.Marshal(profile.Issues)
+		if err != nil {
+			return fmt.Errorf("failed to encode issues: %w", err)
+		}
+
+		err = batch.Append(
+			profile.DetectedAt,
+			profile.Interface,
+			profile.Protocol,
+			profile.Confidence,
+			profile.Scores,
+			profile.WindowStart,
+			profile.WindowEnd,
+			profile.FrameCount,
+			profile.StandardFrames,
+			profile.ExtendedFrames,
+			profile.UniqueIDs,
+			profile.Evidence,
+			string(issues),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to append to batch: %w", err)
+		}
+	}
+
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("failed to send batch: %w", err)
+	}
+	return nil
+}
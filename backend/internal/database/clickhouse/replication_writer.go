@@ -0,0 +1,55 @@
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// CreateReplicationTable creates the table of the sequences replicated from each edge store
+// Every acknowledged batch inserts a row; the highest sequence per store is kept.
+func CreateReplicationTable(conn driver.Conn, tableName string) error {
+	query := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			device_id String,
+			store_id String,
+			acked_sequence UInt64,
+			updated_at DateTime64(6)
+		) ENGINE = ReplacingMergeTree(acked_sequence)
+		ORDER BY (device_id, store_id)
+		SETTINGS index_granularity = 8192
+	`, tableName)
+
+	return conn.Exec(context.Background(), query)
+}
+
+// ReadReplicationCheckpoint returns the highest sequence written from an edge store, 0 for a new store
+func ReadReplicationCheckpoint(ctx context.Context, conn driver.Conn, tableName, deviceID, storeID string) (uint64, error) {
+	query := fmt.Sprintf("SELECT max(acked_sequence) FROM %s WHERE device_id = ? AND store_id = ?", tableName)
+
+	var acked uint64
+	if err := conn.QueryRow(ctx, query, deviceID, storeID).Scan(&acked); err != nil {
+		return 0, err
+	}
+	return acked, nil
+}
+
+// WriteReplicationCheckpoint records the highest sequence written from an edge store
+func WriteReplicationCheckpoint(ctx context.Context, conn driver.Conn, tableName, deviceID, storeID string, acked uint64) error {
+	batch, err := conn.PrepareBatch(ctx, fmt.Sprintf("INSERT INTO %s", tableName))
+	if err != nil {
+		return fmt.Errorf("failed to prepare batch: %w", err)
+	}
+
+	if err := batch.Append(deviceID, storeID, acked, time.Now()); err != nil {
+		return fmt.Errorf("failed to append to batch: %w", err)
+	}
+
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("failed to send batch: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package clickhouse
+
+import (
+	"can-db-writer/internal/models"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// SessionColumns are the columns of the sessions table in the order scanned by ScanSession
+const SessionColumns = `session_id, name, interface, status, operator, robot_serial, software_version,
+	test_case, tags, notes, start_time, end_time, updated_at, device_id`
+
+// CreateSessionsTable creates the recording sessions table in ClickHouse
+// Every start, stop and tag change inserts a new row; the latest row per session is kept.
+func CreateSessionsTable(conn driver.Conn, tableName string) error {
+	query := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			session_id String,
+			name String,
+			interface String,
+			status LowCardinality(String),
+			operator String,
+			robot_serial String,
+			software_version String,
+			test_case String,
+			tags Array(String),
+			notes String,
+			start_time DateTime64(6),
+			end_time Nullable(DateTime64(6)),
+			updated_at DateTime64(6),
+			device_id LowCardinality(String)
+		) ENGINE = ReplacingMergeTree(updated_at)
+		ORDER BY session_id
+		SETTINGS index_granularity = 8192
+	`, tableName)
+
+	if err := conn.Exec(context.Background(), query); err != nil {
+		return err
+	}
+
+	// Tables created before fleets have no device column
+	return conn.Exec(context.Background(), fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS device_id LowCardinality(String)", tableName))
+}
+
+// WriteSession inserts the current state of a session and sets its update time
+func WriteSession(ctx context.Context, conn driver.Conn, tableName string, session *models.Session) error {
+	session.UpdatedAt = time.Now()
+
+	batch, err := conn.PrepareBatch(ctx, fmt.Sprintf("INSERT INTO %s", tableName))
+	if err != nil {
+		return fmt.Errorf("failed to prepare batch: %w", err)
+	}
+
+	err = batch.Append(
+		session.SessionID,
+		session.Name,
+		session.Interface,
+		session.Status,
+		session.Operator,
+		session.RobotSerial,
+		session.SoftwareVersion,
+		session.TestCase,
+		session.Tags,
+		session.Notes,
+		session.StartTime,
+		session.EndTime,
+		session.UpdatedAt,
+		session.DeviceID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to append to batch: %w", err)
+	}
+
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("failed to send batch: %w", err)
+	}
+
+	return nil
+}
+
+// ScanSession scans a row selected with SessionColumns
+func ScanSession(rows driver.Rows) (models.Session, error) {
+	var session models.Session
+	err := rows.Scan(
+		&session.SessionID, &session.Name, &session.Interface, &session.Status, &session.Operator,
+		&session.RobotSerial, &session.SoftwareVersion, &session.TestCase, &session.Tags,
+		&session.Notes, &session.StartTime, &session.EndTime, &session.UpdatedAt, &session.DeviceID,
+	)
+	return session, err
+}
+
+// ReadSession returns a session by id, or nil when it does not exist
+func ReadSession(ctx context.Context, conn driver.Conn, tableName, sessionID string) (*models.Session, error) {
+	return readSession(ctx, conn, fmt.Sprintf("SELECT %s FROM %s FINAL WHERE session_id = ?", SessionColumns, tableName), sessionID)
+}
+
+// ReadActiveSession returns the active session of a device's interface, or nil when there is none
+func ReadActiveSession(ctx context.Context, conn driver.Conn, tableName, deviceID, iface string) (*models.Session, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s FINAL WHERE device_id = ? AND interface = ? AND status = ? ORDER BY start_time DESC LIMIT 1", SessionColumns, tableName)
+	return readSession(ctx, conn, query, deviceID, iface, models.SessionActive)
+}
+
+// readSession returns the first session selected by a query, or nil
+func readSession(ctx context.Context, conn driver.Conn, query string, args ...any) (*models.Session, error) {
+	rows, err := conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	session, err := ScanSession(rows)
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// StartSession records a new active session, stopping the session previously active on its device's interface
+func StartSession(ctx context.Context, conn driver.Conn, tableName string, session *models.Session) error {
+	active, err := ReadActiveSession(ctx, conn, tableName, session.DeviceID, session.Interface)
+	if err != nil {
+		return err
+	}
+	if active != nil {
+		if _, err := StopSession(ctx, conn, tableName, active.SessionID, session.StartTime); err != nil {
+			return err
+		}
+	}
+
+	return WriteSession(ctx, conn, tableName, session)
+}
+
+// StopSession stops an active session at the given time and returns it
+// Stopping a session that is already stopped returns it unchanged.
+func StopSession(ctx context.Context, conn driver.Conn, tableName, sessionID string, endTime time.Time) (*models.Session, error) {
+	session, err := ReadSession(ctx, conn, tableName, sessionID)
+	if err != nil || session == nil || session.Status == models.SessionStopped {
+		return session, err
+	}
+
+	session.Status = models.SessionStopped
+	session.EndTime = &endTime
+	if err := WriteSession(ctx, conn, tableName, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
@@ -0,0 +1,150 @@
+package clickhouse
+
+import (
+	"can-db-writer/internal/models"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// SignalWriter handles writing decoded signal values to ClickHouse
+type SignalWriter struct {
+	conn       driver.Conn
+	batchSize  int
+	batch      []models.SignalValue
+	batchChan  chan models.SignalValue
+	ctx        context.Context
+	cancel     context.CancelFunc
+	flushTimer *time.Ticker
+}
+
+// NewSignalWriter creates a new ClickHouse signal writer
+func NewSignalWriter(conn driver.Conn, batchSize int) *SignalWriter {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	writer := &SignalWriter{
+		conn:       conn,
+		batchSize:  batchSize,
+		batch:      make([]models.SignalValue, 0, batchSize),
+		batchChan:  make(chan models.SignalValue, batchSize*2),
+		ctx:        ctx,
+		cancel:     cancel,
+		flushTimer: time.NewTicker(1 * time.Second), // Flush every second
+	}
+
+	return writer
+}
+
+// CreateSignalsTable creates the long-format decoded signals table in ClickHouse
+func CreateSignalsTable(conn driver.Conn, tableName string) error {
+	query := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			timestamp DateTime64(6),
+			interface String,
+			node_id UInt8,
+			signal LowCardinality(String),
+			value_float Float64,
+			value_int Int64,
+			unit LowCardinality(String),
+			%s
+		) ENGINE = MergeTree()
+		ORDER BY (device_id, signal, node_id, timestamp)
+		PARTITION BY toYYYYMMDD(timestamp)
+		SETTINGS index_granularity = 8192
+	`, tableName, deviceColumns)
+
+	if err := conn.Exec(context.Background(), query); err != nil {
+		return err
+	}
+	return addDeviceColumns(conn, tableName)
+}
+
+// Start begins processing and writing signal values
+func (w *SignalWriter) Start(tableName string) {
+	go w.writeLoop(tableName)
+}
+
+// writeLoop processes signal values and writes them in batches
+func (w *SignalWriter) writeLoop(tableName string) {
+	for {
+		select {
+		case <-w.ctx.Done():
+			// Flush remaining values before exiting
+			if len(w.batch) > 0 {
+				w.flush(tableName)
+			}
+			return
+
+		case value := <-w.batchChan:
+			w.batch = append(w.batch, value)
+			if len(w.batch) >= w.batchSize {
+				w.flush(tableName)
+			}
+
+		case <-w.flushTimer.C:
+			if len(w.batch) > 0 {
+				w.flush(tableName)
+			}
+		}
+	}
+}
+
+// flush writes the current batch to ClickHouse
+func (w *SignalWriter) flush(tableName string) error {
+	if len(w.batch) == 0 {
+		return nil
+	}
+
+	batch, err := w.conn.PrepareBatch(w.ctx, fmt.Sprintf("INSERT INTO %s", tableName))
+	if err != nil {
+		return fmt.Errorf("failed to prepare batch: %w", err)
+	}
+
+	for _, value := range w.batch {
+		err = batch.Append(
+			value.Timestamp,
+			value.Interface,
+			value.NodeID,
+			value.Signal,
+			value.ValueFloat,
+			value.ValueInt,
+			value.Unit,
+			value.DeviceID,
+			value.Site,
+			value.HWRevision,
+		)
+
+		if err != nil {
+			return fmt.Errorf("failed to append to batch: %w", err)
+		}
+	}
+
+	err = batch.Send()
+	if err != nil {
+		return fmt.Errorf("failed to send batch: %w", err)
+	}
+
+	fmt.Printf("Flushed %d signal values to ClickHouse\n", len(w.batch))
+	w.batch = w.batch[:0] // Clear batch
+
+	return nil
+}
+
+// Write queues a signal value for writing
+func (w *SignalWriter) Write(value models.SignalValue) {
+	select {
+	case w.batchChan <- value:
+	default:
+		fmt.Println("Warning: signal batch channel full, dropping value")
+	}
+}
+
+// Close closes the signal writer
+func (w *SignalWriter) Close() error {
+	w.cancel()
+	w.flushTimer.Stop()
+	close(w.batchChan)
+	return nil
+}
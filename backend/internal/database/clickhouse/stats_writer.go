@@ -94,14 +94,20 @@ func CreateStatsTable(conn driver.Conn, tableName string) error {
 			arbitration_lost UInt64,
 			error_warning UInt64,
 			error_passive UInt64,
-			bus_off UInt64
+			bus_off UInt64,
+
+			-- Device identity
+			%s
 		) ENGINE = MergeTree()
-		ORDER BY (timestamp, interface)
+		ORDER BY (device_id, timestamp, interface)
 		PARTITION BY toYYYYMMDD(timestamp)
 		SETTINGS index_granularity = 8192
-	`, tableName)
+	`, tableName, deviceColumns)
 
-	return conn.Exec(context.Background(), query)
+	if err := conn.Exec(context.Background(), query); err != nil {
+		return err
+	}
+	return addDeviceColumns(conn, tableName)
 }
 
 // Start begins processing and writing statistics
@@ -193,6 +199,9 @@ func (w *StatsWriter) flush(tableName string) error {
 			stat.ErrorWarning,
 			stat.ErrorPassive,
 			stat.BusOff,
+			stat.DeviceID,
+			stat.Site,
+			stat.HWRevision,
 		)
 
 		if err != nil {
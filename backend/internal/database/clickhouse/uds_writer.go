@@ -0,0 +1,173 @@
+package clickhouse
+
+import (
+	"can-db-writer/internal/models"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// UDSWriter handles writing decoded UDS diagnostic transactions to ClickHouse
+type UDSWriter struct {
+	conn       driver.Conn
+	batchSize  int
+	batch      []models.UDSTransaction
+	batchChan  chan models.UDSTransaction
+	ctx        context.Context
+	cancel     context.CancelFunc
+	flushTimer *time.Ticker
+}
+
+// NewUDSWriter creates a new ClickHouse UDS transaction writer
+func NewUDSWriter(conn driver.Conn, batchSize int) *UDSWriter {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	writer := &UDSWriter{
+		conn:       conn,
+		batchSize:  batchSize,
+		batch:      make([]models.UDSTransaction, 0, batchSize),
+		batchChan:  make(chan models.UDSTransaction, batchSize*2),
+		ctx:        ctx,
+		cancel:     cancel,
+		flushTimer: time.NewTicker(1 * time.Second), // Flush every second
+	}
+
+	return writer
+}
+
+// CreateUDSTable creates the UDS transactions table in ClickHouse
+func CreateUDSTable(conn driver.Conn, tableName string) error {
+	query := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			timestamp DateTime64(6),
+			response_time Nullable(DateTime64(6)),
+			interface String,
+			request_id UInt32,
+			response_id UInt32,
+			service_id UInt8,
+			service LowCardinality(String),
+			sub_function LowCardinality(String),
+			outcome LowCardinality(String),
+			nrc UInt8,
+			nrc_name LowCardinality(String),
+			latency_ms Float64,
+			pending_count UInt16,
+			request Array(UInt8),
+			response Array(UInt8),
+			details Map(String, String),
+			device_id LowCardinality(String)
+		) ENGINE = MergeTree()
+		ORDER BY (timestamp, interface, request_id)
+		PARTITION BY toYYYYMM(timestamp)
+		SETTINGS index_granularity = 8192
+	`, tableName)
+
+	if err := conn.Exec(context.Background(), query); err != nil {
+		return err
+	}
+
+	// Tables created before fleets have no device column
+	return conn.Exec(context.Background(), fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS device_id LowCardinality(String)", tableName))
+}
+
+// Start begins processing and writing transactions
+func (w *UDSWriter) Start(tableName string) {
+	go w.writeLoop(tableName)
+}
+
+// writeLoop processes transactions and writes them in batches
+func (w *UDSWriter) writeLoop(tableName string) {
+	for {
+		select {
+		case <-w.ctx.Done():
+			// Flush remaining transactions before exiting
+			if len(w.batch) > 0 {
+				w.flush(tableName)
+			}
+			return
+
+		case tx := <-w.batchChan:
+			w.batch = append(w.batch, tx)
+			if len(w.batch) >= w.batchSize {
+				w.flush(tableName)
+			}
+
+		case <-w.flushTimer.C:
+			if len(w.batch) > 0 {
+				w.flush(tableName)
+			}
+		}
+	}
+}
+
+// flush writes the current batch to ClickHouse
+func (w *UDSWriter) flush(tableName string) error {
+	if len(w.batch) == 0 {
+		return nil
+	}
+
+	batch, err := w.conn.PrepareBatch(w.ctx, fmt.Sprintf("INSERT INTO %s", tableName))
+	if err != nil {
+		return fmt.Errorf("failed to prepare batch: %w", err)
+	}
+
+	for _, tx := range w.batch {
+		details := tx.Details
+		if details == nil {
+			details = map[string]string{}
+		}
+
+		err = batch.Append(
+			tx.Timestamp,
+			tx.ResponseTime,
+			tx.Interface,
+			tx.RequestID,
+			tx.ResponseID,
+			tx.ServiceID,
+			tx.Service,
+			tx.SubFunction,
+			tx.Outcome,
+			tx.NRC,
+			tx.NRCName,
+			tx.LatencyMs,
+			tx.PendingCount,
+			tx.Request,
+			tx.Response,
+			details,
+			tx.DeviceID,
+		)
+
+		if err != nil {
+			return fmt.Errorf("failed to append to batch: %w", err)
+		}
+	}
+
+	err = batch.Send()
+	if err != nil {
+		return fmt.Errorf("failed to send batch: %w", err)
+	}
+
+	fmt.Printf("Flushed %d UDS transactions to ClickHouse\n", len(w.batch))
+	w.batch = w.batch[:0] // Clear batch
+
+	return nil
+}
+
+// Write queues a transaction for writing
+func (w *UDSWriter) Write(tx models.UDSTransaction) {
+	select {
+	case w.batchChan <- tx:
+	default:
+		fmt.Println("Warning: UDS batch channel full, dropping transaction")
+	}
+}
+
+// Close closes the UDS transaction writer
+func (w *UDSWriter) Close() error {
+	w.cancel()
+	w.flushTimer.Stop()
+	close(w.batchChan)
+	return nil
+}
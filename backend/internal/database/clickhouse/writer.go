@@ -83,15 +83,46 @@ func createTable(conn driver.Conn, tableName string) error {
 			timestamp DateTime64(6),
 			interface String,
 			can_id UInt32,
-			data Array(UInt8)
+			data Array(UInt8),
+			session_id String,
+			%s,
+			direction LowCardinality(String),
+			user String,
+			dlc UInt8
 		) ENGINE = MergeTree()
-		ORDER BY (timestamp, can_id)
+		ORDER BY (device_id, timestamp, can_id)
 		PARTITION BY toYYYYMMDD(timestamp)
 		TTL timestamp + INTERVAL 1 MONTH
 		SETTINGS index_granularity = 8192
-	`, tableName)
+	`, tableName, deviceColumns)
 
-	return conn.Exec(context.Background(), query)
+	if err := conn.Exec(context.Background(), query); err != nil {
+		return err
+	}
+
+	// Tables created before recording sessions have no session column
+	if err := conn.Exec(context.Background(), fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS session_id String", tableName)); err != nil {
+		return err
+	}
+	if err := addDeviceColumns(conn, tableName); err != nil {
+		return err
+	}
+
+	// Tables created before frame transmission have no direction and user columns
+	if err := conn.Exec(context.Background(), fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS direction LowCardinality(String)", tableName)); err != nil {
+		return err
+	}
+	if err := conn.Exec(context.Background(), fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS user String", tableName)); err != nil {
+		return err
+	}
+
+	// Tables created before the DLC was stored count every frame at its 8 stored data bytes
+	if err := conn.Exec(context.Background(), fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS dlc UInt8 DEFAULT length(data)", tableName)); err != nil {
+		return err
+	}
+
+	// Edge batches written again after a lost replication checkpoint are dropped by their deduplication token
+	return conn.Exec(context.Background(), fmt.Sprintf("ALTER TABLE %s MODIFY SETTING non_replicated_deduplication_window = 1000", tableName))
 }
 
 // Start begins processing and writing messages
@@ -130,17 +161,41 @@ func (w *Writer) flush(tableName string) error {
 		return nil
 	}
 
-	batch, err := w.conn.PrepareBatch(w.ctx, fmt.Sprintf("INSERT INTO %s", tableName))
+	if err := WriteMessages(w.ctx, w.conn, tableName, w.batch); err != nil {
+		return err
+	}
+
+	fmt.Printf("Flushed %d messages to ClickHouse\n", len(w.batch))
+	w.batch = w.batch[:0] // Clear batch
+
+	return nil
+}
+
+// WriteMessages inserts messages directly, e.g. the pre-trigger frames of a capture that would overflow the queue
+func WriteMessages(ctx context.Context, conn driver.Conn, tableName string, msgs []models.CANMessage) error {
+	batch, err := conn.PrepareBatch(ctx, fmt.Sprintf("INSERT INTO %s", tableName))
 	if err != nil {
 		return fmt.Errorf("failed to prepare batch: %w", err)
 	}
 
-	for _, msg := range w.batch {
+	for _, msg := range msgs {
+		direction := msg.Direction
+		if direction == "" {
+			direction = models.DirectionRX
+		}
+
 		err = batch.Append(
 			msg.Timestamp,
 			msg.Interface,
 			msg.Frame.ID,
 			msg.Frame.Data[:],
+			msg.SessionID,
+			msg.DeviceID,
+			msg.Site,
+			msg.HWRevision,
+			direction,
+			msg.User,
+			msg.Frame.DLC,
 		)
 
 		if err != nil {
@@ -148,14 +203,10 @@ func (w *Writer) flush(tableName string) error {
 		}
 	}
 
-	err = batch.Send()
-	if err != nil {
+	if err := batch.Send(); err != nil {
 		return fmt.Errorf("failed to send batch: %w", err)
 	}
 
-	fmt.Printf("Flushed %d messages to ClickHouse\n", len(w.batch))
-	w.batch = w.batch[:0] // Clear batch
-
 	return nil
 }
 
@@ -168,6 +219,16 @@ func (w *Writer) Write(msg models.CANMessage) {
 	}
 }
 
+// WriteContext queues a message for writing, waiting while the queue is full instead of dropping it
+func (w *Writer) WriteContext(ctx context.Context, msg models.CANMessage) error {
+	select {
+	case w.batchChan <- msg:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // Close closes the ClickHouse connection
 func (w *Writer) Close() error {
 	w.cancel()
@@ -200,6 +261,82 @@ type ExportOptions struct {
 	EndTime     time.Time
 	OutputPath  string
 	Compression string // snappy, lz4, brotli, zstd, gzip, none (uncompressed) - default: zstd
+	SessionID   string // Exports the frames of a recording session instead of the time range
+	DeviceID    string // Exports the frames of a single device
+
+	// AnnotationsTable adds an annotations column with the text of the annotations overlapping each
+	// frame, selected from StartTime to EndTime
+	AnnotationsTable string
+}
+
+// exportFilter returns the WHERE condition of an export and the query parameters it binds
+func exportFilter(opts ExportOptions) (string, clickhouse.Parameters) {
+	filter := fmt.Sprintf("timestamp >= '%s' AND timestamp < '%s'",
+		opts.StartTime.Format("2006-01-02 15:04:05"),
+		opts.EndTime.Format("2006-01-02 15:04:05"),
+	)
+	params := clickhouse.Parameters{}
+	if opts.SessionID != "" {
+		filter = "session_id = {session_id:String}"
+		params["session_id"] = opts.SessionID
+	}
+	if opts.DeviceID != "" {
+		filter += " AND device_id = {device_id:String}"
+		params["device_id"] = opts.DeviceID
+	}
+	return filter, params
+}
+
+// exportQuery returns the SELECT statement of an export and its query parameters
+func exportQuery(tableName string, opts ExportOptions) (string, clickhouse.Parameters) {
+	filter, params := exportFilter(opts)
+	if opts.AnnotationsTable == "" {
+		return fmt.Sprintf(`
+		SELECT
+			timestamp,
+			interface,
+			can_id,
+			data,
+			session_id,
+			device_id,
+			site,
+			hw_revision,
+			direction,
+			user,
+			dlc
+		FROM %s
+		WHERE %s
+		ORDER BY timestamp`, tableName, filter), params
+	}
+
+	return fmt.Sprintf(`
+		WITH (
+			SELECT groupArray((start_time, end_time, interface, text, device_id))
+			FROM %s FINAL
+			WHERE deleted = 0 AND end_time >= '%s' AND start_time <= '%s'
+		) AS notes
+		SELECT
+			timestamp,
+			interface,
+			can_id,
+			data,
+			session_id,
+			device_id,
+			site,
+			hw_revision,
+			direction,
+			user,
+			dlc,
+			arrayMap(n -> n.4, arrayFilter(n -> n.1 <= timestamp AND n.2 >= timestamp AND (n.3 = '' OR n.3 = interface) AND (n.5 = '' OR n.5 = device_id), notes)) AS annotations
+		FROM %s
+		WHERE %s
+		ORDER BY timestamp`,
+		opts.AnnotationsTable,
+		opts.StartTime.Format("2006-01-02 15:04:05"),
+		opts.EndTime.Format("2006-01-02 15:04:05"),
+		tableName,
+		filter,
+	), params
 }
 
 // ExportToParquet exports data to Parquet format
@@ -215,27 +352,18 @@ func (w *Writer) ExportToParquet(tableName string, opts ExportOptions) error {
 	}
 
 	// Build query with time range filter
-	query := fmt.Sprintf(`
-		SELECT
-			timestamp,
-			interface,
-			can_id,
-			data
-		FROM %s
-		WHERE timestamp >= '%s' AND timestamp < '%s'
-		ORDER BY timestamp
+	query, params := exportQuery(tableName, opts)
+	query += fmt.Sprintf(`
 		INTO OUTFILE '%s'
 		FORMAT Parquet
 		SETTINGS output_format_parquet_compression_method='%s'
 	`,
-		tableName,
-		opts.StartTime.Format("2006-01-02 15:04:05"),
-		opts.EndTime.Format("2006-01-02 15:04:05"),
 		opts.OutputPath,
 		opts.Compression,
 	)
 
-	if err := w.conn.Exec(context.Background(), query); err != nil {
+	ctx := clickhouse.Context(context.Background(), clickhouse.WithParameters(params))
+	if err := w.conn.Exec(ctx, query); err != nil {
 		return fmt.Errorf("failed to export to Parquet: %w", err)
 	}
 
@@ -256,27 +384,18 @@ func (w *Writer) ExportToIceberg(tableName string, opts ExportOptions) error {
 	}
 
 	// Build query with time range filter
-	query := fmt.Sprintf(`
-		SELECT
-			timestamp,
-			interface,
-			can_id,
-			data
-		FROM %s
-		WHERE timestamp >= '%s' AND timestamp < '%s'
-		ORDER BY timestamp
+	query, params := exportQuery(tableName, opts)
+	query += fmt.Sprintf(`
 		INTO OUTFILE '%s'
 		FORMAT Iceberg
 		SETTINGS output_format_parquet_compression_method='%s'
 	`,
-		tableName,
-		opts.StartTime.Format("2006-01-02 15:04:05"),
-		opts.EndTime.Format("2006-01-02 15:04:05"),
 		opts.OutputPath,
 		opts.Compression,
 	)
 
-	if err := w.conn.Exec(context.Background(), query); err != nil {
+	ctx := clickhouse.Context(context.Background(), clickhouse.WithParameters(params))
+	if err := w.conn.Exec(ctx, query); err != nil {
 		return fmt.Errorf("failed to export to Iceberg: %w", err)
 	}
 
@@ -308,21 +427,11 @@ func (w *Writer) ExportToWriter(writer io.Writer, tableName string, opts ExportO
 	}
 
 	// Build query with ClickHouse's native format output
-	query := fmt.Sprintf(`
-		SELECT
-			timestamp,
-			interface,
-			can_id,
-			data
-		FROM %s
-		WHERE timestamp >= '%s' AND timestamp < '%s'
-		ORDER BY timestamp
+	query, queryParams := exportQuery(tableName, opts)
+	query += fmt.Sprintf(`
 		FORMAT %s
 		%s
 	`,
-		tableName,
-		opts.StartTime.Format("2006-01-02 15:04:05"),
-		opts.EndTime.Format("2006-01-02 15:04:05"),
 		formatStr,
 		settings,
 	)
@@ -334,6 +443,9 @@ func (w *Writer) ExportToWriter(writer io.Writer, tableName string, opts ExportO
 	params := url.Values{}
 	params.Set("query", query)
 	params.Set("database", w.config.Database)
+	for name, value := range queryParams {
+		params.Set("param_"+name, value)
+	}
 
 	// Add authentication if needed
 	if w.config.Username != "" {
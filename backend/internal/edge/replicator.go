@@ -0,0 +1,253 @@
+package edge
+
+import (
+	"can-db-writer/internal/models"
+	pb "can-db-writer/internal/proto/can"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// streamBatches is the number of batches sent per stream before waiting for the acknowledgement
+	streamBatches = 20
+	// streamTimeout bounds a stream, on top of its transfer time at the bandwidth limit
+	streamTimeout = 30 * time.Second
+	// pollInterval is the wait for new frames once the store is replicated
+	pollInterval = time.Second
+	// Retry backoff after a failed stream
+	retryMin = time.Second
+	retryMax = time.Minute
+)
+
+// Credentials authenticate the replicator to the central server
+type Credentials struct {
+	Token  string // Producer token of the device, sent as "authorization: Bearer <token>"
+	TLS    bool   // Connect with TLS, verifying the server with CAFile or the system roots
+	CAFile string // CA certificate of the server, implies TLS
+}
+
+// Secure reports whether the connection uses TLS
+func (c Credentials) Secure() bool {
+	return c.TLS || c.CAFile != ""
+}
+
+// dialOptions returns the transport and token credentials of the connection
+func (c Credentials) dialOptions() ([]grpc.DialOption, error) {
+	transport := insecure.NewCredentials()
+	if c.CAFile != "" {
+		var err error
+		transport, err = credentials.NewClientTLSFromFile(c.CAFile, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to load CA certificate: %w", err)
+		}
+	} else if c.TLS {
+		transport = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	}
+
+	options := []grpc.DialOption{grpc.WithTransportCredentials(transport)}
+	if c.Token != "" {
+		options = append(options, grpc.WithPerRPCCredentials(bearerCredentials{token: c.Token, secure: c.Secure()}))
+	}
+	return options, nil
+}
+
+// bearerCredentials send a token as bearer authorization metadata with every call
+type bearerCredentials struct {
+	token  string
+	secure bool
+}
+
+// GetRequestMetadata returns the authorization metadata
+func (c bearerCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + c.token}, nil
+}
+
+// RequireTransportSecurity reports whether the token may only be sent over TLS
+func (c bearerCredentials) RequireTransportSecurity() bool {
+	return c.secure
+}
+
+// Replicator asynchronously replicates the frames of a store to the central server, resuming
+// from the acknowledged sequence after a failure or a restart
+type Replicator struct {
+	store     *Store
+	conn      *grpc.ClientConn
+	client    pb.CanServiceClient
+	device    models.Device
+	batchSize int
+	bandwidth float64 // Bytes per second, 0 = unlimited
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewReplicator creates a replicator of a store to the gRPC server at addr
+// bandwidth limits the replicated bytes per second before compression, 0 for no limit.
+func NewReplicator(store *Store, addr string, creds Credentials, device models.Device, batchSize, bandwidth int) (*Replicator, error) {
+	options, err := creds.dialOptions()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := grpc.NewClient(addr, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gRPC client: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Replicator{
+		store:     store,
+		conn:      conn,
+		client:    pb.NewCanServiceClient(conn),
+		device:    device,
+		batchSize: max(batchSize, 1),
+		bandwidth: float64(bandwidth),
+		ctx:       ctx,
+		cancel:    cancel,
+	}, nil
+}
+
+// Start begins replicating
+func (r *Replicator) Start() {
+	r.wg.Add(1)
+	go r.run()
+}
+
+// Stop stops replicating; frames of an unacknowledged stream are replicated again on the next start
+func (r *Replicator) Stop() {
+	r.cancel()
+	r.wg.Wait()
+	r.conn.Close()
+}
+
+// run replicates streams of frames until stopped, backing off after failures
+func (r *Replicator) run() {
+	defer r.wg.Done()
+
+	backoff := retryMin
+	for {
+		sent, err := r.replicate()
+		wait := pollInterval
+		switch {
+		case err != nil:
+			if r.ctx.Err() != nil {
+				return
+			}
+			log.Printf("Warning: Replication failed (%d frames pending, retrying in %v): %v", r.store.Pending(), backoff, err)
+			wait = backoff
+			backoff = min(backoff*2, retryMax)
+		case sent > 0:
+			backoff = retryMin
+			wait = 0
+		default:
+			backoff = retryMin
+		}
+
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// replicate sends the frames following the acknowledged sequence in one stream and stores the
+// acknowledgement, returning the number of frames sent
+func (r *Replicator) replicate() (int, error) {
+	frames, err := r.store.Read(r.store.Acked()+1, r.batchSize*streamBatches)
+	if err != nil || len(frames) == 0 {
+		return 0, err
+	}
+
+	batches := []*pb.ReplicationBatch{}
+	totalBytes := 0
+	for start := 0; start < len(frames); start += r.batchSize {
+		batch := r.newBatch(frames[start:min(start+r.batchSize, len(frames))])
+		batches = append(batches, batch)
+		totalBytes += proto.Size(batch)
+	}
+
+	timeout := streamTimeout
+	if r.bandwidth > 0 {
+		timeout += time.Duration(float64(totalBytes) / r.bandwidth * float64(time.Second))
+	}
+	ctx, cancel := context.WithTimeout(r.ctx, timeout)
+	defer cancel()
+
+	stream, err := r.client.ReplicateFrames(ctx, grpc.UseCompressor(gzip.Name))
+	if err != nil {
+		return 0, err
+	}
+
+	// Pace the batches to the bandwidth limit
+	next := time.Now()
+	for _, batch := range batches {
+		if delay := time.Until(next); delay > 0 {
+			select {
+			case <-ctx.Done():
+				return 0, ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+		if r.bandwidth > 0 {
+			next = time.Now().Add(time.Duration(float64(proto.Size(batch)) / r.bandwidth * float64(time.Second)))
+		}
+
+		if err := stream.Send(batch); err != nil {
+			// The server's error is returned by CloseAndRecv
+			break
+		}
+	}
+
+	ack, err := stream.CloseAndRecv()
+	if err != nil {
+		return 0, err
+	}
+	if err := r.store.Ack(ack.AckedSequence); err != nil {
+		return 0, err
+	}
+
+	log.Printf("Replicated %d frames (%d duplicates), acked sequence %d, %d pending",
+		ack.Frames, ack.Duplicates, ack.AckedSequence, r.store.Pending())
+	if ack.Rejected > 0 {
+		log.Printf("Warning: The central server rejected %d invalid frames: %s", ack.Rejected, strings.Join(ack.Errors, "; "))
+	}
+	return len(frames), nil
+}
+
+// newBatch converts stored frames to a replication batch
+func (r *Replicator) newBatch(frames []Frame) *pb.ReplicationBatch {
+	batch := &pb.ReplicationBatch{
+		DeviceId:   r.device.DeviceID,
+		Site:       r.device.Site,
+		HwRevision: r.device.HWRevision,
+		StoreId:    r.store.ID(),
+		Frames:     make([]*pb.ReplicatedFrame, 0, len(frames)),
+	}
+
+	for _, frame := range frames {
+		msg := frame.Message
+		batch.Frames = append(batch.Frames, &pb.ReplicatedFrame{
+			Sequence:  frame.Sequence,
+			Timestamp: timestamppb.New(msg.Timestamp),
+			Interface: msg.Interface,
+			CanId:     msg.Frame.ID,
+			Data:      msg.Frame.Payload(),
+			SessionId: msg.SessionID,
+		})
+	}
+
+	return batch
+}
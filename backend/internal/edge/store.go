@@ -0,0 +1,471 @@
+package edge
+
+import (
+	"bufio"
+	"can-db-writer/internal/models"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// segmentBytes is the size at which the active segment is closed and a new one started
+	segmentBytes = 16 * 1024 * 1024
+	// recordHeaderSize is the length and CRC32 preceding every record
+	recordHeaderSize = 8
+
+	segmentExt     = ".seg"
+	checkpointFile = "checkpoint"
+	storeIDFile    = "store_id"
+)
+
+// Frame is a stored CAN message and its sequence number in the store
+type Frame struct {
+	Sequence uint64
+	Message  models.CANMessage
+}
+
+// segment is a log file holding the frames from its first sequence up to the next segment
+type segment struct {
+	first uint64
+	path  string
+	size  int64
+}
+
+// Store is an embedded append-only frame log kept on the edge device until the central server
+// acknowledges the frames. Frames are numbered with increasing sequences that never restart for
+// the store; a new store (e.g. after wiping the directory) gets a new store id.
+//
+// The log is split into segments of segmentBytes. Acknowledged segments are deleted, and the
+// oldest segments are dropped, replicated or not, when the store exceeds its size limit.
+type Store struct {
+	mu       sync.Mutex
+	dir      string
+	id       string
+	maxBytes int64
+
+	segments []segment // Sorted by first sequence, the last one is active
+	file     *os.File
+	writer   *bufio.Writer
+	nextSeq  uint64
+	acked    uint64
+
+	// Position after the last read, so sequential reads do not rescan the segment
+	cursorSeq    uint64
+	cursorPath   string
+	cursorOffset int64
+}
+
+// OpenStore opens or creates the store in dir, recovering the frames written before a crash
+func OpenStore(dir string, maxBytes int64) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create store directory: %w", err)
+	}
+
+	s := &Store{dir: dir, maxBytes: maxBytes}
+
+	id, err := s.loadID()
+	if err != nil {
+		return nil, err
+	}
+	s.id = id
+
+	if s.acked, err = s.loadCheckpoint(); err != nil {
+		return nil, err
+	}
+
+	if err := s.loadSegments(); err != nil {
+		return nil, err
+	}
+
+	if len(s.segments) == 0 {
+		if err := s.openSegment(s.acked + 1); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+
+	// Recover the active segment, truncating a record torn by a crash
+	active := &s.segments[len(s.segments)-1]
+	last, end, err := scanSegment(active.path, active.first)
+	if err != nil {
+		return nil, err
+	}
+	if end < active.size {
+		log.Printf("Warning: Truncating %d bytes of a torn record in %s", active.size-end, active.path)
+		if err := os.Truncate(active.path, end); err != nil {
+			return nil, fmt.Errorf("failed to truncate segment: %w", err)
+		}
+		active.size = end
+	}
+	s.nextSeq = max(last+1, active.first)
+
+	file, err := os.OpenFile(active.path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open segment: %w", err)
+	}
+	s.file = file
+	s.writer = bufio.NewWriter(file)
+
+	return s, nil
+}
+
+// ID returns the id of the store, sent with every replicated batch
+func (s *Store) ID() string {
+	return s.id
+}
+
+// Append adds a message to the store and returns its sequence
+func (s *Store) Append(msg models.CANMessage) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seq := s.nextSeq
+	record := encodeRecord(seq, msg)
+	if _, err := s.writer.Write(record); err != nil {
+		return 0, fmt.Errorf("failed to write frame: %w", err)
+	}
+	s.nextSeq++
+
+	active := &s.segments[len(s.segments)-1]
+	active.size += int64(len(record))
+	if active.size >= segmentBytes {
+		if err := s.rollSegment(); err != nil {
+			return seq, err
+		}
+	}
+
+	return seq, nil
+}
+
+// Read returns up to limit frames starting at sequence from, or at the oldest stored frame when
+// from has been dropped
+func (s *Store) Read(from uint64, limit int) ([]Frame, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.writer.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to flush store: %w", err)
+	}
+
+	frames := []Frame{}
+	for i, seg := range s.segments {
+		if i+1 < len(s.segments) && s.segments[i+1].first <= from {
+			continue
+		}
+
+		offset := int64(0)
+		if seg.path == s.cursorPath && from == s.cursorSeq {
+			offset = s.cursorOffset
+		}
+
+		file, err := os.Open(seg.path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open segment: %w", err)
+		}
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to seek segment: %w", err)
+		}
+
+		reader := bufio.NewReader(file)
+		for len(frames) < limit {
+			frame, n, err := readRecord(reader)
+			if err != nil {
+				break // End of segment, or an incomplete record still being written
+			}
+			offset += n
+			if frame.Sequence < from {
+				continue
+			}
+			frames = append(frames, frame)
+			from = frame.Sequence + 1
+			s.cursorSeq, s.cursorPath, s.cursorOffset = from, seg.path, offset
+		}
+		file.Close()
+
+		if len(frames) >= limit {
+			break
+		}
+	}
+
+	return frames, nil
+}
+
+// Acked returns the highest sequence acknowledged by the central server
+func (s *Store) Acked() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.acked
+}
+
+// Pending returns the number of stored frames not acknowledged yet
+func (s *Store) Pending() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	oldest := max(s.acked+1, s.segments[0].first)
+	if s.nextSeq <= oldest {
+		return 0
+	}
+	return s.nextSeq - oldest
+}
+
+// Ack persists the acknowledged sequence and deletes the segments it covers
+func (s *Store) Ack(seq uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if seq <= s.acked {
+		return nil
+	}
+	s.acked = min(seq, s.nextSeq-1)
+
+	tmp := filepath.Join(s.dir, checkpointFile+".tmp")
+	if err := os.WriteFile(tmp, []byte(strconv.FormatUint(s.acked, 10)), 0644); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(s.dir, checkpointFile)); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+
+	// Every frame of a closed segment is acknowledged once the next segment starts after the checkpoint
+	for len(s.segments) > 1 && s.segments[1].first <= s.acked+1 {
+		if err := os.Remove(s.segments[0].path); err != nil {
+			return fmt.Errorf("failed to delete segment: %w", err)
+		}
+		s.segments = s.segments[1:]
+	}
+
+	return nil
+}
+
+// Flush writes buffered frames to the active segment file and syncs it to disk
+func (s *Store) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.writer.Flush(); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+// Close flushes and closes the store
+func (s *Store) Close() error {
+	if err := s.Flush(); err != nil {
+		return err
+	}
+	return s.file.Close()
+}
+
+// rollSegment closes the active segment, starts a new one and enforces the size limit
+func (s *Store) rollSegment() error {
+	if err := s.writer.Flush(); err != nil {
+		return err
+	}
+	if err := s.file.Sync(); err != nil {
+		return err
+	}
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+
+	if err := s.openSegment(s.nextSeq); err != nil {
+		return err
+	}
+
+	var total int64
+	for _, seg := range s.segments {
+		total += seg.size
+	}
+	for len(s.segments) > 1 && total > s.maxBytes {
+		dropped := s.segments[0]
+		if next := s.segments[1].first; next > s.acked+1 {
+			log.Printf("Warning: Store size limit reached, dropping %d frames not replicated yet",
+				next-max(dropped.first, s.acked+1))
+		}
+		if err := os.Remove(dropped.path); err != nil {
+			return fmt.Errorf("failed to delete segment: %w", err)
+		}
+		total -= dropped.size
+		s.segments = s.segments[1:]
+	}
+
+	return nil
+}
+
+// openSegment creates a new active segment starting at sequence first
+func (s *Store) openSegment(first uint64) error {
+	path := filepath.Join(s.dir, fmt.Sprintf("%020d%s", first, segmentExt))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create segment: %w", err)
+	}
+
+	s.file = file
+	s.writer = bufio.NewWriter(file)
+	s.segments = append(s.segments, segment{first: first, path: path})
+	s.nextSeq = first
+	return nil
+}
+
+// loadSegments lists the segment files of the store directory
+func (s *Store) loadSegments() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("failed to list store directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		first, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return fmt.Errorf("failed to stat segment: %w", err)
+		}
+		s.segments = append(s.segments, segment{first: first, path: filepath.Join(s.dir, name), size: info.Size()})
+	}
+
+	sort.Slice(s.segments, func(i, j int) bool {
+		return s.segments[i].first < s.segments[j].first
+	})
+	return nil
+}
+
+// loadID reads the store id, creating it for a new store
+func (s *Store) loadID() (string, error) {
+	path := filepath.Join(s.dir, storeIDFile)
+	data, err := os.ReadFile(path)
+	if err == nil {
+		return strings.TrimSpace(string(data)), nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("failed to read store id: %w", err)
+	}
+
+	id := models.NewRecordingID()
+	if err := os.WriteFile(path, []byte(id), 0644); err != nil {
+		return "", fmt.Errorf("failed to write store id: %w", err)
+	}
+	return id, nil
+}
+
+// loadCheckpoint reads the acknowledged sequence, 0 when nothing was acknowledged
+func (s *Store) loadCheckpoint() (uint64, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, checkpointFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+
+	acked, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid checkpoint: %w", err)
+	}
+	return acked, nil
+}
+
+// scanSegment returns the last sequence of a segment and the end offset of its last complete record
+func scanSegment(path string, first uint64) (uint64, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to open segment: %w", err)
+	}
+	defer file.Close()
+
+	last := first - 1
+	var end int64
+	reader := bufio.NewReader(file)
+	for {
+		frame, n, err := readRecord(reader)
+		if err != nil {
+			return last, end, nil
+		}
+		last = frame.Sequence
+		end += n
+	}
+}
+
+// encodeRecord encodes a frame as length, CRC32 and payload:
+// sequence, timestamp (Unix ns), CAN ID, DLC, 8 data bytes, then the interface and session id,
+// each prefixed with its 16-bit length
+func encodeRecord(seq uint64, msg models.CANMessage) []byte {
+	payloadSize := 8 + 8 + 4 + 1 + 8 + 2 + len(msg.Interface) + 2 + len(msg.SessionID)
+	record := make([]byte, recordHeaderSize, recordHeaderSize+payloadSize)
+
+	record = binary.LittleEndian.AppendUint64(record, seq)
+	record = binary.LittleEndian.AppendUint64(record, uint64(msg.Timestamp.UnixNano()))
+	record = binary.LittleEndian.AppendUint32(record, msg.Frame.ID)
+	record = append(record, msg.Frame.DLC)
+	record = append(record, msg.Frame.Data[:]...)
+	record = binary.LittleEndian.AppendUint16(record, uint16(len(msg.Interface)))
+	record = append(record, msg.Interface...)
+	record = binary.LittleEndian.AppendUint16(record, uint16(len(msg.SessionID)))
+	record = append(record, msg.SessionID...)
+
+	payload := record[recordHeaderSize:]
+	binary.LittleEndian.PutUint32(record[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(payload))
+	return record
+}
+
+// readRecord reads the next record and returns its frame and encoded size
+func readRecord(reader *bufio.Reader) (Frame, int64, error) {
+	header := make([]byte, recordHeaderSize)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return Frame{}, 0, err
+	}
+	size := binary.LittleEndian.Uint32(header[0:4])
+	if size < 33 || size > 1<<17 {
+		return Frame{}, 0, fmt.Errorf("invalid record size %d", size)
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return Frame{}, 0, err
+	}
+	if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(header[4:8]) {
+		return Frame{}, 0, fmt.Errorf("record checksum mismatch")
+	}
+
+	var frame Frame
+	frame.Sequence = binary.LittleEndian.Uint64(payload[0:8])
+	frame.Message.Timestamp = time.Unix(0, int64(binary.LittleEndian.Uint64(payload[8:16])))
+	frame.Message.Frame.ID = binary.LittleEndian.Uint32(payload[16:20])
+	frame.Message.Frame.DLC = payload[20]
+	copy(frame.Message.Frame.Data[:], payload[21:29])
+
+	rest := payload[29:]
+	for _, target := range []*string{&frame.Message.Interface, &frame.Message.SessionID} {
+		if len(rest) < 2 {
+			return Frame{}, 0, fmt.Errorf("truncated record")
+		}
+		n := int(binary.LittleEndian.Uint16(rest[0:2]))
+		if len(rest) < 2+n {
+			return Frame{}, 0, fmt.Errorf("truncated record")
+		}
+		*target = string(rest[2 : 2+n])
+		rest = rest[2+n:]
+	}
+
+	return frame, int64(recordHeaderSize) + int64(size), nil
+}
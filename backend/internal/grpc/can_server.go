@@ -1,9 +1,13 @@
 package grpc
 
 import (
+	"can-db-writer/internal/can"
+	"can-db-writer/internal/database/clickhouse"
+	"can-db-writer/internal/models"
 	pb "can-db-writer/internal/proto/can"
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
@@ -13,15 +17,28 @@ import (
 // CANServer implements the gRPC canService
 type CANServer struct {
 	pb.UnimplementedCanServiceServer
-	conn      driver.Conn
-	tableName string
+	conn             driver.Conn
+	tableName        string
+	replicationTable string
+	storeLocks       sync.Map           // Edge store -> *sync.Mutex
+	writer           *clickhouse.Writer // Batched writer of ingested frames, nil disables ingestion
+	transmitter      *can.Transmitter   // Frame transmission, nil disables it
+	scheduler        *can.Scheduler     // Cyclic transmission, nil disables it
+	operators        []models.TxOperator
+	producers        []models.Producer // Tokens of the remote producers and the device each may write
 }
 
 // NewCANServer creates a new gRPC CAN server
-func NewCANServer(conn driver.Conn, tableName string) *CANServer {
+func NewCANServer(conn driver.Conn, tableName, replicationTable string, writer *clickhouse.Writer, transmitter *can.Transmitter, scheduler *can.Scheduler, operators []models.TxOperator, producers []models.Producer) *CANServer {
 	return &CANServer{
-		conn:      conn,
-		tableName: tableName,
+		conn:             conn,
+		tableName:        tableName,
+		replicationTable: replicationTable,
+		writer:           writer,
+		transmitter:      transmitter,
+		scheduler:        scheduler,
+		operators:        operators,
+		producers:        producers,
 	}
 }
 
@@ -43,6 +60,10 @@ func (s *CANServer) GetCANopenMessages(ctx context.Context, req *pb.GetCANopenMe
 			query += " AND interface = ?"
 			args = append(args, req.Filter.Interface)
 		}
+		if req.Filter.DeviceId != "" {
+			query += " AND device_id = ?"
+			args = append(args, req.Filter.DeviceId)
+		}
 	}
 
 	query += " ORDER BY timestamp DESC"
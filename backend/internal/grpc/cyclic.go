@@ -0,0 +1,118 @@
+package grpc
+
+import (
+	"can-db-writer/internal/models"
+	pb "can-db-writer/internal/proto/can"
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// CreateCyclicJob starts a cyclic transmission job through the same scheduler as POST /api/can/cyclic
+// Creating, updating and deleting a job needs the "authorization: Bearer <token>" metadata of an operator.
+func (s *CANServer) CreateCyclicJob(ctx context.Context, req *pb.CreateCyclicJobRequest) (*pb.CyclicJob, error) {
+	if s.scheduler == nil {
+		return nil, status.Error(codes.Unavailable, "cyclic transmission is not enabled")
+	}
+	if req.Interface == "" {
+		return nil, status.Error(codes.InvalidArgument, "interface is required")
+	}
+	operator, err := s.authorize(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := s.scheduler.Create(models.CyclicJobRequest{
+		Interface: req.Interface,
+		CANID:     req.CanId,
+		Data:      req.Data,
+		PeriodMs:  req.PeriodMs,
+		Mode:      req.Mode,
+		Paused:    req.Paused,
+		User:      operator.User,
+	})
+	if err != nil {
+		return nil, status.Error(txCode(err), err.Error())
+	}
+	return cyclicJobProto(job), nil
+}
+
+// UpdateCyclicJob changes the payload, period or pause state of a job
+func (s *CANServer) UpdateCyclicJob(ctx context.Context, req *pb.UpdateCyclicJobRequest) (*pb.CyclicJob, error) {
+	if s.scheduler == nil {
+		return nil, status.Error(codes.Unavailable, "cyclic transmission is not enabled")
+	}
+	operator, err := s.authorize(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	update := models.CyclicJobUpdate{
+		PeriodMs: req.PeriodMs,
+		Paused:   req.Paused,
+	}
+	if req.Data != nil {
+		update.Data = &req.Data
+	}
+
+	job, err := s.scheduler.Update(req.Id, update, operator.User)
+	if err != nil {
+		return nil, status.Error(txCode(err), err.Error())
+	}
+	return cyclicJobProto(job), nil
+}
+
+// DeleteCyclicJob stops and removes a job, returning its final counts
+func (s *CANServer) DeleteCyclicJob(ctx context.Context, req *pb.DeleteCyclicJobRequest) (*pb.CyclicJob, error) {
+	if s.scheduler == nil {
+		return nil, status.Error(codes.Unavailable, "cyclic transmission is not enabled")
+	}
+	operator, err := s.authorize(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := s.scheduler.Delete(req.Id, operator.User)
+	if err != nil {
+		return nil, status.Error(txCode(err), err.Error())
+	}
+	return cyclicJobProto(job), nil
+}
+
+// ListCyclicJobs returns the jobs with their counts and jitter
+func (s *CANServer) ListCyclicJobs(ctx context.Context, req *pb.ListCyclicJobsRequest) (*pb.ListCyclicJobsResponse, error) {
+	if s.scheduler == nil {
+		return nil, status.Error(codes.Unavailable, "cyclic transmission is not enabled")
+	}
+
+	response := &pb.ListCyclicJobsResponse{}
+	for _, job := range s.scheduler.List() {
+		response.Jobs = append(response.Jobs, cyclicJobProto(job))
+	}
+	return response, nil
+}
+
+// cyclicJobProto converts a cyclic job to its protobuf message
+func cyclicJobProto(job models.CyclicJob) *pb.CyclicJob {
+	msg := &pb.CyclicJob{
+		Id:             job.ID,
+		Interface:      job.Interface,
+		CanId:          job.CANID,
+		Data:           job.Data,
+		PeriodMs:       job.PeriodMs,
+		Mode:           job.Mode,
+		State:          job.State,
+		User:           job.User,
+		CreatedAt:      timestamppb.New(job.CreatedAt),
+		Frames:         job.Frames,
+		MeanPeriodMs:   job.MeanPeriodMs,
+		JitterMs:       job.JitterMs,
+		MaxDeviationMs: job.MaxDeviationMs,
+	}
+	if job.LastTx != nil {
+		msg.LastTx = timestamppb.New(*job.LastTx)
+	}
+	return msg
+}
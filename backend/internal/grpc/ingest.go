@@ -0,0 +1,138 @@
+package grpc
+
+import (
+	"can-db-writer/internal/models"
+	pb "can-db-writer/internal/proto/can"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// maxIngestBatch is the maximum number of frames of an ingested batch
+	maxIngestBatch = 10000
+	// maxIngestErrors is the number of rejection reasons returned in the summary or acknowledgement
+	maxIngestErrors = 10
+	// maxIngestClockSkew is how far in the future an ingested or replicated frame may be timestamped
+	maxIngestClockSkew = time.Minute
+)
+
+// IngestFrames queues the frames of remote producers in the same batched writer as the CAN reader.
+// The stream needs the "authorization: Bearer <token>" metadata of a producer and may only write the
+// producer's device. Batches without interface or of another device and invalid frames are rejected
+// and reported in the summary; the other frames of the stream are still written.
+func (s *CANServer) IngestFrames(stream grpc.ClientStreamingServer[pb.IngestBatch, pb.IngestSummary]) error {
+	if s.writer == nil {
+		return status.Error(codes.Unavailable, "frame ingestion is not enabled")
+	}
+
+	ctx := stream.Context()
+	producer, err := s.authenticateProducer(ctx)
+	if err != nil {
+		return err
+	}
+
+	summary := &pb.IngestSummary{}
+	reject := func(count int, reason string) {
+		summary.Rejected += uint64(count)
+		if len(summary.Errors) < maxIngestErrors {
+			summary.Errors = append(summary.Errors, reason)
+		}
+	}
+
+	for {
+		batch, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			if summary.Batches > 0 {
+				log.Printf("Ingested %d frames in %d batches (%d rejected)", summary.Accepted, summary.Batches, summary.Rejected)
+			}
+			return stream.SendAndClose(summary)
+		}
+		if err != nil {
+			return err
+		}
+		summary.Batches++
+
+		if batch.DeviceId == "" || batch.Interface == "" {
+			reject(len(batch.Frames), fmt.Sprintf("batch %d: device_id and interface are required", summary.Batches))
+			continue
+		}
+		if batch.DeviceId != producer.DeviceID {
+			reject(len(batch.Frames), fmt.Sprintf("batch %d: the token may not write device %s", summary.Batches, batch.DeviceId))
+			continue
+		}
+		if len(batch.Frames) > maxIngestBatch {
+			reject(len(batch.Frames), fmt.Sprintf("batch %d: %d frames, at most %d", summary.Batches, len(batch.Frames), maxIngestBatch))
+			continue
+		}
+
+		now := time.Now().UTC()
+		device := models.Device{DeviceID: batch.DeviceId, Site: batch.Site, HWRevision: batch.HwRevision}
+		for i, frame := range batch.Frames {
+			msg, err := frameMessage(frame.CanId, frame.Data, frame.Timestamp, now)
+			if err != nil {
+				reject(1, fmt.Sprintf("batch %d frame %d: %v", summary.Batches, i, err))
+				continue
+			}
+			msg.Interface = batch.Interface
+			msg.SessionID = batch.SessionId
+			msg.Device = device
+
+			if err := s.writer.WriteContext(ctx, msg); err != nil {
+				return status.FromContextError(err).Err()
+			}
+			summary.Accepted++
+		}
+	}
+}
+
+// authenticateProducer returns the producer of the bearer token in the authorization metadata
+func (s *CANServer) authenticateProducer(ctx context.Context) (models.Producer, error) {
+	if len(s.producers) == 0 {
+		return models.Producer{}, status.Error(codes.PermissionDenied, "no producers are configured")
+	}
+	producer, ok := models.FindProducer(s.producers, bearerToken(ctx))
+	if !ok {
+		return models.Producer{}, status.Error(codes.Unauthenticated, "missing or invalid producer token")
+	}
+	return producer, nil
+}
+
+// frameMessage validates an ingested or replicated frame and converts it to a message received at now
+// when it has no timestamp
+func frameMessage(canID uint32, data []byte, timestamp *timestamppb.Timestamp, now time.Time) (models.CANMessage, error) {
+	msg := models.CANMessage{Timestamp: now}
+
+	if len(data) > 8 {
+		return msg, fmt.Errorf("%d data bytes, at most 8", len(data))
+	}
+
+	msg.Frame.ID = canID
+	msg.Frame.DLC = uint8(len(data))
+	copy(msg.Frame.Data[:], data)
+
+	flags := models.CANEffFlag | models.CANRtrFlag | models.CANErrFlag
+	if !msg.Frame.IsExtended() && canID&^flags > models.CANSffMask {
+		return msg, fmt.Errorf("standard CAN ID 0x%X exceeds 11 bits, set the EFF flag (0x80000000) for extended IDs", canID&^flags)
+	}
+
+	if timestamp != nil {
+		if err := timestamp.CheckValid(); err != nil {
+			return msg, fmt.Errorf("invalid timestamp: %v", err)
+		}
+		msg.Timestamp = timestamp.AsTime()
+		if msg.Timestamp.After(now.Add(maxIngestClockSkew)) {
+			return msg, fmt.Errorf("timestamp %s is in the future", msg.Timestamp.Format(time.RFC3339Nano))
+		}
+	}
+
+	return msg, nil
+}
@@ -0,0 +1,132 @@
+package grpc
+
+import (
+	"can-db-writer/internal/database/clickhouse"
+	"can-db-writer/internal/models"
+	pb "can-db-writer/internal/proto/can"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	clickhousego "github.com/ClickHouse/clickhouse-go/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ReplicateFrames writes the frames replicated by an edge store, skipping the sequences already
+// written so that a batch resent after a lost acknowledgement is not stored twice. Streams of the
+// same store are serialized. The acknowledgement carries the highest sequence written.
+// Like IngestFrames, the stream needs the token of a producer and may only replicate its device.
+// Invalid frames are validated as ingested frames and acknowledged without being written, so that
+// they do not block the store.
+func (s *CANServer) ReplicateFrames(stream grpc.ClientStreamingServer[pb.ReplicationBatch, pb.ReplicationAck]) error {
+	ctx := stream.Context()
+	producer, err := s.authenticateProducer(ctx)
+	if err != nil {
+		return err
+	}
+
+	ack := &pb.ReplicationAck{}
+	reject := func(sequence uint64, err error) {
+		ack.Rejected++
+		if len(ack.Errors) < maxIngestErrors {
+			ack.Errors = append(ack.Errors, fmt.Sprintf("frame %d: %v", sequence, err))
+		}
+	}
+
+	var deviceID, storeID string
+	for {
+		batch, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			if deviceID != "" {
+				log.Printf("Replicated %d frames from %s (store %s, %d duplicates, %d rejected, acked %d)",
+					ack.Frames, deviceID, storeID, ack.Duplicates, ack.Rejected, ack.AckedSequence)
+			}
+			return stream.SendAndClose(ack)
+		}
+		if err != nil {
+			return err
+		}
+
+		if batch.DeviceId == "" || batch.StoreId == "" {
+			return status.Error(codes.InvalidArgument, "device_id and store_id are required")
+		}
+		if batch.DeviceId != producer.DeviceID {
+			return status.Errorf(codes.PermissionDenied, "the token may not replicate device %s", batch.DeviceId)
+		}
+		if deviceID == "" {
+			deviceID, storeID = batch.DeviceId, batch.StoreId
+
+			lock := s.storeLock(deviceID + "/" + storeID)
+			lock.Lock()
+			defer lock.Unlock()
+
+			ack.AckedSequence, err = clickhouse.ReadReplicationCheckpoint(ctx, s.conn, s.replicationTable, deviceID, storeID)
+			if err != nil {
+				return status.Errorf(codes.Internal, "failed to read checkpoint: %v", err)
+			}
+		} else if batch.DeviceId != deviceID || batch.StoreId != storeID {
+			return status.Error(codes.InvalidArgument, "a stream must replicate a single store")
+		}
+
+		now := time.Now().UTC()
+		device := models.Device{DeviceID: batch.DeviceId, Site: batch.Site, HWRevision: batch.HwRevision}
+		msgs := make([]models.CANMessage, 0, len(batch.Frames))
+		first, last := uint64(0), ack.AckedSequence
+		for _, frame := range batch.Frames {
+			if frame.Sequence <= last {
+				if frame.Sequence <= ack.AckedSequence {
+					ack.Duplicates++
+					continue
+				}
+				return status.Errorf(codes.InvalidArgument, "frame sequence %d is not increasing", frame.Sequence)
+			}
+			last = frame.Sequence
+
+			if frame.Timestamp == nil {
+				reject(frame.Sequence, errors.New("no timestamp"))
+				continue
+			}
+			msg, err := frameMessage(frame.CanId, frame.Data, frame.Timestamp, now)
+			if err != nil {
+				reject(frame.Sequence, err)
+				continue
+			}
+			msg.Interface = frame.Interface
+			msg.SessionID = frame.SessionId
+			msg.Device = device
+			msgs = append(msgs, msg)
+			if first == 0 {
+				first = frame.Sequence
+			}
+		}
+		if last == ack.AckedSequence {
+			continue
+		}
+
+		// Frames are written before the checkpoint. A batch written again after a failure in between carries
+		// the same deduplication token, which ClickHouse drops within the table's deduplication window.
+		if len(msgs) > 0 {
+			token := fmt.Sprintf("%s/%s/%d-%d", deviceID, storeID, first, last)
+			insertCtx := clickhousego.Context(ctx, clickhousego.WithSettings(clickhousego.Settings{"insert_deduplication_token": token}))
+			if err := clickhouse.WriteMessages(insertCtx, s.conn, s.tableName, msgs); err != nil {
+				return status.Errorf(codes.Unavailable, "failed to write frames: %v", err)
+			}
+		}
+		if err := clickhouse.WriteReplicationCheckpoint(ctx, s.conn, s.replicationTable, deviceID, storeID, last); err != nil {
+			return status.Errorf(codes.Unavailable, "failed to write checkpoint: %v", err)
+		}
+		ack.AckedSequence = last
+		ack.Frames += uint64(len(msgs))
+	}
+}
+
+// storeLock returns the lock serializing the replication streams of an edge store
+func (s *CANServer) storeLock(key string) *sync.Mutex {
+	lock, _ := s.storeLocks.LoadOrStore(key, &sync.Mutex{})
+	return lock.(*sync.Mutex)
+}
@@ -0,0 +1,92 @@
+package grpc
+
+import (
+	"can-db-writer/internal/can"
+	"can-db-writer/internal/models"
+	pb "can-db-writer/internal/proto/can"
+	"context"
+	"errors"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// SendFrame transmits a single frame through the same transmitter as POST /api/can/send
+// The frame is recorded with the operator of the "authorization: Bearer <token>" metadata.
+func (s *CANServer) SendFrame(ctx context.Context, req *pb.SendFrameRequest) (*pb.SendFrameResponse, error) {
+	if s.transmitter == nil {
+		return nil, status.Error(codes.Unavailable, "frame transmission is not enabled")
+	}
+	if req.Interface == "" {
+		return nil, status.Error(codes.InvalidArgument, "interface is required")
+	}
+	operator, err := s.authorize(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.transmitter.Send(ctx, models.CANSendRequest{
+		Interface: req.Interface,
+		CANID:     req.CanId,
+		Data:      req.Data,
+		User:      operator.User,
+		TimeoutMs: int(req.TimeoutMs),
+	})
+	if err != nil {
+		return nil, status.Error(txCode(err), err.Error())
+	}
+
+	return &pb.SendFrameResponse{
+		Interface:    result.Interface,
+		CanId:        result.CANID,
+		Data:         result.Data,
+		Confirmation: result.Confirmation,
+		TxTimestamp:  timestamppb.New(result.TxTimestamp),
+		LatencyUs:    result.LatencyUs,
+		Logged:       result.Logged,
+	}, nil
+}
+
+// authorize returns the operator of the bearer token in the authorization metadata if it may act on a
+// CANopen node, 0 for raw frames
+func (s *CANServer) authorize(ctx context.Context, nodeID uint8) (models.TxOperator, error) {
+	operator, err := can.Authorize(s.operators, bearerToken(ctx), nodeID)
+	if err != nil {
+		return operator, status.Error(txCode(err), err.Error())
+	}
+	return operator, nil
+}
+
+// bearerToken returns the bearer token of the authorization metadata, empty without one
+func bearerToken(ctx context.Context) string {
+	token := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, value := range md.Get("authorization") {
+			if bearer, ok := strings.CutPrefix(value, "Bearer "); ok {
+				token = bearer
+			}
+		}
+	}
+	return token
+}
+
+// txCode returns the status code of a transmission error
+func txCode(err error) codes.Code {
+	switch {
+	case errors.Is(err, can.ErrTxDisabled), errors.Is(err, can.ErrTxForbidden):
+		return codes.PermissionDenied
+	case errors.Is(err, can.ErrTxUnauthorized):
+		return codes.Unauthenticated
+	case errors.Is(err, can.ErrTxInvalid):
+		return codes.InvalidArgument
+	case errors.Is(err, can.ErrTxTimeout):
+		return codes.DeadlineExceeded
+	case errors.Is(err, can.ErrCyclicNotFound):
+		return codes.NotFound
+	default:
+		return codes.Internal
+	}
+}
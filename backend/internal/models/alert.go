@@ -0,0 +1,315 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Alert rule types
+const (
+	AlertRuleSignal  = "signal"  // A decoded signal compared with value, or any of bit_mask set in its raw value
+	AlertRuleStats   = "stats"   // A SocketCANStats field compared with value, or equal to equals
+	AlertRulePeriod  = "period"  // The inter-arrival time (ms) of a CAN ID compared with value
+	AlertRulePayload = "payload" // A frame of a CAN ID whose payload matches data under mask
+)
+
+// Alert statuses
+const (
+	AlertFiring   = "firing"
+	AlertResolved = "resolved"
+)
+
+// Alert notification channels
+const (
+	NotifyWebhook = "webhook"
+	NotifyEmail   = "email"
+	NotifyStream  = "stream"
+)
+
+// alertOps are the comparison operators of alert rules
+var alertOps = []string{">", ">=", "<", "<=", "==", "!="}
+
+// AlertRule is a condition evaluated continuously against live frames, signals and interface statistics
+// The alert fires once the condition has held for the for duration and resolves when it no longer holds.
+type AlertRule struct {
+	ID          string   `yaml:"id" json:"id"`
+	Name        string   `yaml:"name" json:"name"`
+	Description string   `yaml:"description" json:"description,omitempty"`
+	Severity    string   `yaml:"severity" json:"severity"`
+	Type        string   `yaml:"type" json:"type"`
+	Signal      string   `yaml:"signal" json:"signal,omitempty"`
+	NodeID      uint8    `yaml:"node_id" json:"node_id,omitempty"` // Signal rules, 0 for every node
+	CANID       uint32   `yaml:"can_id" json:"can_id,omitempty"`   // Period and payload rules, with SocketCAN flags
+	Field       string   `yaml:"field" json:"field,omitempty"`     // Stats rules: JSON field name, e.g. rx_error_counter
+	Op          string   `yaml:"op" json:"op,omitempty"`
+	Value       *float64 `yaml:"value" json:"value,omitempty"`
+	Equals      string   `yaml:"equals" json:"equals,omitempty"`     // Stats rules on string fields, e.g. bus_state
+	BitMask     uint64   `yaml:"bit_mask" json:"bit_mask,omitempty"` // Signal rules: fires when any bit is set
+	Data        string   `yaml:"data" json:"data,omitempty"`         // Payload rules: hex bytes, e.g. "08 00"
+	Mask        string   `yaml:"mask" json:"mask,omitempty"`         // Payload rules: hex bytes, defaults to FF
+	For         string   `yaml:"for" json:"for,omitempty"`           // Duration the condition must hold, e.g. 1s
+	Notify      []string `yaml:"notify" json:"notify,omitempty"`     // Channels, default every configured channel
+	Disabled    bool     `yaml:"disabled" json:"disabled,omitempty"`
+
+	forDuration time.Duration
+	data        []byte
+	mask        []byte
+}
+
+// Validate checks the rule, fills in the defaults and parses its duration and payload pattern
+func (r *AlertRule) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("alert rule has no name")
+	}
+	if r.ID == "" {
+		r.ID = r.Name
+	}
+	if r.Severity == "" {
+		r.Severity = SeverityWarning
+	}
+	if !slices.Contains([]string{SeverityInfo, SeverityWarning, SeverityError}, r.Severity) {
+		return fmt.Errorf("invalid severity '%s' of rule '%s', must be info, warning or error", r.Severity, r.Name)
+	}
+
+	comparison := r.Op != "" || r.Value != nil
+	if comparison && (r.Value == nil || !slices.Contains(alertOps, r.Op)) {
+		return fmt.Errorf("rule '%s' requires op (one of > >= < <= == !=) and value", r.Name)
+	}
+
+	switch r.Type {
+	case AlertRuleSignal:
+		if r.Signal == "" || comparison == (r.BitMask != 0) {
+			return fmt.Errorf("signal rule '%s' requires signal and either op/value or bit_mask", r.Name)
+		}
+	case AlertRuleStats:
+		if r.Field == "" || comparison == (r.Equals != "") {
+			return fmt.Errorf("stats rule '%s' requires field and either op/value or equals", r.Name)
+		}
+	case AlertRulePeriod:
+		if !comparison {
+			return fmt.Errorf("period rule '%s' requires op and value (ms)", r.Name)
+		}
+	case AlertRulePayload:
+		data, err := parseHexBytes(r.Data)
+		if err != nil || len(data) == 0 {
+			return fmt.Errorf("invalid data '%s' of rule '%s'", r.Data, r.Name)
+		}
+		mask := make([]byte, len(data))
+		for i := range mask {
+			mask[i] = 0xFF
+		}
+		if r.Mask != "" {
+			mask, err = parseHexBytes(r.Mask)
+			if err != nil || len(mask) != len(data) {
+				return fmt.Errorf("invalid mask '%s' of rule '%s', must have as many bytes as data", r.Mask, r.Name)
+			}
+		}
+		r.data, r.mask = data, mask
+	default:
+		return fmt.Errorf("invalid type '%s' of rule '%s', must be one of: signal, stats, period, payload", r.Type, r.Name)
+	}
+
+	r.forDuration = 0
+	if r.For != "" {
+		duration, err := time.ParseDuration(r.For)
+		if err != nil || duration < 0 {
+			return fmt.Errorf("invalid for '%s' of rule '%s', expected a duration such as 500ms or 1s", r.For, r.Name)
+		}
+		r.forDuration = duration
+	}
+
+	for _, channel := range r.Notify {
+		if !slices.Contains([]string{NotifyWebhook, NotifyEmail, NotifyStream}, channel) {
+			return fmt.Errorf("invalid notify channel '%s' of rule '%s', must be webhook, email or stream", channel, r.Name)
+		}
+	}
+
+	return nil
+}
+
+// ForDuration returns how long the condition must hold before the alert fires
+func (r *AlertRule) ForDuration() time.Duration {
+	return r.forDuration
+}
+
+// Compare applies the rule's comparison to a value
+func (r *AlertRule) Compare(x float64) bool {
+	value := *r.Value
+	switch r.Op {
+	case ">":
+		return x > value
+	case ">=":
+		return x >= value
+	case "<":
+		return x < value
+	case "<=":
+		return x <= value
+	case "==":
+		return x == value
+	case "!=":
+		return x != value
+	}
+	return false
+}
+
+// EvalSignal evaluates a signal rule against a decoded value
+// applies is false when the value is of another signal or node.
+func (r *AlertRule) EvalSignal(value SignalValue) (holds bool, observed string, applies bool) {
+	if r.Type != AlertRuleSignal || value.Signal != r.Signal || (r.NodeID != 0 && value.NodeID != r.NodeID) {
+		return false, "", false
+	}
+	if r.BitMask != 0 {
+		return uint64(value.ValueInt)&r.BitMask != 0, fmt.Sprintf("0x%X", value.ValueInt), true
+	}
+	return r.Compare(value.ValueFloat), strconv.FormatFloat(value.ValueFloat, 'g', -1, 64), true
+}
+
+// EvalStats evaluates a stats rule against interface statistics
+// applies is false when the statistics have no such field.
+func (r *AlertRule) EvalStats(stats SocketCANStats) (holds bool, observed string, applies bool) {
+	if r.Type != AlertRuleStats {
+		return false, "", false
+	}
+
+	// Fields are addressed by their JSON names, so every statistic is available to rules
+	content, err := json.Marshal(stats)
+	if err != nil {
+		return false, "", false
+	}
+	fields := map[string]any{}
+	if err := json.Unmarshal(content, &fields); err != nil {
+		return false, "", false
+	}
+
+	switch field := fields[r.Field].(type) {
+	case float64:
+		if r.Value == nil {
+			return false, "", false
+		}
+		return r.Compare(field), strconv.FormatFloat(field, 'g', -1, 64), true
+	case string:
+		if r.Equals == "" {
+			return false, "", false
+		}
+		return field == r.Equals, field, true
+	}
+	return false, "", false
+}
+
+// EvalPayload evaluates a payload rule against a frame
+// applies is false when the frame is of another CAN ID.
+func (r *AlertRule) EvalPayload(canID uint32, data []byte) (holds bool, observed string, applies bool) {
+	if r.Type != AlertRulePayload || canID != r.CANID {
+		return false, "", false
+	}
+	observed = fmt.Sprintf("% X", data)
+	if len(data) < len(r.data) {
+		return false, observed, true
+	}
+	for i := range r.data {
+		if data[i]&r.mask[i] != r.data[i]&r.mask[i] {
+			return false, observed, true
+		}
+	}
+	return true, observed, true
+}
+
+// Condition describes the rule's condition for notifications, e.g. "rx_error_counter > 96"
+func (r *AlertRule) Condition() string {
+	switch r.Type {
+	case AlertRuleSignal:
+		if r.BitMask != 0 {
+			return fmt.Sprintf("%s & 0x%X", r.Signal, r.BitMask)
+		}
+		return fmt.Sprintf("%s %s %g", r.Signal, r.Op, *r.Value)
+	case AlertRuleStats:
+		if r.Equals != "" {
+			return fmt.Sprintf("%s == %s", r.Field, r.Equals)
+		}
+		return fmt.Sprintf("%s %s %g", r.Field, r.Op, *r.Value)
+	case AlertRulePeriod:
+		return fmt.Sprintf("period of 0x%X %s %gms", r.CANID, r.Op, *r.Value)
+	case AlertRulePayload:
+		return fmt.Sprintf("0x%X payload matches %s", r.CANID, r.Data)
+	}
+	return ""
+}
+
+// AlertRuleFile is a YAML file of alert rules
+// Example:
+//
+//	rules:
+//	  - name: drive3_fault
+//	    type: signal
+//	    signal: statusword
+//	    node_id: 3
+//	    bit_mask: 0x0008
+//	    severity: error
+//	  - name: rx_error_passive
+//	    type: stats
+//	    field: rx_error_counter
+//	    op: ">"
+//	    value: 96
+//	  - name: bus_off
+//	    type: stats
+//	    field: bus_state
+//	    equals: BUS-OFF
+//	    severity: error
+//	    notify: [webhook, stream]
+//	  - name: tpdo1_late
+//	    type: period
+//	    can_id: 0x181
+//	    op: ">"
+//	    value: 12
+//	    for: 1s
+type AlertRuleFile struct {
+	Rules []AlertRule `yaml:"rules"`
+}
+
+// LoadAlertRules loads alert rules from YAML files
+func LoadAlertRules(paths []string) ([]AlertRule, error) {
+	rules := []AlertRule{}
+
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read alert rules %s: %w", path, err)
+		}
+
+		file := &AlertRuleFile{}
+		if err := yaml.Unmarshal(content, file); err != nil {
+			return nil, fmt.Errorf("failed to parse alert rules %s: %w", path, err)
+		}
+
+		for _, rule := range file.Rules {
+			if err := rule.Validate(); err != nil {
+				return nil, fmt.Errorf("%v in %s", err, path)
+			}
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules, nil
+}
+
+// Alert is a firing or resolve notification of an alert rule, one row of the alert history
+type Alert struct {
+	Timestamp time.Time `json:"timestamp"`
+	RuleID    string    `json:"rule_id"`
+	RuleName  string    `json:"rule_name"`
+	Severity  string    `json:"severity"`
+	Status    string    `json:"status"`
+	DeviceID  string    `json:"device_id"`
+	Interface string    `json:"interface"`
+	Subject   string    `json:"subject"` // What the rule fired for, e.g. "node 3", "0x181" or the interface
+	Value     string    `json:"value"`   // Last observed value
+	Message   string    `json:"message"`
+	FiredAt   time.Time `json:"fired_at"` // Start of the firing period
+	Notify    []string  `json:"-"`        // Channels of the rule
+}
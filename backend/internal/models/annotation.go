@@ -0,0 +1,39 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Annotation marks a moment or period on the timeline, e.g. "collision" or "e-stop pressed"
+type Annotation struct {
+	AnnotationID string    `json:"annotation_id"`
+	StartTime    time.Time `json:"start_time"`
+	EndTime      time.Time `json:"end_time"`             // Equal to start_time for a single moment
+	DeviceID     string    `json:"device_id"`            // Empty for every device
+	Interface    string    `json:"interface"`            // Empty for every interface
+	SessionID    string    `json:"session_id,omitempty"` // Recording session the annotation belongs to
+	Author       string    `json:"author"`
+	Text         string    `json:"text"`
+	Tags         []string  `json:"tags"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Validate checks the annotation and fills in the defaults
+func (a *Annotation) Validate() error {
+	if a.Text == "" {
+		return fmt.Errorf("annotation has no text")
+	}
+	if a.StartTime.IsZero() {
+		return fmt.Errorf("annotation has no start_time")
+	}
+	if a.EndTime.IsZero() {
+		a.EndTime = a.StartTime
+	}
+	if a.EndTime.Before(a.StartTime) {
+		return fmt.Errorf("annotation end_time is before start_time")
+	}
+	a.Tags = mergeTags(a.Tags, nil, nil)
+	return nil
+}
@@ -0,0 +1,256 @@
+package models
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+// BusLoadDefaultTop is the number of top contributors listed by default
+const BusLoadDefaultTop = 10
+
+// canFDLengths are the CAN FD payload lengths selectable by the DLC
+var canFDLengths = []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 12, 16, 20, 24, 32, 48, 64}
+
+// FrameBits returns the worst-case length in bits of a frame on the bus, including dynamic stuff bits,
+// the CRC, ACK and EOF fields and the 3-bit interframe space
+// A payload above 8 bytes is a CAN FD frame; its bits after BRS up to the CRC delimiter are returned
+// as dataBits, sent at the data bitrate. Classic frames return all bits as nominalBits.
+func FrameBits(extended bool, dataLen int) (nominalBits, dataBits int) {
+	if dataLen <= 8 {
+		// Classic CAN: g control bits subject to stuffing, 13 bits of fixed-form trailer
+		// (CRC delimiter, ACK slot and delimiter, EOF, IFS)
+		g := 34
+		if extended {
+			g = 54
+		}
+		stuffed := g + 8*dataLen
+		return stuffed + 13 + (stuffed-1)/4, 0
+	}
+
+	dataLen = fdLength(dataLen)
+
+	// Arbitration phase from SOF to BRS, then the nominal-rate trailer
+	arbitration := 17
+	if extended {
+		arbitration = 36
+	}
+	nominalBits = arbitration + (arbitration-1)/4 + 13
+
+	// Data phase: ESI, DLC and payload with dynamic stuffing, then the stuff count and CRC with fixed stuff bits
+	control := 5 + 8*dataLen
+	crcBits, fixedStuff := 17, 6
+	if dataLen > 16 {
+		crcBits, fixedStuff = 21, 7
+	}
+	dataBits = control + (control-1)/4 + 4 + crcBits + fixedStuff
+
+	return nominalBits, dataBits
+}
+
+// fdLength rounds a payload length up to the next length a CAN FD DLC can encode
+func fdLength(n int) int {
+	for _, length := range canFDLengths {
+		if length >= n {
+			return length
+		}
+	}
+	return 64
+}
+
+// BusLoadContributor is the share of the bus time used by one CAN ID
+type BusLoadContributor struct {
+	CANID        uint32  `json:"can_id"`
+	CANIDHex     string  `json:"can_id_hex"`
+	Frames       uint64  `json:"frames"`
+	Bits         uint64  `json:"bits"`
+	SharePercent float64 `json:"share_percent"` // Of the bus time used by all frames
+	LoadPercent  float64 `json:"load_percent"`  // Of the analysed window
+}
+
+// BusLoadBucket is the estimated bus load of one time bucket
+type BusLoadBucket struct {
+	TimeBucket  time.Time `json:"time_bucket"`
+	Frames      uint64    `json:"frames"`
+	Bits        uint64    `json:"bits"`
+	LoadPercent float64   `json:"load_percent"`
+}
+
+// BusLoad is the estimated bus load time series of one interface
+type BusLoad struct {
+	Interface       string               `json:"interface"`
+	Bitrate         uint32               `json:"bitrate"`
+	DataBitrate     uint32               `json:"data_bitrate"`
+	BitrateSource   string               `json:"bitrate_source"` // "stats", "request" or "unknown"
+	StartTime       time.Time            `json:"start_time"`
+	EndTime         time.Time            `json:"end_time"`
+	Frames          uint64               `json:"frames"`
+	Bits            uint64               `json:"bits"`
+	AvgLoadPercent  float64              `json:"avg_load_percent"`
+	PeakLoadPercent float64              `json:"peak_load_percent"`
+	Buckets         []BusLoadBucket      `json:"buckets"`
+	TopContributors []BusLoadContributor `json:"top_contributors"`
+}
+
+// busLoadTotals accumulates frames, bits and bus time
+type busLoadTotals struct {
+	frames  uint64
+	bits    uint64
+	seconds float64
+}
+
+// BusLoadEstimator accumulates frame counts of one interface into a bus load time series
+type BusLoadEstimator struct {
+	load     BusLoad
+	interval time.Duration
+	buckets  map[int64]*busLoadTotals
+	canIDs   map[uint32]*busLoadTotals
+	total    busLoadTotals
+}
+
+// NewBusLoadEstimator creates an estimator for the window [start, end] split into interval buckets
+// A zero dataBitrate sends the FD data phase at the nominal bitrate (no bit rate switch).
+func NewBusLoadEstimator(iface string, bitrate, dataBitrate uint32, source string, start, end time.Time, interval time.Duration) *BusLoadEstimator {
+	if dataBitrate == 0 {
+		dataBitrate = bitrate
+	}
+	return &BusLoadEstimator{
+		load: BusLoad{
+			Interface:     iface,
+			Bitrate:       bitrate,
+			DataBitrate:   dataBitrate,
+			BitrateSource: source,
+			StartTime:     start,
+			EndTime:       end,
+		},
+		interval: interval,
+		buckets:  make(map[int64]*busLoadTotals),
+		canIDs:   make(map[uint32]*busLoadTotals),
+	}
+}
+
+// Add counts frames of a CAN ID (with SocketCAN flags) and payload length in the bucket starting at bucketMicros
+func (e *BusLoadEstimator) Add(bucketMicros int64, canID uint32, dataLen int, frames uint64) {
+	// Error frames are reported by the controller, not sent on the bus
+	if canID&CANErrFlag != 0 {
+		return
+	}
+	if canID&CANRtrFlag != 0 {
+		dataLen = 0
+	}
+
+	nominalBits, dataBits := FrameBits(canID&CANEffFlag != 0, dataLen)
+	bits := uint64(nominalBits+dataBits) * frames
+
+	var seconds float64
+	if e.load.Bitrate > 0 {
+		seconds = (float64(nominalBits)/float64(e.load.Bitrate) + float64(dataBits)/float64(e.load.DataBitrate)) * float64(frames)
+	}
+
+	for _, totals := range []*busLoadTotals{e.bucket(bucketMicros), e.contributor(canID), &e.total} {
+		totals.frames += frames
+		totals.bits += bits
+		totals.seconds += seconds
+	}
+}
+
+// bucket returns the totals of a time bucket
+func (e *BusLoadEstimator) bucket(micros int64) *busLoadTotals {
+	totals, ok := e.buckets[micros]
+	if !ok {
+		totals = &busLoadTotals{}
+		e.buckets[micros] = totals
+	}
+	return totals
+}
+
+// contributor returns the totals of a CAN ID
+func (e *BusLoadEstimator) contributor(canID uint32) *busLoadTotals {
+	totals, ok := e.canIDs[canID]
+	if !ok {
+		totals = &busLoadTotals{}
+		e.canIDs[canID] = totals
+	}
+	return totals
+}
+
+// Result returns the bus load series with the top contributors by bus time
+// The first and last buckets are clipped to the window so partial buckets are not under-reported.
+func (e *BusLoadEstimator) Result(top int) BusLoad {
+	load := e.load
+	load.Frames = e.total.frames
+	load.Bits = e.total.bits
+	load.Buckets = []BusLoadBucket{}
+	load.TopContributors = []BusLoadContributor{}
+
+	window := load.EndTime.Sub(load.StartTime).Seconds()
+	if window > 0 {
+		load.AvgLoadPercent = percent(e.total.seconds, window)
+	}
+
+	for _, micros := range slices.Sorted(maps.Keys(e.buckets)) {
+		totals := e.buckets[micros]
+		bucketStart := time.UnixMicro(micros).UTC()
+		bucketEnd := bucketStart.Add(e.interval)
+
+		bucket := BusLoadBucket{TimeBucket: bucketStart, Frames: totals.frames, Bits: totals.bits}
+		if duration := minTime(bucketEnd, load.EndTime).Sub(maxTime(bucketStart, load.StartTime)).Seconds(); duration > 0 {
+			bucket.LoadPercent = percent(totals.seconds, duration)
+		}
+		load.PeakLoadPercent = max(load.PeakLoadPercent, bucket.LoadPercent)
+		load.Buckets = append(load.Buckets, bucket)
+	}
+
+	for canID, totals := range e.canIDs {
+		contributor := BusLoadContributor{
+			CANID:    canID,
+			CANIDHex: fmt.Sprintf("0x%X", canID),
+			Frames:   totals.frames,
+			Bits:     totals.bits,
+		}
+		if e.total.seconds > 0 {
+			contributor.SharePercent = percent(totals.seconds, e.total.seconds)
+		} else if e.total.bits > 0 {
+			contributor.SharePercent = percent(float64(totals.bits), float64(e.total.bits))
+		}
+		if window > 0 {
+			contributor.LoadPercent = percent(totals.seconds, window)
+		}
+		load.TopContributors = append(load.TopContributors, contributor)
+	}
+
+	slices.SortFunc(load.TopContributors, func(a, b BusLoadContributor) int {
+		if c := cmp.Compare(b.SharePercent, a.SharePercent); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.CANID, b.CANID)
+	})
+	if len(load.TopContributors) > top {
+		load.TopContributors = load.TopContributors[:top]
+	}
+
+	return load
+}
+
+// percent returns part / whole as a percentage rounded to three decimals
+func percent(part, whole float64) float64 {
+	return round3(part / whole * 100)
+}
+
+// minTime returns the earlier of two times
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+// maxTime returns the later of two times
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
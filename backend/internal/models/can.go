@@ -2,6 +2,15 @@ package models
 
 import "time"
 
+// SocketCAN CAN ID flags and masks (linux/can.h)
+const (
+	CANEffFlag uint32 = 0x80000000 // Extended frame format (29-bit ID)
+	CANRtrFlag uint32 = 0x40000000 // Remote transmission request
+	CANErrFlag uint32 = 0x20000000 // Error message frame
+	CANSffMask uint32 = 0x000007FF // Standard frame format ID mask
+	CANEffMask uint32 = 0x1FFFFFFF // Extended frame format ID mask
+)
+
 // CANFrame represents a CAN 2.0 frame
 type CANFrame struct {
 	ID   uint32
@@ -9,11 +18,47 @@ type CANFrame struct {
 	Data [8]byte
 }
 
+// IsExtended reports whether the frame uses a 29-bit identifier
+func (f CANFrame) IsExtended() bool {
+	return f.ID&CANEffFlag != 0
+}
+
+// IsRTR reports whether the frame is a remote transmission request
+func (f CANFrame) IsRTR() bool {
+	return f.ID&CANRtrFlag != 0
+}
+
+// IsError reports whether the frame is a SocketCAN error message frame
+func (f CANFrame) IsError() bool {
+	return f.ID&CANErrFlag != 0
+}
+
+// ArbitrationID returns the CAN identifier without the SocketCAN flags
+func (f CANFrame) ArbitrationID() uint32 {
+	if f.IsExtended() {
+		return f.ID & CANEffMask
+	}
+	return f.ID & CANSffMask
+}
+
+// Payload returns the data bytes covered by the DLC
+func (f CANFrame) Payload() []byte {
+	n := int(f.DLC)
+	if n > len(f.Data) {
+		n = len(f.Data)
+	}
+	return f.Data[:n]
+}
+
 // CANMessage includes the CAN frame and timestamp
 type CANMessage struct {
 	Frame     CANFrame
 	Timestamp time.Time
 	Interface string
+	SessionID string // Recording session active when the frame was received, empty outside sessions
+	Device
+	Direction string // DirectionTX for frames sent from the recording host, empty or DirectionRX for received frames
+	User      string // Requesting user of a transmitted frame
 }
 
 // CANMessageResponse represents a CAN message in API response
@@ -25,4 +70,8 @@ type CANMessageResponse struct {
 	DLC       uint8     `json:"dlc"`
 	Data      []uint8   `json:"data"`
 	DataHex   string    `json:"data_hex"`
+	SessionID string    `json:"session_id,omitempty"`
+	DeviceID  string    `json:"device_id,omitempty"`
+	Direction string    `json:"direction,omitempty"`
+	User      string    `json:"user,omitempty"`
 }
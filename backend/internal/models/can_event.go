@@ -0,0 +1,120 @@
+package models
+
+import "time"
+
+// Event severities
+const (
+	SeverityInfo    = "info"
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+// Event types raised by the can-reader monitors
+const (
+	EventHeartbeatTimeout    = "heartbeat_timeout"
+	EventHeartbeatRecovered  = "heartbeat_recovered"
+	EventGuardingToggleError = "guarding_toggle_error"
+	EventUnexpectedBootup    = "unexpected_bootup"
+)
+
+// Event types recorded by the can-reader when it starts and stops capturing
+const (
+	EventReaderStarted = "reader_started"
+	EventReaderStopped = "reader_stopped"
+)
+
+// Event types recorded by the API server when a cyclic transmission job is changed
+const (
+	EventCyclicJobCreated = "cyclic_job_created"
+	EventCyclicJobUpdated = "cyclic_job_updated"
+	EventCyclicJobDeleted = "cyclic_job_deleted"
+)
+
+// EventFrameSent is recorded by the API server for every frame it transmits, with the operator
+// The frame itself is recorded by the CAN Reader from its loopback, with the same kernel timestamp.
+const EventFrameSent = "frame_sent"
+
+// EventSDOWrite is recorded by the API server for every SDO write of an object dictionary entry
+const EventSDOWrite = "sdo_write"
+
+// CANEvent is an event detected on the bus, e.g. a missed heartbeat
+type CANEvent struct {
+	Timestamp time.Time         `json:"timestamp"`
+	Interface string            `json:"interface"`
+	NodeID    uint8             `json:"node_id"`
+	EventType string            `json:"event_type"`
+	Severity  string            `json:"severity"`
+	Message   string            `json:"message"`
+	Details   map[string]string `json:"details,omitempty"`
+	Device
+}
+
+// Live stream message types
+const (
+	StreamTypeFrame   = "frame"
+	StreamTypeEvent   = "event"
+	StreamTypeSniffer = "sniffer"
+	StreamTypeAlert   = "alert"
+)
+
+// StreamMessage is a message published to live stream subscribers
+// Frames carry id/data at the top level so simple clients can read them directly.
+type StreamMessage struct {
+	Type      string           `json:"type"`
+	Timestamp time.Time        `json:"timestamp"`
+	Interface string           `json:"interface"`
+	ID        *uint32          `json:"id,omitempty"`
+	Extended  bool             `json:"extended,omitempty"`
+	Data      []int            `json:"data,omitempty"`
+	Event     *CANEvent        `json:"event,omitempty"`
+	Changes   []PayloadChanges `json:"changes,omitempty"`
+	Alert     *Alert           `json:"alert,omitempty"`
+}
+
+// NewFrameStreamMessage creates a stream message for a received CAN frame
+func NewFrameStreamMessage(msg CANMessage) StreamMessage {
+	id := msg.Frame.ArbitrationID()
+	payload := msg.Frame.Payload()
+	data := make([]int, len(payload))
+	for i, b := range payload {
+		data[i] = int(b)
+	}
+
+	return StreamMessage{
+		Type:      StreamTypeFrame,
+		Timestamp: msg.Timestamp,
+		Interface: msg.Interface,
+		ID:        &id,
+		Extended:  msg.Frame.IsExtended(),
+		Data:      data,
+	}
+}
+
+// NewEventStreamMessage creates a stream message for an event
+func NewEventStreamMessage(event CANEvent) StreamMessage {
+	return StreamMessage{
+		Type:      StreamTypeEvent,
+		Timestamp: event.Timestamp,
+		Interface: event.Interface,
+		Event:     &event,
+	}
+}
+
+// NewSnifferStreamMessage creates a stream message for the payload changes of the past interval
+func NewSnifferStreamMessage(changes []PayloadChanges) StreamMessage {
+	return StreamMessage{
+		Type:      StreamTypeSniffer,
+		Timestamp: time.Now(),
+		Changes:   changes,
+	}
+}
+
+// NewAlertStreamMessage creates a stream message for a firing or resolve notification
+func NewAlertStreamMessage(alert Alert) StreamMessage {
+	return StreamMessage{
+		Type:      StreamTypeAlert,
+		Timestamp: alert.Timestamp,
+		Interface: alert.Interface,
+		Alert:     &alert,
+	}
+}
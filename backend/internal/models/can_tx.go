@@ -0,0 +1,105 @@
+package models
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frame directions of the messages table
+const (
+	DirectionRX = "rx" // Received from the bus
+	DirectionTX = "tx" // Transmitted from the recording host, looped back by the kernel
+)
+
+// TX confirmations
+const (
+	TxConfirmEcho     = "echo"     // Echoed by the driver once the frame was sent on the bus
+	TxConfirmLoopback = "loopback" // Looped back by the kernel when queued to a driver without echo support
+)
+
+// CANSendRequest is the request to transmit a single frame
+type CANSendRequest struct {
+	Interface string `json:"interface"`
+	CANID     uint32 `json:"can_id"`     // With SocketCAN flags for extended IDs and remote frames
+	Data      []byte `json:"data"`       // Up to 8 bytes, a JSON array or base64
+	User      string `json:"-"`          // Authenticated operator, recorded with the frame
+	TimeoutMs int    `json:"timeout_ms"` // Wait for the confirmation, default 1000
+}
+
+// CANSendResult is the confirmation of a transmitted frame
+type CANSendResult struct {
+	Interface    string    `json:"interface"`
+	CANID        uint32    `json:"can_id"`
+	CANIDHex     string    `json:"can_id_hex"`
+	DLC          uint8     `json:"dlc"`
+	Data         []uint8   `json:"data"`
+	DataHex      string    `json:"data_hex"`
+	User         string    `json:"user,omitempty"`
+	Confirmation string    `json:"confirmation"` // "echo" or "loopback"
+	TxTimestamp  time.Time `json:"tx_timestamp"` // Kernel timestamp of the confirmation
+	LatencyUs    int64     `json:"latency_us"`   // From the write to the confirmation
+	Logged       bool      `json:"logged"`       // Recorded as a frame_sent event
+}
+
+// TxOperator is a user allowed to transmit through the API, identified by a bearer token
+type TxOperator struct {
+	User  string
+	Token string
+	Nodes []uint8 // CANopen nodes the operator may command, empty for every node, broadcasts and raw frames
+}
+
+// Allows reports whether the operator may act on a CANopen node, 0 being a broadcast or a raw frame
+func (o TxOperator) Allows(nodeID uint8) bool {
+	if len(o.Nodes) == 0 {
+		return true
+	}
+	for _, id := range o.Nodes {
+		if id == nodeID && nodeID != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTxOperators parses USER:TOKEN or USER:TOKEN:NODES entries, NODES separated by ';' (e.g. "lee:s3cret:3;4")
+func ParseTxOperators(entries []string) ([]TxOperator, error) {
+	operators := make([]TxOperator, 0, len(entries))
+	tokens := make(map[string]bool)
+	for _, entry := range entries {
+		parts := strings.Split(entry, ":")
+		if len(parts) < 2 || len(parts) > 3 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+			return nil, fmt.Errorf("invalid operator '%s', must be USER:TOKEN or USER:TOKEN:NODES", entry)
+		}
+
+		operator := TxOperator{User: strings.TrimSpace(parts[0]), Token: strings.TrimSpace(parts[1])}
+		if tokens[operator.Token] {
+			return nil, fmt.Errorf("operator '%s' reuses the token of another operator", operator.User)
+		}
+		tokens[operator.Token] = true
+
+		if len(parts) == 3 {
+			for _, node := range strings.Split(parts[2], ";") {
+				nodeID, err := strconv.ParseUint(strings.TrimSpace(node), 0, 8)
+				if err != nil || nodeID < 1 || nodeID > 127 {
+					return nil, fmt.Errorf("invalid node '%s' of operator '%s', must be 1-127", node, operator.User)
+				}
+				operator.Nodes = append(operator.Nodes, uint8(nodeID))
+			}
+		}
+		operators = append(operators, operator)
+	}
+	return operators, nil
+}
+
+// FindTxOperator returns the operator of a bearer token
+func FindTxOperator(operators []TxOperator, token string) (TxOperator, bool) {
+	for _, operator := range operators {
+		if subtle.ConstantTimeCompare([]byte(operator.Token), []byte(token)) == 1 {
+			return operator, true
+		}
+	}
+	return TxOperator{}, false
+}
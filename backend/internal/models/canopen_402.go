@@ -0,0 +1,471 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// CiA 402 objects of the first axis; axis n uses index + 0x800*(n-1)
+const (
+	DS402ObjControlword      uint16 = 0x6040
+	DS402ObjStatusword       uint16 = 0x6041
+	DS402ObjModesOfOp        uint16 = 0x6060
+	DS402ObjModesOfOpDisplay uint16 = 0x6061
+)
+
+// DS402MaxAxes is the number of axes a CiA 402 device can expose (0x6000-0x67FF ... 0x9800-0x9FFF)
+const DS402MaxAxes = 8
+
+// DS402State represents a state of the CiA 402 power drive state machine
+type DS402State uint8
+
+const (
+	DS402StateUnknown DS402State = iota
+	DS402StateNotReadyToSwitchOn
+	DS402StateSwitchOnDisabled
+	DS402StateReadyToSwitchOn
+	DS402StateSwitchedOn
+	DS402StateOperationEnabled
+	DS402StateQuickStopActive
+	DS402StateFaultReactionActive
+	DS402StateFault
+)
+
+// String returns the state name
+func (s DS402State) String() string {
+	switch s {
+	case DS402StateNotReadyToSwitchOn:
+		return "not_ready_to_switch_on"
+	case DS402StateSwitchOnDisabled:
+		return "switch_on_disabled"
+	case DS402StateReadyToSwitchOn:
+		return "ready_to_switch_on"
+	case DS402StateSwitchedOn:
+		return "switched_on"
+	case DS402StateOperationEnabled:
+		return "operation_enabled"
+	case DS402StateQuickStopActive:
+		return "quick_stop_active"
+	case DS402StateFaultReactionActive:
+		return "fault_reaction_active"
+	case DS402StateFault:
+		return "fault"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText encodes the state by name in JSON
+func (s DS402State) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// IsFault reports whether the state belongs to the fault branch of the state machine
+func (s DS402State) IsFault() bool {
+	return s == DS402StateFault || s == DS402StateFaultReactionActive
+}
+
+// DecodeStatusword derives the drive state from statusword bits 0-3, 5 and 6
+func DecodeStatusword(statusword uint16) DS402State {
+	switch {
+	case statusword&0x4F == 0x00:
+		return DS402StateNotReadyToSwitchOn
+	case statusword&0x4F == 0x40:
+		return DS402StateSwitchOnDisabled
+	case statusword&0x6F == 0x21:
+		return DS402StateReadyToSwitchOn
+	case statusword&0x6F == 0x23:
+		return DS402StateSwitchedOn
+	case statusword&0x6F == 0x27:
+		return DS402StateOperationEnabled
+	case statusword&0x6F == 0x07:
+		return DS402StateQuickStopActive
+	case statusword&0x4F == 0x0F:
+		return DS402StateFaultReactionActive
+	case statusword&0x4F == 0x08:
+		return DS402StateFault
+	default:
+		return DS402StateUnknown
+	}
+}
+
+// statuswordFlags names the statusword bits that are not part of the state encoding
+var statuswordFlags = []struct {
+	bit  uint
+	name string
+}{
+	{4, "voltage_enabled"},
+	{7, "warning"},
+	{9, "remote"},
+	{10, "target_reached"},
+	{11, "internal_limit_active"},
+	{12, "operation_mode_specific_12"},
+	{13, "operation_mode_specific_13"},
+}
+
+// DecodeStatuswordFlags returns the names of the set statusword flag bits
+func DecodeStatuswordFlags(statusword uint16) []string {
+	flags := []string{}
+	for _, flag := range statuswordFlags {
+		if statusword&(1<<flag.bit) != 0 {
+			flags = append(flags, flag.name)
+		}
+	}
+	return flags
+}
+
+// DS402Command is a device control command encoded in the controlword
+type DS402Command string
+
+const (
+	DS402CmdNone            DS402Command = ""
+	DS402CmdShutdown        DS402Command = "shutdown"
+	DS402CmdSwitchOn        DS402Command = "switch_on" // Also "disable operation" from Operation Enabled
+	DS402CmdEnableOperation DS402Command = "enable_operation"
+	DS402CmdDisableVoltage  DS402Command = "disable_voltage"
+	DS402CmdQuickStop       DS402Command = "quick_stop"
+	DS402CmdFaultReset      DS402Command = "fault_reset"
+)
+
+// DecodeControlword derives the device control command from controlword bits 0-3 and 7
+// Fault reset is only a command on the rising edge of bit 7; the caller tracks the edge.
+func DecodeControlword(controlword uint16) DS402Command {
+	switch {
+	case controlword&0x80 != 0:
+		return DS402CmdFaultReset
+	case controlword&0x02 == 0:
+		return DS402CmdDisableVoltage
+	case controlword&0x06 == 0x02:
+		return DS402CmdQuickStop
+	case controlword&0x07 == 0x06:
+		return DS402CmdShutdown
+	case controlword&0x0F == 0x07:
+		return DS402CmdSwitchOn
+	case controlword&0x0F == 0x0F:
+		return DS402CmdEnableOperation
+	default:
+		return DS402CmdNone
+	}
+}
+
+// ds402ModeNames names the modes of operation (0x6060/0x6061)
+var ds402ModeNames = map[int8]string{
+	0:  "no_mode",
+	1:  "profile_position",
+	2:  "velocity",
+	3:  "profile_velocity",
+	4:  "profile_torque",
+	6:  "homing",
+	7:  "interpolated_position",
+	8:  "cyclic_synchronous_position",
+	9:  "cyclic_synchronous_velocity",
+	10: "cyclic_synchronous_torque",
+}
+
+// DS402ModeName returns the name of a mode of operation; negative modes are manufacturer-specific
+func DS402ModeName(mode int8) string {
+	if name, ok := ds402ModeNames[mode]; ok {
+		return name
+	}
+	if mode < 0 {
+		return fmt.Sprintf("manufacturer_specific_%d", mode)
+	}
+	return fmt.Sprintf("reserved_%d", mode)
+}
+
+// DS402Object splits an object index into the first-axis CiA 402 object and the axis number (1-8)
+func DS402Object(index uint16) (uint16, int, bool) {
+	for axis := 0; axis < DS402MaxAxes; axis++ {
+		base := index - 0x800*uint16(axis)
+		switch base {
+		case DS402ObjControlword, DS402ObjStatusword, DS402ObjModesOfOp, DS402ObjModesOfOpDisplay:
+			return base, axis + 1, true
+		}
+	}
+	return 0, 0, false
+}
+
+// Sources of a CiA 402 object value
+const (
+	DS402SourcePDO = "pdo"
+	DS402SourceSDO = "sdo"
+)
+
+// DS402StateChange is a transition of the drive state machine
+type DS402StateChange struct {
+	Timestamp     time.Time    `json:"timestamp"`
+	From          DS402State   `json:"from"`
+	To            DS402State   `json:"to"`
+	Statusword    uint16       `json:"statusword"`
+	StatuswordHex string       `json:"statusword_hex"`
+	Command       DS402Command `json:"command,omitempty"` // Last controlword command before the transition
+	Source        string       `json:"source"`
+}
+
+// DS402ModeChange is a change of the requested (0x6060) or displayed (0x6061) mode of operation
+type DS402ModeChange struct {
+	Timestamp time.Time `json:"timestamp"`
+	Object    string    `json:"object"` // "modes_of_operation" or "modes_of_operation_display"
+	Mode      int8      `json:"mode"`
+	ModeName  string    `json:"mode_name"`
+	Source    string    `json:"source"`
+}
+
+// DS402Fault is a single stay of the drive in the fault branch and its reset sequence
+type DS402Fault struct {
+	Start         time.Time    `json:"start"`
+	Statusword    uint16       `json:"statusword"`
+	PreviousState DS402State   `json:"previous_state"`
+	ResetRequests []time.Time  `json:"reset_requests"` // Rising edges of controlword bit 7
+	Cleared       *time.Time   `json:"cleared,omitempty"`
+	Recovered     *time.Time   `json:"recovered,omitempty"` // Back in Operation Enabled
+	DurationMs    *float64     `json:"duration_ms,omitempty"`
+	ResetLatency  *float64     `json:"reset_latency_ms,omitempty"` // Last reset request to leaving the fault
+	RecoveryMs    *float64     `json:"recovery_ms,omitempty"`      // Fault start to Operation Enabled
+	ClearedBy     DS402Command `json:"cleared_by,omitempty"`
+}
+
+// DS402FaultSummary aggregates the faults of an axis
+type DS402FaultSummary struct {
+	Faults           int         `json:"faults"`
+	Active           bool        `json:"active"`
+	TotalFaultTimeMs float64     `json:"total_fault_time_ms"`
+	ResetRequests    int         `json:"reset_requests"`
+	FailedResets     int         `json:"failed_resets"` // Reset requests that did not clear the fault
+	LastFault        *DS402Fault `json:"last_fault,omitempty"`
+}
+
+// DS402Axis is the decoded state and history of a single drive axis
+type DS402Axis struct {
+	Interface       string             `json:"interface"`
+	NodeID          uint8              `json:"node_id"`
+	Axis            int                `json:"axis"`
+	State           DS402State         `json:"state"`
+	Statusword      uint16             `json:"statusword"`
+	StatuswordHex   string             `json:"statusword_hex"`
+	StatusFlags     []string           `json:"status_flags"`
+	Controlword     *uint16            `json:"controlword,omitempty"`
+	LastCommand     DS402Command       `json:"last_command,omitempty"`
+	ModeOfOperation *int8              `json:"mode_of_operation,omitempty"`
+	ModeDisplay     *int8              `json:"mode_of_operation_display,omitempty"`
+	ModeDisplayName string             `json:"mode_of_operation_display_name,omitempty"`
+	LastUpdate      time.Time          `json:"last_update"`
+	StateHistory    []DS402StateChange `json:"state_history"`
+	ModeHistory     []DS402ModeChange  `json:"mode_history"`
+	Faults          []*DS402Fault      `json:"faults"`
+	FaultSummary    DS402FaultSummary  `json:"fault_summary"`
+
+	hasStatusword bool
+	activeFault   *DS402Fault
+	lastFault     *DS402Fault // Most recent fault awaiting Operation Enabled
+}
+
+// DS402Tracker replays controlword, statusword and mode frames into per-axis state histories
+type DS402Tracker struct {
+	axes map[string]*DS402Axis
+}
+
+// NewDS402Tracker creates a new CiA 402 tracker
+func NewDS402Tracker() *DS402Tracker {
+	return &DS402Tracker{axes: make(map[string]*DS402Axis)}
+}
+
+// axis returns the state of an axis, creating it on first use
+func (t *DS402Tracker) axis(iface string, nodeID uint8, axisNo int) *DS402Axis {
+	key := fmt.Sprintf("%s/%d/%d", iface, nodeID, axisNo)
+	axis, ok := t.axes[key]
+	if !ok {
+		axis = &DS402Axis{
+			Interface:    iface,
+			NodeID:       nodeID,
+			Axis:         axisNo,
+			StatusFlags:  []string{},
+			StateHistory: []DS402StateChange{},
+			ModeHistory:  []DS402ModeChange{},
+			Faults:       []*DS402Fault{},
+		}
+		t.axes[key] = axis
+	}
+	return axis
+}
+
+// AddFrame decodes the CiA 402 objects of a PDO (resolved through pdoMap) or an expedited SDO
+// of one of the given drive nodes; frames are expected in time order
+func (t *DS402Tracker) AddFrame(timestamp time.Time, iface string, canID uint32, data []byte, pdoMap *PDOObjectMap, drives map[uint8]bool) {
+	if layout, ok := pdoMap.Get(canID); ok {
+		for _, object := range layout.Objects {
+			if _, _, ok := DS402Object(object.Index); !ok {
+				continue
+			}
+			if value, ok := object.Extract(data); ok {
+				t.AddObject(timestamp, iface, layout.NodeID, object.Index, value, DS402SourcePDO)
+			}
+		}
+		return
+	}
+
+	if sdo, ok := ParseExpeditedSDO(canID, data); ok && drives[sdo.NodeID] {
+		t.AddObject(timestamp, iface, sdo.NodeID, sdo.Index, sdo.Value, DS402SourceSDO)
+	}
+}
+
+// AddObject applies a value of a CiA 402 object; other objects are ignored
+func (t *DS402Tracker) AddObject(timestamp time.Time, iface string, nodeID uint8, index uint16, value uint64, source string) {
+	object, axisNo, ok := DS402Object(index)
+	if !ok {
+		return
+	}
+
+	axis := t.axis(iface, nodeID, axisNo)
+	axis.LastUpdate = timestamp
+
+	switch object {
+	case DS402ObjStatusword:
+		axis.addStatusword(timestamp, uint16(value), source)
+	case DS402ObjControlword:
+		axis.addControlword(timestamp, uint16(value))
+	case DS402ObjModesOfOp:
+		mode := int8(value)
+		if axis.ModeOfOperation == nil || *axis.ModeOfOperation != mode {
+			axis.ModeHistory = append(axis.ModeHistory, DS402ModeChange{
+				Timestamp: timestamp, Object: "modes_of_operation", Mode: mode, ModeName: DS402ModeName(mode), Source: source,
+			})
+		}
+		axis.ModeOfOperation = &mode
+	case DS402ObjModesOfOpDisplay:
+		mode := int8(value)
+		if axis.ModeDisplay == nil || *axis.ModeDisplay != mode {
+			axis.ModeHistory = append(axis.ModeHistory, DS402ModeChange{
+				Timestamp: timestamp, Object: "modes_of_operation_display", Mode: mode, ModeName: DS402ModeName(mode), Source: source,
+			})
+		}
+		axis.ModeDisplay = &mode
+		axis.ModeDisplayName = DS402ModeName(mode)
+	}
+}
+
+// addControlword records the command and detects fault reset requests (rising edge of bit 7)
+func (a *DS402Axis) addControlword(timestamp time.Time, controlword uint16) {
+	risingReset := controlword&0x80 != 0 && (a.Controlword == nil || *a.Controlword&0x80 == 0)
+
+	if command := DecodeControlword(controlword); command != DS402CmdFaultReset || risingReset {
+		a.LastCommand = command
+	}
+	if risingReset && a.activeFault != nil {
+		a.activeFault.ResetRequests = append(a.activeFault.ResetRequests, timestamp)
+	}
+
+	a.Controlword = &controlword
+}
+
+// addStatusword updates the state and the fault bookkeeping
+func (a *DS402Axis) addStatusword(timestamp time.Time, statusword uint16, source string) {
+	state := DecodeStatusword(statusword)
+	previous := a.State
+
+	a.Statusword = statusword
+	a.StatuswordHex = fmt.Sprintf("0x%04X", statusword)
+	a.StatusFlags = DecodeStatuswordFlags(statusword)
+
+	if a.hasStatusword && state == previous {
+		return
+	}
+	a.hasStatusword = true
+	a.State = state
+
+	a.StateHistory = append(a.StateHistory, DS402StateChange{
+		Timestamp:     timestamp,
+		From:          previous,
+		To:            state,
+		Statusword:    statusword,
+		StatuswordHex: a.StatuswordHex,
+		Command:       a.LastCommand,
+		Source:        source,
+	})
+
+	switch {
+	case state.IsFault() && a.activeFault == nil:
+		a.activeFault = &DS402Fault{
+			Start:         timestamp,
+			Statusword:    statusword,
+			PreviousState: previous,
+			ResetRequests: []time.Time{},
+		}
+		a.Faults = append(a.Faults, a.activeFault)
+		a.lastFault = a.activeFault
+
+	case !state.IsFault() && a.activeFault != nil:
+		fault := a.activeFault
+		cleared := timestamp
+		duration := msBetween(fault.Start, cleared)
+		fault.Cleared = &cleared
+		fault.DurationMs = &duration
+		if n := len(fault.ResetRequests); n > 0 {
+			latency := msBetween(fault.ResetRequests[n-1], cleared)
+			fault.ResetLatency = &latency
+			fault.ClearedBy = DS402CmdFaultReset
+		}
+		a.activeFault = nil
+	}
+
+	if state == DS402StateOperationEnabled && a.lastFault != nil && a.lastFault.Cleared != nil {
+		recovered := timestamp
+		recovery := msBetween(a.lastFault.Start, recovered)
+		a.lastFault.Recovered = &recovered
+		a.lastFault.RecoveryMs = &recovery
+		a.lastFault = nil
+	}
+}
+
+// summarize computes the fault summary of the axis
+func (a *DS402Axis) summarize() {
+	summary := DS402FaultSummary{
+		Faults: len(a.Faults),
+		Active: a.activeFault != nil,
+	}
+
+	for _, fault := range a.Faults {
+		summary.ResetRequests += len(fault.ResetRequests)
+		if fault.Cleared != nil {
+			summary.TotalFaultTimeMs += *fault.DurationMs
+			if len(fault.ResetRequests) > 1 {
+				summary.FailedResets += len(fault.ResetRequests) - 1
+			}
+		} else {
+			summary.TotalFaultTimeMs += msBetween(fault.Start, a.LastUpdate)
+			summary.FailedResets += len(fault.ResetRequests)
+		}
+	}
+	if len(a.Faults) > 0 {
+		summary.LastFault = a.Faults[len(a.Faults)-1]
+	}
+
+	a.FaultSummary = summary
+}
+
+// Axes returns the tracked axes sorted by interface, node ID and axis number
+func (t *DS402Tracker) Axes() []*DS402Axis {
+	axes := make([]*DS402Axis, 0, len(t.axes))
+	for _, axis := range t.axes {
+		axis.summarize()
+		axes = append(axes, axis)
+	}
+
+	sort.Slice(axes, func(i, j int) bool {
+		if axes[i].Interface != axes[j].Interface {
+			return axes[i].Interface < axes[j].Interface
+		}
+		if axes[i].NodeID != axes[j].NodeID {
+			return axes[i].NodeID < axes[j].NodeID
+		}
+		return axes[i].Axis < axes[j].Axis
+	})
+
+	return axes
+}
+
+// msBetween returns the duration between two timestamps in milliseconds
+func msBetween(from, to time.Time) float64 {
+	return float64(to.Sub(from)) / float64(time.Millisecond)
+}
@@ -0,0 +1,59 @@
+package models
+
+// CANopen predefined connection set message types, as labelled by the CANopen message API
+const (
+	CANopenNMT       = "NMT"
+	CANopenSYNC      = "SYNC"
+	CANopenEMCY      = "EMCY"
+	CANopenSDOTx     = "SDO_TX"
+	CANopenSDORx     = "SDO_RX"
+	CANopenHeartbeat = "HEARTBEAT"
+	CANopenUnknown   = "UNKNOWN"
+)
+
+// canopenFunctionCodes are the node-specific COB-ID ranges of the predefined connection set
+var canopenFunctionCodes = []struct {
+	base        uint32
+	messageType string
+}{
+	{0x080, CANopenEMCY},
+	{0x180, "TPDO1"},
+	{0x200, "RPDO1"},
+	{0x280, "TPDO2"},
+	{0x300, "RPDO2"},
+	{0x380, "TPDO3"},
+	{0x400, "RPDO3"},
+	{0x480, "TPDO4"},
+	{0x500, "RPDO4"},
+	{0x580, CANopenSDOTx},
+	{0x600, CANopenSDORx},
+	{0x700, CANopenHeartbeat},
+}
+
+// ClassifyCANopen returns the predefined connection set message type and node ID of a CAN ID
+// Extended frames and unassigned COB-IDs are UNKNOWN with node ID 0.
+func ClassifyCANopen(canID uint32) (string, uint8) {
+	switch {
+	case canID == 0x000:
+		return CANopenNMT, 0
+	case canID == 0x080:
+		return CANopenSYNC, 0
+	}
+
+	for _, fc := range canopenFunctionCodes {
+		if canID > fc.base && canID <= fc.base+0x7F {
+			return fc.messageType, uint8(canID - fc.base)
+		}
+	}
+	return CANopenUnknown, 0
+}
+
+// CANopenCOBID returns the predefined connection set COB-ID of a message type for a node
+func CANopenCOBID(messageType string, nodeID uint8) (uint32, bool) {
+	for _, fc := range canopenFunctionCodes {
+		if fc.messageType == messageType {
+			return fc.base + uint32(nodeID), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,410 @@
+package models
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CANopen object dictionary data types (CiA 301)
+const (
+	ODTypeBoolean       uint16 = 0x0001
+	ODTypeInteger8      uint16 = 0x0002
+	ODTypeInteger16     uint16 = 0x0003
+	ODTypeInteger32     uint16 = 0x0004
+	ODTypeUnsigned8     uint16 = 0x0005
+	ODTypeUnsigned16    uint16 = 0x0006
+	ODTypeUnsigned32    uint16 = 0x0007
+	ODTypeReal32        uint16 = 0x0008
+	ODTypeVisibleString uint16 = 0x0009
+	ODTypeOctetString   uint16 = 0x000A
+	ODTypeUnicodeString uint16 = 0x000B
+	ODTypeDomain        uint16 = 0x000F
+	ODTypeReal64        uint16 = 0x0011
+	ODTypeInteger64     uint16 = 0x0015
+	ODTypeUnsigned64    uint16 = 0x001B
+)
+
+// ODEntry is a single object dictionary entry (index/subindex) from an EDS or DCF file
+type ODEntry struct {
+	Index          uint16 `json:"index"`
+	SubIndex       uint8  `json:"sub_index"`
+	ParameterName  string `json:"parameter_name"`
+	ObjectType     uint8  `json:"object_type"`
+	DataType       uint16 `json:"data_type"`
+	AccessType     string `json:"access_type"`
+	DefaultValue   string `json:"default_value,omitempty"`
+	ParameterValue string `json:"parameter_value,omitempty"` // DCF only
+	PDOMapping     bool   `json:"pdo_mapping"`
+}
+
+// RawValue returns the configured value of the entry, preferring the DCF ParameterValue
+func (e *ODEntry) RawValue() string {
+	if e.ParameterValue != "" {
+		return e.ParameterValue
+	}
+	return e.DefaultValue
+}
+
+// IntValue evaluates the entry's value as an integer, resolving $NODEID expressions
+func (e *ODEntry) IntValue(nodeID uint8) (uint64, error) {
+	return ParseEDSInteger(e.RawValue(), nodeID)
+}
+
+// EDSDeviceInfo holds the [DeviceInfo] section of an EDS file
+type EDSDeviceInfo struct {
+	VendorName     string `json:"vendor_name"`
+	VendorNumber   uint32 `json:"vendor_number"`
+	ProductName    string `json:"product_name"`
+	ProductNumber  uint32 `json:"product_number"`
+	RevisionNumber uint32 `json:"revision_number"`
+	OrderCode      string `json:"order_code,omitempty"`
+}
+
+// EDSFile is a parsed EDS or DCF file
+type EDSFile struct {
+	FileName   string                        `json:"file_name"`
+	NodeID     uint8                         `json:"node_id"` // From [DeviceComissioning] or the file name
+	NodeName   string                        `json:"node_name,omitempty"`
+	DeviceInfo EDSDeviceInfo                 `json:"device_info"`
+	Objects    map[uint16]map[uint8]*ODEntry `json:"-"`
+	Sections   map[string][]uint16           `json:"-"` // Object lists: mandatory, optional and manufacturer
+}
+
+// Entry looks up an object dictionary entry
+func (f *EDSFile) Entry(index uint16, subIndex uint8) (*ODEntry, bool) {
+	subs, ok := f.Objects[index]
+	if !ok {
+		return nil, false
+	}
+	entry, ok := subs[subIndex]
+	return entry, ok
+}
+
+// Section returns the indexes of an object list section (mandatory, optional or manufacturer) in file order
+func (f *EDSFile) Section(name string) ([]uint16, bool) {
+	indexes, ok := f.Sections[strings.ToLower(name)]
+	return indexes, ok
+}
+
+// Entries returns all entries of an index ordered by subindex
+func (f *EDSFile) Entries(index uint16) []*ODEntry {
+	subs := f.Objects[index]
+	entries := make([]*ODEntry, 0, len(subs))
+	for _, entry := range subs {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].SubIndex < entries[j].SubIndex })
+	return entries
+}
+
+var (
+	edsObjectSection = regexp.MustCompile(`^([0-9A-Fa-f]{4})$`)
+	edsSubSection    = regexp.MustCompile(`^([0-9A-Fa-f]{4})sub([0-9A-Fa-f]{1,2})$`)
+	edsNodeFileName  = regexp.MustCompile(`^(?:node[_-]?)?(\d{1,3})(?:[_.-].*)?$`)
+)
+
+// ParseEDS parses an EDS or DCF file
+func ParseEDS(r io.Reader) (*EDSFile, error) {
+	file := &EDSFile{
+		Objects:  make(map[uint16]map[uint8]*ODEntry),
+		Sections: make(map[string][]uint16),
+	}
+
+	var section string
+	var entry *ODEntry
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			entry = nil
+
+			if m := edsObjectSection.FindStringSubmatch(section); m != nil {
+				index, _ := strconv.ParseUint(m[1], 16, 16)
+				entry = file.addEntry(uint16(index), 0)
+			} else if m := edsSubSection.FindStringSubmatch(section); m != nil {
+				index, _ := strconv.ParseUint(m[1], 16, 16)
+				subIndex, _ := strconv.ParseUint(m[2], 16, 8)
+				entry = file.addEntry(uint16(index), uint8(subIndex))
+			}
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key := strings.TrimSpace(parts[0])
+		value := strings.TrimSpace(parts[1])
+
+		if entry != nil {
+			entry.set(key, value)
+			continue
+		}
+
+		switch strings.ToLower(section) {
+		case "deviceinfo":
+			file.DeviceInfo.set(key, value)
+		case "devicecomissioning", "devicecommissioning":
+			switch strings.ToLower(key) {
+			case "nodeid":
+				if n, err := ParseEDSInteger(value, 0); err == nil {
+					file.NodeID = uint8(n)
+				}
+			case "nodename":
+				file.NodeName = value
+			}
+		case "mandatoryobjects", "optionalobjects", "manufacturerobjects":
+			// Numbered entries list the indexes, SupportedObjects counts them
+			if _, err := strconv.Atoi(key); err != nil {
+				continue
+			}
+			if index, err := ParseEDSInteger(value, 0); err == nil && index <= 0xFFFF {
+				name := strings.TrimSuffix(strings.ToLower(section), "objects")
+				file.Sections[name] = append(file.Sections[name], uint16(index))
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read EDS: %w", err)
+	}
+
+	return file, nil
+}
+
+// addEntry creates the entry for index/subindex
+func (f *EDSFile) addEntry(index uint16, subIndex uint8) *ODEntry {
+	if f.Objects[index] == nil {
+		f.Objects[index] = make(map[uint8]*ODEntry)
+	}
+	entry := &ODEntry{Index: index, SubIndex: subIndex}
+	f.Objects[index][subIndex] = entry
+	return entry
+}
+
+// set assigns an EDS key of an object section
+func (e *ODEntry) set(key, value string) {
+	switch strings.ToLower(key) {
+	case "parametername":
+		e.ParameterName = value
+	case "objecttype":
+		n, _ := ParseEDSInteger(value, 0)
+		e.ObjectType = uint8(n)
+	case "datatype":
+		n, _ := ParseEDSInteger(value, 0)
+		e.DataType = uint16(n)
+	case "accesstype":
+		e.AccessType = strings.ToLower(value)
+	case "defaultvalue":
+		e.DefaultValue = value
+	case "parametervalue":
+		e.ParameterValue = value
+	case "pdomapping":
+		e.PDOMapping = value == "1"
+	}
+}
+
+// set assigns an EDS key of the [DeviceInfo] section
+func (d *EDSDeviceInfo) set(key, value string) {
+	switch strings.ToLower(key) {
+	case "vendorname":
+		d.VendorName = value
+	case "vendornumber":
+		n, _ := ParseEDSInteger(value, 0)
+		d.VendorNumber = uint32(n)
+	case "productname":
+		d.ProductName = value
+	case "productnumber":
+		n, _ := ParseEDSInteger(value, 0)
+		d.ProductNumber = uint32(n)
+	case "revisionnumber":
+		n, _ := ParseEDSInteger(value, 0)
+		d.RevisionNumber = uint32(n)
+	case "ordercode":
+		d.OrderCode = value
+	}
+}
+
+// ParseEDSInteger parses an EDS integer value (decimal, 0x hex or 0 octal),
+// including "$NODEID+..." expressions
+func ParseEDSInteger(value string, nodeID uint8) (uint64, error) {
+	value = strings.TrimSpace(value)
+	var offset uint64
+
+	upper := strings.ToUpper(value)
+	if strings.Contains(upper, "$NODEID") {
+		offset = uint64(nodeID)
+		upper = strings.ReplaceAll(upper, "$NODEID", "")
+		upper = strings.Trim(strings.TrimSpace(upper), "+")
+		value = strings.TrimSpace(upper)
+		if value == "" {
+			return offset, nil
+		}
+	}
+
+	n, err := strconv.ParseUint(strings.ToLower(value), 0, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid EDS integer '%s': %v", value, err)
+	}
+	return n + offset, nil
+}
+
+// EDSRegistry holds the EDS/DCF files registered per node ID
+type EDSRegistry struct {
+	files map[uint8]*EDSFile
+}
+
+// NewEDSRegistry creates an empty registry
+func NewEDSRegistry() *EDSRegistry {
+	return &EDSRegistry{files: make(map[uint8]*EDSFile)}
+}
+
+// LoadEDSRegistry loads every *.eds and *.dcf file from a directory
+// The node ID is taken from [DeviceComissioning] NodeID or from the file name (e.g. node3.dcf, 3.eds)
+func LoadEDSRegistry(dir string) (*EDSRegistry, error) {
+	registry := NewEDSRegistry()
+	if dir == "" {
+		return registry, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read EDS directory %s: %w", dir, err)
+	}
+
+	for _, dirEntry := range entries {
+		ext := strings.ToLower(filepath.Ext(dirEntry.Name()))
+		if dirEntry.IsDir() || (ext != ".eds" && ext != ".dcf") {
+			continue
+		}
+
+		path := filepath.Join(dir, dirEntry.Name())
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", path, err)
+		}
+		file, err := ParseEDS(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		file.FileName = dirEntry.Name()
+
+		if file.NodeID == 0 {
+			base := strings.TrimSuffix(dirEntry.Name(), filepath.Ext(dirEntry.Name()))
+			if m := edsNodeFileName.FindStringSubmatch(strings.ToLower(base)); m != nil {
+				n, _ := strconv.Atoi(m[1])
+				file.NodeID = uint8(n)
+			}
+		}
+		if file.NodeID < 1 || file.NodeID > 127 {
+			return nil, fmt.Errorf("cannot determine node ID of %s, set [DeviceComissioning] NodeID or name it node<id>%s", path, ext)
+		}
+
+		// A DCF describes the configured node and overrides a plain EDS
+		if existing, ok := registry.files[file.NodeID]; ok && strings.HasSuffix(strings.ToLower(existing.FileName), ".dcf") && ext == ".eds" {
+			continue
+		}
+		registry.files[file.NodeID] = file
+	}
+
+	return registry, nil
+}
+
+// Get returns the EDS/DCF registered for a node
+func (r *EDSRegistry) Get(nodeID uint8) (*EDSFile, bool) {
+	file, ok := r.files[nodeID]
+	return file, ok
+}
+
+// HeartbeatProducerTime returns the producer heartbeat time (object 0x1017) of a node
+func (r *EDSRegistry) HeartbeatProducerTime(nodeID uint8) (time.Duration, bool) {
+	file, ok := r.Get(nodeID)
+	if !ok {
+		return 0, false
+	}
+	entry, ok := file.Entry(0x1017, 0)
+	if !ok {
+		return 0, false
+	}
+	ms, err := entry.IntValue(nodeID)
+	if err != nil || ms == 0 {
+		return 0, false
+	}
+	return time.Duration(ms) * time.Millisecond, true
+}
+
+// HeartbeatConsumerTimes derives heartbeat consumer times for the registered nodes
+// Entries of 0x1016 (consumer heartbeat time) in any DCF take precedence; otherwise the
+// node's own producer time (0x1017) multiplied by tolerance is used.
+func (r *EDSRegistry) HeartbeatConsumerTimes(tolerance float64) map[uint8]time.Duration {
+	times := make(map[uint8]time.Duration)
+
+	for nodeID := range r.files {
+		if producer, ok := r.HeartbeatProducerTime(nodeID); ok {
+			times[nodeID] = time.Duration(float64(producer) * tolerance)
+		}
+	}
+
+	for nodeID, file := range r.files {
+		for _, entry := range file.Entries(0x1016) {
+			if entry.SubIndex == 0 {
+				continue
+			}
+			value, err := entry.IntValue(nodeID)
+			if err != nil {
+				continue
+			}
+			// Bits 16-23: monitored node ID, bits 0-15: consumer time in ms
+			monitored := uint8(value >> 16)
+			ms := value & 0xFFFF
+			if monitored == 0 || monitored > 127 || ms == 0 {
+				continue
+			}
+			times[monitored] = time.Duration(ms) * time.Millisecond
+		}
+	}
+
+	return times
+}
+
+// Nodes returns the node IDs with a registered EDS/DCF in ascending order
+func (r *EDSRegistry) Nodes() []uint8 {
+	nodes := make([]uint8, 0, len(r.files))
+	for nodeID := range r.files {
+		nodes = append(nodes, nodeID)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i] < nodes[j] })
+	return nodes
+}
+
+// DeviceProfile returns the device profile number of a node (low word of object 0x1000, e.g. 402 for drives)
+func (r *EDSRegistry) DeviceProfile(nodeID uint8) (uint16, bool) {
+	file, ok := r.Get(nodeID)
+	if !ok {
+		return 0, false
+	}
+	entry, ok := file.Entry(0x1000, 0)
+	if !ok {
+		return 0, false
+	}
+	deviceType, err := entry.IntValue(nodeID)
+	if err != nil {
+		return 0, false
+	}
+	return uint16(deviceType & 0xFFFF), true
+}
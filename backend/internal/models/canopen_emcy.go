@@ -0,0 +1,515 @@
+package models
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// EMCY error code profiles
+const (
+	EMCYProfileCiA301 = "CiA 301"
+	EMCYProfileCiA402 = "CiA 402"
+	EMCYProfileVendor = "vendor"
+)
+
+// cia301ErrorCodes holds the error codes defined by CiA 301 (communication profile)
+var cia301ErrorCodes = map[uint16]string{
+	0x0000: "Error reset or no error",
+	0x1000: "Generic error",
+	0x2000: "Current",
+	0x2100: "Current, CANopen device input side",
+	0x2200: "Current inside the CANopen device",
+	0x2300: "Current, CANopen device output side",
+	0x3000: "Voltage",
+	0x3100: "Mains voltage",
+	0x3200: "Voltage inside the CANopen device",
+	0x3300: "Output voltage",
+	0x4000: "Temperature",
+	0x4100: "Ambient temperature",
+	0x4200: "Device temperature",
+	0x5000: "CANopen device hardware",
+	0x6000: "CANopen device software",
+	0x6100: "Internal software",
+	0x6200: "User software",
+	0x6300: "Data set",
+	0x7000: "Additional modules",
+	0x8000: "Monitoring",
+	0x8100: "Communication",
+	0x8110: "CAN overrun (objects lost)",
+	0x8120: "CAN in error passive mode",
+	0x8130: "Life guard error or heartbeat error",
+	0x8140: "Recovered from bus off",
+	0x8150: "CAN-ID collision",
+	0x8200: "Protocol error",
+	0x8210: "PDO not processed due to length error",
+	0x8220: "PDO length exceeded",
+	0x8230: "DAM MPDO not processed, destination object not available",
+	0x8240: "Unexpected SYNC data length",
+	0x8250: "RPDO timeout",
+	0x9000: "External error",
+	0xF000: "Additional functions",
+	0xFF00: "Device specific",
+}
+
+// cia402ErrorCodes holds the error codes defined by CiA 402 (drives and motion control)
+var cia402ErrorCodes = map[uint16]string{
+	0x2110: "Short circuit/earth leakage (input)",
+	0x2120: "Earth leakage (input)",
+	0x2121: "Earth leakage phase L1",
+	0x2122: "Earth leakage phase L2",
+	0x2123: "Earth leakage phase L3",
+	0x2130: "Short circuit (input)",
+	0x2211: "Internal current no. 1",
+	0x2212: "Internal current no. 2",
+	0x2213: "Over-current in ramp function",
+	0x2214: "Over-current in the sequence",
+	0x2220: "Continuous over current (device internal)",
+	0x2230: "Short circuit/earth leakage (device internal)",
+	0x2240: "Earth leakage (device internal)",
+	0x2250: "Short circuit (device internal)",
+	0x2310: "Continuous over current",
+	0x2311: "Continuous over current no. 1",
+	0x2312: "Continuous over current no. 2",
+	0x2320: "Short circuit/earth leakage (motor-side)",
+	0x2330: "Earth leakage (motor-side)",
+	0x2340: "Short circuit (motor-side)",
+	0x3110: "Mains over-voltage",
+	0x3120: "Mains under-voltage",
+	0x3130: "Phase failure",
+	0x3140: "Mains frequency",
+	0x3210: "DC link over-voltage",
+	0x3220: "DC link under-voltage",
+	0x3230: "Load error",
+	0x3310: "Output over-voltage",
+	0x3320: "Armature circuit",
+	0x3330: "Field circuit",
+	0x4110: "Excess ambient temperature",
+	0x4120: "Too low ambient temperature",
+	0x4130: "Temperature supply air",
+	0x4140: "Temperature air outlet",
+	0x4210: "Excess temperature device",
+	0x4220: "Too low temperature device",
+	0x4300: "Drive temperature",
+	0x4310: "Excess temperature drive",
+	0x4320: "Too low temperature drive",
+	0x4400: "Supply temperature",
+	0x5111: "Supply low voltage U1 (±15 V)",
+	0x5112: "Supply low voltage U2 (+24 V)",
+	0x5113: "Supply low voltage U3 (+5 V)",
+	0x5210: "Measurement circuit",
+	0x5220: "Computing circuit",
+	0x5300: "Operating unit",
+	0x5400: "Power section",
+	0x5410: "Output stages",
+	0x5420: "Chopper",
+	0x5430: "Input stages",
+	0x5440: "Contacts",
+	0x5450: "Fuses",
+	0x5500: "Data storage",
+	0x5510: "Working memory",
+	0x5520: "Program memory",
+	0x5530: "Non-volatile data memory",
+	0x6010: "Software reset (watchdog)",
+	0x6301: "Loss of parameters",
+	0x6320: "Parameter error",
+	0x7110: "Brake chopper",
+	0x7111: "Failure brake chopper",
+	0x7112: "Over current brake chopper",
+	0x7113: "Protective circuit brake chopper",
+	0x7120: "Motor",
+	0x7121: "Motor blocked",
+	0x7122: "Motor error or commutation malfunction",
+	0x7123: "Motor tilted",
+	0x7200: "Measurement circuit",
+	0x7300: "Sensor",
+	0x7301: "Tacho fault",
+	0x7302: "Tacho wrong polarity",
+	0x7303: "Resolver 1 fault",
+	0x7304: "Resolver 2 fault",
+	0x7305: "Incremental sensor 1 fault",
+	0x7306: "Incremental sensor 2 fault",
+	0x7307: "Incremental sensor 3 fault",
+	0x7310: "Speed",
+	0x7320: "Position",
+	0x7400: "Computation circuit",
+	0x7500: "Communication",
+	0x7510: "Serial interface no. 1",
+	0x7520: "Serial interface no. 2",
+	0x7600: "Data storage",
+	0x8300: "Torque control",
+	0x8311: "Excess torque",
+	0x8312: "Difficult start up",
+	0x8313: "Standstill torque",
+	0x8321: "Insufficient torque",
+	0x8331: "Torque fault",
+	0x8400: "Velocity speed controller",
+	0x8500: "Position controller",
+	0x8600: "Positioning controller",
+	0x8611: "Following error",
+	0x8612: "Reference limit",
+	0x8700: "Sync controller",
+	0x8800: "Winding controller",
+	0x8900: "Process data monitoring",
+	0x8A00: "Control",
+	0xF001: "Deceleration",
+	0xF002: "Sub-synchronous run",
+	0xF003: "Stroke operation",
+	0xF004: "Control",
+}
+
+// emcyErrorRegisterBits names the bits of the error register (object 0x1001)
+var emcyErrorRegisterBits = []string{
+	"generic",
+	"current",
+	"voltage",
+	"temperature",
+	"communication",
+	"device_profile",
+	"reserved",
+	"manufacturer",
+}
+
+// EMCYCodeTable is a vendor-specific error code table loaded from a YAML file
+//
+// Example:
+//
+//	vendor: Acme Drives
+//	nodes: [3, 4]          # optional, applies to all nodes when empty
+//	codes:
+//	  "0xFF01": Encoder battery low
+//	  "0xFF02": STO circuit open
+type EMCYCodeTable struct {
+	Vendor string            `yaml:"vendor" json:"vendor"`
+	Nodes  []uint8           `yaml:"nodes" json:"nodes,omitempty"`
+	Codes  map[string]string `yaml:"codes" json:"-"`
+
+	codes map[uint16]string
+}
+
+// appliesTo reports whether the table covers the given node
+func (t *EMCYCodeTable) appliesTo(nodeID uint8) bool {
+	if len(t.Nodes) == 0 {
+		return true
+	}
+	for _, n := range t.Nodes {
+		if n == nodeID {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadEMCYCodeTable loads a vendor error code table from a YAML file
+func LoadEMCYCodeTable(path string) (*EMCYCodeTable, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read EMCY code table %s: %w", path, err)
+	}
+
+	table := &EMCYCodeTable{}
+	if err := yaml.Unmarshal(content, table); err != nil {
+		return nil, fmt.Errorf("failed to parse EMCY code table %s: %w", path, err)
+	}
+
+	table.codes = make(map[uint16]string, len(table.Codes))
+	for codeStr, description := range table.Codes {
+		code, err := strconv.ParseUint(strings.TrimSpace(codeStr), 0, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid error code '%s' in %s: %v", codeStr, path, err)
+		}
+		table.codes[uint16(code)] = description
+	}
+
+	if table.Vendor == "" {
+		table.Vendor = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	return table, nil
+}
+
+// EMCYDecoder decodes CANopen emergency frames using the CiA 301/402 tables
+// and optional vendor tables
+type EMCYDecoder struct {
+	vendorTables []*EMCYCodeTable
+}
+
+// NewEMCYDecoder creates a decoder with the given vendor tables
+// Vendor tables take precedence over the standard tables, in the given order
+func NewEMCYDecoder(vendorTables ...*EMCYCodeTable) *EMCYDecoder {
+	return &EMCYDecoder{vendorTables: vendorTables}
+}
+
+// LoadEMCYDecoder creates a decoder from a list of vendor table files
+func LoadEMCYDecoder(paths []string) (*EMCYDecoder, error) {
+	tables := make([]*EMCYCodeTable, 0, len(paths))
+	for _, path := range paths {
+		table, err := LoadEMCYCodeTable(path)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	return NewEMCYDecoder(tables...), nil
+}
+
+// EMCYMessage is a decoded CANopen emergency frame
+type EMCYMessage struct {
+	Timestamp         time.Time `json:"timestamp"`
+	Interface         string    `json:"interface"`
+	NodeID            uint8     `json:"node_id"`
+	ErrorCode         uint16    `json:"error_code"`
+	ErrorCodeHex      string    `json:"error_code_hex"`
+	ErrorClass        string    `json:"error_class"` // Description of the code's class (high byte)
+	Description       string    `json:"description"`
+	Profile           string    `json:"profile"` // CiA 301, CiA 402 or vendor
+	Vendor            string    `json:"vendor,omitempty"`
+	ErrorRegister     uint8     `json:"error_register"`
+	ErrorRegisterBits []string  `json:"error_register_bits"`
+	ManufacturerData  []uint8   `json:"manufacturer_data"`
+	ManufacturerHex   string    `json:"manufacturer_data_hex"`
+}
+
+// IsReset reports whether the frame signals "error reset or no error"
+func (m *EMCYMessage) IsReset() bool {
+	return m.ErrorCode == 0x0000
+}
+
+// IsEMCYFrame reports whether the CAN ID is in the EMCY COB-ID range (0x081-0x0FF)
+func IsEMCYFrame(canID uint32) bool {
+	return canID >= 0x081 && canID <= 0x0FF
+}
+
+// Decode decodes an EMCY frame
+func (d *EMCYDecoder) Decode(timestamp time.Time, iface string, canID uint32, data []byte) EMCYMessage {
+	payload := make([]byte, 8)
+	copy(payload, data)
+
+	nodeID := uint8(canID - 0x080)
+	code := uint16(payload[0]) | uint16(payload[1])<<8
+
+	msg := EMCYMessage{
+		Timestamp:        timestamp,
+		Interface:        iface,
+		NodeID:           nodeID,
+		ErrorCode:        code,
+		ErrorCodeHex:     fmt.Sprintf("0x%04X", code),
+		ErrorRegister:    payload[2],
+		ManufacturerData: payload[3:8],
+		ManufacturerHex:  fmt.Sprintf("% X", payload[3:8]),
+	}
+
+	msg.ErrorRegisterBits = DecodeErrorRegister(payload[2])
+	msg.Description, msg.Profile, msg.Vendor = d.Describe(nodeID, code)
+	msg.ErrorClass = describeErrorClass(code)
+
+	return msg
+}
+
+// Describe looks up an error code, returning its description, profile and vendor
+func (d *EMCYDecoder) Describe(nodeID uint8, code uint16) (string, string, string) {
+	for _, table := range d.vendorTables {
+		if !table.appliesTo(nodeID) {
+			continue
+		}
+		if description, ok := table.codes[code]; ok {
+			return description, EMCYProfileVendor, table.Vendor
+		}
+	}
+
+	if description, ok := cia402ErrorCodes[code]; ok {
+		return description, EMCYProfileCiA402, ""
+	}
+	if description, ok := cia301ErrorCodes[code]; ok {
+		return description, EMCYProfileCiA301, ""
+	}
+
+	// Fall back to the closest class defined by the standard tables
+	for _, mask := range []uint16{0xFFF0, 0xFF00} {
+		if description, ok := cia402ErrorCodes[code&mask]; ok {
+			return description, EMCYProfileCiA402, ""
+		}
+		if description, ok := cia301ErrorCodes[code&mask]; ok {
+			return description, EMCYProfileCiA301, ""
+		}
+	}
+
+	return describeErrorClass(code), EMCYProfileCiA301, ""
+}
+
+// describeErrorClass returns the CiA 301 error class of an error code
+func describeErrorClass(code uint16) string {
+	if code&0xFF00 == 0xFF00 {
+		return cia301ErrorCodes[0xFF00]
+	}
+	if code&0xF000 == 0xF000 {
+		return cia301ErrorCodes[0xF000]
+	}
+	if description, ok := cia301ErrorCodes[code&0xFF00]; ok {
+		return description
+	}
+	if description, ok := cia301ErrorCodes[code&0xF000]; ok {
+		return description
+	}
+	return "Unknown"
+}
+
+// DecodeErrorRegister returns the names of the bits set in the error register
+func DecodeErrorRegister(register uint8) []string {
+	bits := []string{}
+	for i, name := range emcyErrorRegisterBits {
+		if register&(1<<i) != 0 {
+			bits = append(bits, name)
+		}
+	}
+	return bits
+}
+
+// errorRegisterBitFor returns the error register bit that a device is expected
+// to set while the given error code is active
+func errorRegisterBitFor(code uint16) uint8 {
+	switch {
+	case code&0xFF00 == 0xFF00:
+		return 1 << 7
+	case code&0xF000 == 0x2000:
+		return 1 << 1
+	case code&0xF000 == 0x3000:
+		return 1 << 2
+	case code&0xF000 == 0x4000:
+		return 1 << 3
+	case code&0xFF00 == 0x8100, code&0xFF00 == 0x8200:
+		return 1 << 4
+	default:
+		return 1 << 0
+	}
+}
+
+// EMCYErrorRecord tracks one error code of a node from its first occurrence until it is cleared
+type EMCYErrorRecord struct {
+	ErrorCode         uint16     `json:"error_code"`
+	ErrorCodeHex      string     `json:"error_code_hex"`
+	ErrorClass        string     `json:"error_class"`
+	Description       string     `json:"description"`
+	Profile           string     `json:"profile"`
+	Vendor            string     `json:"vendor,omitempty"`
+	ErrorRegister     uint8      `json:"error_register"`
+	ErrorRegisterBits []string   `json:"error_register_bits"`
+	ManufacturerData  []uint8    `json:"manufacturer_data"`
+	ManufacturerHex   string     `json:"manufacturer_data_hex"`
+	FirstSeen         time.Time  `json:"first_seen"`
+	LastSeen          time.Time  `json:"last_seen"`
+	Occurrences       int        `json:"occurrences"`
+	Active            bool       `json:"active"`
+	ClearedAt         *time.Time `json:"cleared_at,omitempty"`
+}
+
+// EMCYNodeSummary groups the active and cleared errors of a node
+type EMCYNodeSummary struct {
+	Interface         string             `json:"interface"`
+	NodeID            uint8              `json:"node_id"`
+	ErrorRegister     uint8              `json:"error_register"`
+	ErrorRegisterBits []string           `json:"error_register_bits"`
+	LastEMCY          time.Time          `json:"last_emcy"`
+	ActiveErrors      []*EMCYErrorRecord `json:"active_errors"`
+	ClearedErrors     []*EMCYErrorRecord `json:"cleared_errors"`
+	History           []EMCYMessage      `json:"history,omitempty"`
+}
+
+// EMCYTracker follows the error state of each node from a time-ordered stream of EMCY frames
+type EMCYTracker struct {
+	keepHistory bool
+	nodes       map[string]*EMCYNodeSummary
+	order       []string
+}
+
+// NewEMCYTracker creates a tracker; keepHistory also records every decoded frame per node
+func NewEMCYTracker(keepHistory bool) *EMCYTracker {
+	return &EMCYTracker{
+		keepHistory: keepHistory,
+		nodes:       make(map[string]*EMCYNodeSummary),
+	}
+}
+
+// Add applies a decoded EMCY frame; frames must be added in time order
+func (t *EMCYTracker) Add(msg EMCYMessage) {
+	key := fmt.Sprintf("%s/%d", msg.Interface, msg.NodeID)
+	node, ok := t.nodes[key]
+	if !ok {
+		node = &EMCYNodeSummary{
+			Interface:     msg.Interface,
+			NodeID:        msg.NodeID,
+			ActiveErrors:  []*EMCYErrorRecord{},
+			ClearedErrors: []*EMCYErrorRecord{},
+		}
+		t.nodes[key] = node
+		t.order = append(t.order, key)
+	}
+
+	node.ErrorRegister = msg.ErrorRegister
+	node.ErrorRegisterBits = msg.ErrorRegisterBits
+	node.LastEMCY = msg.Timestamp
+	if t.keepHistory {
+		node.History = append(node.History, msg)
+	}
+
+	if msg.IsReset() {
+		// An error reset clears every error whose register bit is no longer set.
+		// With an empty error register all errors are gone.
+		remaining := node.ActiveErrors[:0]
+		for _, record := range node.ActiveErrors {
+			if msg.ErrorRegister != 0 && msg.ErrorRegister&errorRegisterBitFor(record.ErrorCode) != 0 {
+				remaining = append(remaining, record)
+				continue
+			}
+			clearedAt := msg.Timestamp
+			record.Active = false
+			record.ClearedAt = &clearedAt
+			node.ClearedErrors = append(node.ClearedErrors, record)
+		}
+		node.ActiveErrors = remaining
+		return
+	}
+
+	for _, record := range node.ActiveErrors {
+		if record.ErrorCode == msg.ErrorCode {
+			record.LastSeen = msg.Timestamp
+			record.Occurrences++
+			record.ErrorRegister = msg.ErrorRegister
+			record.ErrorRegisterBits = msg.ErrorRegisterBits
+			record.ManufacturerData = msg.ManufacturerData
+			record.ManufacturerHex = msg.ManufacturerHex
+			return
+		}
+	}
+
+	node.ActiveErrors = append(node.ActiveErrors, &EMCYErrorRecord{
+		ErrorCode:         msg.ErrorCode,
+		ErrorCodeHex:      msg.ErrorCodeHex,
+		ErrorClass:        msg.ErrorClass,
+		Description:       msg.Description,
+		Profile:           msg.Profile,
+		Vendor:            msg.Vendor,
+		ErrorRegister:     msg.ErrorRegister,
+		ErrorRegisterBits: msg.ErrorRegisterBits,
+		ManufacturerData:  msg.ManufacturerData,
+		ManufacturerHex:   msg.ManufacturerHex,
+		FirstSeen:         msg.Timestamp,
+		LastSeen:          msg.Timestamp,
+		Occurrences:       1,
+		Active:            true,
+	})
+}
+
+// Nodes returns the per-node summaries in the order the nodes were first seen
+func (t *EMCYTracker) Nodes() []*EMCYNodeSummary {
+	nodes := make([]*EMCYNodeSummary, 0, len(t.order))
+	for _, key := range t.order {
+		nodes = append(nodes, t.nodes[key])
+	}
+	return nodes
+}
@@ -0,0 +1,390 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// NMTState is the NMT state reported by a node in its boot-up/heartbeat frame
+type NMTState uint8
+
+const (
+	NMTStateBootup         NMTState = 0x00
+	NMTStateStopped        NMTState = 0x04
+	NMTStateOperational    NMTState = 0x05
+	NMTStatePreOperational NMTState = 0x7F
+	NMTStateUnknown        NMTState = 0xFF
+)
+
+// String returns the state name used in API responses
+func (s NMTState) String() string {
+	switch s {
+	case NMTStateBootup:
+		return "BOOTUP"
+	case NMTStateStopped:
+		return "STOPPED"
+	case NMTStateOperational:
+		return "OPERATIONAL"
+	case NMTStatePreOperational:
+		return "PRE-OPERATIONAL"
+	case NMTStateUnknown:
+		return "UNKNOWN"
+	default:
+		return fmt.Sprintf("UNKNOWN(0x%02X)", uint8(s))
+	}
+}
+
+// MarshalText encodes the state by name
+func (s NMTState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes a state name, e.g. from the NMT audit log
+func (s *NMTState) UnmarshalText(text []byte) error {
+	for _, state := range []NMTState{NMTStateBootup, NMTStateStopped, NMTStateOperational, NMTStatePreOperational, NMTStateUnknown} {
+		if state.String() == string(text) {
+			*s = state
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid NMT state '%s'", text)
+}
+
+// NMTCommand is an NMT module control command specifier (COB-ID 0x000, byte 0)
+type NMTCommand uint8
+
+const (
+	NMTCommandStart              NMTCommand = 0x01
+	NMTCommandStop               NMTCommand = 0x02
+	NMTCommandPreOperational     NMTCommand = 0x80
+	NMTCommandResetNode          NMTCommand = 0x81
+	NMTCommandResetCommunication NMTCommand = 0x82
+)
+
+// String returns the command name used in API responses
+func (c NMTCommand) String() string {
+	switch c {
+	case NMTCommandStart:
+		return "start"
+	case NMTCommandStop:
+		return "stop"
+	case NMTCommandPreOperational:
+		return "pre_operational"
+	case NMTCommandResetNode:
+		return "reset_node"
+	case NMTCommandResetCommunication:
+		return "reset_communication"
+	default:
+		return fmt.Sprintf("unknown(0x%02X)", uint8(c))
+	}
+}
+
+// MarshalText encodes the command by name
+func (c NMTCommand) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText decodes a command name
+func (c *NMTCommand) UnmarshalText(text []byte) error {
+	command, err := ParseNMTCommand(string(text))
+	if err != nil {
+		return err
+	}
+	*c = command
+	return nil
+}
+
+// ExpectedState returns the state a node enters after executing the command
+func (c NMTCommand) ExpectedState() NMTState {
+	switch c {
+	case NMTCommandStart:
+		return NMTStateOperational
+	case NMTCommandStop:
+		return NMTStateStopped
+	case NMTCommandPreOperational:
+		return NMTStatePreOperational
+	case NMTCommandResetNode, NMTCommandResetCommunication:
+		return NMTStateBootup
+	default:
+		return NMTStateUnknown
+	}
+}
+
+// ParseNMTCommand parses an NMT command name
+func ParseNMTCommand(name string) (NMTCommand, error) {
+	for _, c := range []NMTCommand{NMTCommandStart, NMTCommandStop, NMTCommandPreOperational, NMTCommandResetNode, NMTCommandResetCommunication} {
+		if c.String() == name {
+			return c, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid NMT command '%s', must be one of: start, stop, pre_operational, reset_node, reset_communication", name)
+}
+
+// Transition causes
+const (
+	NMTCauseBootup      = "bootup"
+	NMTCauseCommand     = "nmt_command"
+	NMTCauseSpontaneous = "spontaneous"
+	NMTCauseFirstSeen   = "first_seen"
+)
+
+// nmtCommandAttributionWindow is how long after an NMT command a state change is attributed to it
+const nmtCommandAttributionWindow = 2 * time.Second
+
+// NMTCommandRecord is an NMT command observed on the bus
+type NMTCommandRecord struct {
+	Timestamp time.Time  `json:"timestamp"`
+	Command   NMTCommand `json:"command"`
+	Target    uint8      `json:"target"`    // 0 = all nodes
+	Broadcast bool       `json:"broadcast"` // true when sent to all nodes
+}
+
+// NMTTransition is a change of a node's NMT state
+type NMTTransition struct {
+	Timestamp time.Time         `json:"timestamp"`
+	From      NMTState          `json:"from"`
+	To        NMTState          `json:"to"`
+	Cause     string            `json:"cause"`             // bootup, nmt_command, spontaneous, first_seen
+	Command   *NMTCommandRecord `json:"command,omitempty"` // Command the transition is attributed to
+	Latency   *float64          `json:"latency_ms,omitempty"`
+}
+
+// HeartbeatGap is a period in which a node's heartbeat was missing
+type HeartbeatGap struct {
+	Start          time.Time `json:"start"`
+	End            time.Time `json:"end"`
+	DurationMS     float64   `json:"duration_ms"`
+	ExpectedPeriod float64   `json:"expected_period_ms"`
+	StateBefore    NMTState  `json:"state_before"`
+	StateAfter     NMTState  `json:"state_after"`
+	Ongoing        bool      `json:"ongoing"` // No heartbeat until the end of the query
+}
+
+// NMTNodeTimeline is the NMT lifecycle of a node
+type NMTNodeTimeline struct {
+	Interface       string             `json:"interface"`
+	NodeID          uint8              `json:"node_id"`
+	State           NMTState           `json:"state"`
+	FirstSeen       time.Time          `json:"first_seen"`
+	LastSeen        time.Time          `json:"last_seen"`
+	Heartbeats      int                `json:"heartbeats"`
+	Bootups         int                `json:"bootups"`
+	HeartbeatPeriod float64            `json:"heartbeat_period_ms"` // Configured (DCF) or estimated period
+	Transitions     []NMTTransition    `json:"transitions"`
+	HeartbeatGaps   []HeartbeatGap     `json:"heartbeat_gaps"`
+	Commands        []NMTCommandRecord `json:"commands"`
+
+	pendingCommand *NMTCommandRecord
+	configured     bool
+}
+
+// NMTTracker follows NMT commands and boot-up/heartbeat frames and builds a timeline per node
+type NMTTracker struct {
+	gapFactor  float64
+	periods    map[uint8]time.Duration
+	nodes      map[string]*NMTNodeTimeline
+	broadcasts map[string][]NMTCommandRecord // Broadcast commands per interface, for nodes seen later
+}
+
+// NewNMTTracker creates a tracker
+// A heartbeat gap is reported when no heartbeat arrives within gapFactor times the node's period.
+func NewNMTTracker(gapFactor float64) *NMTTracker {
+	if gapFactor <= 1 {
+		gapFactor = 3
+	}
+	return &NMTTracker{
+		gapFactor:  gapFactor,
+		periods:    make(map[uint8]time.Duration),
+		nodes:      make(map[string]*NMTNodeTimeline),
+		broadcasts: make(map[string][]NMTCommandRecord),
+	}
+}
+
+// SetHeartbeatPeriod sets the expected heartbeat producer time of a node (e.g. from 0x1017 in its DCF)
+func (t *NMTTracker) SetHeartbeatPeriod(nodeID uint8, period time.Duration) {
+	t.periods[nodeID] = period
+}
+
+// IsNMTFrame reports whether the CAN ID is an NMT command or boot-up/heartbeat frame
+func IsNMTFrame(canID uint32) bool {
+	return canID == 0x000 || (canID >= 0x701 && canID <= 0x77F)
+}
+
+// Add applies a frame; frames must be added in time order
+func (t *NMTTracker) Add(timestamp time.Time, iface string, canID uint32, data []byte) {
+	switch {
+	case canID == 0x000 && len(data) >= 2:
+		record := NMTCommandRecord{
+			Timestamp: timestamp,
+			Command:   NMTCommand(data[0]),
+			Target:    data[1],
+			Broadcast: data[1] == 0,
+		}
+		if !record.Broadcast {
+			t.node(iface, record.Target).addCommand(record)
+			return
+		}
+		// Nodes first seen after the command (e.g. booting after a reset of all nodes) receive it on creation
+		t.broadcasts[iface] = append(t.broadcasts[iface], record)
+		for _, node := range t.nodes {
+			if node.Interface == iface {
+				node.addCommand(record)
+			}
+		}
+
+	case canID >= 0x701 && canID <= 0x77F && len(data) >= 1:
+		node := t.node(iface, uint8(canID-0x700))
+		// Bit 7 is the node guarding toggle bit
+		node.addState(timestamp, NMTState(data[0]&0x7F), t.gapFactor)
+	}
+}
+
+// node returns the timeline of a node, creating it if needed
+func (t *NMTTracker) node(iface string, nodeID uint8) *NMTNodeTimeline {
+	key := fmt.Sprintf("%s/%d", iface, nodeID)
+	node, ok := t.nodes[key]
+	if !ok {
+		node = &NMTNodeTimeline{
+			Interface:     iface,
+			NodeID:        nodeID,
+			State:         NMTStateUnknown,
+			Transitions:   []NMTTransition{},
+			HeartbeatGaps: []HeartbeatGap{},
+			Commands:      []NMTCommandRecord{},
+		}
+		if period, ok := t.periods[nodeID]; ok && period > 0 {
+			node.HeartbeatPeriod = float64(period) / float64(time.Millisecond)
+			node.configured = true
+		}
+		for _, record := range t.broadcasts[iface] {
+			node.addCommand(record)
+		}
+		t.nodes[key] = node
+	}
+	return node
+}
+
+// addCommand records an NMT command addressed to the node
+func (n *NMTNodeTimeline) addCommand(record NMTCommandRecord) {
+	n.Commands = append(n.Commands, record)
+	n.pendingCommand = &record
+}
+
+// addState applies a boot-up or heartbeat frame
+func (n *NMTNodeTimeline) addState(timestamp time.Time, state NMTState, gapFactor float64) {
+	if n.Heartbeats > 0 {
+		interval := float64(timestamp.Sub(n.LastSeen)) / float64(time.Millisecond)
+		if n.HeartbeatPeriod > 0 && interval > gapFactor*n.HeartbeatPeriod {
+			n.HeartbeatGaps = append(n.HeartbeatGaps, HeartbeatGap{
+				Start:          n.LastSeen,
+				End:            timestamp,
+				DurationMS:     interval,
+				ExpectedPeriod: n.HeartbeatPeriod,
+				StateBefore:    n.State,
+				StateAfter:     state,
+			})
+		} else if !n.configured && state != NMTStateBootup {
+			// Estimate the producer time with a moving average of regular intervals
+			if n.HeartbeatPeriod == 0 {
+				n.HeartbeatPeriod = interval
+			} else {
+				n.HeartbeatPeriod = 0.9*n.HeartbeatPeriod + 0.1*interval
+			}
+		}
+	} else {
+		n.FirstSeen = timestamp
+	}
+
+	n.Heartbeats++
+	n.LastSeen = timestamp
+	if state == NMTStateBootup {
+		n.Bootups++
+	}
+
+	if state == n.State && state != NMTStateBootup {
+		return
+	}
+
+	transition := NMTTransition{
+		Timestamp: timestamp,
+		From:      n.State,
+		To:        state,
+	}
+
+	switch {
+	case n.pendingCommand != nil && timestamp.Sub(n.pendingCommand.Timestamp) <= nmtCommandAttributionWindow &&
+		n.pendingCommand.Command.ExpectedState() == state:
+		command := *n.pendingCommand
+		latency := float64(timestamp.Sub(command.Timestamp)) / float64(time.Millisecond)
+		transition.Cause = NMTCauseCommand
+		transition.Command = &command
+		transition.Latency = &latency
+		n.pendingCommand = nil
+	case state == NMTStateBootup, n.State == NMTStateBootup && state == NMTStatePreOperational:
+		// A node enters PRE-OPERATIONAL on its own after the boot-up
+		transition.Cause = NMTCauseBootup
+	case n.State == NMTStateUnknown:
+		transition.Cause = NMTCauseFirstSeen
+	default:
+		transition.Cause = NMTCauseSpontaneous
+	}
+
+	n.Transitions = append(n.Transitions, transition)
+	n.State = state
+}
+
+// Finish reports the heartbeats missing from the last heartbeat of each node until the end of the query
+// as ongoing gaps; it is called once after the last frame.
+func (t *NMTTracker) Finish(end time.Time) {
+	for _, n := range t.nodes {
+		if n.Heartbeats == 0 || n.HeartbeatPeriod <= 0 || !end.After(n.LastSeen) {
+			continue
+		}
+		silence := float64(end.Sub(n.LastSeen)) / float64(time.Millisecond)
+		if silence > t.gapFactor*n.HeartbeatPeriod {
+			n.HeartbeatGaps = append(n.HeartbeatGaps, HeartbeatGap{
+				Start:          n.LastSeen,
+				End:            end,
+				DurationMS:     silence,
+				ExpectedPeriod: n.HeartbeatPeriod,
+				StateBefore:    n.State,
+				StateAfter:     n.State,
+				Ongoing:        true,
+			})
+		}
+	}
+}
+
+// Nodes returns the timelines of all nodes that sent boot-up or heartbeat frames, ordered by interface and node ID
+func (t *NMTTracker) Nodes() []*NMTNodeTimeline {
+	nodes := make([]*NMTNodeTimeline, 0, len(t.nodes))
+	for _, node := range t.nodes {
+		if node.Heartbeats == 0 {
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Interface != nodes[j].Interface {
+			return nodes[i].Interface < nodes[j].Interface
+		}
+		return nodes[i].NodeID < nodes[j].NodeID
+	})
+	return nodes
+}
+
+// CANopenNodeStatus is the current NMT status of a node
+type CANopenNodeStatus struct {
+	Interface       string         `json:"interface"`
+	NodeID          uint8          `json:"node_id"`
+	State           NMTState       `json:"state"`
+	FirstSeen       time.Time      `json:"first_seen"`
+	LastSeen        time.Time      `json:"last_seen"`
+	Heartbeats      uint64         `json:"heartbeats"`
+	Bootups         uint64         `json:"bootups"`
+	LastBootup      *time.Time     `json:"last_bootup,omitempty"`
+	HeartbeatPeriod float64        `json:"heartbeat_period_ms"`
+	EDSFile         string         `json:"eds_file,omitempty"`
+	DeviceInfo      *EDSDeviceInfo `json:"device_info,omitempty"`
+}
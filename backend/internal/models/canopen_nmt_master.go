@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Outcomes of an NMT command sent through the API
+const (
+	NMTOutcomeSuccess  = "success"  // Every addressed node reported the expected state
+	NMTOutcomeTimeout  = "timeout"  // A node did not report the expected state in time
+	NMTOutcomeDenied   = "denied"   // Rejected by the operator check, nothing was sent
+	NMTOutcomeRejected = "rejected" // Invalid request, nothing was sent
+	NMTOutcomeFailed   = "failed"   // The command could not be transmitted
+)
+
+// NMTCommandRequest is the request to send an NMT command
+type NMTCommandRequest struct {
+	Interface string  `json:"interface"`
+	Command   string  `json:"command"`    // start, stop, pre_operational, reset_node, reset_communication
+	NodeID    uint8   `json:"node_id"`    // 0 = broadcast to all nodes
+	Nodes     []uint8 `json:"nodes"`      // Nodes expected to answer a broadcast, default: the nodes with an EDS/DCF
+	TimeoutMs int     `json:"timeout_ms"` // Wait for the expected state, default 3000
+}
+
+// NMTNodeResult is the state a node reported after an NMT command
+type NMTNodeResult struct {
+	NodeID    uint8    `json:"node_id"`
+	State     NMTState `json:"state"` // Last state reported after the command, UNKNOWN without boot-up/heartbeat
+	Reached   bool     `json:"reached"`
+	LatencyMs *float64 `json:"latency_ms,omitempty"` // From the command to the expected state
+}
+
+// NMTCommandResult is an NMT command sent through the API, as returned and recorded in the audit log
+type NMTCommandResult struct {
+	ID            string          `json:"id"`
+	Timestamp     time.Time       `json:"timestamp"` // Transmission of the command, or of the request if not sent
+	DeviceID      string          `json:"device_id,omitempty"`
+	Interface     string          `json:"interface"`
+	Command       NMTCommand      `json:"command"`
+	NodeID        uint8           `json:"node_id"` // 0 = all nodes
+	Broadcast     bool            `json:"broadcast"`
+	ExpectedState NMTState        `json:"expected_state"`
+	User          string          `json:"user"`
+	RemoteAddr    string          `json:"remote_addr"`
+	Outcome       string          `json:"outcome"` // success, timeout, denied, failed
+	Nodes         []NMTNodeResult `json:"nodes"`
+	DurationMs    float64         `json:"duration_ms"`
+	Error         string          `json:"error,omitempty"`
+	Audited       bool            `json:"audited"` // Recorded in the audit log
+}
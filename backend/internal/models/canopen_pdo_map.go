@@ -0,0 +1,179 @@
+package models
+
+import (
+	"sort"
+)
+
+// MappedObject is an object dictionary entry mapped into a PDO
+type MappedObject struct {
+	Index     uint16 `json:"index"`
+	SubIndex  uint8  `json:"sub_index"`
+	BitOffset int    `json:"bit_offset"`
+	BitLength int    `json:"bit_length"`
+}
+
+// Extract returns the raw value of the object from the PDO data (little-endian)
+func (o MappedObject) Extract(data []byte) (uint64, bool) {
+	if o.BitLength <= 0 || o.BitLength > 64 || o.BitOffset+o.BitLength > 64 {
+		return 0, false
+	}
+	if (o.BitOffset+o.BitLength+7)/8 > len(data) {
+		return 0, false
+	}
+
+	var raw uint64
+	for i := 0; i < len(data) && i < 8; i++ {
+		raw |= uint64(data[i]) << (8 * i)
+	}
+
+	value := raw >> o.BitOffset
+	if o.BitLength < 64 {
+		value &= (1 << o.BitLength) - 1
+	}
+	return value, true
+}
+
+// PDOLayout describes the objects carried by a single PDO
+type PDOLayout struct {
+	CANID     uint32         `json:"can_id"`
+	NodeID    uint8          `json:"node_id"`
+	PDONumber int            `json:"pdo_number"`
+	Direction string         `json:"direction"` // "TX" or "RX", seen from the node
+	Objects   []MappedObject `json:"objects"`
+}
+
+// PDOObjectMap resolves CAN IDs to the object dictionary entries mapped into them
+type PDOObjectMap struct {
+	pdos map[uint32]*PDOLayout
+}
+
+// NewPDOObjectMap creates an empty PDO object map
+func NewPDOObjectMap() *PDOObjectMap {
+	return &PDOObjectMap{pdos: make(map[uint32]*PDOLayout)}
+}
+
+// Add registers a PDO layout, replacing any layout with the same CAN ID
+func (m *PDOObjectMap) Add(layout PDOLayout) {
+	m.pdos[layout.CANID] = &layout
+}
+
+// Get returns the layout of the PDO sent on a CAN ID
+func (m *PDOObjectMap) Get(canID uint32) (*PDOLayout, bool) {
+	layout, ok := m.pdos[canID]
+	return layout, ok
+}
+
+// CANIDs returns the CAN IDs of all registered PDOs in ascending order
+func (m *PDOObjectMap) CANIDs() []uint32 {
+	ids := make([]uint32, 0, len(m.pdos))
+	for id := range m.pdos {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// AddEDS registers the PDOs configured in a node's EDS/DCF
+// Communication parameters (0x1400/0x1800) provide the COB-ID, mapping parameters
+// (0x1600/0x1A00) the mapped objects. It returns the number of PDOs found.
+func (m *PDOObjectMap) AddEDS(nodeID uint8, file *EDSFile) int {
+	count := 0
+	for _, dir := range []struct {
+		direction string
+		commBase  uint16
+		mapBase   uint16
+		cobBase   uint32
+	}{
+		{"RX", 0x1400, 0x1600, 0x200},
+		{"TX", 0x1800, 0x1A00, 0x180},
+	} {
+		for n := uint16(0); n < 512; n++ {
+			mapEntries := file.Entries(dir.mapBase + n)
+			if len(mapEntries) == 0 {
+				continue
+			}
+
+			// Predefined connection set COB-IDs for PDO 1-4
+			var cobID uint64
+			if entry, ok := file.Entry(dir.commBase+n, 1); ok {
+				value, err := entry.IntValue(nodeID)
+				if err != nil {
+					continue
+				}
+				cobID = value
+			} else if n < 4 {
+				cobID = uint64(dir.cobBase + 0x100*uint32(n) + uint32(nodeID))
+			} else {
+				continue
+			}
+			// Bit 31: PDO not valid
+			if cobID&0x80000000 != 0 {
+				continue
+			}
+			canID := uint32(cobID) & CANSffMask
+			if cobID&0x20000000 != 0 {
+				canID = uint32(cobID) & CANEffMask
+			}
+
+			layout := PDOLayout{
+				CANID:     canID,
+				NodeID:    nodeID,
+				PDONumber: int(n) + 1,
+				Direction: dir.direction,
+			}
+
+			countEntry, ok := file.Entry(dir.mapBase+n, 0)
+			if !ok {
+				continue
+			}
+			mapped, err := countEntry.IntValue(nodeID)
+			if err != nil || mapped == 0 {
+				continue
+			}
+
+			bitOffset := 0
+			for sub := uint8(1); sub <= uint8(mapped) && sub <= 64; sub++ {
+				entry, ok := file.Entry(dir.mapBase+n, sub)
+				if !ok {
+					break
+				}
+				value, err := entry.IntValue(nodeID)
+				if err != nil {
+					break
+				}
+				// Mapping entry: index (bits 16-31), sub-index (bits 8-15), length in bits (bits 0-7)
+				object := MappedObject{
+					Index:     uint16(value >> 16),
+					SubIndex:  uint8(value >> 8),
+					BitOffset: bitOffset,
+					BitLength: int(value & 0xFF),
+				}
+				layout.Objects = append(layout.Objects, object)
+				bitOffset += object.BitLength
+			}
+
+			if len(layout.Objects) > 0 {
+				m.Add(layout)
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// AddDS402Defaults registers the CiA 402 default PDO mapping of a drive:
+// RPDO1 controlword, RPDO2 controlword + modes of operation,
+// TPDO1 statusword, TPDO2 statusword + modes of operation display
+func (m *PDOObjectMap) AddDS402Defaults(nodeID uint8) {
+	controlword := MappedObject{Index: 0x6040, BitLength: 16}
+	statusword := MappedObject{Index: 0x6041, BitLength: 16}
+
+	m.Add(PDOLayout{CANID: 0x200 + uint32(nodeID), NodeID: nodeID, PDONumber: 1, Direction: "RX",
+		Objects: []MappedObject{controlword}})
+	m.Add(PDOLayout{CANID: 0x300 + uint32(nodeID), NodeID: nodeID, PDONumber: 2, Direction: "RX",
+		Objects: []MappedObject{controlword, {Index: 0x6060, BitOffset: 16, BitLength: 8}}})
+	m.Add(PDOLayout{CANID: 0x180 + uint32(nodeID), NodeID: nodeID, PDONumber: 1, Direction: "TX",
+		Objects: []MappedObject{statusword}})
+	m.Add(PDOLayout{CANID: 0x280 + uint32(nodeID), NodeID: nodeID, PDONumber: 2, Direction: "TX",
+		Objects: []MappedObject{statusword, {Index: 0x6061, BitOffset: 16, BitLength: 8}}})
+}
@@ -0,0 +1,177 @@
+package models
+
+import (
+	"encoding/binary"
+	"encoding/json"
+)
+
+// SDO client (command) and server (response) specifiers, bits 5-7 of the first data byte
+const (
+	SDOClientDownloadInitiate = 1
+	SDOClientUploadInitiate   = 2
+	SDOServerUploadInitiate   = 2
+	SDOServerDownloadInitiate = 3
+	SDOAbort                  = 4
+)
+
+// SDOExpedited is an expedited SDO transfer, which carries up to 4 bytes of object data in a single frame
+type SDOExpedited struct {
+	NodeID   uint8  `json:"node_id"`
+	Index    uint16 `json:"index"`
+	SubIndex uint8  `json:"sub_index"`
+	Value    uint64 `json:"value"`
+	Size     int    `json:"size"`   // Number of valid data bytes, 4 if the size was not indicated
+	Upload   bool   `json:"upload"` // true for an upload response (read), false for a download request (write)
+}
+
+// IsSDORequest checks whether a CAN ID is an SDO request (client -> server, 0x600 + node ID)
+func IsSDORequest(canID uint32) bool {
+	return canID >= 0x601 && canID <= 0x67F
+}
+
+// IsSDOResponse checks whether a CAN ID is an SDO response (server -> client, 0x580 + node ID)
+func IsSDOResponse(canID uint32) bool {
+	return canID >= 0x581 && canID <= 0x5FF
+}
+
+// ParseExpeditedSDO decodes an expedited download request (0x600 + node ID) or an
+// expedited upload response (0x580 + node ID); any other frame returns false
+func ParseExpeditedSDO(canID uint32, data []byte) (SDOExpedited, bool) {
+	if len(data) < 8 {
+		return SDOExpedited{}, false
+	}
+
+	command := data[0]
+	specifier := command >> 5
+	expedited := command&0x02 != 0
+	sizeIndicated := command&0x01 != 0
+
+	var sdo SDOExpedited
+	switch {
+	case IsSDORequest(canID) && specifier == SDOClientDownloadInitiate:
+		sdo.NodeID = uint8(canID - 0x600)
+	case IsSDOResponse(canID) && specifier == SDOServerUploadInitiate:
+		sdo.NodeID = uint8(canID - 0x580)
+		sdo.Upload = true
+	default:
+		return SDOExpedited{}, false
+	}
+	if !expedited {
+		return SDOExpedited{}, false
+	}
+
+	sdo.Index = binary.LittleEndian.Uint16(data[1:3])
+	sdo.SubIndex = data[3]
+	sdo.Size = 4
+	if sizeIndicated {
+		sdo.Size = 4 - int((command>>2)&0x03)
+	}
+
+	for i := 0; i < sdo.Size; i++ {
+		sdo.Value |= uint64(data[4+i]) << (8 * i)
+	}
+
+	return sdo, true
+}
+
+// SDO transfer types
+const (
+	SDOTransferExpedited = "expedited" // Up to 4 bytes in the initiate frame
+	SDOTransferSegmented = "segmented" // 7 bytes per confirmed segment
+	SDOTransferBlock     = "block"     // Blocks of up to 127 segments confirmed at once, with a CRC
+)
+
+// SDO abort codes (CiA 301)
+var sdoAbortCodes = map[uint32]string{
+	0x05030000: "toggle bit not alternated",
+	0x05040000: "SDO protocol timed out",
+	0x05040001: "client/server command specifier not valid or unknown",
+	0x05040002: "invalid block size",
+	0x05040003: "invalid sequence number",
+	0x05040004: "CRC error",
+	0x05040005: "out of memory",
+	0x06010000: "unsupported access to an object",
+	0x06010001: "attempt to read a write only object",
+	0x06010002: "attempt to write a read only object",
+	0x06020000: "object does not exist in the object dictionary",
+	0x06040041: "object cannot be mapped to the PDO",
+	0x06040042: "the number and length of the objects to be mapped would exceed PDO length",
+	0x06040043: "general parameter incompatibility reason",
+	0x06040047: "general internal incompatibility in the device",
+	0x06060000: "access failed due to a hardware error",
+	0x06070010: "data type does not match, length of service parameter does not match",
+	0x06070012: "data type does not match, length of service parameter too high",
+	0x06070013: "data type does not match, length of service parameter too low",
+	0x06090011: "sub-index does not exist",
+	0x06090030: "invalid value for parameter",
+	0x06090031: "value of parameter written too high",
+	0x06090032: "value of parameter written too low",
+	0x06090036: "maximum value is less than minimum value",
+	0x060A0023: "resource not available: SDO connection",
+	0x08000000: "general error",
+	0x08000020: "data cannot be transferred or stored to the application",
+	0x08000021: "data cannot be transferred or stored to the application because of local control",
+	0x08000022: "data cannot be transferred or stored to the application because of the present device state",
+	0x08000023: "object dictionary dynamic generation fails or no object dictionary is present",
+	0x08000024: "no data available",
+}
+
+// SDOAbortDescription returns the CiA 301 description of an SDO abort code
+func SDOAbortDescription(code uint32) string {
+	if description, ok := sdoAbortCodes[code]; ok {
+		return description
+	}
+	return "unknown abort code"
+}
+
+// SDOWriteRequest is the request to write an object dictionary entry
+type SDOWriteRequest struct {
+	Interface string          `json:"interface"`
+	Value     json.RawMessage `json:"value"`      // Number, string or boolean encoded by the data type
+	Data      []byte          `json:"data"`       // Raw bytes instead of value, a JSON array or base64
+	DataType  uint16          `json:"data_type"`  // Overrides the EDS data type
+	Transfer  string          `json:"transfer"`   // "block" requests a block download
+	User      string          `json:"-"`          // Authenticated operator, recorded with the write
+	TimeoutMs int             `json:"timeout_ms"` // Wait for each SDO response, default 1000
+}
+
+// SDOResult is the outcome of an SDO upload (read) or download (write) of an object dictionary entry
+type SDOResult struct {
+	NodeID        uint8   `json:"node_id"`
+	Interface     string  `json:"interface"`
+	Index         uint16  `json:"index"`
+	SubIndex      uint8   `json:"sub_index"`
+	IndexHex      string  `json:"index_hex"` // e.g. "0x1018sub1"
+	ParameterName string  `json:"parameter_name,omitempty"`
+	DataType      uint16  `json:"data_type,omitempty"`
+	AccessType    string  `json:"access_type,omitempty"`
+	Value         any     `json:"value,omitempty"` // Decoded by the data type
+	Data          []uint8 `json:"data,omitempty"`
+	DataHex       string  `json:"data_hex,omitempty"`
+	Size          int     `json:"size"`
+	Transfer      string  `json:"transfer,omitempty"` // "expedited", "segmented" or "block"
+	DurationMs    float64 `json:"duration_ms"`
+	User          string  `json:"user,omitempty"`
+
+	// Batch reads compare the node with its DCF
+	ConfiguredValue string `json:"configured_value,omitempty"` // ParameterValue or DefaultValue of the DCF
+	Differs         bool   `json:"differs,omitempty"`          // Read value differs from the configured value
+
+	// Failed transfer of a batch read
+	Error     string `json:"error,omitempty"`
+	AbortCode uint32 `json:"abort_code,omitempty"`
+}
+
+// SDOBatchResult is the outcome of reading a DCF section from a node
+type SDOBatchResult struct {
+	NodeID     uint8       `json:"node_id"`
+	Interface  string      `json:"interface"`
+	EDSFile    string      `json:"eds_file"`
+	Section    string      `json:"section"`
+	Entries    []SDOResult `json:"entries"`
+	Read       int         `json:"read"`
+	Failed     int         `json:"failed"`
+	Differs    int         `json:"differs"`
+	Skipped    int         `json:"skipped"` // Write only entries and the entries after a timeout
+	DurationMs float64     `json:"duration_ms"`
+}
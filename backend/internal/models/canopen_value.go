@@ -0,0 +1,182 @@
+package models
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// odTypeSizes holds the encoded size in bytes of the fixed size object dictionary data types
+var odTypeSizes = map[uint16]int{
+	ODTypeBoolean:    1,
+	ODTypeInteger8:   1,
+	ODTypeInteger16:  2,
+	ODTypeInteger32:  4,
+	ODTypeUnsigned8:  1,
+	ODTypeUnsigned16: 2,
+	ODTypeUnsigned32: 4,
+	ODTypeReal32:     4,
+	ODTypeReal64:     8,
+	ODTypeInteger64:  8,
+	ODTypeUnsigned64: 8,
+}
+
+// ODTypeName returns the CiA 301 name of an object dictionary data type
+func ODTypeName(dataType uint16) string {
+	switch dataType {
+	case ODTypeBoolean:
+		return "BOOLEAN"
+	case ODTypeInteger8:
+		return "INTEGER8"
+	case ODTypeInteger16:
+		return "INTEGER16"
+	case ODTypeInteger32:
+		return "INTEGER32"
+	case ODTypeUnsigned8:
+		return "UNSIGNED8"
+	case ODTypeUnsigned16:
+		return "UNSIGNED16"
+	case ODTypeUnsigned32:
+		return "UNSIGNED32"
+	case ODTypeReal32:
+		return "REAL32"
+	case ODTypeVisibleString:
+		return "VISIBLE_STRING"
+	case ODTypeOctetString:
+		return "OCTET_STRING"
+	case ODTypeUnicodeString:
+		return "UNICODE_STRING"
+	case ODTypeDomain:
+		return "DOMAIN"
+	case ODTypeReal64:
+		return "REAL64"
+	case ODTypeInteger64:
+		return "INTEGER64"
+	case ODTypeUnsigned64:
+		return "UNSIGNED64"
+	default:
+		return fmt.Sprintf("0x%04X", dataType)
+	}
+}
+
+// DecodeODValue decodes the data of an object dictionary entry by its data type
+// Integers decode to int64 or uint64, strings to string, and octet strings and domains to a hex string.
+// Devices answering with more bytes than the type holds (an expedited upload without size) are truncated.
+func DecodeODValue(dataType uint16, data []byte) (any, error) {
+	if size, ok := odTypeSizes[dataType]; ok {
+		if len(data) < size {
+			return nil, fmt.Errorf("%s needs %d bytes, got %d", ODTypeName(dataType), size, len(data))
+		}
+		data = data[:size]
+	}
+
+	switch dataType {
+	case ODTypeBoolean:
+		return data[0] != 0, nil
+	case ODTypeInteger8, ODTypeInteger16, ODTypeInteger32, ODTypeInteger64:
+		value := leUint(data)
+		shift := 64 - 8*len(data)
+		return int64(value<<shift) >> shift, nil
+	case ODTypeUnsigned8, ODTypeUnsigned16, ODTypeUnsigned32, ODTypeUnsigned64:
+		return leUint(data), nil
+	case ODTypeReal32:
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(data))), nil
+	case ODTypeReal64:
+		return math.Float64frombits(binary.LittleEndian.Uint64(data)), nil
+	case ODTypeVisibleString:
+		// Strings may be padded with NUL bytes up to the object size
+		return strings.TrimRight(string(data), "\x00"), nil
+	case ODTypeUnicodeString:
+		units := make([]uint16, 0, len(data)/2)
+		for i := 0; i+1 < len(data); i += 2 {
+			units = append(units, binary.LittleEndian.Uint16(data[i:]))
+		}
+		return strings.TrimRight(string(utf16.Decode(units)), "\x00"), nil
+	case ODTypeOctetString, ODTypeDomain:
+		return fmt.Sprintf("% X", data), nil
+	default:
+		return nil, fmt.Errorf("unsupported data type %s", ODTypeName(dataType))
+	}
+}
+
+// EncodeODValue encodes a value for an object dictionary entry by its data type
+// Integers accept decimal, 0x hex and "$NODEID+..." expressions as in EDS files, booleans true/false
+// or 0/1, and octet strings and domains hex bytes with optional spaces (e.g. "01 02 0A").
+func EncodeODValue(dataType uint16, value string, nodeID uint8) ([]byte, error) {
+	value = strings.TrimSpace(value)
+	size := odTypeSizes[dataType]
+	buf := make([]byte, 8)
+
+	switch dataType {
+	case ODTypeBoolean:
+		switch strings.ToLower(value) {
+		case "true", "1":
+			return []byte{1}, nil
+		case "false", "0":
+			return []byte{0}, nil
+		}
+		return nil, fmt.Errorf("invalid BOOLEAN '%s', must be true or false", value)
+	case ODTypeInteger8, ODTypeInteger16, ODTypeInteger32, ODTypeInteger64:
+		n, err := strconv.ParseInt(value, 0, 8*size)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s '%s': %v", ODTypeName(dataType), value, err)
+		}
+		binary.LittleEndian.PutUint64(buf, uint64(n))
+		return buf[:size], nil
+	case ODTypeUnsigned8, ODTypeUnsigned16, ODTypeUnsigned32, ODTypeUnsigned64:
+		n, err := ParseEDSInteger(value, nodeID)
+		if err != nil {
+			return nil, err
+		}
+		if size < 8 && n >= 1<<(8*size) {
+			return nil, fmt.Errorf("invalid %s '%s': value out of range", ODTypeName(dataType), value)
+		}
+		binary.LittleEndian.PutUint64(buf, n)
+		return buf[:size], nil
+	case ODTypeReal32:
+		f, err := strconv.ParseFloat(value, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid REAL32 '%s': %v", value, err)
+		}
+		binary.LittleEndian.PutUint32(buf, math.Float32bits(float32(f)))
+		return buf[:4], nil
+	case ODTypeReal64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid REAL64 '%s': %v", value, err)
+		}
+		binary.LittleEndian.PutUint64(buf, math.Float64bits(f))
+		return buf, nil
+	case ODTypeVisibleString:
+		return []byte(value), nil
+	case ODTypeUnicodeString:
+		units := utf16.Encode([]rune(value))
+		data := make([]byte, 2*len(units))
+		for i, unit := range units {
+			binary.LittleEndian.PutUint16(data[2*i:], unit)
+		}
+		return data, nil
+	case ODTypeOctetString, ODTypeDomain:
+		digits := strings.NewReplacer(" ", "", "0x", "", "0X", "").Replace(value)
+		data, err := hex.DecodeString(digits)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s '%s': %v", ODTypeName(dataType), value, err)
+		}
+		return data, nil
+	default:
+		return nil, fmt.Errorf("unsupported data type %s", ODTypeName(dataType))
+	}
+}
+
+// leUint decodes up to 8 little-endian bytes
+func leUint(data []byte) uint64 {
+	var value uint64
+	for i, b := range data {
+		value |= uint64(b) << (8 * i)
+	}
+	return value
+}
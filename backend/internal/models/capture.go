@@ -0,0 +1,226 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Capture trigger types
+const (
+	TriggerCANID   = "can_id"  // Any frame of a CAN ID
+	TriggerPayload = "payload" // A frame of a CAN ID whose payload matches data under mask
+	TriggerEMCY    = "emcy"    // An EMCY frame, optionally of a node and error code
+	TriggerBusOff  = "bus_off" // The interface statistics report BUS-OFF
+	TriggerSignal  = "signal"  // A decoded signal rises above or falls below a threshold
+)
+
+// Capture statuses
+const (
+	CaptureRecording = "recording" // Post-trigger frames are still being persisted
+	CaptureComplete  = "complete"
+)
+
+// CaptureTrigger describes a condition that starts or extends a triggered capture
+type CaptureTrigger struct {
+	Name      string   `yaml:"name" json:"name"`
+	Type      string   `yaml:"type" json:"type"`
+	CANID     uint32   `yaml:"can_id" json:"can_id,omitempty"`         // With SocketCAN flags for extended IDs
+	Data      string   `yaml:"data" json:"data,omitempty"`             // Hex bytes, e.g. "01 00 FF"
+	Mask      string   `yaml:"mask" json:"mask,omitempty"`             // Hex bytes, defaults to FF for every data byte
+	NodeID    uint8    `yaml:"node_id" json:"node_id,omitempty"`       // EMCY and signal triggers, 0 for any node
+	ErrorCode *uint16  `yaml:"error_code" json:"error_code,omitempty"` // EMCY trigger, any non-zero code when unset
+	Signal    string   `yaml:"signal" json:"signal,omitempty"`
+	Above     *float64 `yaml:"above" json:"above,omitempty"`
+	Below     *float64 `yaml:"below" json:"below,omitempty"`
+
+	data []byte
+	mask []byte
+}
+
+// validate checks the trigger and parses its payload pattern
+func (t *CaptureTrigger) validate() error {
+	switch t.Type {
+	case TriggerCANID, TriggerBusOff:
+	case TriggerPayload:
+		data, err := parseHexBytes(t.Data)
+		if err != nil || len(data) == 0 {
+			return fmt.Errorf("invalid data '%s' of trigger '%s'", t.Data, t.Name)
+		}
+		mask := make([]byte, len(data))
+		for i := range mask {
+			mask[i] = 0xFF
+		}
+		if t.Mask != "" {
+			mask, err = parseHexBytes(t.Mask)
+			if err != nil || len(mask) != len(data) {
+				return fmt.Errorf("invalid mask '%s' of trigger '%s', must have as many bytes as data", t.Mask, t.Name)
+			}
+		}
+		t.data, t.mask = data, mask
+	case TriggerEMCY:
+		if t.NodeID > 127 {
+			return fmt.Errorf("invalid node_id %d of trigger '%s'", t.NodeID, t.Name)
+		}
+	case TriggerSignal:
+		if t.Signal == "" || (t.Above == nil && t.Below == nil) {
+			return fmt.Errorf("signal trigger '%s' requires signal and above or below", t.Name)
+		}
+	default:
+		return fmt.Errorf("invalid type '%s' of trigger '%s', must be one of: can_id, payload, emcy, bus_off, signal", t.Type, t.Name)
+	}
+	if t.Name == "" {
+		t.Name = t.Type
+	}
+	return nil
+}
+
+// MatchFrame reports whether a frame fires a can_id, payload or EMCY trigger, with a description
+func (t *CaptureTrigger) MatchFrame(canID uint32, data []byte) (string, bool) {
+	switch t.Type {
+	case TriggerCANID:
+		if canID == t.CANID {
+			return fmt.Sprintf("0x%X", canID), true
+		}
+	case TriggerPayload:
+		if canID != t.CANID || len(data) < len(t.data) {
+			return "", false
+		}
+		for i := range t.data {
+			if data[i]&t.mask[i] != t.data[i]&t.mask[i] {
+				return "", false
+			}
+		}
+		return fmt.Sprintf("0x%X % X", canID, data), true
+	case TriggerEMCY:
+		if !IsEMCYFrame(canID) || len(data) < 2 {
+			return "", false
+		}
+		nodeID := uint8(canID - 0x080)
+		code := uint16(data[0]) | uint16(data[1])<<8
+		if code == 0 || (t.NodeID != 0 && nodeID != t.NodeID) || (t.ErrorCode != nil && code != *t.ErrorCode) {
+			return "", false
+		}
+		return fmt.Sprintf("node %d EMCY 0x%04X", nodeID, code), true
+	}
+	return "", false
+}
+
+// MatchSignal reports whether a decoded value meets a signal trigger's threshold, with a description
+func (t *CaptureTrigger) MatchSignal(value SignalValue) (string, bool) {
+	if t.Type != TriggerSignal || value.Signal != t.Signal || (t.NodeID != 0 && value.NodeID != t.NodeID) {
+		return "", false
+	}
+	if t.Above != nil && value.ValueFloat > *t.Above {
+		return fmt.Sprintf("%s=%g > %g", value.Signal, value.ValueFloat, *t.Above), true
+	}
+	if t.Below != nil && value.ValueFloat < *t.Below {
+		return fmt.Sprintf("%s=%g < %g", value.Signal, value.ValueFloat, *t.Below), true
+	}
+	return "", false
+}
+
+// MatchStats reports whether interface statistics fire a bus_off trigger
+func (t *CaptureTrigger) MatchStats(stats SocketCANStats) (string, bool) {
+	if t.Type != TriggerBusOff || stats.BusState != "BUS-OFF" {
+		return "", false
+	}
+	return fmt.Sprintf("%s BUS-OFF", stats.Interface), true
+}
+
+// parseHexBytes parses space-separated hex bytes, e.g. "01 0A FF"
+func parseHexBytes(s string) ([]byte, error) {
+	fields := strings.Fields(s)
+	data := make([]byte, 0, len(fields))
+	for _, field := range fields {
+		b, err := strconv.ParseUint(strings.TrimPrefix(field, "0x"), 16, 8)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, byte(b))
+	}
+	return data, nil
+}
+
+// CaptureTriggerFile is a YAML file of capture triggers
+// Example:
+//
+//	triggers:
+//	  - name: drive_fault
+//	    type: payload
+//	    can_id: 0x183
+//	    data: "08 00"
+//	    mask: "08 00"
+//	  - name: any_emcy
+//	    type: emcy
+//	  - name: overpressure
+//	    type: signal
+//	    signal: hydraulic_pressure
+//	    above: 250
+//	  - name: bus_off
+//	    type: bus_off
+type CaptureTriggerFile struct {
+	Triggers []CaptureTrigger `yaml:"triggers"`
+}
+
+// LoadCaptureTriggers loads capture triggers from YAML files
+func LoadCaptureTriggers(paths []string) ([]CaptureTrigger, error) {
+	triggers := []CaptureTrigger{}
+
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read capture triggers %s: %w", path, err)
+		}
+
+		file := &CaptureTriggerFile{}
+		if err := yaml.Unmarshal(content, file); err != nil {
+			return nil, fmt.Errorf("failed to parse capture triggers %s: %w", path, err)
+		}
+
+		for _, trigger := range file.Triggers {
+			if err := trigger.validate(); err != nil {
+				return nil, fmt.Errorf("%v in %s", err, path)
+			}
+			triggers = append(triggers, trigger)
+		}
+	}
+
+	return triggers, nil
+}
+
+// Capture is the metadata of a triggered recording
+type Capture struct {
+	RecordingID   string    `json:"recording_id"`
+	DeviceID      string    `json:"device_id"`
+	Interface     string    `json:"interface"`
+	Status        string    `json:"status"`
+	TriggerName   string    `json:"trigger_name"`
+	TriggerType   string    `json:"trigger_type"`
+	TriggerDetail string    `json:"trigger_detail"`
+	TriggerTime   time.Time `json:"trigger_time"`
+	StartTime     time.Time `json:"start_time"` // First persisted pre-trigger frame
+	EndTime       time.Time `json:"end_time"`   // Post-trigger deadline of the last trigger
+	Frames        uint64    `json:"frames"`
+	Triggers      uint64    `json:"triggers"` // Times a trigger fired during the capture
+	PreTriggerMs  uint32    `json:"pre_trigger_ms"`
+	PostTriggerMs uint32    `json:"post_trigger_ms"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// NewRecordingID returns a random RFC 4122 version 4 UUID
+func NewRecordingID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0F | 0x40
+	b[8] = b[8]&0x3F | 0x80
+
+	id := hex.EncodeToString(b[:])
+	return id[0:8] + "-" + id[8:12] + "-" + id[12:16] + "-" + id[16:20] + "-" + id[20:32]
+}
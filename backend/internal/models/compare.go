@@ -0,0 +1,303 @@
+package models
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"time"
+)
+
+// CompareDefaultTolerance is the relative change in percent below which a difference is not reported
+const CompareDefaultTolerance = 10.0
+
+// CANIDWindowStats holds the frame count and inter-arrival statistics of a CAN ID in a window
+type CANIDWindowStats struct {
+	CANID    uint32  `json:"can_id"`
+	CANIDHex string  `json:"can_id_hex"`
+	Frames   uint64  `json:"frames"`
+	PeriodMs float64 `json:"period_ms"` // Mean inter-arrival time
+	JitterMs float64 `json:"jitter_ms"` // Standard deviation of the inter-arrival time
+	P50Ms    float64 `json:"p50_ms"`
+}
+
+// ValueDistribution summarises the values of a payload byte or decoded signal in a window
+type ValueDistribution struct {
+	Count    uint64  `json:"count"`
+	Min      float64 `json:"min"`
+	Max      float64 `json:"max"`
+	Mean     float64 `json:"mean"`
+	StdDev   float64 `json:"stddev"`
+	Distinct uint64  `json:"distinct"`
+}
+
+// EMCYCodeCount is an EMCY error code seen from a node in a window
+type EMCYCodeCount struct {
+	NodeID       uint8     `json:"node_id"`
+	ErrorCode    uint16    `json:"error_code"`
+	ErrorCodeHex string    `json:"error_code_hex"`
+	Description  string    `json:"description"`
+	Count        uint64    `json:"count"`
+	FirstSeen    time.Time `json:"first_seen"`
+}
+
+// byteKey identifies a payload byte of a CAN ID
+type byteKey struct {
+	canID uint32
+	index int
+}
+
+// signalKey identifies a decoded signal of a node
+type signalKey struct {
+	signal string
+	nodeID uint8
+}
+
+// emcyKey identifies an EMCY error code of a node
+type emcyKey struct {
+	nodeID uint8
+	code   uint16
+}
+
+// CompareWindow collects the traffic summary of one of the compared windows
+type CompareWindow struct {
+	Interface string    `json:"interface,omitempty"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Frames    uint64    `json:"frames"`
+	CANIDs    int       `json:"can_ids"`
+
+	canIDs  map[uint32]CANIDWindowStats
+	bytes   map[byteKey]ValueDistribution
+	signals map[signalKey]ValueDistribution
+	units   map[string]string
+	emcy    map[emcyKey]EMCYCodeCount
+}
+
+// NewCompareWindow creates an empty window summary
+func NewCompareWindow(iface string, start, end time.Time) *CompareWindow {
+	return &CompareWindow{
+		Interface: iface,
+		Start:     start,
+		End:       end,
+		canIDs:    make(map[uint32]CANIDWindowStats),
+		bytes:     make(map[byteKey]ValueDistribution),
+		signals:   make(map[signalKey]ValueDistribution),
+		units:     make(map[string]string),
+		emcy:      make(map[emcyKey]EMCYCodeCount),
+	}
+}
+
+// AddCANID records the statistics of a CAN ID
+func (w *CompareWindow) AddCANID(stats CANIDWindowStats) {
+	stats.CANIDHex = fmt.Sprintf("0x%X", stats.CANID)
+	stats.PeriodMs = roundMicro(stats.PeriodMs)
+	stats.JitterMs = roundMicro(stats.JitterMs)
+	stats.P50Ms = roundMicro(stats.P50Ms)
+	w.canIDs[stats.CANID] = stats
+	w.Frames += stats.Frames
+	w.CANIDs = len(w.canIDs)
+}
+
+// AddByte records the value distribution of a payload byte
+func (w *CompareWindow) AddByte(canID uint32, index int, dist ValueDistribution) {
+	w.bytes[byteKey{canID: canID, index: index}] = roundDistribution(dist)
+}
+
+// AddSignal records the value distribution of a decoded signal
+func (w *CompareWindow) AddSignal(signal string, nodeID uint8, unit string, dist ValueDistribution) {
+	w.signals[signalKey{signal: signal, nodeID: nodeID}] = roundDistribution(dist)
+	w.units[signal] = unit
+}
+
+// AddEMCY records an EMCY error code
+func (w *CompareWindow) AddEMCY(code EMCYCodeCount) {
+	code.ErrorCodeHex = fmt.Sprintf("0x%04X", code.ErrorCode)
+	w.emcy[emcyKey{nodeID: code.NodeID, code: code.ErrorCode}] = code
+}
+
+// TimingChange is a CAN ID whose period or jitter changed between the windows
+type TimingChange struct {
+	CANID           uint32           `json:"can_id"`
+	CANIDHex        string           `json:"can_id_hex"`
+	A               CANIDWindowStats `json:"a"`
+	B               CANIDWindowStats `json:"b"`
+	PeriodChangePct float64          `json:"period_change_pct"`
+	JitterChangeMs  float64          `json:"jitter_change_ms"`
+}
+
+// ByteChange is a payload byte whose value distribution changed between the windows
+type ByteChange struct {
+	CANID    uint32            `json:"can_id"`
+	CANIDHex string            `json:"can_id_hex"`
+	Index    int               `json:"index"`
+	A        ValueDistribution `json:"a"`
+	B        ValueDistribution `json:"b"`
+}
+
+// SignalChange is a decoded signal whose value distribution changed between the windows
+type SignalChange struct {
+	Signal        string            `json:"signal"`
+	NodeID        uint8             `json:"node_id"`
+	Unit          string            `json:"unit,omitempty"`
+	A             ValueDistribution `json:"a"`
+	B             ValueDistribution `json:"b"`
+	MeanChangePct float64           `json:"mean_change_pct"` // Relative to the value scale of window A
+}
+
+// CompareReport is the difference between two windows
+type CompareReport struct {
+	A                 *CompareWindow     `json:"a"`
+	B                 *CompareWindow     `json:"b"`
+	TolerancePct      float64            `json:"tolerance_pct"`
+	OnlyInA           []CANIDWindowStats `json:"only_in_a"`
+	OnlyInB           []CANIDWindowStats `json:"only_in_b"`
+	TimingChanges     []TimingChange     `json:"timing_changes"`
+	ByteChanges       []ByteChange       `json:"byte_changes"`
+	SignalChanges     []SignalChange     `json:"signal_changes"`
+	NewEMCYCodes      []EMCYCodeCount    `json:"new_emcy_codes"`
+	ResolvedEMCYCodes []EMCYCodeCount    `json:"resolved_emcy_codes"` // Seen in A only
+}
+
+// CompareWindows reports the differences between window a (baseline) and window b
+// A period, jitter or value distribution is reported when it changed by more than tolerancePct percent:
+// the period relative to A's period, the jitter relative to A's period, and values relative to A's value
+// range (or mean for constant values). A byte whose range changed is always reported.
+func CompareWindows(a, b *CompareWindow, tolerancePct float64) CompareReport {
+	report := CompareReport{
+		A:                 a,
+		B:                 b,
+		TolerancePct:      tolerancePct,
+		OnlyInA:           []CANIDWindowStats{},
+		OnlyInB:           []CANIDWindowStats{},
+		TimingChanges:     []TimingChange{},
+		ByteChanges:       []ByteChange{},
+		SignalChanges:     []SignalChange{},
+		NewEMCYCodes:      []EMCYCodeCount{},
+		ResolvedEMCYCodes: []EMCYCodeCount{},
+	}
+
+	for _, canID := range slices.Sorted(maps.Keys(a.canIDs)) {
+		statsA := a.canIDs[canID]
+		statsB, ok := b.canIDs[canID]
+		if !ok {
+			report.OnlyInA = append(report.OnlyInA, statsA)
+			continue
+		}
+
+		// A CAN ID seen once has no period to compare
+		if statsA.Frames < 2 || statsB.Frames < 2 || statsA.PeriodMs <= 0 {
+			continue
+		}
+		periodChange := (statsB.PeriodMs - statsA.PeriodMs) / statsA.PeriodMs * 100
+		jitterChange := statsB.JitterMs - statsA.JitterMs
+		if math.Abs(periodChange) > tolerancePct || math.Abs(jitterChange)/statsA.PeriodMs*100 > tolerancePct {
+			report.TimingChanges = append(report.TimingChanges, TimingChange{
+				CANID:           canID,
+				CANIDHex:        statsA.CANIDHex,
+				A:               statsA,
+				B:               statsB,
+				PeriodChangePct: round3(periodChange),
+				JitterChangeMs:  roundMicro(jitterChange),
+			})
+		}
+	}
+	for _, canID := range slices.Sorted(maps.Keys(b.canIDs)) {
+		if _, ok := a.canIDs[canID]; !ok {
+			report.OnlyInB = append(report.OnlyInB, b.canIDs[canID])
+		}
+	}
+
+	byteKeys := slices.SortedFunc(maps.Keys(a.bytes), func(x, y byteKey) int {
+		if c := cmp.Compare(x.canID, y.canID); c != 0 {
+			return c
+		}
+		return cmp.Compare(x.index, y.index)
+	})
+	for _, key := range byteKeys {
+		distA := a.bytes[key]
+		distB, ok := b.bytes[key]
+		if !ok {
+			continue
+		}
+		if distA.Min != distB.Min || distA.Max != distB.Max || distributionChange(distA, distB) > tolerancePct {
+			report.ByteChanges = append(report.ByteChanges, ByteChange{
+				CANID:    key.canID,
+				CANIDHex: fmt.Sprintf("0x%X", key.canID),
+				Index:    key.index,
+				A:        distA,
+				B:        distB,
+			})
+		}
+	}
+
+	signalKeys := slices.SortedFunc(maps.Keys(a.signals), func(x, y signalKey) int {
+		if c := cmp.Compare(x.signal, y.signal); c != 0 {
+			return c
+		}
+		return cmp.Compare(x.nodeID, y.nodeID)
+	})
+	for _, key := range signalKeys {
+		distA := a.signals[key]
+		distB, ok := b.signals[key]
+		if !ok {
+			continue
+		}
+		if change := distributionChange(distA, distB); change > tolerancePct {
+			report.SignalChanges = append(report.SignalChanges, SignalChange{
+				Signal:        key.signal,
+				NodeID:        key.nodeID,
+				Unit:          a.units[key.signal],
+				A:             distA,
+				B:             distB,
+				MeanChangePct: round3((distB.Mean - distA.Mean) / valueScale(distA) * 100),
+			})
+		}
+	}
+
+	report.NewEMCYCodes = emcyDifference(b.emcy, a.emcy)
+	report.ResolvedEMCYCodes = emcyDifference(a.emcy, b.emcy)
+
+	return report
+}
+
+// distributionChange returns the largest change of the mean, standard deviation or bounds in percent
+// of the value scale of the baseline
+func distributionChange(a, b ValueDistribution) float64 {
+	scale := valueScale(a)
+	change := 0.0
+	for _, delta := range []float64{b.Mean - a.Mean, b.StdDev - a.StdDev, b.Min - a.Min, b.Max - a.Max} {
+		change = max(change, math.Abs(delta)/scale*100)
+	}
+	return change
+}
+
+// valueScale is the value range of a distribution, or its magnitude when constant
+func valueScale(d ValueDistribution) float64 {
+	return max(d.Max-d.Min, math.Abs(d.Mean), 1e-9)
+}
+
+// emcyDifference returns the EMCY codes of from that are not in other, ordered by node and code
+func emcyDifference(from, other map[emcyKey]EMCYCodeCount) []EMCYCodeCount {
+	codes := []EMCYCodeCount{}
+	for key, code := range from {
+		if _, ok := other[key]; !ok {
+			codes = append(codes, code)
+		}
+	}
+	slices.SortFunc(codes, func(x, y EMCYCodeCount) int {
+		if c := cmp.Compare(x.NodeID, y.NodeID); c != 0 {
+			return c
+		}
+		return cmp.Compare(x.ErrorCode, y.ErrorCode)
+	})
+	return codes
+}
+
+// roundDistribution rounds the mean and standard deviation to three decimals
+func roundDistribution(d ValueDistribution) ValueDistribution {
+	d.Mean = round3(d.Mean)
+	d.StdDev = round3(d.StdDev)
+	return d
+}
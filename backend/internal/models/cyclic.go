@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// Cyclic transmission modes
+const (
+	CyclicModeBCM   = "bcm"   // Kernel Broadcast Manager (CAN_BCM TX_SETUP)
+	CyclicModeTimer = "timer" // User-space timer writing to a raw socket
+)
+
+// Cyclic job states
+const (
+	CyclicRunning = "running"
+	CyclicPaused  = "paused"
+)
+
+// CyclicJobRequest is the request to create a cyclic transmission job
+type CyclicJobRequest struct {
+	Interface string  `json:"interface"`
+	CANID     uint32  `json:"can_id"` // With SocketCAN flags for extended IDs and remote frames
+	Data      []byte  `json:"data"`   // Up to 8 bytes, a JSON array or base64
+	PeriodMs  float64 `json:"period_ms"`
+	Mode      string  `json:"mode"` // "bcm" (default, the timer is used when BCM is unavailable) or "timer"
+	Paused    bool    `json:"paused"`
+	User      string  `json:"-"` // Authenticated operator
+}
+
+// CyclicJobUpdate changes a running job; omitted fields are kept
+type CyclicJobUpdate struct {
+	Data     *[]byte  `json:"data"` // Applied from the next transmission
+	PeriodMs *float64 `json:"period_ms"`
+	Paused   *bool    `json:"paused"`
+}
+
+// CyclicJob is a cyclic transmission job and the timing of its transmitted frames
+// The timing is measured on the frames looped back by the interface; intervals spanning a pause
+// or a period change are not counted.
+type CyclicJob struct {
+	ID             string     `json:"id"`
+	Interface      string     `json:"interface"`
+	CANID          uint32     `json:"can_id"`
+	CANIDHex       string     `json:"can_id_hex"`
+	DLC            uint8      `json:"dlc"`
+	Data           []uint8    `json:"data"`
+	DataHex        string     `json:"data_hex"`
+	PeriodMs       float64    `json:"period_ms"`
+	Mode           string     `json:"mode"`
+	State          string     `json:"state"`
+	User           string     `json:"user,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	Frames         uint64     `json:"frames"` // Transmitted frames
+	LastTx         *time.Time `json:"last_tx,omitempty"`
+	MeanPeriodMs   float64    `json:"mean_period_ms"`
+	JitterMs       float64    `json:"jitter_ms"`        // Standard deviation of the period
+	MaxDeviationMs float64    `json:"max_deviation_ms"` // Largest deviation from period_ms
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Device identifies the device (robot, gateway) a row was recorded on,
+// so that the rows of a fleet writing to one database can be told apart
+type Device struct {
+	DeviceID   string `json:"device_id,omitempty"`
+	Site       string `json:"site,omitempty"`
+	HWRevision string `json:"hw_revision,omitempty"`
+}
+
+// DeviceInfo summarizes the data a device ingested
+type DeviceInfo struct {
+	DeviceID   string    `json:"device_id"`
+	Site       string    `json:"site"`
+	HWRevision string    `json:"hw_revision"`
+	Interfaces []string  `json:"interfaces"`
+	FirstSeen  time.Time `json:"first_seen"`
+	LastSeen   time.Time `json:"last_seen"`
+	Frames     uint64    `json:"frames"`      // Frames received in the queried range
+	IngestRate float64   `json:"ingest_rate"` // Frames per second over the rate window
+}
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"fmt"
+	"math"
+)
+
+// Downsampling methods for plotted series
+const (
+	DownsampleMinMax = "minmax" // Minimum and maximum sample of each time bucket
+	DownsampleLTTB   = "lttb"   // Largest-Triangle-Three-Buckets over the per-bucket extremes
+)
+
+// DownsampleMinPoints is the smallest max_points accepted
+const DownsampleMinPoints = 4
+
+// DownsamplePoint is a sample of a series; Index refers back to the caller's row
+type DownsamplePoint struct {
+	X     int64 // Unix time in microseconds
+	Y     float64
+	Index int
+}
+
+// ValidateDownsample checks a downsampling method and point budget
+func ValidateDownsample(method string, maxPoints int) error {
+	if method != DownsampleMinMax && method != DownsampleLTTB {
+		return fmt.Errorf("invalid downsample method '%s', must be minmax or lttb", method)
+	}
+	if maxPoints < DownsampleMinPoints {
+		return fmt.Errorf("invalid max_points %d, must be at least %d", maxPoints, DownsampleMinPoints)
+	}
+	return nil
+}
+
+// DownsampleBuckets returns the number of time buckets whose minimum and maximum are fetched per series
+// Min/max keeps both extremes of each bucket, so it uses half the point budget. LTTB selects from
+// the extremes of four times as many candidates, so single-sample spikes survive either way.
+func DownsampleBuckets(method string, maxPoints int) int {
+	if method == DownsampleLTTB {
+		return maxPoints * 2
+	}
+	return maxPoints / 2
+}
+
+// LTTB reduces a time-ordered series to threshold points with the Largest-Triangle-Three-Buckets
+// algorithm (Steinarsson, 2013); the first and last points are always kept
+func LTTB(points []DownsamplePoint, threshold int) []DownsamplePoint {
+	if threshold >= len(points) || threshold < 3 {
+		return points
+	}
+
+	sampled := make([]DownsamplePoint, 0, threshold)
+	sampled = append(sampled, points[0])
+
+	// Bucket size excluding the first and last points
+	every := float64(len(points)-2) / float64(threshold-2)
+	a := 0
+
+	for i := 0; i < threshold-2; i++ {
+		// Average of the next bucket is the third triangle vertex
+		avgStart := int(math.Floor(float64(i+1)*every)) + 1
+		avgEnd := min(int(math.Floor(float64(i+2)*every))+1, len(points))
+		var avgX, avgY float64
+		for _, p := range points[avgStart:avgEnd] {
+			avgX += float64(p.X)
+			avgY += p.Y
+		}
+		n := float64(avgEnd - avgStart)
+		avgX /= n
+		avgY /= n
+
+		// Point of the current bucket forming the largest triangle with the previously selected point
+		rangeStart := int(math.Floor(float64(i)*every)) + 1
+		rangeEnd := int(math.Floor(float64(i+1)*every)) + 1
+		ax, ay := float64(points[a].X), points[a].Y
+
+		maxArea := -1.0
+		next := rangeStart
+		for j := rangeStart; j < rangeEnd; j++ {
+			area := math.Abs((ax-avgX)*(points[j].Y-ay) - (ax-float64(points[j].X))*(avgY-ay))
+			if area > maxArea {
+				maxArea = area
+				next = j
+			}
+		}
+
+		sampled = append(sampled, points[next])
+		a = next
+	}
+
+	return append(sampled, points[len(points)-1])
+}
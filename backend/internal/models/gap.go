@@ -0,0 +1,104 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Gap scopes: what went silent
+const (
+	GapScopeInterface = "interface"
+	GapScopeNode      = "node"
+	GapScopeCANID     = "can_id"
+)
+
+// Gap causes, from the most to the least specific
+const (
+	GapCauseReaderRestart = "reader_restart" // The can-reader stopped or restarted: logging outage
+	GapCauseInterfaceDown = "interface_down" // The interface was reported DOWN or the controller STOPPED
+	GapCauseBusOff        = "bus_off"        // The controller was reported BUS-OFF
+	GapCauseSilent        = "silent"         // Nothing recorded: the device or bus stopped sending
+)
+
+// GapDefaultFactor is the multiple of the learned period a silence must exceed without a threshold
+const GapDefaultFactor = 3.0
+
+// GapEvidence is a reader lifecycle event or interface state sample falling inside a gap
+type GapEvidence struct {
+	Timestamp time.Time `json:"timestamp"`
+	Source    string    `json:"source"` // "event" or "stats"
+	Type      string    `json:"type"`   // Event type, or the reported interface/bus state
+}
+
+// Gap is a period in which an interface, node or CAN ID was silent
+type Gap struct {
+	Interface  string        `json:"interface"`
+	Scope      string        `json:"scope"`
+	CANID      *uint32       `json:"can_id,omitempty"`
+	CANIDHex   string        `json:"can_id_hex,omitempty"`
+	NodeID     *uint8        `json:"node_id,omitempty"`
+	Start      time.Time     `json:"start"` // Last frame before the silence, or the start of the window
+	End        time.Time     `json:"end"`   // First frame after the silence, or the end of the window
+	DurationMs float64       `json:"duration_ms"`
+	PeriodMs   float64       `json:"period_ms"` // Learned period: median inter-arrival time
+	Periods    float64       `json:"periods"`   // Duration in learned periods
+	Leading    bool          `json:"leading"`   // No frame from the start of the window
+	Ongoing    bool          `json:"ongoing"`   // No frame until the end of the window
+	Cause      string        `json:"cause"`
+	Evidence   []GapEvidence `json:"evidence"`
+}
+
+// NewGap creates a gap of a scope key (0 for the interface scope) between two times
+func NewGap(iface, scope string, key uint32, start, end time.Time, periodMs float64) Gap {
+	gap := Gap{
+		Interface:  iface,
+		Scope:      scope,
+		Start:      start,
+		End:        end,
+		DurationMs: roundMicro(durationMs(end.Sub(start))),
+		PeriodMs:   roundMicro(periodMs),
+		Cause:      GapCauseSilent,
+		Evidence:   []GapEvidence{},
+	}
+	if periodMs > 0 {
+		gap.Periods = round3(durationMs(end.Sub(start)) / periodMs)
+	}
+
+	switch scope {
+	case GapScopeCANID:
+		gap.CANID = &key
+		gap.CANIDHex = fmt.Sprintf("0x%X", key)
+	case GapScopeNode:
+		nodeID := uint8(key)
+		gap.NodeID = &nodeID
+	}
+
+	return gap
+}
+
+// Classify attaches the evidence falling inside the gap and derives its cause
+// Evidence must be of the gap's interface. A reader restart outranks interface states because
+// nothing can have been recorded while the reader was down.
+func (g *Gap) Classify(evidence []GapEvidence) {
+	rank := map[string]int{GapCauseSilent: 0, GapCauseBusOff: 1, GapCauseInterfaceDown: 2, GapCauseReaderRestart: 3}
+
+	for _, e := range evidence {
+		if e.Timestamp.Before(g.Start) || e.Timestamp.After(g.End) {
+			continue
+		}
+		g.Evidence = append(g.Evidence, e)
+
+		cause := GapCauseSilent
+		switch {
+		case e.Source == "event" && (e.Type == EventReaderStarted || e.Type == EventReaderStopped):
+			cause = GapCauseReaderRestart
+		case e.Source == "stats" && (e.Type == "DOWN" || e.Type == "STOPPED"):
+			cause = GapCauseInterfaceDown
+		case e.Source == "stats" && e.Type == "BUS-OFF":
+			cause = GapCauseBusOff
+		}
+		if rank[cause] > rank[g.Cause] {
+			g.Cause = cause
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// ISO-TP protocol control information frame types (ISO 15765-2)
+const (
+	ISOTPSingleFrame      = 0x0
+	ISOTPFirstFrame       = 0x1
+	ISOTPConsecutiveFrame = 0x2
+	ISOTPFlowControl      = 0x3
+)
+
+// ISOTPTimeoutCr is the maximum time between consecutive frames (N_Cr)
+const ISOTPTimeoutCr = 1000 * time.Millisecond
+
+// ISOTPPair is a request/response CAN ID pair of a diagnostic connection
+type ISOTPPair struct {
+	RequestID  uint32 `json:"request_id"`
+	ResponseID uint32 `json:"response_id"`
+}
+
+// ParseISOTPPair parses a "REQUEST:RESPONSE" pair of hex CAN IDs (e.g. "7E0:7E8", "18DA00F1:18DAF100")
+func ParseISOTPPair(pairStr string) (ISOTPPair, error) {
+	var pair ISOTPPair
+	if _, err := fmt.Sscanf(pairStr, "%x:%x", &pair.RequestID, &pair.ResponseID); err != nil {
+		return pair, fmt.Errorf("invalid ISO-TP pair '%s', expected REQUEST:RESPONSE hex CAN IDs: %v", pairStr, err)
+	}
+	if pair.RequestID > CANEffMask || pair.ResponseID > CANEffMask {
+		return pair, fmt.Errorf("invalid ISO-TP pair '%s', CAN IDs exceed 29 bits", pairStr)
+	}
+	return pair, nil
+}
+
+// ISOTPMessage is a reassembled ISO-TP message
+type ISOTPMessage struct {
+	Timestamp time.Time `json:"timestamp"` // Single or first frame
+	End       time.Time `json:"end"`       // Last consecutive frame
+	Interface string    `json:"interface"`
+	CANID     uint32    `json:"can_id"`
+	Data      []uint8   `json:"data"`
+	Frames    int       `json:"frames"`
+	Error     string    `json:"error,omitempty"` // Sequence error or timeout, data is incomplete
+}
+
+// isotpTransfer is a segmented message in progress
+type isotpTransfer struct {
+	start   time.Time
+	last    time.Time
+	length  int
+	data    []byte
+	nextSeq uint8
+	frames  int
+}
+
+// ISOTPReassembler reassembles ISO-TP messages per interface and CAN ID
+type ISOTPReassembler struct {
+	transfers map[string]*isotpTransfer
+}
+
+// NewISOTPReassembler creates a new ISO-TP reassembler
+func NewISOTPReassembler() *ISOTPReassembler {
+	return &ISOTPReassembler{transfers: make(map[string]*isotpTransfer)}
+}
+
+// Add processes a frame payload and returns the completed message, if any
+// Flow control frames carry no message data and are ignored.
+func (r *ISOTPReassembler) Add(timestamp time.Time, iface string, canID uint32, data []byte) *ISOTPMessage {
+	if len(data) == 0 {
+		return nil
+	}
+
+	key := fmt.Sprintf("%s/%d", iface, canID)
+	transfer, inProgress := r.transfers[key]
+
+	switch data[0] >> 4 {
+	case ISOTPSingleFrame:
+		length, offset := int(data[0]&0x0F), 1
+		if length == 0 && len(data) > 2 {
+			// CAN FD single frame escape sequence
+			length, offset = int(data[1]), 2
+		}
+		if length == 0 || offset+length > len(data) {
+			return nil
+		}
+
+		// An unexpected single frame aborts the reception in progress (ISO 15765-2)
+		delete(r.transfers, key)
+		return &ISOTPMessage{
+			Timestamp: timestamp,
+			End:       timestamp,
+			Interface: iface,
+			CANID:     canID,
+			Data:      append([]byte(nil), data[offset:offset+length]...),
+			Frames:    1,
+		}
+
+	case ISOTPFirstFrame:
+		if len(data) < 2 {
+			return nil
+		}
+		length, offset := int(data[0]&0x0F)<<8|int(data[1]), 2
+		if length == 0 && len(data) >= 6 {
+			// Escape sequence for messages longer than 4095 bytes
+			length = int(data[2])<<24 | int(data[3])<<16 | int(data[4])<<8 | int(data[5])
+			offset = 6
+		}
+		if length == 0 {
+			return nil
+		}
+
+		// An unexpected first frame restarts the reception
+		transfer := &isotpTransfer{
+			start:   timestamp,
+			last:    timestamp,
+			length:  length,
+			data:    make([]byte, 0, min(length, 4095)),
+			nextSeq: 1,
+			frames:  1,
+		}
+		transfer.data = append(transfer.data, data[offset:]...)
+		r.transfers[key] = transfer
+
+	case ISOTPConsecutiveFrame:
+		if !inProgress {
+			return nil
+		}
+
+		seq := data[0] & 0x0F
+		if seq != transfer.nextSeq {
+			msg := r.abort(iface, canID, transfer, fmt.Sprintf("sequence error: expected %d, got %d", transfer.nextSeq, seq))
+			delete(r.transfers, key)
+			return msg
+		}
+		if timestamp.Sub(transfer.last) > ISOTPTimeoutCr {
+			msg := r.abort(iface, canID, transfer, "consecutive frame timeout (N_Cr)")
+			delete(r.transfers, key)
+			return msg
+		}
+
+		transfer.data = append(transfer.data, data[1:]...)
+		transfer.nextSeq = (transfer.nextSeq + 1) & 0x0F
+		transfer.last = timestamp
+		transfer.frames++
+
+		if len(transfer.data) >= transfer.length {
+			delete(r.transfers, key)
+			return &ISOTPMessage{
+				Timestamp: transfer.start,
+				End:       timestamp,
+				Interface: iface,
+				CANID:     canID,
+				Data:      transfer.data[:transfer.length],
+				Frames:    transfer.frames,
+			}
+		}
+	}
+
+	return nil
+}
+
+// abort builds the partial message of an interrupted transfer
+func (r *ISOTPReassembler) abort(iface string, canID uint32, transfer *isotpTransfer, reason string) *ISOTPMessage {
+	data := transfer.data
+	if len(data) > transfer.length {
+		data = data[:transfer.length]
+	}
+	return &ISOTPMessage{
+		Timestamp: transfer.start,
+		End:       transfer.last,
+		Interface: iface,
+		CANID:     canID,
+		Data:      data,
+		Frames:    transfer.frames,
+		Error:     reason,
+	}
+}
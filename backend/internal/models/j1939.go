@@ -0,0 +1,461 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// J1939 parameter groups handled by the decoder
+const (
+	J1939PGNRequest      uint32 = 0xEA00 // 59904
+	J1939PGNAddressClaim uint32 = 0xEE00 // 60928
+	J1939PGNTPDT         uint32 = 0xEB00 // 60160, transport protocol data transfer
+	J1939PGNTPCM         uint32 = 0xEC00 // 60416, transport protocol connection management
+	J1939PGNDM1          uint32 = 0xFECA // 65226, active diagnostic trouble codes
+)
+
+// J1939 special addresses
+const (
+	J1939AddressGlobal uint8 = 0xFF
+	J1939AddressNull   uint8 = 0xFE // Used by nodes that cannot claim an address
+)
+
+// TP.CM control bytes
+const (
+	j1939TPCMRTS   = 16
+	j1939TPCMCTS   = 17
+	j1939TPCMBAM   = 32
+	j1939TPCMAbort = 255
+)
+
+// J1939 transport protocol timeouts (SAE J1939-21)
+const (
+	J1939TimeoutT1 = 750 * time.Millisecond  // Between data packets (receiver)
+	J1939TimeoutT2 = 1250 * time.Millisecond // After CTS waiting for data
+)
+
+// J1939 transport types of a decoded message
+const (
+	J1939TransportSingle = "single"
+	J1939TransportBAM    = "bam"
+	J1939TransportRTSCTS = "rts_cts"
+)
+
+// J1939ID is a 29-bit CAN identifier split into its J1939 fields
+type J1939ID struct {
+	Priority uint8  `json:"priority"`
+	PGN      uint32 `json:"pgn"`
+	SA       uint8  `json:"sa"`
+	DA       uint8  `json:"da"` // 0xFF (global) for PDU2 parameter groups
+}
+
+// ParseJ1939ID splits a 29-bit CAN identifier; the SocketCAN flags are ignored
+func ParseJ1939ID(canID uint32) J1939ID {
+	id := canID & CANEffMask
+
+	pf := uint8(id >> 16)
+	ps := uint8(id >> 8)
+	dpEdp := (id >> 24) & 0x03
+
+	parsed := J1939ID{
+		Priority: uint8(id>>26) & 0x07,
+		SA:       uint8(id),
+	}
+
+	if pf < 240 {
+		// PDU1: PS is the destination address
+		parsed.PGN = dpEdp<<16 | uint32(pf)<<8
+		parsed.DA = ps
+	} else {
+		// PDU2: PS is the group extension, always broadcast
+		parsed.PGN = dpEdp<<16 | uint32(pf)<<8 | uint32(ps)
+		parsed.DA = J1939AddressGlobal
+	}
+
+	return parsed
+}
+
+// J1939IsPDU1 reports whether a PGN is destination specific
+func J1939IsPDU1(pgn uint32) bool {
+	return (pgn>>8)&0xFF < 240
+}
+
+// J1939Message is a decoded J1939 parameter group, reassembled if it was sent with the transport protocol
+type J1939Message struct {
+	Timestamp time.Time       `json:"timestamp"`
+	Interface string          `json:"interface"`
+	Priority  uint8           `json:"priority"`
+	PGN       uint32          `json:"pgn"`
+	PGNHex    string          `json:"pgn_hex"`
+	Name      string          `json:"name,omitempty"`
+	SA        uint8           `json:"sa"`
+	DA        uint8           `json:"da"`
+	Data      []uint8         `json:"data"`
+	DataHex   string          `json:"data_hex"`
+	Transport string          `json:"transport"`
+	Packets   int             `json:"packets,omitempty"` // Number of TP.DT packets
+	Error     string          `json:"error,omitempty"`   // Aborted or timed out transfer, data is incomplete
+	SPNs      []J1939SPNValue `json:"spns,omitempty"`
+	DTCs      []J1939DTC      `json:"dtcs,omitempty"`
+	Lamps     *J1939Lamps     `json:"lamps,omitempty"`
+	Claim     *J1939Name      `json:"address_claim,omitempty"`
+	Requested *uint32         `json:"requested_pgn,omitempty"` // Request (PGN 59904)
+}
+
+// j1939Session is a transport protocol transfer in progress
+type j1939Session struct {
+	iface     string
+	start     time.Time
+	last      time.Time
+	priority  uint8
+	pgn       uint32
+	sa        uint8
+	da        uint8
+	size      int
+	packets   int
+	transport string
+	data      []byte
+	received  map[uint8]bool
+}
+
+// J1939Reassembler decodes J1939 frames and reassembles TP.CM/TP.DT multi-packet messages
+type J1939Reassembler struct {
+	database *J1939Database
+	sessions map[string]*j1939Session
+}
+
+// NewJ1939Reassembler creates a reassembler decoding parameter groups with the given SPN database
+func NewJ1939Reassembler(database *J1939Database) *J1939Reassembler {
+	return &J1939Reassembler{
+		database: database,
+		sessions: make(map[string]*j1939Session),
+	}
+}
+
+// sessionKey identifies a transfer: one BAM per source and one RTS/CTS connection per source/destination pair
+func sessionKey(iface string, sa, da uint8) string {
+	return fmt.Sprintf("%s/%d/%d", iface, sa, da)
+}
+
+// Add processes a frame in time order and returns the messages it completes
+// Transport protocol frames themselves are not returned, only the reassembled message
+// (or the partial message of an aborted or timed out transfer).
+func (r *J1939Reassembler) Add(timestamp time.Time, iface string, canID uint32, data []byte) []J1939Message {
+	id := ParseJ1939ID(canID)
+	messages := r.expire(timestamp)
+
+	switch id.PGN {
+	case J1939PGNTPCM:
+		if msg := r.connectionManagement(timestamp, iface, id, data); msg != nil {
+			messages = append(messages, *msg)
+		}
+	case J1939PGNTPDT:
+		if msg := r.dataTransfer(timestamp, iface, id, data); msg != nil {
+			messages = append(messages, *msg)
+		}
+	default:
+		messages = append(messages, r.decode(J1939Message{
+			Timestamp: timestamp,
+			Interface: iface,
+			Priority:  id.Priority,
+			PGN:       id.PGN,
+			SA:        id.SA,
+			DA:        id.DA,
+			Data:      data,
+			Transport: J1939TransportSingle,
+		}))
+	}
+
+	return messages
+}
+
+// Flush returns the transfers that are still incomplete at the end of the data
+func (r *J1939Reassembler) Flush() []J1939Message {
+	messages := []J1939Message{}
+	for key, session := range r.sessions {
+		messages = append(messages, r.finish(session, "incomplete at end of query range"))
+		delete(r.sessions, key)
+	}
+	return messages
+}
+
+// connectionManagement handles a TP.CM frame
+func (r *J1939Reassembler) connectionManagement(timestamp time.Time, iface string, id J1939ID, data []byte) *J1939Message {
+	if len(data) < 8 {
+		return nil
+	}
+	pgn := uint32(data[5]) | uint32(data[6])<<8 | uint32(data[7])<<16
+
+	switch data[0] {
+	case j1939TPCMBAM, j1939TPCMRTS:
+		transport := J1939TransportBAM
+		da := J1939AddressGlobal
+		if data[0] == j1939TPCMRTS {
+			transport = J1939TransportRTSCTS
+			da = id.DA
+		}
+
+		key := sessionKey(iface, id.SA, da)
+		var aborted *J1939Message
+		if old, ok := r.sessions[key]; ok {
+			msg := r.finish(old, "replaced by a new transfer")
+			aborted = &msg
+		}
+
+		size := int(data[1]) | int(data[2])<<8
+		r.sessions[key] = &j1939Session{
+			iface:     iface,
+			start:     timestamp,
+			last:      timestamp,
+			priority:  id.Priority,
+			pgn:       pgn,
+			sa:        id.SA,
+			da:        da,
+			size:      size,
+			packets:   int(data[3]),
+			transport: transport,
+			data:      make([]byte, int(data[3])*7),
+			received:  make(map[uint8]bool),
+		}
+		return aborted
+
+	case j1939TPCMCTS:
+		// CTS is sent by the receiver: the connection is keyed by the originator (DA of the CTS)
+		if session, ok := r.sessions[sessionKey(iface, id.DA, id.SA)]; ok {
+			session.last = timestamp
+		}
+
+	case j1939TPCMAbort:
+		// Either side may abort the connection
+		for _, key := range []string{sessionKey(iface, id.SA, id.DA), sessionKey(iface, id.DA, id.SA)} {
+			if session, ok := r.sessions[key]; ok && session.pgn == pgn {
+				delete(r.sessions, key)
+				msg := r.finish(session, fmt.Sprintf("connection aborted by 0x%02X (reason %d)", id.SA, data[1]))
+				return &msg
+			}
+		}
+	}
+
+	return nil
+}
+
+// dataTransfer handles a TP.DT frame
+func (r *J1939Reassembler) dataTransfer(timestamp time.Time, iface string, id J1939ID, data []byte) *J1939Message {
+	if len(data) < 8 {
+		return nil
+	}
+
+	da := id.DA
+	key := sessionKey(iface, id.SA, da)
+	session, ok := r.sessions[key]
+	if !ok {
+		return nil
+	}
+
+	seq := data[0]
+	if seq < 1 || int(seq) > session.packets {
+		return nil
+	}
+	copy(session.data[(int(seq)-1)*7:], data[1:8])
+	session.received[seq] = true
+	session.last = timestamp
+
+	if len(session.received) < session.packets {
+		return nil
+	}
+
+	delete(r.sessions, key)
+	msg := r.finish(session, "")
+	msg.Timestamp = timestamp
+	return &msg
+}
+
+// expire ends the transfers whose next packet is overdue
+func (r *J1939Reassembler) expire(now time.Time) []J1939Message {
+	var messages []J1939Message
+	for key, session := range r.sessions {
+		timeout := J1939TimeoutT1
+		if session.transport == J1939TransportRTSCTS {
+			timeout = J1939TimeoutT2
+		}
+		if now.Sub(session.last) <= timeout {
+			continue
+		}
+		delete(r.sessions, key)
+		messages = append(messages, r.finish(session, fmt.Sprintf("timed out after %d of %d packets", len(session.received), session.packets)))
+	}
+	return messages
+}
+
+// finish builds the message of a transfer; errMsg marks an incomplete transfer
+func (r *J1939Reassembler) finish(session *j1939Session, errMsg string) J1939Message {
+	data := session.data
+	if session.size < len(data) {
+		data = data[:session.size]
+	}
+
+	msg := J1939Message{
+		Timestamp: session.start,
+		Interface: session.iface,
+		Priority:  session.priority,
+		PGN:       session.pgn,
+		SA:        session.sa,
+		DA:        session.da,
+		Data:      data,
+		Transport: session.transport,
+		Packets:   session.packets,
+		Error:     errMsg,
+	}
+	if errMsg != "" {
+		msg.PGNHex = fmt.Sprintf("0x%04X", msg.PGN)
+		msg.DataHex = fmt.Sprintf("%X", msg.Data)
+		msg.Name = r.database.PGNName(msg.PGN)
+		return msg
+	}
+	return r.decode(msg)
+}
+
+// decode fills in the names and decoded parameters of a complete message
+func (r *J1939Reassembler) decode(msg J1939Message) J1939Message {
+	msg.PGNHex = fmt.Sprintf("0x%04X", msg.PGN)
+	msg.DataHex = fmt.Sprintf("%X", msg.Data)
+	msg.Name = r.database.PGNName(msg.PGN)
+
+	switch msg.PGN {
+	case J1939PGNAddressClaim:
+		if name, ok := ParseJ1939Name(msg.Data); ok {
+			msg.Claim = &name
+		}
+	case J1939PGNRequest:
+		if len(msg.Data) >= 3 {
+			requested := uint32(msg.Data[0]) | uint32(msg.Data[1])<<8 | uint32(msg.Data[2])<<16
+			msg.Requested = &requested
+		}
+	case J1939PGNDM1:
+		msg.Lamps, msg.DTCs = DecodeJ1939DM1(msg.Data)
+		for i := range msg.DTCs {
+			msg.DTCs[i].SPNName = r.database.SPNName(msg.DTCs[i].SPN)
+		}
+	default:
+		msg.SPNs = r.database.Decode(msg.PGN, msg.Data)
+	}
+
+	return msg
+}
+
+// J1939Name is the 64-bit NAME sent with an address claim
+type J1939Name struct {
+	Raw                     string `json:"raw"`
+	IdentityNumber          uint32 `json:"identity_number"`
+	ManufacturerCode        uint16 `json:"manufacturer_code"`
+	ECUInstance             uint8  `json:"ecu_instance"`
+	FunctionInstance        uint8  `json:"function_instance"`
+	Function                uint8  `json:"function"`
+	VehicleSystem           uint8  `json:"vehicle_system"`
+	VehicleSystemInstance   uint8  `json:"vehicle_system_instance"`
+	IndustryGroup           uint8  `json:"industry_group"`
+	ArbitraryAddressCapable bool   `json:"arbitrary_address_capable"`
+}
+
+// ParseJ1939Name decodes the NAME of an address claim (8 bytes, little-endian)
+func ParseJ1939Name(data []byte) (J1939Name, bool) {
+	if len(data) < 8 {
+		return J1939Name{}, false
+	}
+
+	var raw uint64
+	for i := 0; i < 8; i++ {
+		raw |= uint64(data[i]) << (8 * i)
+	}
+
+	return J1939Name{
+		Raw:                     fmt.Sprintf("0x%016X", raw),
+		IdentityNumber:          uint32(raw & 0x1FFFFF),
+		ManufacturerCode:        uint16((raw >> 21) & 0x7FF),
+		ECUInstance:             uint8((raw >> 32) & 0x07),
+		FunctionInstance:        uint8((raw >> 35) & 0x1F),
+		Function:                uint8((raw >> 40) & 0xFF),
+		VehicleSystem:           uint8((raw >> 49) & 0x7F),
+		VehicleSystemInstance:   uint8((raw >> 56) & 0x0F),
+		IndustryGroup:           uint8((raw >> 60) & 0x07),
+		ArbitraryAddressCapable: raw>>63 != 0,
+	}, true
+}
+
+// J1939AddressClaim is a single address claim (or cannot claim) seen on the bus
+type J1939AddressClaim struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Address     uint8     `json:"address"`
+	Name        J1939Name `json:"name"`
+	CannotClaim bool      `json:"cannot_claim"`
+}
+
+// J1939Node is a J1939 controller application seen on the bus
+type J1939Node struct {
+	Interface    string              `json:"interface"`
+	Address      uint8               `json:"address"`
+	FirstSeen    time.Time           `json:"first_seen"`
+	LastSeen     time.Time           `json:"last_seen"`
+	MessageCount uint64              `json:"message_count"`
+	PGNs         []J1939PGNInfo      `json:"pgns"`
+	Name         *J1939Name          `json:"name,omitempty"`
+	Claims       []J1939AddressClaim `json:"claims,omitempty"`
+	Conflict     bool                `json:"conflict"` // Different NAMEs claimed this address
+}
+
+// J1939PGNInfo is a parameter group sent by a node
+type J1939PGNInfo struct {
+	PGN    uint32 `json:"pgn"`
+	PGNHex string `json:"pgn_hex"`
+	Name   string `json:"name,omitempty"`
+	Count  uint64 `json:"count"`
+}
+
+// J1939ClaimTracker follows address claims per interface and detects address conflicts
+type J1939ClaimTracker struct {
+	claims map[string][]J1939AddressClaim // interface/address -> claims in time order
+}
+
+// NewJ1939ClaimTracker creates an empty address claim tracker
+func NewJ1939ClaimTracker() *J1939ClaimTracker {
+	return &J1939ClaimTracker{claims: make(map[string][]J1939AddressClaim)}
+}
+
+// Add records an address claim frame (PGN 60928)
+func (t *J1939ClaimTracker) Add(timestamp time.Time, iface string, canID uint32, data []byte) {
+	id := ParseJ1939ID(canID)
+	if id.PGN != J1939PGNAddressClaim {
+		return
+	}
+	name, ok := ParseJ1939Name(data)
+	if !ok {
+		return
+	}
+
+	key := fmt.Sprintf("%s/%d", iface, id.SA)
+	t.claims[key] = append(t.claims[key], J1939AddressClaim{
+		Timestamp:   timestamp,
+		Address:     id.SA,
+		Name:        name,
+		CannotClaim: id.SA == J1939AddressNull,
+	})
+}
+
+// Apply attaches the claims of the node's address; the latest claim determines the NAME
+func (t *J1939ClaimTracker) Apply(node *J1939Node) {
+	claims, ok := t.claims[fmt.Sprintf("%s/%d", node.Interface, node.Address)]
+	if !ok {
+		return
+	}
+
+	node.Claims = claims
+	latest := claims[len(claims)-1].Name
+	node.Name = &latest
+
+	for _, claim := range claims {
+		if claim.Name.Raw != latest.Raw {
+			node.Conflict = true
+			break
+		}
+	}
+}
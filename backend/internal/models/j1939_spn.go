@@ -0,0 +1,310 @@
+package models
+
+import (
+	"fmt"
+	"os"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// J1939SPN defines a suspect parameter inside a parameter group
+type J1939SPN struct {
+	SPN        uint32  `yaml:"spn" json:"spn"`
+	Name       string  `yaml:"name" json:"name"`
+	StartBit   int     `yaml:"start_bit" json:"start_bit"` // 0-based bit position in the data, little-endian
+	Length     int     `yaml:"length" json:"length"`       // Length in bits
+	Resolution float64 `yaml:"resolution" json:"resolution"`
+	Offset     float64 `yaml:"offset" json:"offset"`
+	Unit       string  `yaml:"unit" json:"unit,omitempty"`
+}
+
+// J1939PGNDefinition defines a parameter group and its SPNs
+type J1939PGNDefinition struct {
+	PGN   uint32     `yaml:"pgn" json:"pgn"`
+	Name  string     `yaml:"name" json:"name"`
+	Label string     `yaml:"label" json:"label,omitempty"`
+	SPNs  []J1939SPN `yaml:"spns" json:"spns"`
+}
+
+// J1939SPNValue is a decoded suspect parameter
+type J1939SPNValue struct {
+	SPN    uint32   `json:"spn"`
+	Name   string   `json:"name"`
+	Raw    uint64   `json:"raw"`
+	Value  *float64 `json:"value,omitempty"`
+	Unit   string   `json:"unit,omitempty"`
+	Status string   `json:"status,omitempty"` // "not_available" or "error" when no value was sent
+}
+
+// j1939SPNStatus classifies the reserved ranges of a raw SPN value (SAE J1939-71)
+func j1939SPNStatus(raw uint64, length int) string {
+	if length < 8 {
+		max := uint64(1)<<length - 1
+		switch {
+		case raw == max:
+			return "not_available"
+		case length == 2 && raw == 2:
+			return "error"
+		}
+		return ""
+	}
+
+	switch raw >> (length - 8) {
+	case 0xFF:
+		return "not_available"
+	case 0xFE:
+		return "error"
+	}
+	return ""
+}
+
+// j1939StandardPGNs contains common SAE J1939-71 parameter groups
+var j1939StandardPGNs = []J1939PGNDefinition{
+	{PGN: 59904, Name: "RQST", Label: "Request"},
+	{PGN: 60160, Name: "TP.DT", Label: "Transport Protocol - Data Transfer"},
+	{PGN: 60416, Name: "TP.CM", Label: "Transport Protocol - Connection Management"},
+	{PGN: 60928, Name: "AC", Label: "Address Claimed"},
+	{PGN: 61443, Name: "EEC2", Label: "Electronic Engine Controller 2", SPNs: []J1939SPN{
+		{SPN: 91, Name: "Accelerator Pedal Position 1", StartBit: 8, Length: 8, Resolution: 0.4, Unit: "%"},
+		{SPN: 92, Name: "Engine Percent Load At Current Speed", StartBit: 16, Length: 8, Resolution: 1, Unit: "%"},
+	}},
+	{PGN: 61444, Name: "EEC1", Label: "Electronic Engine Controller 1", SPNs: []J1939SPN{
+		{SPN: 512, Name: "Driver's Demand Engine - Percent Torque", StartBit: 8, Length: 8, Resolution: 1, Offset: -125, Unit: "%"},
+		{SPN: 513, Name: "Actual Engine - Percent Torque", StartBit: 16, Length: 8, Resolution: 1, Offset: -125, Unit: "%"},
+		{SPN: 190, Name: "Engine Speed", StartBit: 24, Length: 16, Resolution: 0.125, Unit: "rpm"},
+		{SPN: 1483, Name: "Source Address of Controlling Device for Engine Control", StartBit: 40, Length: 8, Resolution: 1},
+	}},
+	{PGN: 65226, Name: "DM1", Label: "Active Diagnostic Trouble Codes"},
+	{PGN: 65253, Name: "HOURS", Label: "Engine Hours, Revolutions", SPNs: []J1939SPN{
+		{SPN: 247, Name: "Engine Total Hours of Operation", StartBit: 0, Length: 32, Resolution: 0.05, Unit: "h"},
+		{SPN: 249, Name: "Engine Total Revolutions", StartBit: 32, Length: 32, Resolution: 1000, Unit: "r"},
+	}},
+	{PGN: 65262, Name: "ET1", Label: "Engine Temperature 1", SPNs: []J1939SPN{
+		{SPN: 110, Name: "Engine Coolant Temperature", StartBit: 0, Length: 8, Resolution: 1, Offset: -40, Unit: "°C"},
+		{SPN: 174, Name: "Engine Fuel Temperature 1", StartBit: 8, Length: 8, Resolution: 1, Offset: -40, Unit: "°C"},
+		{SPN: 175, Name: "Engine Oil Temperature 1", StartBit: 16, Length: 16, Resolution: 0.03125, Offset: -273, Unit: "°C"},
+	}},
+	{PGN: 65263, Name: "EFL/P1", Label: "Engine Fluid Level/Pressure 1", SPNs: []J1939SPN{
+		{SPN: 94, Name: "Engine Fuel Delivery Pressure", StartBit: 0, Length: 8, Resolution: 4, Unit: "kPa"},
+		{SPN: 98, Name: "Engine Oil Level", StartBit: 16, Length: 8, Resolution: 0.4, Unit: "%"},
+		{SPN: 100, Name: "Engine Oil Pressure", StartBit: 24, Length: 8, Resolution: 4, Unit: "kPa"},
+		{SPN: 111, Name: "Engine Coolant Level", StartBit: 56, Length: 8, Resolution: 0.4, Unit: "%"},
+	}},
+	{PGN: 65265, Name: "CCVS", Label: "Cruise Control/Vehicle Speed", SPNs: []J1939SPN{
+		{SPN: 84, Name: "Wheel-Based Vehicle Speed", StartBit: 8, Length: 16, Resolution: 1.0 / 256, Unit: "km/h"},
+		{SPN: 597, Name: "Brake Switch", StartBit: 28, Length: 2, Resolution: 1},
+		{SPN: 598, Name: "Clutch Switch", StartBit: 30, Length: 2, Resolution: 1},
+	}},
+	{PGN: 65266, Name: "LFE", Label: "Fuel Economy (Liquid)", SPNs: []J1939SPN{
+		{SPN: 183, Name: "Engine Fuel Rate", StartBit: 0, Length: 16, Resolution: 0.05, Unit: "L/h"},
+		{SPN: 184, Name: "Engine Instantaneous Fuel Economy", StartBit: 16, Length: 16, Resolution: 1.0 / 512, Unit: "km/L"},
+		{SPN: 51, Name: "Engine Throttle Valve 1 Position", StartBit: 48, Length: 8, Resolution: 0.4, Unit: "%"},
+	}},
+	{PGN: 65269, Name: "AMB", Label: "Ambient Conditions", SPNs: []J1939SPN{
+		{SPN: 108, Name: "Barometric Pressure", StartBit: 0, Length: 8, Resolution: 0.5, Unit: "kPa"},
+		{SPN: 171, Name: "Ambient Air Temperature", StartBit: 24, Length: 16, Resolution: 0.03125, Offset: -273, Unit: "°C"},
+	}},
+	{PGN: 65270, Name: "IC1", Label: "Inlet/Exhaust Conditions 1", SPNs: []J1939SPN{
+		{SPN: 102, Name: "Engine Intake Manifold #1 Pressure", StartBit: 8, Length: 8, Resolution: 2, Unit: "kPa"},
+		{SPN: 105, Name: "Engine Intake Manifold 1 Temperature", StartBit: 16, Length: 8, Resolution: 1, Offset: -40, Unit: "°C"},
+	}},
+	{PGN: 65271, Name: "VEP1", Label: "Vehicle Electrical Power 1", SPNs: []J1939SPN{
+		{SPN: 167, Name: "Charging System Potential (Voltage)", StartBit: 32, Length: 16, Resolution: 0.05, Unit: "V"},
+		{SPN: 168, Name: "Battery Potential / Power Input 1", StartBit: 48, Length: 16, Resolution: 0.05, Unit: "V"},
+	}},
+	{PGN: 65276, Name: "DD", Label: "Dash Display", SPNs: []J1939SPN{
+		{SPN: 96, Name: "Fuel Level 1", StartBit: 8, Length: 8, Resolution: 0.4, Unit: "%"},
+	}},
+}
+
+// J1939DatabaseFile is the YAML format of a loadable SPN database
+//
+//	pgns:
+//	  - pgn: 65280
+//	    name: PROP_A
+//	    spns:
+//	      - spn: 520192
+//	        name: Hydraulic Pressure
+//	        start_bit: 0
+//	        length: 16
+//	        resolution: 0.1
+//	        unit: bar
+type J1939DatabaseFile struct {
+	PGNs []J1939PGNDefinition `yaml:"pgns"`
+}
+
+// J1939Database resolves PGN names and decodes SPNs
+type J1939Database struct {
+	pgns     map[uint32]J1939PGNDefinition
+	spnNames map[uint32]string
+}
+
+// NewJ1939Database creates a database with the standard parameter groups
+func NewJ1939Database() *J1939Database {
+	db := &J1939Database{
+		pgns:     make(map[uint32]J1939PGNDefinition),
+		spnNames: make(map[uint32]string),
+	}
+	for _, def := range j1939StandardPGNs {
+		db.Add(def)
+	}
+	return db
+}
+
+// LoadJ1939Database creates a database with the standard parameter groups and the
+// definitions of the given YAML files; later definitions replace earlier ones per PGN
+func LoadJ1939Database(paths []string) (*J1939Database, error) {
+	db := NewJ1939Database()
+
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read J1939 database %s: %w", path, err)
+		}
+
+		file := &J1939DatabaseFile{}
+		if err := yaml.Unmarshal(content, file); err != nil {
+			return nil, fmt.Errorf("failed to parse J1939 database %s: %w", path, err)
+		}
+
+		for _, def := range file.PGNs {
+			for _, spn := range def.SPNs {
+				if spn.Length < 1 || spn.Length > 64 || spn.StartBit < 0 {
+					return nil, fmt.Errorf("invalid bit range of SPN %d in %s", spn.SPN, path)
+				}
+			}
+			db.Add(def)
+		}
+	}
+
+	return db, nil
+}
+
+// Add registers a parameter group definition
+func (db *J1939Database) Add(def J1939PGNDefinition) {
+	for i := range def.SPNs {
+		if def.SPNs[i].Resolution == 0 {
+			def.SPNs[i].Resolution = 1
+		}
+		db.spnNames[def.SPNs[i].SPN] = def.SPNs[i].Name
+	}
+	db.pgns[def.PGN] = def
+}
+
+// PGNName returns the acronym of a parameter group, "" if unknown
+func (db *J1939Database) PGNName(pgn uint32) string {
+	return db.pgns[pgn].Name
+}
+
+// SPNName returns the name of a suspect parameter, "" if unknown
+func (db *J1939Database) SPNName(spn uint32) string {
+	return db.spnNames[spn]
+}
+
+// Decode decodes the SPNs of a parameter group; SPNs outside the data are skipped
+func (db *J1939Database) Decode(pgn uint32, data []byte) []J1939SPNValue {
+	def, ok := db.pgns[pgn]
+	if !ok || len(def.SPNs) == 0 {
+		return nil
+	}
+
+	values := make([]J1939SPNValue, 0, len(def.SPNs))
+	for _, spn := range def.SPNs {
+		raw, ok := extractBits(data, spn.StartBit, spn.Length)
+		if !ok {
+			continue
+		}
+
+		value := J1939SPNValue{
+			SPN:    spn.SPN,
+			Name:   spn.Name,
+			Raw:    raw,
+			Unit:   spn.Unit,
+			Status: j1939SPNStatus(raw, spn.Length),
+		}
+		if value.Status == "" {
+			scaled := float64(raw)*spn.Resolution + spn.Offset
+			value.Value = &scaled
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+// extractBits reads an unsigned little-endian bit field of up to 64 bits from data of any length
+func extractBits(data []byte, startBit, length int) (uint64, bool) {
+	if length < 1 || length > 64 || startBit < 0 || (startBit+length+7)/8 > len(data) {
+		return 0, false
+	}
+
+	var value uint64
+	for i := 0; i < length; i++ {
+		bit := startBit + i
+		if data[bit/8]&(1<<(bit%8)) != 0 {
+			value |= 1 << i
+		}
+	}
+	return value, true
+}
+
+// J1939Lamps is the lamp status of a diagnostic message
+type J1939Lamps struct {
+	MalfunctionIndicator string `json:"malfunction_indicator"`
+	RedStop              string `json:"red_stop"`
+	AmberWarning         string `json:"amber_warning"`
+	Protect              string `json:"protect"`
+}
+
+// J1939DTC is a diagnostic trouble code of a DM1 message
+type J1939DTC struct {
+	SPN              uint32 `json:"spn"`
+	SPNName          string `json:"spn_name,omitempty"`
+	FMI              uint8  `json:"fmi"`
+	OccurrenceCount  uint8  `json:"occurrence_count"`
+	ConversionMethod uint8  `json:"conversion_method"`
+}
+
+// j1939LampState names a 2-bit lamp status
+func j1939LampState(bits uint8) string {
+	switch bits & 0x03 {
+	case 0:
+		return "off"
+	case 1:
+		return "on"
+	default:
+		return "not_available"
+	}
+}
+
+// DecodeJ1939DM1 decodes the lamp status and active DTCs of a DM1 message
+func DecodeJ1939DM1(data []byte) (*J1939Lamps, []J1939DTC) {
+	if len(data) < 2 {
+		return nil, nil
+	}
+
+	lamps := &J1939Lamps{
+		MalfunctionIndicator: j1939LampState(data[0] >> 6),
+		RedStop:              j1939LampState(data[0] >> 4),
+		AmberWarning:         j1939LampState(data[0] >> 2),
+		Protect:              j1939LampState(data[0]),
+	}
+
+	dtcs := []J1939DTC{}
+	for i := 2; i+4 <= len(data); i += 4 {
+		b := data[i : i+4]
+		spn := uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2]>>5)<<16
+		fmi := b[2] & 0x1F
+		// SPN 0/FMI 0 means "no active DTC", all ones is padding
+		if (spn == 0 && fmi == 0) || (b[0] == 0xFF && b[1] == 0xFF && b[2] == 0xFF && b[3] == 0xFF) {
+			continue
+		}
+		dtcs = append(dtcs, J1939DTC{
+			SPN:              spn,
+			FMI:              fmi,
+			OccurrenceCount:  b[3] & 0x7F,
+			ConversionMethod: b[3] >> 7,
+		})
+	}
+
+	return lamps, dtcs
+}
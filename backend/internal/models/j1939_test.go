@@ -0,0 +1,227 @@
+package models
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// j1939TestFrame is a frame of a scripted exchange, at an offset from the start of the test
+type j1939TestFrame struct {
+	at   time.Duration
+	id   uint32
+	data []byte
+}
+
+// tpcm builds a TP.CM frame from sa to da announcing pgn
+func tpcm(sa, da, control byte, size uint16, packets byte, pgn uint32) j1939TestFrame {
+	return j1939TestFrame{
+		id:   0x1CEC0000 | uint32(da)<<8 | uint32(sa),
+		data: []byte{control, byte(size), byte(size >> 8), packets, 0xFF, byte(pgn), byte(pgn >> 8), byte(pgn >> 16)},
+	}
+}
+
+// tpdt builds a TP.DT frame from sa to da
+func tpdt(sa, da, seq byte, payload ...byte) j1939TestFrame {
+	data := append([]byte{seq}, payload...)
+	for len(data) < 8 {
+		data = append(data, 0xFF)
+	}
+	return j1939TestFrame{id: 0x1CEB0000 | uint32(da)<<8 | uint32(sa), data: data}
+}
+
+// after returns the frame delayed to at
+func (f j1939TestFrame) after(at time.Duration) j1939TestFrame {
+	f.at = at
+	return f
+}
+
+func TestJ1939ReassemblerTransport(t *testing.T) {
+	pgn := uint32(0xFF00)
+	payload := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+	type want struct {
+		transport string
+		sa, da    uint8
+		data      []byte
+		packets   int
+		err       string
+	}
+
+	tests := []struct {
+		name   string
+		frames []j1939TestFrame
+		flush  bool
+		want   []want
+	}{
+		{
+			name: "BAM",
+			frames: []j1939TestFrame{
+				tpcm(0x10, 0xFF, j1939TPCMBAM, 10, 2, pgn),
+				tpdt(0x10, 0xFF, 1, payload[:7]...).after(50 * time.Millisecond),
+				tpdt(0x10, 0xFF, 2, payload[7:]...).after(100 * time.Millisecond),
+			},
+			want: []want{{transport: J1939TransportBAM, sa: 0x10, da: 0xFF, data: payload, packets: 2}},
+		},
+		{
+			name: "RTS/CTS with the CTS of the receiver",
+			frames: []j1939TestFrame{
+				tpcm(0x10, 0x20, j1939TPCMRTS, 10, 2, pgn),
+				tpcm(0x20, 0x10, j1939TPCMCTS, 0, 0, pgn),
+				tpdt(0x10, 0x20, 1, payload[:7]...),
+				tpdt(0x10, 0x20, 2, payload[7:]...),
+			},
+			want: []want{{transport: J1939TransportRTSCTS, sa: 0x10, da: 0x20, data: payload, packets: 2}},
+		},
+		{
+			name: "packets out of order",
+			frames: []j1939TestFrame{
+				tpcm(0x10, 0xFF, j1939TPCMBAM, 10, 2, pgn),
+				tpdt(0x10, 0xFF, 2, payload[7:]...),
+				tpdt(0x10, 0xFF, 1, payload[:7]...),
+			},
+			want: []want{{transport: J1939TransportBAM, sa: 0x10, da: 0xFF, data: payload, packets: 2}},
+		},
+		{
+			name: "sequence numbers outside the transfer are ignored",
+			frames: []j1939TestFrame{
+				tpcm(0x10, 0xFF, j1939TPCMBAM, 10, 2, pgn),
+				tpdt(0x10, 0xFF, 0, 0xAA),
+				tpdt(0x10, 0xFF, 3, 0xAA),
+				tpdt(0x10, 0xFF, 1, payload[:7]...),
+				tpdt(0x10, 0xFF, 2, payload[7:]...),
+			},
+			want: []want{{transport: J1939TransportBAM, sa: 0x10, da: 0xFF, data: payload, packets: 2}},
+		},
+		{
+			name: "data without a connection is dropped",
+			frames: []j1939TestFrame{
+				tpdt(0x10, 0xFF, 1, payload[:7]...),
+				tpdt(0x10, 0x20, 1, payload[:7]...),
+			},
+		},
+		{
+			name: "aborted by the receiver",
+			frames: []j1939TestFrame{
+				tpcm(0x10, 0x20, j1939TPCMRTS, 10, 2, pgn),
+				tpdt(0x10, 0x20, 1, payload[:7]...),
+				{id: 0x1CEC1020, data: []byte{j1939TPCMAbort, 3, 0xFF, 0xFF, 0xFF, byte(pgn), byte(pgn >> 8), 0}},
+			},
+			want: []want{{
+				transport: J1939TransportRTSCTS, sa: 0x10, da: 0x20, packets: 2,
+				data: []byte{1, 2, 3, 4, 5, 6, 7, 0, 0, 0},
+				err:  "connection aborted by 0x20 (reason 3)",
+			}},
+		},
+		{
+			name: "abort of another parameter group is ignored",
+			frames: []j1939TestFrame{
+				tpcm(0x10, 0x20, j1939TPCMRTS, 10, 2, pgn),
+				{id: 0x1CEC1020, data: []byte{j1939TPCMAbort, 3, 0xFF, 0xFF, 0xFF, 0xCA, 0xFE, 0}},
+				tpdt(0x10, 0x20, 1, payload[:7]...),
+				tpdt(0x10, 0x20, 2, payload[7:]...),
+			},
+			want: []want{{transport: J1939TransportRTSCTS, sa: 0x10, da: 0x20, data: payload, packets: 2}},
+		},
+		{
+			name: "BAM timed out after T1",
+			frames: []j1939TestFrame{
+				tpcm(0x10, 0xFF, j1939TPCMBAM, 10, 2, pgn),
+				tpdt(0x10, 0xFF, 1, payload[:7]...).after(100 * time.Millisecond),
+				{at: 100*time.Millisecond + J1939TimeoutT1 + time.Millisecond, id: 0x18FF0010, data: payload[:8]},
+			},
+			want: []want{
+				{
+					transport: J1939TransportBAM, sa: 0x10, da: 0xFF, packets: 2,
+					data: []byte{1, 2, 3, 4, 5, 6, 7, 0, 0, 0},
+					err:  "timed out after 1 of 2 packets",
+				},
+				{transport: J1939TransportSingle, sa: 0x10, da: 0xFF, data: payload[:8]},
+			},
+		},
+		{
+			name: "RTS/CTS waits T2",
+			frames: []j1939TestFrame{
+				tpcm(0x10, 0x20, j1939TPCMRTS, 10, 2, pgn),
+				tpdt(0x10, 0x20, 1, payload[:7]...).after(J1939TimeoutT1 + time.Millisecond),
+				tpdt(0x10, 0x20, 2, payload[7:]...).after(J1939TimeoutT1 + J1939TimeoutT2),
+			},
+			want: []want{{transport: J1939TransportRTSCTS, sa: 0x10, da: 0x20, data: payload, packets: 2}},
+		},
+		{
+			name: "replaced by a new transfer",
+			frames: []j1939TestFrame{
+				tpcm(0x10, 0xFF, j1939TPCMBAM, 10, 2, pgn),
+				tpdt(0x10, 0xFF, 1, payload[:7]...),
+				tpcm(0x10, 0xFF, j1939TPCMBAM, 10, 2, pgn),
+			},
+			want: []want{{
+				transport: J1939TransportBAM, sa: 0x10, da: 0xFF, packets: 2,
+				data: []byte{1, 2, 3, 4, 5, 6, 7, 0, 0, 0},
+				err:  "replaced by a new transfer",
+			}},
+		},
+		{
+			name: "incomplete at the end of the data",
+			frames: []j1939TestFrame{
+				tpcm(0x10, 0x20, j1939TPCMRTS, 10, 2, pgn),
+				tpdt(0x10, 0x20, 2, payload[7:]...),
+			},
+			flush: true,
+			want: []want{{
+				transport: J1939TransportRTSCTS, sa: 0x10, da: 0x20, packets: 2,
+				data: []byte{0, 0, 0, 0, 0, 0, 0, 8, 9, 10},
+				err:  "incomplete at end of query range",
+			}},
+		},
+	}
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewJ1939Reassembler(NewJ1939Database())
+
+			var got []J1939Message
+			for _, frame := range tt.frames {
+				got = append(got, r.Add(start.Add(frame.at), "can0", frame.id|CANEffFlag, frame.data)...)
+			}
+			if tt.flush {
+				got = append(got, r.Flush()...)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d messages, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, w := range tt.want {
+				msg := got[i]
+				if msg.PGN != pgn || msg.Transport != w.transport || msg.SA != w.sa || msg.DA != w.da ||
+					msg.Packets != w.packets || msg.Error != w.err {
+					t.Errorf("message %d: got PGN 0x%X %s %d->%d %d packets error %q, want PGN 0x%X %s %d->%d %d packets error %q",
+						i, msg.PGN, msg.Transport, msg.SA, msg.DA, msg.Packets, msg.Error,
+						pgn, w.transport, w.sa, w.da, w.packets, w.err)
+				}
+				if !bytes.Equal(msg.Data, w.data) {
+					t.Errorf("message %d: got data % X, want % X", i, msg.Data, w.data)
+				}
+			}
+		})
+	}
+}
+
+func TestParseJ1939ID(t *testing.T) {
+	tests := []struct {
+		canID uint32
+		want  J1939ID
+	}{
+		{0x18FECA00 | CANEffFlag, J1939ID{Priority: 6, PGN: J1939PGNDM1, SA: 0x00, DA: J1939AddressGlobal}},
+		{0x1CEC2010, J1939ID{Priority: 7, PGN: J1939PGNTPCM, SA: 0x10, DA: 0x20}},
+		{0x18EAFFF9, J1939ID{Priority: 6, PGN: J1939PGNRequest, SA: 0xF9, DA: J1939AddressGlobal}},
+		{0x0DF00421, J1939ID{Priority: 3, PGN: 0x1F004, SA: 0x21, DA: J1939AddressGlobal}}, // Data page set
+	}
+
+	for _, tt := range tests {
+		if got := ParseJ1939ID(tt.canID); got != tt.want {
+			t.Errorf("ParseJ1939ID(0x%08X) = %+v, want %+v", tt.canID, got, tt.want)
+		}
+	}
+}
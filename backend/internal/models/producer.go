@@ -0,0 +1,43 @@
+package models
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"strings"
+)
+
+// Producer is a remote client allowed to write the frames of one device through gRPC, identified by a bearer token
+type Producer struct {
+	DeviceID string
+	Token    string
+}
+
+// ParseProducers parses DEVICE:TOKEN entries (e.g. "HIL-SIM-01:s3cret")
+func ParseProducers(entries []string) ([]Producer, error) {
+	producers := make([]Producer, 0, len(entries))
+	tokens := make(map[string]bool)
+	for _, entry := range entries {
+		deviceID, token, ok := strings.Cut(entry, ":")
+		deviceID, token = strings.TrimSpace(deviceID), strings.TrimSpace(token)
+		if !ok || deviceID == "" || token == "" {
+			return nil, fmt.Errorf("invalid producer '%s', must be DEVICE:TOKEN", entry)
+		}
+		if tokens[token] {
+			return nil, fmt.Errorf("producer '%s' reuses the token of another producer", deviceID)
+		}
+		tokens[token] = true
+
+		producers = append(producers, Producer{DeviceID: deviceID, Token: token})
+	}
+	return producers, nil
+}
+
+// FindProducer returns the producer of a bearer token
+func FindProducer(producers []Producer, token string) (Producer, bool) {
+	for _, producer := range producers {
+		if subtle.ConstantTimeCompare([]byte(producer.Token), []byte(token)) == 1 {
+			return producer, true
+		}
+	}
+	return Producer{}, false
+}
@@ -0,0 +1,551 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Bus protocols recognized by the protocol detector
+const (
+	ProtocolCANopen = "canopen"
+	ProtocolJ1939   = "j1939"
+	ProtocolRaw     = "raw" // Proprietary/DBC-defined traffic without a higher layer protocol
+)
+
+// ProtocolMinScore is the score a protocol needs to be selected; below it the interface is raw
+const ProtocolMinScore = 0.5
+
+// protocolSDOTimeout is the maximum delay between an SDO request and its response
+const protocolSDOTimeout = time.Second
+
+// protocolMixedShare is the share of frames of the minority frame format that is reported as mixed traffic
+const protocolMixedShare = 0.05
+
+// ProtocolIssue is an inconsistency found while profiling an interface
+type ProtocolIssue struct {
+	Type     string   `json:"type"`
+	Severity string   `json:"severity"`
+	Message  string   `json:"message"`
+	CANID    uint32   `json:"can_id,omitempty"`
+	NodeID   uint8    `json:"node_id,omitempty"`
+	Sources  []string `json:"sources,omitempty"`
+}
+
+// Protocol issue types
+const (
+	IssueCOBIDOverlap         = "cob_id_overlap"
+	IssueMixedFrameFormats    = "mixed_frame_formats"
+	IssueUnknownNodeTraffic   = "unknown_node_traffic"
+	IssueJ1939AddressConflict = "j1939_address_conflict"
+)
+
+// ProtocolProfile is the estimated protocol of an interface over a capture window
+type ProtocolProfile struct {
+	DetectedAt     time.Time          `json:"detected_at"`
+	Interface      string             `json:"interface"`
+	Protocol       string             `json:"protocol"`
+	Confidence     float64            `json:"confidence"`
+	Scores         map[string]float64 `json:"scores"`
+	WindowStart    time.Time          `json:"window_start"`
+	WindowEnd      time.Time          `json:"window_end"`
+	FrameCount     uint64             `json:"frame_count"`
+	StandardFrames uint64             `json:"standard_frames"`
+	ExtendedFrames uint64             `json:"extended_frames"`
+	UniqueIDs      uint32             `json:"unique_ids"`
+	Evidence       map[string]string  `json:"evidence"`
+	Issues         []ProtocolIssue    `json:"issues"`
+}
+
+// sdoRequest is an SDO request waiting for its response
+type sdoRequest struct {
+	timestamp time.Time
+	index     uint16
+	subIndex  uint8
+}
+
+// protocolStats collects the protocol evidence of one interface
+type protocolStats struct {
+	start, end time.Time
+	frames     uint64
+	standard   uint64
+	extended   uint64
+	ids        map[uint32]uint64
+
+	// CANopen evidence
+	predefined      uint64 // Standard frames inside the predefined connection set
+	nmtCommands     uint64
+	syncs           uint64
+	bootups         map[uint8]uint64
+	heartbeats      map[uint8]uint64
+	invalidNMT      map[uint8]uint64 // Frames on a heartbeat COB-ID that are not a valid NMT state
+	sdoRequests     map[uint8]sdoRequest
+	sdoTransfers    uint64
+	nodeTraffic     map[uint8]uint64 // Node-specific frames (PDO, SDO, EMCY) per node ID
+	nodeTrafficByID map[uint8]uint32 // A CAN ID seen for the node, for reporting
+
+	// J1939 evidence
+	j1939Known       uint64 // Extended frames with a PGN from the J1939 database
+	j1939Protocol    uint64 // Requests, address claims and transport protocol frames
+	j1939Claims      map[uint8]map[string]bool
+	j1939Priorities  uint64 // Extended frames with a typical J1939 priority (3, 6, 7)
+	j1939SourceAddrs map[uint8]bool
+}
+
+// ProtocolDetector estimates the protocol used on each interface from a window of frames
+type ProtocolDetector struct {
+	database   *J1939Database
+	stats      map[string]*protocolStats
+	configured map[uint32][]string // COB-IDs produced by PDOs configured in EDS/DCF files
+	edsNodes   map[uint8]bool
+}
+
+// NewProtocolDetector creates a detector; J1939 PGNs are looked up in database
+func NewProtocolDetector(database *J1939Database) *ProtocolDetector {
+	if database == nil {
+		database = NewJ1939Database()
+	}
+	return &ProtocolDetector{
+		database:   database,
+		stats:      make(map[string]*protocolStats),
+		configured: make(map[uint32][]string),
+		edsNodes:   make(map[uint8]bool),
+	}
+}
+
+// AddEDSRegistry registers the transmit PDOs configured in the EDS/DCF files, which are
+// checked for COB-IDs shared with other producers
+func (d *ProtocolDetector) AddEDSRegistry(registry *EDSRegistry) {
+	for _, nodeID := range registry.Nodes() {
+		file, _ := registry.Get(nodeID)
+		d.edsNodes[nodeID] = true
+
+		pdos := NewPDOObjectMap()
+		pdos.AddEDS(nodeID, file)
+		for _, canID := range pdos.CANIDs() {
+			layout, _ := pdos.Get(canID)
+			if layout.Direction != "TX" {
+				continue
+			}
+			d.configured[canID] = append(d.configured[canID], fmt.Sprintf("node %d TPDO%d (DCF)", nodeID, layout.PDONumber))
+		}
+	}
+}
+
+// Add processes a frame; canID includes the SocketCAN flags as stored in ClickHouse
+func (d *ProtocolDetector) Add(timestamp time.Time, iface string, canID uint32, data []byte) {
+	if canID&(CANRtrFlag|CANErrFlag) != 0 {
+		return
+	}
+
+	s, ok := d.stats[iface]
+	if !ok {
+		s = &protocolStats{
+			start:            timestamp,
+			ids:              make(map[uint32]uint64),
+			bootups:          make(map[uint8]uint64),
+			heartbeats:       make(map[uint8]uint64),
+			invalidNMT:       make(map[uint8]uint64),
+			sdoRequests:      make(map[uint8]sdoRequest),
+			nodeTraffic:      make(map[uint8]uint64),
+			nodeTrafficByID:  make(map[uint8]uint32),
+			j1939Claims:      make(map[uint8]map[string]bool),
+			j1939SourceAddrs: make(map[uint8]bool),
+		}
+		d.stats[iface] = s
+	}
+	if timestamp.Before(s.start) {
+		s.start = timestamp
+	}
+	if timestamp.After(s.end) {
+		s.end = timestamp
+	}
+	s.frames++
+	s.ids[canID]++
+
+	if canID&CANEffFlag != 0 {
+		s.extended++
+		d.addJ1939(s, canID, data)
+		return
+	}
+	s.standard++
+	d.addCANopen(s, timestamp, canID&CANSffMask, data)
+}
+
+// addCANopen collects the CANopen evidence of a standard frame
+func (d *ProtocolDetector) addCANopen(s *protocolStats, timestamp time.Time, canID uint32, data []byte) {
+	messageType, nodeID := ClassifyCANopen(canID)
+	if messageType == CANopenUnknown {
+		return
+	}
+	s.predefined++
+
+	switch messageType {
+	case CANopenNMT:
+		if len(data) >= 2 && NMTCommand(data[0]).ExpectedState() != NMTStateUnknown && data[1] <= 127 {
+			s.nmtCommands++
+		}
+	case CANopenSYNC:
+		s.syncs++
+	case CANopenHeartbeat:
+		if nodeID == 0 || len(data) == 0 {
+			return
+		}
+		// Stored frames are zero padded, a boot-up/heartbeat carries a single byte
+		padded := true
+		for _, b := range data[1:] {
+			if b != 0 {
+				padded = false
+				break
+			}
+		}
+		switch state := NMTState(data[0] & 0x7F); {
+		case !padded:
+			s.invalidNMT[nodeID]++
+		case state == NMTStateBootup:
+			s.bootups[nodeID]++
+		case state == NMTStateStopped || state == NMTStateOperational || state == NMTStatePreOperational:
+			s.heartbeats[nodeID]++
+		default:
+			s.invalidNMT[nodeID]++
+		}
+	case CANopenSDORx:
+		if len(data) >= 4 {
+			s.sdoRequests[nodeID] = sdoRequest{
+				timestamp: timestamp,
+				index:     uint16(data[1]) | uint16(data[2])<<8,
+				subIndex:  data[3],
+			}
+		}
+		d.addNodeTraffic(s, nodeID, canID)
+	case CANopenSDOTx:
+		// A response echoes the multiplexer of the request (initiate and abort frames)
+		request, ok := s.sdoRequests[nodeID]
+		if ok && len(data) >= 4 && timestamp.Sub(request.timestamp) <= protocolSDOTimeout &&
+			uint16(data[1])|uint16(data[2])<<8 == request.index && data[3] == request.subIndex {
+			s.sdoTransfers++
+			delete(s.sdoRequests, nodeID)
+		}
+		d.addNodeTraffic(s, nodeID, canID)
+	default:
+		d.addNodeTraffic(s, nodeID, canID)
+	}
+}
+
+// addNodeTraffic counts a node-specific frame
+func (d *ProtocolDetector) addNodeTraffic(s *protocolStats, nodeID uint8, canID uint32) {
+	s.nodeTraffic[nodeID]++
+	if _, ok := s.nodeTrafficByID[nodeID]; !ok {
+		s.nodeTrafficByID[nodeID] = canID
+	}
+}
+
+// addJ1939 collects the J1939 evidence of an extended frame
+func (d *ProtocolDetector) addJ1939(s *protocolStats, canID uint32, data []byte) {
+	id := ParseJ1939ID(canID)
+	s.j1939SourceAddrs[id.SA] = true
+
+	switch id.Priority {
+	case 3, 6, 7:
+		s.j1939Priorities++
+	}
+
+	switch id.PGN {
+	case J1939PGNRequest, J1939PGNTPCM, J1939PGNTPDT:
+		s.j1939Protocol++
+		return
+	case J1939PGNAddressClaim:
+		s.j1939Protocol++
+		if name, ok := ParseJ1939Name(data); ok && id.SA != J1939AddressNull {
+			if s.j1939Claims[id.SA] == nil {
+				s.j1939Claims[id.SA] = make(map[string]bool)
+			}
+			s.j1939Claims[id.SA][name.Raw] = true
+		}
+		return
+	}
+
+	if d.database.PGNName(id.PGN) != "" {
+		s.j1939Known++
+	}
+}
+
+// Profiles returns the profile of every interface seen, ordered by interface
+func (d *ProtocolDetector) Profiles(detectedAt time.Time) []ProtocolProfile {
+	ifaces := make([]string, 0, len(d.stats))
+	for iface := range d.stats {
+		ifaces = append(ifaces, iface)
+	}
+	sort.Strings(ifaces)
+
+	profiles := make([]ProtocolProfile, 0, len(ifaces))
+	for _, iface := range ifaces {
+		profiles = append(profiles, d.profile(detectedAt, iface, d.stats[iface]))
+	}
+	return profiles
+}
+
+// profile scores the protocols of an interface and checks its traffic for inconsistencies
+func (d *ProtocolDetector) profile(detectedAt time.Time, iface string, s *protocolStats) ProtocolProfile {
+	profile := ProtocolProfile{
+		DetectedAt:     detectedAt,
+		Interface:      iface,
+		WindowStart:    s.start,
+		WindowEnd:      s.end,
+		FrameCount:     s.frames,
+		StandardFrames: s.standard,
+		ExtendedFrames: s.extended,
+		UniqueIDs:      uint32(len(s.ids)),
+		Evidence:       make(map[string]string),
+		Issues:         []ProtocolIssue{},
+	}
+
+	alive := make(map[uint8]bool)
+	for nodeID := range s.bootups {
+		alive[nodeID] = true
+	}
+	for nodeID := range s.heartbeats {
+		alive[nodeID] = true
+	}
+
+	canopen := d.canopenScore(s, alive)
+	j1939 := d.j1939Score(s)
+	profile.Scores = map[string]float64{
+		ProtocolCANopen: round3(canopen),
+		ProtocolJ1939:   round3(j1939),
+		ProtocolRaw:     round3(1 - max(canopen, j1939)),
+	}
+
+	switch {
+	case canopen >= ProtocolMinScore && canopen >= j1939:
+		profile.Protocol = ProtocolCANopen
+	case j1939 >= ProtocolMinScore:
+		profile.Protocol = ProtocolJ1939
+	default:
+		profile.Protocol = ProtocolRaw
+	}
+	profile.Confidence = profile.Scores[profile.Protocol]
+
+	profile.Evidence["canopen_nodes"] = formatNodeIDs(alive)
+	profile.Evidence["canopen_bootups"] = fmt.Sprintf("%d", sumCounts(s.bootups))
+	profile.Evidence["canopen_heartbeats"] = fmt.Sprintf("%d", sumCounts(s.heartbeats))
+	profile.Evidence["canopen_sdo_transfers"] = fmt.Sprintf("%d", s.sdoTransfers)
+	profile.Evidence["canopen_nmt_commands"] = fmt.Sprintf("%d", s.nmtCommands)
+	profile.Evidence["canopen_sync"] = fmt.Sprintf("%d", s.syncs)
+	profile.Evidence["j1939_known_pgn_frames"] = fmt.Sprintf("%d", s.j1939Known)
+	profile.Evidence["j1939_protocol_frames"] = fmt.Sprintf("%d", s.j1939Protocol)
+	profile.Evidence["j1939_source_addresses"] = fmt.Sprintf("%d", len(s.j1939SourceAddrs))
+	profile.Evidence["j1939_address_claims"] = fmt.Sprintf("%d", len(s.j1939Claims))
+
+	profile.Issues = append(profile.Issues, d.frameFormatIssues(s)...)
+	profile.Issues = append(profile.Issues, d.cobIDIssues(s, alive)...)
+	if profile.Protocol == ProtocolCANopen {
+		profile.Issues = append(profile.Issues, d.unknownNodeIssues(s, alive)...)
+	}
+	profile.Issues = append(profile.Issues, d.addressClaimIssues(s)...)
+
+	return profile
+}
+
+// canopenScore rates the standard frames: the share inside the predefined connection set,
+// raised by protocol traffic only a CANopen network produces
+func (d *ProtocolDetector) canopenScore(s *protocolStats, alive map[uint8]bool) float64 {
+	if s.standard == 0 {
+		return 0
+	}
+
+	score := 0.5 * float64(s.predefined) / float64(s.standard)
+	if len(alive) > 0 {
+		score += 0.3
+	}
+	if s.sdoTransfers > 0 {
+		score += 0.1
+	}
+	if s.nmtCommands > 0 || s.syncs > 0 {
+		score += 0.1
+	}
+	return score * float64(s.standard) / float64(s.frames)
+}
+
+// j1939Score rates the extended frames: J1939 priorities and known PGNs, raised by
+// network management and transport protocol traffic
+func (d *ProtocolDetector) j1939Score(s *protocolStats) float64 {
+	if s.extended == 0 {
+		return 0
+	}
+
+	score := 0.3*float64(s.j1939Priorities)/float64(s.extended) +
+		0.4*float64(s.j1939Known+s.j1939Protocol)/float64(s.extended)
+	if len(s.j1939Claims) > 0 {
+		score += 0.2
+	}
+	if s.j1939Protocol > 0 {
+		score += 0.1
+	}
+	return min(score, 1) * float64(s.extended) / float64(s.frames)
+}
+
+// frameFormatIssues reports interfaces carrying a significant share of both 11-bit and 29-bit frames
+func (d *ProtocolDetector) frameFormatIssues(s *protocolStats) []ProtocolIssue {
+	minority := min(s.standard, s.extended)
+	if s.frames == 0 || float64(minority)/float64(s.frames) < protocolMixedShare {
+		return nil
+	}
+	return []ProtocolIssue{{
+		Type:     IssueMixedFrameFormats,
+		Severity: "warning",
+		Message: fmt.Sprintf("%d standard and %d extended frames on the same interface, possibly more than one protocol",
+			s.standard, s.extended),
+	}}
+}
+
+// cobIDIssues reports COB-IDs used by more than one producer: transmit PDOs configured on
+// another node's predefined COB-IDs, and heartbeat COB-IDs also carrying non-NMT payloads
+func (d *ProtocolDetector) cobIDIssues(s *protocolStats, alive map[uint8]bool) []ProtocolIssue {
+	producers := make(map[uint32][]string)
+	for canID, sources := range d.configured {
+		storedID := canID
+		if canID > CANSffMask {
+			storedID |= CANEffFlag
+		}
+		if s.ids[storedID] > 0 {
+			producers[canID] = append(producers[canID], sources...)
+		}
+	}
+
+	for nodeID := range alive {
+		for _, messageType := range []string{CANopenEMCY, CANopenSDOTx, CANopenHeartbeat} {
+			canID, _ := CANopenCOBID(messageType, nodeID)
+			if len(producers[canID]) > 0 {
+				producers[canID] = append(producers[canID], fmt.Sprintf("node %d %s", nodeID, messageType))
+			}
+		}
+		// Without a DCF the node is assumed to use the default TPDO COB-IDs
+		if d.edsNodes[nodeID] {
+			continue
+		}
+		for pdo := 1; pdo <= 4; pdo++ {
+			canID, _ := CANopenCOBID(fmt.Sprintf("TPDO%d", pdo), nodeID)
+			if len(producers[canID]) > 0 {
+				producers[canID] = append(producers[canID], fmt.Sprintf("node %d TPDO%d (default)", nodeID, pdo))
+			}
+		}
+	}
+
+	issues := []ProtocolIssue{}
+	for canID, sources := range producers {
+		if len(sources) < 2 {
+			continue
+		}
+		sort.Strings(sources)
+		issues = append(issues, ProtocolIssue{
+			Type:     IssueCOBIDOverlap,
+			Severity: "error",
+			Message:  fmt.Sprintf("COB-ID 0x%03X is produced by %d sources", canID, len(sources)),
+			CANID:    canID,
+			Sources:  sources,
+		})
+	}
+
+	for nodeID, count := range s.invalidNMT {
+		if s.heartbeats[nodeID] == 0 && s.bootups[nodeID] == 0 {
+			continue
+		}
+		canID, _ := CANopenCOBID(CANopenHeartbeat, nodeID)
+		issues = append(issues, ProtocolIssue{
+			Type:     IssueCOBIDOverlap,
+			Severity: "error",
+			Message: fmt.Sprintf("COB-ID 0x%03X carries heartbeats of node %d and %d frames that are not a valid NMT state",
+				canID, nodeID, count),
+			CANID:  canID,
+			NodeID: nodeID,
+		})
+	}
+
+	sort.Slice(issues, func(i, j int) bool { return issues[i].CANID < issues[j].CANID })
+	return issues
+}
+
+// unknownNodeIssues reports node-specific traffic of node IDs that never sent a boot-up or
+// heartbeat and have no DCF, which is usually raw/DBC traffic inside the CANopen ID ranges
+func (d *ProtocolDetector) unknownNodeIssues(s *protocolStats, alive map[uint8]bool) []ProtocolIssue {
+	nodes := make([]uint8, 0)
+	for nodeID := range s.nodeTraffic {
+		if !alive[nodeID] && !d.edsNodes[nodeID] {
+			nodes = append(nodes, nodeID)
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i] < nodes[j] })
+
+	issues := make([]ProtocolIssue, 0, len(nodes))
+	for _, nodeID := range nodes {
+		issues = append(issues, ProtocolIssue{
+			Type:     IssueUnknownNodeTraffic,
+			Severity: "warning",
+			Message: fmt.Sprintf("%d frames for node %d (e.g. 0x%03X), which sent no boot-up or heartbeat",
+				s.nodeTraffic[nodeID], nodeID, s.nodeTrafficByID[nodeID]),
+			CANID:  s.nodeTrafficByID[nodeID],
+			NodeID: nodeID,
+		})
+	}
+	return issues
+}
+
+// addressClaimIssues reports J1939 source addresses claimed by more than one NAME
+func (d *ProtocolDetector) addressClaimIssues(s *protocolStats) []ProtocolIssue {
+	addresses := make([]int, 0)
+	for sa, names := range s.j1939Claims {
+		if len(names) > 1 {
+			addresses = append(addresses, int(sa))
+		}
+	}
+	sort.Ints(addresses)
+
+	issues := make([]ProtocolIssue, 0, len(addresses))
+	for _, sa := range addresses {
+		names := make([]string, 0, len(s.j1939Claims[uint8(sa)]))
+		for name := range s.j1939Claims[uint8(sa)] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		issues = append(issues, ProtocolIssue{
+			Type:     IssueJ1939AddressConflict,
+			Severity: "error",
+			Message:  fmt.Sprintf("Address 0x%02X is claimed by %d NAMEs", sa, len(names)),
+			Sources:  names,
+		})
+	}
+	return issues
+}
+
+// formatNodeIDs returns the node IDs of a set as a sorted comma-separated list
+func formatNodeIDs(nodes map[uint8]bool) string {
+	ids := make([]int, 0, len(nodes))
+	for nodeID := range nodes {
+		ids = append(ids, int(nodeID))
+	}
+	sort.Ints(ids)
+
+	list := ""
+	for i, id := range ids {
+		if i > 0 {
+			list += ","
+		}
+		list += fmt.Sprintf("%d", id)
+	}
+	return list
+}
+
+// sumCounts returns the total of per-node counters
+func sumCounts(counts map[uint8]uint64) uint64 {
+	var total uint64
+	for _, count := range counts {
+		total += count
+	}
+	return total
+}
+
+// round3 rounds a score to three decimals
+func round3(v float64) float64 {
+	return float64(int64(v*1000+0.5)) / 1000
+}
@@ -8,6 +8,8 @@ type QueryParams struct {
 	EndTime   *time.Time
 	CANID     *uint32
 	Interface string
+	SessionID string
+	DeviceID  string
 	Limit     int
 	Offset    int
 }
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Session statuses
+const (
+	SessionActive  = "active"
+	SessionStopped = "stopped"
+)
+
+// Session is a recording session: the frames an interface of a device receives between start and stop,
+// tagged with the test context they were recorded in
+type Session struct {
+	SessionID       string     `json:"session_id"`
+	Name            string     `json:"name"`
+	DeviceID        string     `json:"device_id"`
+	Interface       string     `json:"interface"`
+	Status          string     `json:"status"`
+	Operator        string     `json:"operator"`
+	RobotSerial     string     `json:"robot_serial"`
+	SoftwareVersion string     `json:"software_version"`
+	TestCase        string     `json:"test_case"`
+	Tags            []string   `json:"tags"`
+	Notes           string     `json:"notes"`
+	StartTime       time.Time  `json:"start_time"`
+	EndTime         *time.Time `json:"end_time,omitempty"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// NewSession creates an active session of a device's interface starting now
+func NewSession(deviceID, iface string) Session {
+	return Session{
+		SessionID: NewRecordingID(),
+		DeviceID:  deviceID,
+		Interface: iface,
+		Status:    SessionActive,
+		Tags:      []string{},
+		StartTime: time.Now(),
+	}
+}
+
+// UpdateTags adds and removes tags, keeping them unique and sorted
+func (s *Session) UpdateTags(add, remove []string) {
+	s.Tags = mergeTags(s.Tags, add, remove)
+}
+
+// mergeTags returns the unique, sorted, non-empty tags of tags and add that are not in remove
+func mergeTags(tags, add, remove []string) []string {
+	merged := []string{}
+	for _, tag := range append(slices.Clone(tags), add...) {
+		if tag != "" && !slices.Contains(remove, tag) && !slices.Contains(merged, tag) {
+			merged = append(merged, tag)
+		}
+	}
+	slices.Sort(merged)
+	return merged
+}
@@ -0,0 +1,403 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Signal value types
+const (
+	SignalUnsigned = "unsigned"
+	SignalSigned   = "signed"
+	SignalFloat    = "float" // IEEE 754, 32 or 64 bits
+)
+
+// Signal byte orders
+const (
+	SignalLittleEndian = "little_endian" // Intel, start_bit is the least significant bit
+	SignalBigEndian    = "big_endian"    // Motorola, start_bit is the most significant bit (DBC numbering)
+)
+
+// SignalDefinition describes a signal carried in the data of a CAN ID
+type SignalDefinition struct {
+	Name      string  `yaml:"name" json:"name"`
+	CANID     uint32  `yaml:"can_id" json:"can_id"`     // Arbitration ID without SocketCAN flags
+	Extended  bool    `yaml:"extended" json:"extended"` // 29-bit identifier
+	NodeID    uint8   `yaml:"node_id" json:"node_id"`
+	StartBit  int     `yaml:"start_bit" json:"start_bit"`
+	Length    int     `yaml:"length" json:"length"` // Length in bits
+	ByteOrder string  `yaml:"byte_order" json:"byte_order"`
+	ValueType string  `yaml:"value_type" json:"value_type"`
+	Scale     float64 `yaml:"scale" json:"scale"`
+	Offset    float64 `yaml:"offset" json:"offset"`
+	Unit      string  `yaml:"unit" json:"unit,omitempty"`
+}
+
+// validate checks the bit range and fills in the defaults
+func (d *SignalDefinition) validate() error {
+	if d.Name == "" {
+		return fmt.Errorf("signal on CAN ID 0x%X has no name", d.CANID)
+	}
+	if d.Length < 1 || d.Length > 64 || d.StartBit < 0 || d.StartBit > 511 {
+		return fmt.Errorf("invalid bit range of signal '%s'", d.Name)
+	}
+	if d.ByteOrder == "" {
+		d.ByteOrder = SignalLittleEndian
+	}
+	if d.ByteOrder != SignalLittleEndian && d.ByteOrder != SignalBigEndian {
+		return fmt.Errorf("invalid byte_order '%s' of signal '%s', must be little_endian or big_endian", d.ByteOrder, d.Name)
+	}
+	if d.ValueType == "" {
+		d.ValueType = SignalUnsigned
+	}
+	switch d.ValueType {
+	case SignalUnsigned, SignalSigned:
+	case SignalFloat:
+		if d.Length != 32 && d.Length != 64 {
+			return fmt.Errorf("float signal '%s' must be 32 or 64 bits", d.Name)
+		}
+	default:
+		return fmt.Errorf("invalid value_type '%s' of signal '%s', must be unsigned, signed or float", d.ValueType, d.Name)
+	}
+	if d.Scale == 0 {
+		d.Scale = 1
+	}
+	if d.Extended {
+		d.CANID &= CANEffMask
+	} else {
+		d.CANID &= CANSffMask
+	}
+	return nil
+}
+
+// SignalMappingFile is a YAML file of signal definitions
+// Example:
+//
+//	signals:
+//	  - name: hydraulic_pressure
+//	    can_id: 0x310
+//	    start_bit: 0
+//	    length: 16
+//	    scale: 0.1
+//	    unit: bar
+//	  - name: motor_temperature
+//	    can_id: 0x18FF1003
+//	    extended: true
+//	    start_bit: 7
+//	    length: 8
+//	    byte_order: big_endian
+//	    value_type: signed
+//	    offset: -40
+//	    unit: degC
+type SignalMappingFile struct {
+	Signals []SignalDefinition `yaml:"signals"`
+}
+
+// LoadSignalMappings loads signal definitions from YAML files
+func LoadSignalMappings(paths []string) ([]SignalDefinition, error) {
+	definitions := []SignalDefinition{}
+
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read signal mappings %s: %w", path, err)
+		}
+
+		file := &SignalMappingFile{}
+		if err := yaml.Unmarshal(content, file); err != nil {
+			return nil, fmt.Errorf("failed to parse signal mappings %s: %w", path, err)
+		}
+
+		for _, def := range file.Signals {
+			if err := def.validate(); err != nil {
+				return nil, fmt.Errorf("%v in %s", err, path)
+			}
+			definitions = append(definitions, def)
+		}
+	}
+
+	return definitions, nil
+}
+
+// SignalValue is a decoded signal sample, one row of the long-format signals table
+type SignalValue struct {
+	Timestamp  time.Time `json:"timestamp"`
+	Interface  string    `json:"interface"`
+	NodeID     uint8     `json:"node_id"`
+	Signal     string    `json:"signal"`
+	ValueFloat float64   `json:"value_float"` // Scaled physical value
+	ValueInt   int64     `json:"value_int"`   // Raw value, sign extended for signed signals
+	Unit       string    `json:"unit,omitempty"`
+	Device
+}
+
+// SignalBucket aggregates the samples of a signal over a time bucket
+type SignalBucket struct {
+	TimeBucket time.Time `json:"time_bucket"`
+	Interface  string    `json:"interface"`
+	NodeID     uint8     `json:"node_id"`
+	Signal     string    `json:"signal"`
+	Unit       string    `json:"unit,omitempty"`
+	Count      uint64    `json:"count"`
+	Min        float64   `json:"min"`
+	Max        float64   `json:"max"`
+	Avg        float64   `json:"avg"`
+	First      float64   `json:"first"`
+	Last       float64   `json:"last"`
+}
+
+// SignalInfo summarizes a recorded signal
+type SignalInfo struct {
+	Signal    string    `json:"signal"`
+	NodeID    uint8     `json:"node_id"`
+	Interface string    `json:"interface"`
+	Unit      string    `json:"unit,omitempty"`
+	Count     uint64    `json:"count"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// ds402Signals names the CiA 402 objects commonly mapped into PDOs
+var ds402Signals = map[uint16]struct {
+	name     string
+	dataType uint16
+}{
+	0x603F: {"error_code", ODTypeUnsigned16},
+	0x6040: {"controlword", ODTypeUnsigned16},
+	0x6041: {"statusword", ODTypeUnsigned16},
+	0x6060: {"modes_of_operation", ODTypeInteger8},
+	0x6061: {"modes_of_operation_display", ODTypeInteger8},
+	0x6062: {"position_demand_value", ODTypeInteger32},
+	0x6064: {"actual_position", ODTypeInteger32},
+	0x606B: {"velocity_demand_value", ODTypeInteger32},
+	0x606C: {"actual_velocity", ODTypeInteger32},
+	0x6071: {"target_torque", ODTypeInteger16},
+	0x6074: {"torque_demand", ODTypeInteger16},
+	0x6077: {"actual_torque", ODTypeInteger16},
+	0x6078: {"actual_current", ODTypeInteger16},
+	0x607A: {"target_position", ODTypeInteger32},
+	0x60F4: {"following_error", ODTypeInteger32},
+	0x60FF: {"target_velocity", ODTypeInteger32},
+}
+
+// SignalDecoder decodes the registered signals of received frames
+type SignalDecoder struct {
+	signals map[uint32][]SignalDefinition // Keyed by CAN ID including the EFF flag
+}
+
+// NewSignalDecoder creates an empty signal decoder
+func NewSignalDecoder() *SignalDecoder {
+	return &SignalDecoder{signals: make(map[uint32][]SignalDefinition)}
+}
+
+// Add registers a signal definition
+func (d *SignalDecoder) Add(def SignalDefinition) {
+	key := def.CANID
+	if def.Extended {
+		key |= CANEffFlag
+	}
+	d.signals[key] = append(d.signals[key], def)
+}
+
+// AddPDOMap registers the objects mapped into PDOs as signals
+// Objects are named after the CiA 402 object (with an _axisN suffix beyond the first axis),
+// else after the ParameterName in the node's EDS/DCF, else obj_INDEX_SUBINDEX. The EDS
+// data type decides whether a value is signed.
+func (d *SignalDecoder) AddPDOMap(pdoMap *PDOObjectMap, registry *EDSRegistry) int {
+	count := 0
+	for _, canID := range pdoMap.CANIDs() {
+		layout, _ := pdoMap.Get(canID)
+		file, _ := registry.Get(layout.NodeID)
+
+		for _, object := range layout.Objects {
+			// Dummy mapping entries (data type indices) only pad the PDO
+			if object.Index < 0x1000 {
+				continue
+			}
+
+			name := fmt.Sprintf("obj_%04X_%02X", object.Index, object.SubIndex)
+			var dataType uint16
+			if base, axis, ok := ds402Axis(object.Index); ok {
+				name = ds402Signals[base].name
+				if axis > 1 {
+					name = fmt.Sprintf("%s_axis%d", name, axis)
+				}
+				dataType = ds402Signals[base].dataType
+			}
+			if file != nil {
+				if entry, ok := file.Entry(object.Index, object.SubIndex); ok {
+					if _, _, ok := ds402Axis(object.Index); !ok && entry.ParameterName != "" {
+						name = signalName(entry.ParameterName)
+					}
+					if entry.DataType != 0 {
+						dataType = entry.DataType
+					}
+				}
+			}
+
+			def := SignalDefinition{
+				Name:      name,
+				CANID:     canID & CANEffMask,
+				Extended:  canID > CANSffMask,
+				NodeID:    layout.NodeID,
+				StartBit:  object.BitOffset,
+				Length:    object.BitLength,
+				ByteOrder: SignalLittleEndian,
+				ValueType: SignalUnsigned,
+				Scale:     1,
+			}
+			switch dataType {
+			case ODTypeInteger8, ODTypeInteger16, ODTypeInteger32, ODTypeInteger64:
+				def.ValueType = SignalSigned
+			case ODTypeReal32, ODTypeReal64:
+				def.ValueType = SignalFloat
+			}
+			if def.validate() != nil {
+				continue
+			}
+			d.Add(def)
+			count++
+		}
+	}
+	return count
+}
+
+// ds402Axis splits a CiA 402 object index of a named signal into the first-axis index and the axis number
+func ds402Axis(index uint16) (uint16, int, bool) {
+	for axis := 0; axis < DS402MaxAxes; axis++ {
+		base := index - 0x800*uint16(axis)
+		if _, ok := ds402Signals[base]; ok {
+			return base, axis + 1, true
+		}
+	}
+	return 0, 0, false
+}
+
+// signalName converts an object dictionary parameter name to a signal name
+// e.g. "Velocity actual value" -> "velocity_actual_value"
+func signalName(parameterName string) string {
+	var b strings.Builder
+	underscore := false
+	for _, r := range strings.ToLower(strings.TrimSpace(parameterName)) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if underscore && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+			underscore = false
+			continue
+		}
+		underscore = true
+	}
+	return b.String()
+}
+
+// Len returns the number of registered signals
+func (d *SignalDecoder) Len() int {
+	count := 0
+	for _, defs := range d.signals {
+		count += len(defs)
+	}
+	return count
+}
+
+// Definitions returns the registered signals ordered by CAN ID and name
+func (d *SignalDecoder) Definitions() []SignalDefinition {
+	definitions := make([]SignalDefinition, 0, d.Len())
+	for _, defs := range d.signals {
+		definitions = append(definitions, defs...)
+	}
+	sort.Slice(definitions, func(i, j int) bool {
+		if definitions[i].CANID != definitions[j].CANID {
+			return definitions[i].CANID < definitions[j].CANID
+		}
+		return definitions[i].Name < definitions[j].Name
+	})
+	return definitions
+}
+
+// Decode returns the values of the signals registered for a frame; canID includes the SocketCAN flags
+// Signals that do not fit in the received data are skipped.
+func (d *SignalDecoder) Decode(timestamp time.Time, iface string, canID uint32, data []byte) []SignalValue {
+	if canID&(CANRtrFlag|CANErrFlag) != 0 {
+		return nil
+	}
+	defs := d.signals[canID]
+	if len(defs) == 0 {
+		return nil
+	}
+
+	values := make([]SignalValue, 0, len(defs))
+	for _, def := range defs {
+		var raw uint64
+		var ok bool
+		if def.ByteOrder == SignalBigEndian {
+			raw, ok = extractBitsBigEndian(data, def.StartBit, def.Length)
+		} else {
+			raw, ok = extractBits(data, def.StartBit, def.Length)
+		}
+		if !ok {
+			continue
+		}
+
+		value := SignalValue{
+			Timestamp: timestamp,
+			Interface: iface,
+			NodeID:    def.NodeID,
+			Signal:    def.Name,
+			Unit:      def.Unit,
+		}
+		switch def.ValueType {
+		case SignalFloat:
+			var f float64
+			if def.Length == 32 {
+				f = float64(math.Float32frombits(uint32(raw)))
+			} else {
+				f = math.Float64frombits(raw)
+			}
+			value.ValueInt = int64(f)
+			value.ValueFloat = f*def.Scale + def.Offset
+		case SignalSigned:
+			v := int64(raw)
+			if def.Length < 64 && raw&(1<<(def.Length-1)) != 0 {
+				v -= 1 << def.Length
+			}
+			value.ValueInt = v
+			value.ValueFloat = float64(v)*def.Scale + def.Offset
+		default:
+			value.ValueInt = int64(raw)
+			value.ValueFloat = float64(raw)*def.Scale + def.Offset
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+// extractBitsBigEndian reads a Motorola bit field; startBit is the most significant bit in DBC
+// numbering (bit 7 of byte 0 is 7, bit 0 of byte 1 is 8)
+func extractBitsBigEndian(data []byte, startBit, length int) (uint64, bool) {
+	if length < 1 || length > 64 || startBit < 0 {
+		return 0, false
+	}
+
+	var value uint64
+	pos := startBit
+	for i := 0; i < length; i++ {
+		if pos/8 >= len(data) {
+			return 0, false
+		}
+		value = value<<1 | uint64(data[pos/8]>>(pos%8))&1
+		if pos%8 == 0 {
+			pos += 15
+		} else {
+			pos--
+		}
+	}
+	return value, true
+}
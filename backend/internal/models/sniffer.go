@@ -0,0 +1,165 @@
+package models
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+// ByteChanges holds the change statistics of one payload byte
+type ByteChanges struct {
+	Index       int       `json:"index"`
+	Min         uint8     `json:"min"`
+	Max         uint8     `json:"max"`
+	Changes     uint64    `json:"changes"`      // Frames in which the byte differed from the previous frame
+	ChangedBits uint8     `json:"changed_bits"` // Mask of the bits that toggled at least once
+	BitToggles  [8]uint64 `json:"bit_toggles"`  // Toggle count per bit, index 0 is the least significant bit
+}
+
+// PayloadChanges is the cansniffer-style change summary of one CAN ID
+type PayloadChanges struct {
+	Interface    string        `json:"interface"`
+	CANID        uint32        `json:"can_id"`
+	CANIDHex     string        `json:"can_id_hex"`
+	Frames       uint64        `json:"frames"`
+	FirstSeen    time.Time     `json:"first_seen"`
+	LastSeen     time.Time     `json:"last_seen"`
+	LastData     []int         `json:"last_data"`
+	LastDataHex  string        `json:"last_data_hex"`
+	ChangedBytes []int         `json:"changed_bytes"` // Indexes of the bytes that changed at least once
+	ChangedMask  string        `json:"changed_mask"`  // ChangedBits of every byte, e.g. "00 FF 01 00"
+	Bytes        []ByteChanges `json:"bytes"`
+}
+
+// snifferKey identifies a CAN ID on an interface
+type snifferKey struct {
+	iface string
+	canID uint32
+}
+
+// snifferEntry accumulates the changes of one CAN ID
+type snifferEntry struct {
+	frames    uint64
+	firstSeen time.Time
+	lastSeen  time.Time
+	last      []uint8
+	bytes     []ByteChanges
+}
+
+// PayloadSniffer tracks which payload bytes and bits change per CAN ID, like cansniffer
+// Frames of each CAN ID must be added in time order.
+type PayloadSniffer struct {
+	entries map[snifferKey]*snifferEntry
+}
+
+// NewPayloadSniffer creates an empty payload sniffer
+func NewPayloadSniffer() *PayloadSniffer {
+	return &PayloadSniffer{
+		entries: make(map[snifferKey]*snifferEntry),
+	}
+}
+
+// Add compares a frame with the previous frame of its CAN ID
+func (s *PayloadSniffer) Add(ts time.Time, iface string, canID uint32, data []uint8) {
+	key := snifferKey{iface: iface, canID: canID}
+	entry, ok := s.entries[key]
+	if !ok {
+		entry = &snifferEntry{}
+		s.entries[key] = entry
+	}
+	if entry.frames == 0 {
+		entry.firstSeen = ts
+	}
+	entry.frames++
+	entry.lastSeen = ts
+
+	for len(entry.bytes) < len(data) {
+		i := len(entry.bytes)
+		entry.bytes = append(entry.bytes, ByteChanges{Index: i, Min: data[i], Max: data[i]})
+	}
+
+	for i, b := range data {
+		stats := &entry.bytes[i]
+		stats.Min = min(stats.Min, b)
+		stats.Max = max(stats.Max, b)
+
+		// Bytes beyond the previous frame's length have nothing to compare with
+		if i >= len(entry.last) {
+			continue
+		}
+		toggled := b ^ entry.last[i]
+		if toggled == 0 {
+			continue
+		}
+		stats.Changes++
+		stats.ChangedBits |= toggled
+		for bit := range 8 {
+			if toggled&(1<<bit) != 0 {
+				stats.BitToggles[bit]++
+			}
+		}
+	}
+
+	entry.last = append(entry.last[:0], data...)
+}
+
+// Result returns the change summary of every CAN ID, ordered by interface and CAN ID
+func (s *PayloadSniffer) Result() []PayloadChanges {
+	keys := slices.SortedFunc(maps.Keys(s.entries), func(a, b snifferKey) int {
+		if c := cmp.Compare(a.iface, b.iface); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.canID, b.canID)
+	})
+
+	result := make([]PayloadChanges, 0, len(keys))
+	for _, key := range keys {
+		entry := s.entries[key]
+		if entry.frames == 0 {
+			continue
+		}
+
+		changes := PayloadChanges{
+			Interface:    key.iface,
+			CANID:        key.canID,
+			CANIDHex:     fmt.Sprintf("0x%X", key.canID),
+			Frames:       entry.frames,
+			FirstSeen:    entry.firstSeen,
+			LastSeen:     entry.lastSeen,
+			LastData:     make([]int, len(entry.last)),
+			LastDataHex:  fmt.Sprintf("% X", entry.last),
+			ChangedBytes: []int{},
+			Bytes:        slices.Clone(entry.bytes),
+		}
+
+		for i, b := range entry.last {
+			changes.LastData[i] = int(b)
+		}
+
+		mask := make([]uint8, len(entry.bytes))
+		for i, stats := range entry.bytes {
+			mask[i] = stats.ChangedBits
+			if stats.Changes > 0 {
+				changes.ChangedBytes = append(changes.ChangedBytes, i)
+			}
+		}
+		changes.ChangedMask = fmt.Sprintf("% X", mask)
+
+		result = append(result, changes)
+	}
+
+	return result
+}
+
+// Reset clears the statistics while keeping the last payload of every CAN ID, so the next frame is
+// still compared with its predecessor
+func (s *PayloadSniffer) Reset() {
+	for _, entry := range s.entries {
+		entry.frames = 0
+		for i := range entry.bytes {
+			entry.bytes[i] = ByteChanges{Index: i, Min: 0xFF, Max: 0}
+		}
+	}
+}
@@ -6,6 +6,7 @@ import "time"
 type SocketCANStats struct {
 	Interface string    `json:"interface"`
 	Timestamp time.Time `json:"timestamp"`
+	Device
 
 	// Interface state
 	State       string `json:"state"`        // UP, DOWN, etc.
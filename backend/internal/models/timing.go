@@ -0,0 +1,260 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"time"
+)
+
+// Inter-arrival thresholds relative to the expected period
+const (
+	TimingMissedFactor = 1.5 // A gap above 1.5 periods is one or more missed cycles
+	TimingBurstFactor  = 0.5 // Gaps below half a period are frames arriving in a burst
+)
+
+// TimingMaxBursts caps the number of bursts listed in a timing analysis
+const TimingMaxBursts = 100
+
+// TimingStats summarises a distribution of intervals in milliseconds
+type TimingStats struct {
+	Count    int     `json:"count"`
+	MeanMs   float64 `json:"mean_ms"`
+	StdDevMs float64 `json:"stddev_ms"` // Jitter
+	MinMs    float64 `json:"min_ms"`
+	P50Ms    float64 `json:"p50_ms"`
+	P95Ms    float64 `json:"p95_ms"`
+	P99Ms    float64 `json:"p99_ms"`
+	MaxMs    float64 `json:"max_ms"`
+}
+
+// NewTimingStats computes the statistics of intervals in milliseconds
+func NewTimingStats(intervals []float64) TimingStats {
+	if len(intervals) == 0 {
+		return TimingStats{}
+	}
+
+	sorted := slices.Clone(intervals)
+	slices.Sort(sorted)
+
+	var sum float64
+	for _, v := range sorted {
+		sum += v
+	}
+	mean := sum / float64(len(sorted))
+
+	var variance float64
+	for _, v := range sorted {
+		variance += (v - mean) * (v - mean)
+	}
+	variance /= float64(len(sorted))
+
+	return TimingStats{
+		Count:    len(sorted),
+		MeanMs:   roundMicro(mean),
+		StdDevMs: roundMicro(math.Sqrt(variance)),
+		MinMs:    roundMicro(sorted[0]),
+		P50Ms:    roundMicro(percentile(sorted, 50)),
+		P95Ms:    roundMicro(percentile(sorted, 95)),
+		P99Ms:    roundMicro(percentile(sorted, 99)),
+		MaxMs:    roundMicro(sorted[len(sorted)-1]),
+	}
+}
+
+// TimingBurst is a run of frames arriving faster than half the expected period
+type TimingBurst struct {
+	Start      time.Time `json:"start"`
+	Frames     int       `json:"frames"`
+	DurationMs float64   `json:"duration_ms"`
+}
+
+// TimingBucket holds the inter-arrival statistics of one time bucket
+type TimingBucket struct {
+	TimeBucket   time.Time   `json:"time_bucket"`
+	Frames       int         `json:"frames"`
+	Period       TimingStats `json:"period"`
+	MissedCycles int         `json:"missed_cycles"`
+	Bursts       int         `json:"bursts"`
+}
+
+// SyncLatency summarises the delay between each SYNC and the first following frame of a CAN ID
+type SyncLatency struct {
+	SyncCount int         `json:"sync_count"`
+	Responded int         `json:"responded"` // SYNC cycles with a frame before the next SYNC
+	Missed    int         `json:"missed"`    // SYNC cycles without a frame
+	Latency   TimingStats `json:"latency"`
+}
+
+// TimingAnalysis is the cycle time and jitter analysis of one CAN ID on one interface
+type TimingAnalysis struct {
+	Interface        string         `json:"interface"`
+	CANID            uint32         `json:"can_id"`
+	CANIDHex         string         `json:"can_id_hex"`
+	StartTime        time.Time      `json:"start_time"`
+	EndTime          time.Time      `json:"end_time"`
+	Frames           int            `json:"frames"`
+	ExpectedPeriodMs float64        `json:"expected_period_ms"`
+	PeriodSource     string         `json:"period_source"` // "request" or "median"
+	Period           TimingStats    `json:"period"`
+	MissedCycles     int            `json:"missed_cycles"`
+	BurstCount       int            `json:"burst_count"`
+	Bursts           []TimingBurst  `json:"bursts"`
+	Buckets          []TimingBucket `json:"buckets"`
+	SyncLatency      *SyncLatency   `json:"sync_latency,omitempty"`
+	Truncated        bool           `json:"truncated"`
+}
+
+// AnalyzeTiming computes the inter-arrival distribution of the frames of a CAN ID
+// timestamps and syncs must be in time order. Without an expected period the median inter-arrival
+// time is used. Buckets are aligned to multiples of interval since the Unix epoch; without an
+// interval all frames are in one bucket.
+func AnalyzeTiming(iface string, canID uint32, timestamps, syncs []time.Time, period, interval time.Duration) TimingAnalysis {
+	analysis := TimingAnalysis{
+		Interface:    iface,
+		CANID:        canID,
+		CANIDHex:     fmt.Sprintf("0x%X", canID),
+		Frames:       len(timestamps),
+		PeriodSource: "request",
+		Bursts:       []TimingBurst{},
+		Buckets:      []TimingBucket{},
+	}
+	if len(timestamps) == 0 {
+		return analysis
+	}
+	analysis.StartTime = timestamps[0]
+	analysis.EndTime = timestamps[len(timestamps)-1]
+
+	gaps := make([]float64, 0, len(timestamps)-1)
+	for i := 1; i < len(timestamps); i++ {
+		gaps = append(gaps, durationMs(timestamps[i].Sub(timestamps[i-1])))
+	}
+	analysis.Period = NewTimingStats(gaps)
+
+	expected := durationMs(period)
+	if period <= 0 {
+		expected = analysis.Period.P50Ms
+		analysis.PeriodSource = "median"
+	}
+	analysis.ExpectedPeriodMs = roundMicro(expected)
+
+	if len(syncs) > 0 && canID != 0x080 {
+		analysis.SyncLatency = syncLatency(timestamps, syncs)
+	}
+
+	bucketOf := func(ts time.Time) time.Time {
+		if interval <= 0 {
+			return analysis.StartTime
+		}
+		micros := interval.Microseconds()
+		return time.UnixMicro(ts.UnixMicro() / micros * micros).UTC()
+	}
+
+	// Each gap belongs to the bucket of the frame ending it
+	var bucket *TimingBucket
+	var bucketGaps []float64
+	var burst *TimingBurst
+
+	flush := func() {
+		if bucket != nil {
+			bucket.Period = NewTimingStats(bucketGaps)
+			analysis.Buckets = append(analysis.Buckets, *bucket)
+		}
+	}
+
+	for i, ts := range timestamps {
+		bucketStart := bucketOf(ts)
+		if bucket == nil || !bucket.TimeBucket.Equal(bucketStart) {
+			flush()
+			bucket = &TimingBucket{TimeBucket: bucketStart}
+			bucketGaps = bucketGaps[:0]
+		}
+		bucket.Frames++
+		if i == 0 || expected <= 0 {
+			continue
+		}
+
+		gap := gaps[i-1]
+		bucketGaps = append(bucketGaps, gap)
+
+		if gap > expected*TimingMissedFactor {
+			missed := max(int(math.Round(gap/expected))-1, 1)
+			bucket.MissedCycles += missed
+			analysis.MissedCycles += missed
+		}
+
+		if gap < expected*TimingBurstFactor {
+			if burst == nil {
+				burst = &TimingBurst{Start: timestamps[i-1], Frames: 1}
+				bucket.Bursts++
+				analysis.BurstCount++
+			}
+			burst.Frames++
+			burst.DurationMs = roundMicro(durationMs(ts.Sub(burst.Start)))
+		} else if burst != nil {
+			analysis.addBurst(*burst)
+			burst = nil
+		}
+	}
+	if burst != nil {
+		analysis.addBurst(*burst)
+	}
+	flush()
+
+	return analysis
+}
+
+// addBurst lists a burst up to TimingMaxBursts
+func (a *TimingAnalysis) addBurst(burst TimingBurst) {
+	if len(a.Bursts) < TimingMaxBursts {
+		a.Bursts = append(a.Bursts, burst)
+	}
+}
+
+// syncLatency measures, for every SYNC, the delay until the first frame before the next SYNC
+// The last SYNC cycle ends one median SYNC period later.
+func syncLatency(timestamps, syncs []time.Time) *SyncLatency {
+	syncPeriods := make([]float64, 0, len(syncs))
+	for i := 1; i < len(syncs); i++ {
+		syncPeriods = append(syncPeriods, durationMs(syncs[i].Sub(syncs[i-1])))
+	}
+	lastCycle := time.Duration(NewTimingStats(syncPeriods).P50Ms * float64(time.Millisecond))
+
+	result := &SyncLatency{SyncCount: len(syncs)}
+	latencies := []float64{}
+	j := 0
+	for i, sync := range syncs {
+		cycleEnd := sync.Add(lastCycle)
+		if i+1 < len(syncs) {
+			cycleEnd = syncs[i+1]
+		}
+
+		for j < len(timestamps) && timestamps[j].Before(sync) {
+			j++
+		}
+		if j < len(timestamps) && timestamps[j].Before(cycleEnd) {
+			latencies = append(latencies, durationMs(timestamps[j].Sub(sync)))
+			result.Responded++
+		} else {
+			result.Missed++
+		}
+	}
+	result.Latency = NewTimingStats(latencies)
+
+	return result
+}
+
+// percentile returns the nearest-rank percentile of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[min(max(rank, 1), len(sorted))-1]
+}
+
+// durationMs converts a duration to milliseconds
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// roundMicro rounds milliseconds to microsecond resolution
+func roundMicro(ms float64) float64 {
+	return math.Round(ms*1000) / 1000
+}
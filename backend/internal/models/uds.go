@@ -0,0 +1,574 @@
+package models
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// UDS service identifiers (ISO 14229-1)
+const (
+	UDSDiagnosticSessionControl uint8 = 0x10
+	UDSECUReset                 uint8 = 0x11
+	UDSClearDiagnosticInfo      uint8 = 0x14
+	UDSReadDTCInformation       uint8 = 0x19
+	UDSReadDataByIdentifier     uint8 = 0x22
+	UDSSecurityAccess           uint8 = 0x27
+	UDSCommunicationControl     uint8 = 0x28
+	UDSWriteDataByIdentifier    uint8 = 0x2E
+	UDSRoutineControl           uint8 = 0x31
+	UDSTesterPresent            uint8 = 0x3E
+	UDSControlDTCSetting        uint8 = 0x85
+	UDSNegativeResponse         uint8 = 0x7F
+	UDSNRCResponsePending       uint8 = 0x78
+	udsPositiveResponseOffset   uint8 = 0x40
+	udsSuppressPositiveResponse uint8 = 0x80
+)
+
+// UDSResponseTimeout is how long a request waits for its final response (P2*server_max default)
+const UDSResponseTimeout = 5 * time.Second
+
+// Outcomes of a UDS transaction
+const (
+	UDSOutcomePositive    = "positive"
+	UDSOutcomeNegative    = "negative"
+	UDSOutcomeNoResponse  = "no_response"
+	UDSOutcomeSuppressed  = "suppressed" // Positive response suppressed by the request, none expected
+	UDSOutcomeUnsolicited = "unsolicited"
+	UDSOutcomeTransport   = "transport_error"
+)
+
+// udsServiceNames names the UDS services
+var udsServiceNames = map[uint8]string{
+	0x10: "DiagnosticSessionControl",
+	0x11: "ECUReset",
+	0x14: "ClearDiagnosticInformation",
+	0x19: "ReadDTCInformation",
+	0x22: "ReadDataByIdentifier",
+	0x23: "ReadMemoryByAddress",
+	0x24: "ReadScalingDataByIdentifier",
+	0x27: "SecurityAccess",
+	0x28: "CommunicationControl",
+	0x29: "Authentication",
+	0x2A: "ReadDataByPeriodicIdentifier",
+	0x2C: "DynamicallyDefineDataIdentifier",
+	0x2E: "WriteDataByIdentifier",
+	0x2F: "InputOutputControlByIdentifier",
+	0x31: "RoutineControl",
+	0x34: "RequestDownload",
+	0x35: "RequestUpload",
+	0x36: "TransferData",
+	0x37: "RequestTransferExit",
+	0x38: "RequestFileTransfer",
+	0x3D: "WriteMemoryByAddress",
+	0x3E: "TesterPresent",
+	0x83: "AccessTimingParameter",
+	0x84: "SecuredDataTransmission",
+	0x85: "ControlDTCSetting",
+	0x86: "ResponseOnEvent",
+	0x87: "LinkControl",
+}
+
+// udsSubFunctionServices are the services whose first parameter byte is a sub-function
+// with the suppressPosRspMsgIndicationBit
+var udsSubFunctionServices = map[uint8]bool{
+	0x10: true, 0x11: true, 0x19: true, 0x27: true, 0x28: true, 0x29: true,
+	0x31: true, 0x3E: true, 0x83: true, 0x85: true, 0x86: true, 0x87: true,
+}
+
+// udsNRCNames names the negative response codes
+var udsNRCNames = map[uint8]string{
+	0x10: "generalReject",
+	0x11: "serviceNotSupported",
+	0x12: "subFunctionNotSupported",
+	0x13: "incorrectMessageLengthOrInvalidFormat",
+	0x14: "responseTooLong",
+	0x21: "busyRepeatRequest",
+	0x22: "conditionsNotCorrect",
+	0x24: "requestSequenceError",
+	0x25: "noResponseFromSubnetComponent",
+	0x26: "failurePreventsExecutionOfRequestedAction",
+	0x31: "requestOutOfRange",
+	0x33: "securityAccessDenied",
+	0x34: "authenticationRequired",
+	0x35: "invalidKey",
+	0x36: "exceedNumberOfAttempts",
+	0x37: "requiredTimeDelayNotExpired",
+	0x70: "uploadDownloadNotAccepted",
+	0x71: "transferDataSuspended",
+	0x72: "generalProgrammingFailure",
+	0x73: "wrongBlockSequenceCounter",
+	0x78: "requestCorrectlyReceivedResponsePending",
+	0x7E: "subFunctionNotSupportedInActiveSession",
+	0x7F: "serviceNotSupportedInActiveSession",
+	0x81: "rpmTooHigh",
+	0x82: "rpmTooLow",
+	0x83: "engineIsRunning",
+	0x84: "engineIsNotRunning",
+	0x85: "engineRunTimeTooLow",
+	0x86: "temperatureTooHigh",
+	0x87: "temperatureTooLow",
+	0x88: "vehicleSpeedTooHigh",
+	0x89: "vehicleSpeedTooLow",
+	0x8A: "throttlePedalTooHigh",
+	0x8B: "throttlePedalTooLow",
+	0x8C: "transmissionRangeNotInNeutral",
+	0x8D: "transmissionRangeNotInGear",
+	0x8F: "brakeSwitchesNotClosed",
+	0x90: "shifterLeverNotInPark",
+	0x91: "torqueConverterClutchLocked",
+	0x92: "voltageTooHigh",
+	0x93: "voltageTooLow",
+}
+
+// udsSessionNames names the diagnostic session types
+var udsSessionNames = map[uint8]string{
+	0x01: "defaultSession",
+	0x02: "programmingSession",
+	0x03: "extendedDiagnosticSession",
+	0x04: "safetySystemDiagnosticSession",
+}
+
+// udsResetNames names the ECU reset types
+var udsResetNames = map[uint8]string{
+	0x01: "hardReset",
+	0x02: "keyOffOnReset",
+	0x03: "softReset",
+	0x04: "enableRapidPowerShutDown",
+	0x05: "disableRapidPowerShutDown",
+}
+
+// udsDTCReportNames names the ReadDTCInformation report types
+var udsDTCReportNames = map[uint8]string{
+	0x01: "reportNumberOfDTCByStatusMask",
+	0x02: "reportDTCByStatusMask",
+	0x03: "reportDTCSnapshotIdentification",
+	0x04: "reportDTCSnapshotRecordByDTCNumber",
+	0x06: "reportDTCExtDataRecordByDTCNumber",
+	0x07: "reportNumberOfDTCBySeverityMaskRecord",
+	0x08: "reportDTCBySeverityMaskRecord",
+	0x0A: "reportSupportedDTC",
+	0x0B: "reportFirstTestFailedDTC",
+	0x0C: "reportFirstConfirmedDTC",
+	0x0D: "reportMostRecentTestFailedDTC",
+	0x0E: "reportMostRecentConfirmedDTC",
+	0x14: "reportDTCFaultDetectionCounter",
+	0x15: "reportDTCWithPermanentStatus",
+}
+
+// udsRoutineControlNames names the RoutineControl types
+var udsRoutineControlNames = map[uint8]string{
+	0x01: "startRoutine",
+	0x02: "stopRoutine",
+	0x03: "requestRoutineResults",
+}
+
+// UDSServiceName returns the name of a UDS service
+func UDSServiceName(sid uint8) string {
+	if name, ok := udsServiceNames[sid]; ok {
+		return name
+	}
+	return fmt.Sprintf("Service_0x%02X", sid)
+}
+
+// UDSNRCName returns the name of a negative response code
+func UDSNRCName(nrc uint8) string {
+	if name, ok := udsNRCNames[nrc]; ok {
+		return name
+	}
+	if nrc >= 0x38 && nrc <= 0x4F {
+		return "reservedByExtendedDataLinkSecurity"
+	}
+	if nrc >= 0xF0 && nrc <= 0xFE {
+		return "vehicleManufacturerSpecific"
+	}
+	return fmt.Sprintf("NRC_0x%02X", nrc)
+}
+
+// UDSTransaction is a diagnostic request together with its final response
+type UDSTransaction struct {
+	Timestamp    time.Time         `json:"timestamp"` // Request
+	ResponseTime *time.Time        `json:"response_time,omitempty"`
+	DeviceID     string            `json:"device_id"`
+	Interface    string            `json:"interface"`
+	RequestID    uint32            `json:"request_id"`
+	ResponseID   uint32            `json:"response_id"`
+	ServiceID    uint8             `json:"service_id"`
+	Service      string            `json:"service"`
+	SubFunction  string            `json:"sub_function,omitempty"`
+	Outcome      string            `json:"outcome"`
+	NRC          uint8             `json:"nrc,omitempty"`
+	NRCName      string            `json:"nrc_name,omitempty"`
+	LatencyMs    float64           `json:"latency_ms"`
+	PendingCount uint16            `json:"pending_count"` // responsePending (0x78) responses before the final one
+	Request      []uint8           `json:"request"`
+	RequestHex   string            `json:"request_hex"`
+	Response     []uint8           `json:"response"`
+	ResponseHex  string            `json:"response_hex"`
+	Details      map[string]string `json:"details"`
+
+	suppressPositive bool
+	deadline         time.Time
+}
+
+// newUDSTransaction creates a transaction for a request and decodes the request parameters
+func newUDSTransaction(msg *ISOTPMessage, pair ISOTPPair) *UDSTransaction {
+	sid := msg.Data[0]
+	tx := &UDSTransaction{
+		Timestamp:  msg.Timestamp,
+		Interface:  msg.Interface,
+		RequestID:  pair.RequestID,
+		ResponseID: pair.ResponseID,
+		ServiceID:  sid,
+		Service:    UDSServiceName(sid),
+		Request:    msg.Data,
+		RequestHex: fmt.Sprintf("%X", msg.Data),
+		Response:   []uint8{},
+		Details:    map[string]string{},
+		deadline:   msg.End.Add(UDSResponseTimeout),
+	}
+
+	if udsSubFunctionServices[sid] && len(msg.Data) > 1 {
+		tx.suppressPositive = msg.Data[1]&udsSuppressPositiveResponse != 0
+		tx.SubFunction = udsSubFunctionName(sid, msg.Data[1]&0x7F)
+	}
+	tx.decodeRequest()
+
+	return tx
+}
+
+// udsSubFunctionName names the sub-function of a service
+func udsSubFunctionName(sid, sub uint8) string {
+	var names map[uint8]string
+	switch sid {
+	case UDSDiagnosticSessionControl:
+		names = udsSessionNames
+	case UDSECUReset:
+		names = udsResetNames
+	case UDSReadDTCInformation:
+		names = udsDTCReportNames
+	case UDSRoutineControl:
+		names = udsRoutineControlNames
+	case UDSSecurityAccess:
+		if sub%2 == 1 {
+			return fmt.Sprintf("requestSeed_level%d", (sub+1)/2)
+		}
+		return fmt.Sprintf("sendKey_level%d", sub/2)
+	case UDSControlDTCSetting:
+		names = map[uint8]string{0x01: "on", 0x02: "off"}
+	case UDSTesterPresent:
+		names = map[uint8]string{0x00: "zeroSubFunction"}
+	}
+	if name, ok := names[sub]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%02X", sub)
+}
+
+// decodeRequest extracts the request parameters into the details
+func (tx *UDSTransaction) decodeRequest() {
+	data := tx.Request
+	switch tx.ServiceID {
+	case UDSReadDataByIdentifier:
+		dids := []string{}
+		for i := 1; i+2 <= len(data); i += 2 {
+			dids = append(dids, fmt.Sprintf("0x%04X", binary.BigEndian.Uint16(data[i:])))
+		}
+		tx.Details["data_identifiers"] = strings.Join(dids, ",")
+
+	case UDSWriteDataByIdentifier:
+		if len(data) >= 3 {
+			tx.Details["data_identifier"] = fmt.Sprintf("0x%04X", binary.BigEndian.Uint16(data[1:]))
+			tx.Details["value_hex"] = fmt.Sprintf("%X", data[3:])
+		}
+
+	case UDSReadDTCInformation:
+		if len(data) >= 3 && (data[1]&0x7F == 0x01 || data[1]&0x7F == 0x02) {
+			tx.Details["status_mask"] = fmt.Sprintf("0x%02X", data[2])
+		}
+		if len(data) >= 5 && (data[1]&0x7F == 0x04 || data[1]&0x7F == 0x06) {
+			tx.Details["dtc"] = fmt.Sprintf("0x%06X", uint32(data[2])<<16|uint32(data[3])<<8|uint32(data[4]))
+		}
+
+	case UDSClearDiagnosticInfo:
+		if len(data) >= 4 {
+			tx.Details["group_of_dtc"] = fmt.Sprintf("0x%06X", uint32(data[1])<<16|uint32(data[2])<<8|uint32(data[3]))
+		}
+
+	case UDSRoutineControl:
+		if len(data) >= 4 {
+			tx.Details["routine_identifier"] = fmt.Sprintf("0x%04X", binary.BigEndian.Uint16(data[2:]))
+		}
+
+	case UDSCommunicationControl:
+		if len(data) >= 3 {
+			tx.Details["communication_type"] = fmt.Sprintf("0x%02X", data[2])
+		}
+	}
+}
+
+// complete applies the final response (positive or negative)
+func (tx *UDSTransaction) complete(msg *ISOTPMessage) {
+	responseTime := msg.End
+	tx.ResponseTime = &responseTime
+	tx.LatencyMs = msBetween(tx.Timestamp, responseTime)
+	tx.Response = msg.Data
+	tx.ResponseHex = fmt.Sprintf("%X", msg.Data)
+
+	if msg.Data[0] == UDSNegativeResponse {
+		tx.Outcome = UDSOutcomeNegative
+		if len(msg.Data) >= 3 {
+			tx.NRC = msg.Data[2]
+			tx.NRCName = UDSNRCName(tx.NRC)
+		}
+		return
+	}
+
+	tx.Outcome = UDSOutcomePositive
+	tx.decodeResponse()
+}
+
+// decodeResponse extracts the positive response parameters into the details
+func (tx *UDSTransaction) decodeResponse() {
+	data := tx.Response
+	switch tx.ServiceID {
+	case UDSDiagnosticSessionControl:
+		if len(data) >= 6 {
+			tx.Details["session"] = udsSubFunctionName(tx.ServiceID, data[1])
+			tx.Details["p2_server_max_ms"] = fmt.Sprintf("%d", binary.BigEndian.Uint16(data[2:]))
+			tx.Details["p2_star_server_max_ms"] = fmt.Sprintf("%d", uint32(binary.BigEndian.Uint16(data[4:]))*10)
+		}
+
+	case UDSReadDataByIdentifier:
+		// The values of several identifiers can only be split with their lengths, which are ECU specific
+		if len(data) >= 3 && len(tx.Request) == 3 {
+			did := fmt.Sprintf("0x%04X", binary.BigEndian.Uint16(data[1:]))
+			tx.Details["value_hex"] = fmt.Sprintf("%X", data[3:])
+			tx.Details["data_identifier"] = did
+			if text, ok := printableASCII(data[3:]); ok {
+				tx.Details["value_ascii"] = text
+			}
+		}
+
+	case UDSReadDTCInformation:
+		if len(data) < 3 {
+			return
+		}
+		switch data[1] {
+		case 0x01, 0x07:
+			if len(data) >= 6 {
+				tx.Details["status_availability_mask"] = fmt.Sprintf("0x%02X", data[2])
+				tx.Details["dtc_count"] = fmt.Sprintf("%d", binary.BigEndian.Uint16(data[4:]))
+			}
+		case 0x02, 0x0A, 0x0B, 0x0C, 0x0D, 0x0E, 0x15:
+			tx.Details["status_availability_mask"] = fmt.Sprintf("0x%02X", data[2])
+			dtcs := []string{}
+			for i := 3; i+4 <= len(data); i += 4 {
+				dtc := uint32(data[i])<<16 | uint32(data[i+1])<<8 | uint32(data[i+2])
+				dtcs = append(dtcs, fmt.Sprintf("0x%06X:0x%02X", dtc, data[i+3]))
+			}
+			tx.Details["dtcs"] = strings.Join(dtcs, ",")
+			tx.Details["dtc_count"] = fmt.Sprintf("%d", len(dtcs))
+		}
+
+	case UDSSecurityAccess:
+		if len(data) >= 2 && data[1]%2 == 1 {
+			tx.Details["seed_length"] = fmt.Sprintf("%d", len(data)-2)
+		}
+
+	case UDSRoutineControl:
+		if len(data) > 4 {
+			tx.Details["routine_status_hex"] = fmt.Sprintf("%X", data[4:])
+		}
+	}
+}
+
+// printableASCII returns the data as text if it only holds printable ASCII (trailing NUL/space padding allowed)
+func printableASCII(data []byte) (string, bool) {
+	text := strings.TrimRight(string(data), "\x00 ")
+	if text == "" {
+		return "", false
+	}
+	for _, c := range []byte(text) {
+		if c < 0x20 || c > 0x7E {
+			return "", false
+		}
+	}
+	return text, true
+}
+
+// UDSTracker reassembles ISO-TP messages of the configured pairs and pairs UDS requests with their responses
+type UDSTracker struct {
+	pairs   []ISOTPPair
+	isotp   *ISOTPReassembler
+	pending map[string]*UDSTransaction // interface/request ID/response ID -> open request
+}
+
+// NewUDSTracker creates a tracker for the given request/response ID pairs
+func NewUDSTracker(pairs []ISOTPPair) *UDSTracker {
+	return &UDSTracker{
+		pairs:   pairs,
+		isotp:   NewISOTPReassembler(),
+		pending: make(map[string]*UDSTransaction),
+	}
+}
+
+// pendingKey identifies the open request of a pair
+func pendingKey(iface string, pair ISOTPPair) string {
+	return fmt.Sprintf("%s/%d/%d", iface, pair.RequestID, pair.ResponseID)
+}
+
+// Add processes a frame (arbitration ID without SocketCAN flags) in time order and returns
+// the transactions it completes
+func (t *UDSTracker) Add(timestamp time.Time, iface string, canID uint32, data []byte) []UDSTransaction {
+	var isRequest, isResponse bool
+	for _, pair := range t.pairs {
+		isRequest = isRequest || pair.RequestID == canID
+		isResponse = isResponse || pair.ResponseID == canID
+	}
+	if !isRequest && !isResponse {
+		return nil
+	}
+
+	completed := t.Expire(timestamp)
+
+	msg := t.isotp.Add(timestamp, iface, canID, data)
+	if msg == nil || len(msg.Data) == 0 {
+		return completed
+	}
+
+	if isRequest {
+		for _, pair := range t.pairs {
+			if pair.RequestID != canID {
+				continue
+			}
+			key := pendingKey(iface, pair)
+
+			// A new request ends the previous one of the pair
+			if old, ok := t.pending[key]; ok {
+				completed = append(completed, old.finishWithoutResponse())
+				delete(t.pending, key)
+			}
+
+			tx := newUDSTransaction(msg, pair)
+			if msg.Error != "" {
+				tx.Outcome = UDSOutcomeTransport
+				tx.Details["error"] = msg.Error
+				completed = append(completed, *tx)
+				continue
+			}
+			t.pending[key] = tx
+		}
+	}
+
+	if isResponse {
+		// Several pairs may share a response ID (e.g. physical and functional requests)
+		var key string
+		var tx *UDSTransaction
+		for _, pair := range t.pairs {
+			if pair.ResponseID != canID {
+				continue
+			}
+			candidate, ok := t.pending[pendingKey(iface, pair)]
+			if ok && candidate.matches(msg.Data) && (tx == nil || candidate.Timestamp.Before(tx.Timestamp)) {
+				key, tx = pendingKey(iface, pair), candidate
+			}
+		}
+
+		switch {
+		case tx == nil:
+			completed = append(completed, t.unsolicited(msg, canID))
+
+		case msg.Error != "":
+			tx.Outcome = UDSOutcomeTransport
+			tx.Response = msg.Data
+			tx.ResponseHex = fmt.Sprintf("%X", msg.Data)
+			tx.Details["error"] = msg.Error
+			completed = append(completed, *tx)
+			delete(t.pending, key)
+
+		case len(msg.Data) >= 3 && msg.Data[0] == UDSNegativeResponse && msg.Data[2] == UDSNRCResponsePending:
+			// The server needs more time: wait up to P2* for the final response
+			tx.PendingCount++
+			tx.deadline = msg.End.Add(UDSResponseTimeout)
+
+		default:
+			tx.complete(msg)
+			completed = append(completed, *tx)
+			delete(t.pending, key)
+		}
+	}
+
+	return completed
+}
+
+// matches reports whether a response belongs to the request's service
+func (tx *UDSTransaction) matches(response []byte) bool {
+	if response[0] == UDSNegativeResponse {
+		return len(response) >= 2 && response[1] == tx.ServiceID
+	}
+	return response[0] == tx.ServiceID+udsPositiveResponseOffset
+}
+
+// unsolicited records a response without a matching request
+func (t *UDSTracker) unsolicited(msg *ISOTPMessage, responseID uint32) UDSTransaction {
+	pair := ISOTPPair{ResponseID: responseID}
+	for _, p := range t.pairs {
+		if p.ResponseID == responseID {
+			pair = p
+			break
+		}
+	}
+
+	sid := msg.Data[0] - udsPositiveResponseOffset
+	if msg.Data[0] == UDSNegativeResponse && len(msg.Data) >= 2 {
+		sid = msg.Data[1]
+	}
+
+	responseTime := msg.End
+	tx := UDSTransaction{
+		Timestamp:    msg.Timestamp,
+		ResponseTime: &responseTime,
+		Interface:    msg.Interface,
+		RequestID:    pair.RequestID,
+		ResponseID:   pair.ResponseID,
+		ServiceID:    sid,
+		Service:      UDSServiceName(sid),
+		Outcome:      UDSOutcomeUnsolicited,
+		Request:      []uint8{},
+		Response:     msg.Data,
+		ResponseHex:  fmt.Sprintf("%X", msg.Data),
+		Details:      map[string]string{},
+	}
+	if msg.Data[0] == UDSNegativeResponse && len(msg.Data) >= 3 {
+		tx.NRC = msg.Data[2]
+		tx.NRCName = UDSNRCName(tx.NRC)
+	}
+	if msg.Error != "" {
+		tx.Details["error"] = msg.Error
+	}
+	return tx
+}
+
+// finishWithoutResponse ends a request that got no final response
+func (tx *UDSTransaction) finishWithoutResponse() UDSTransaction {
+	tx.Outcome = UDSOutcomeNoResponse
+	if tx.suppressPositive && tx.PendingCount == 0 {
+		tx.Outcome = UDSOutcomeSuppressed
+	}
+	return *tx
+}
+
+// Expire ends the requests whose response timeout elapsed
+func (t *UDSTracker) Expire(now time.Time) []UDSTransaction {
+	var completed []UDSTransaction
+	for key, tx := range t.pending {
+		if now.Before(tx.deadline) {
+			continue
+		}
+		completed = append(completed, tx.finishWithoutResponse())
+		delete(t.pending, key)
+	}
+	return completed
+}
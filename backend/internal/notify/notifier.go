@@ -0,0 +1,132 @@
+package notify
+
+import (
+	"bytes"
+	"can-db-writer/internal/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Config holds the alert delivery configuration; empty settings disable their channel
+type Config struct {
+	WebhookURL string
+	SMTPHost   string
+	SMTPPort   int
+	EmailFrom  string
+	EmailTo    []string
+}
+
+// Publisher publishes alerts to live stream subscribers
+type Publisher interface {
+	PublishAlert(alert models.Alert)
+}
+
+// Notifier delivers alert notifications by webhook, email and the live stream
+type Notifier struct {
+	config    Config
+	publisher Publisher
+	client    *http.Client
+}
+
+// NewNotifier creates a notifier; publisher may be nil when the live stream is disabled
+func NewNotifier(config Config, publisher Publisher) *Notifier {
+	return &Notifier{
+		config:    config,
+		publisher: publisher,
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Channels returns the configured delivery channels
+func (n *Notifier) Channels() []string {
+	channels := []string{}
+	if n.config.WebhookURL != "" {
+		channels = append(channels, models.NotifyWebhook)
+	}
+	if n.config.SMTPHost != "" && len(n.config.EmailTo) > 0 {
+		channels = append(channels, models.NotifyEmail)
+	}
+	if n.publisher != nil {
+		channels = append(channels, models.NotifyStream)
+	}
+	return channels
+}
+
+// Notify delivers an alert to the given channels, or to every configured channel when none are given
+// Channels that are not configured are skipped; delivery errors are returned together.
+func (n *Notifier) Notify(alert models.Alert, channels []string) error {
+	if len(channels) == 0 {
+		channels = n.Channels()
+	}
+
+	var errs []string
+	for _, channel := range channels {
+		if !slices.Contains(n.Channels(), channel) {
+			continue
+		}
+
+		var err error
+		switch channel {
+		case models.NotifyWebhook:
+			err = n.sendWebhook(alert)
+		case models.NotifyEmail:
+			err = n.sendEmail(alert)
+		case models.NotifyStream:
+			n.publisher.PublishAlert(alert)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", channel, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to deliver alert: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// sendWebhook posts the alert as JSON
+func (n *Notifier) sendWebhook(alert models.Alert) error {
+	payload, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	resp, err := n.client.Post(n.config.WebhookURL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// sendEmail sends the alert through the SMTP relay without authentication
+func (n *Notifier) sendEmail(alert models.Alert) error {
+	subject := fmt.Sprintf("[%s] %s %s", strings.ToUpper(alert.Severity), alert.RuleName, alert.Status)
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "From: %s\r\n", n.config.EmailFrom)
+	fmt.Fprintf(&body, "To: %s\r\n", strings.Join(n.config.EmailTo, ", "))
+	fmt.Fprintf(&body, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&body, "Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	fmt.Fprintf(&body, "%s\r\n\r\n", alert.Message)
+	fmt.Fprintf(&body, "Rule:      %s (%s)\r\n", alert.RuleName, alert.RuleID)
+	fmt.Fprintf(&body, "Status:    %s\r\n", alert.Status)
+	fmt.Fprintf(&body, "Interface: %s\r\n", alert.Interface)
+	fmt.Fprintf(&body, "Subject:   %s\r\n", alert.Subject)
+	fmt.Fprintf(&body, "Value:     %s\r\n", alert.Value)
+	fmt.Fprintf(&body, "Fired at:  %s\r\n", alert.FiredAt.Format(time.RFC3339Nano))
+	fmt.Fprintf(&body, "Time:      %s\r\n", alert.Timestamp.Format(time.RFC3339Nano))
+
+	addr := fmt.Sprintf("%s:%d", n.config.SMTPHost, n.config.SMTPPort)
+	return smtp.SendMail(addr, nil, n.config.EmailFrom, n.config.EmailTo, []byte(body.String()))
+}
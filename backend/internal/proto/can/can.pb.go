@@ -31,6 +31,7 @@ type QueryFilter struct {
 	Interface     string                 `protobuf:"bytes,4,opt,name=interface,proto3" json:"interface,omitempty"`
 	Limit         int32                  `protobuf:"varint,5,opt,name=limit,proto3" json:"limit,omitempty"`
 	Offset        int32                  `protobuf:"varint,6,opt,name=offset,proto3" json:"offset,omitempty"`
+	DeviceId      string                 `protobuf:"bytes,7,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -107,32 +108,38 @@ func (x *QueryFilter) GetOffset() int32 {
 	return 0
 }
 
-// CAN Message
-type CANMessage struct {
+func (x *QueryFilter) GetDeviceId() string {
+	if x != nil {
+		return x.DeviceId
+	}
+	return ""
+}
+
+// GetCANopenMessages request/response
+type GetCANopenMessagesRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
-	Interface     string                 `protobuf:"bytes,2,opt,name=interface,proto3" json:"interface,omitempty"`
-	CanId         uint32                 `protobuf:"varint,3,opt,name=can_id,json=canId,proto3" json:"can_id,omitempty"`
-	CanIdHex      string                 `protobuf:"bytes,4,opt,name=can_id_hex,json=canIdHex,proto3" json:"can_id_hex,omitempty"`
-	Data          []byte                 `protobuf:"bytes,5,opt,name=data,proto3" json:"data,omitempty"`
+	Filter        *QueryFilter           `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	MessageType   string                 `protobuf:"bytes,2,opt,name=message_type,json=messageType,proto3" json:"message_type,omitempty"` // nmt, sync, emcy, pdo, sdo, heartbeat
+	NodeId        *uint32                `protobuf:"varint,3,opt,name=node_id,json=nodeId,proto3,oneof" json:"node_id,omitempty"`
+	PdoMappings   map[string]string      `protobuf:"bytes,4,rep,name=pdo_mappings,json=pdoMappings,proto3" json:"pdo_mappings,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // key: tpdo1/rpdo1, value: field mapping string
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CANMessage) Reset() {
-	*x = CANMessage{}
+func (x *GetCANopenMessagesRequest) Reset() {
+	*x = GetCANopenMessagesRequest{}
 	mi := &file_internal_proto_can_can_proto_msgTypes[1]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CANMessage) String() string {
+func (x *GetCANopenMessagesRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CANMessage) ProtoMessage() {}
+func (*GetCANopenMessagesRequest) ProtoMessage() {}
 
-func (x *CANMessage) ProtoReflect() protoreflect.Message {
+func (x *GetCANopenMessagesRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_internal_proto_can_can_proto_msgTypes[1]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -144,68 +151,67 @@ func (x *CANMessage) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CANMessage.ProtoReflect.Descriptor instead.
-func (*CANMessage) Descriptor() ([]byte, []int) {
+// Deprecated: Use GetCANopenMessagesRequest.ProtoReflect.Descriptor instead.
+func (*GetCANopenMessagesRequest) Descriptor() ([]byte, []int) {
 	return file_internal_proto_can_can_proto_rawDescGZIP(), []int{1}
 }
 
-func (x *CANMessage) GetTimestamp() *timestamppb.Timestamp {
+func (x *GetCANopenMessagesRequest) GetFilter() *QueryFilter {
 	if x != nil {
-		return x.Timestamp
+		return x.Filter
 	}
 	return nil
 }
 
-func (x *CANMessage) GetInterface() string {
+func (x *GetCANopenMessagesRequest) GetMessageType() string {
 	if x != nil {
-		return x.Interface
+		return x.MessageType
 	}
 	return ""
 }
 
-func (x *CANMessage) GetCanId() uint32 {
-	if x != nil {
-		return x.CanId
+func (x *GetCANopenMessagesRequest) GetNodeId() uint32 {
+	if x != nil && x.NodeId != nil {
+		return *x.NodeId
 	}
 	return 0
 }
 
-func (x *CANMessage) GetCanIdHex() string {
-	if x != nil {
-		return x.CanIdHex
-	}
-	return ""
-}
-
-func (x *CANMessage) GetData() []byte {
+func (x *GetCANopenMessagesRequest) GetPdoMappings() map[string]string {
 	if x != nil {
-		return x.Data
+		return x.PdoMappings
 	}
 	return nil
 }
 
-// GetMessages request/response
-type GetMessagesRequest struct {
+type CANopenMessage struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Filter        *QueryFilter           `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Interface     string                 `protobuf:"bytes,2,opt,name=interface,proto3" json:"interface,omitempty"`
+	CanId         uint32                 `protobuf:"varint,3,opt,name=can_id,json=canId,proto3" json:"can_id,omitempty"`
+	CanIdHex      string                 `protobuf:"bytes,4,opt,name=can_id_hex,json=canIdHex,proto3" json:"can_id_hex,omitempty"`
+	Data          []byte                 `protobuf:"bytes,5,opt,name=data,proto3" json:"data,omitempty"`
+	MessageType   string                 `protobuf:"bytes,6,opt,name=message_type,json=messageType,proto3" json:"message_type,omitempty"`
+	NodeId        uint32                 `protobuf:"varint,7,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	ParsedData    map[string]string      `protobuf:"bytes,8,rep,name=parsed_data,json=parsedData,proto3" json:"parsed_data,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // parsed PDO data if available
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetMessagesRequest) Reset() {
-	*x = GetMessagesRequest{}
+func (x *CANopenMessage) Reset() {
+	*x = CANopenMessage{}
 	mi := &file_internal_proto_can_can_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetMessagesRequest) String() string {
+func (x *CANopenMessage) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetMessagesRequest) ProtoMessage() {}
+func (*CANopenMessage) ProtoMessage() {}
 
-func (x *GetMessagesRequest) ProtoReflect() protoreflect.Message {
+func (x *CANopenMessage) ProtoReflect() protoreflect.Message {
 	mi := &file_internal_proto_can_can_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -217,39 +223,88 @@ func (x *GetMessagesRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetMessagesRequest.ProtoReflect.Descriptor instead.
-func (*GetMessagesRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use CANopenMessage.ProtoReflect.Descriptor instead.
+func (*CANopenMessage) Descriptor() ([]byte, []int) {
 	return file_internal_proto_can_can_proto_rawDescGZIP(), []int{2}
 }
 
-func (x *GetMessagesRequest) GetFilter() *QueryFilter {
+func (x *CANopenMessage) GetTimestamp() *timestamppb.Timestamp {
 	if x != nil {
-		return x.Filter
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *CANopenMessage) GetInterface() string {
+	if x != nil {
+		return x.Interface
+	}
+	return ""
+}
+
+func (x *CANopenMessage) GetCanId() uint32 {
+	if x != nil {
+		return x.CanId
+	}
+	return 0
+}
+
+func (x *CANopenMessage) GetCanIdHex() string {
+	if x != nil {
+		return x.CanIdHex
+	}
+	return ""
+}
+
+func (x *CANopenMessage) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *CANopenMessage) GetMessageType() string {
+	if x != nil {
+		return x.MessageType
+	}
+	return ""
+}
+
+func (x *CANopenMessage) GetNodeId() uint32 {
+	if x != nil {
+		return x.NodeId
+	}
+	return 0
+}
+
+func (x *CANopenMessage) GetParsedData() map[string]string {
+	if x != nil {
+		return x.ParsedData
 	}
 	return nil
 }
 
-type GetMessagesResponse struct {
+type GetCANopenMessagesResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Messages      []*CANMessage          `protobuf:"bytes,1,rep,name=messages,proto3" json:"messages,omitempty"`
+	Messages      []*CANopenMessage      `protobuf:"bytes,1,rep,name=messages,proto3" json:"messages,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetMessagesResponse) Reset() {
-	*x = GetMessagesResponse{}
+func (x *GetCANopenMessagesResponse) Reset() {
+	*x = GetCANopenMessagesResponse{}
 	mi := &file_internal_proto_can_can_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetMessagesResponse) String() string {
+func (x *GetCANopenMessagesResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetMessagesResponse) ProtoMessage() {}
+func (*GetCANopenMessagesResponse) ProtoMessage() {}
 
-func (x *GetMessagesResponse) ProtoReflect() protoreflect.Message {
+func (x *GetCANopenMessagesResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_internal_proto_can_can_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -261,40 +316,45 @@ func (x *GetMessagesResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetMessagesResponse.ProtoReflect.Descriptor instead.
-func (*GetMessagesResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use GetCANopenMessagesResponse.ProtoReflect.Descriptor instead.
+func (*GetCANopenMessagesResponse) Descriptor() ([]byte, []int) {
 	return file_internal_proto_can_can_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *GetMessagesResponse) GetMessages() []*CANMessage {
+func (x *GetCANopenMessagesResponse) GetMessages() []*CANopenMessage {
 	if x != nil {
 		return x.Messages
 	}
 	return nil
 }
 
-// GetMessageCount request/response
-type GetMessageCountRequest struct {
+// Store-and-forward replication from edge can-readers
+type ReplicatedFrame struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Filter        *QueryFilter           `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	Sequence      uint64                 `protobuf:"varint,1,opt,name=sequence,proto3" json:"sequence,omitempty"` // Increasing sequence of the frame in the edge store
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Interface     string                 `protobuf:"bytes,3,opt,name=interface,proto3" json:"interface,omitempty"`
+	CanId         uint32                 `protobuf:"varint,4,opt,name=can_id,json=canId,proto3" json:"can_id,omitempty"`
+	Data          []byte                 `protobuf:"bytes,5,opt,name=data,proto3" json:"data,omitempty"`
+	SessionId     string                 `protobuf:"bytes,6,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetMessageCountRequest) Reset() {
-	*x = GetMessageCountRequest{}
+func (x *ReplicatedFrame) Reset() {
+	*x = ReplicatedFrame{}
 	mi := &file_internal_proto_can_can_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetMessageCountRequest) String() string {
+func (x *ReplicatedFrame) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetMessageCountRequest) ProtoMessage() {}
+func (*ReplicatedFrame) ProtoMessage() {}
 
-func (x *GetMessageCountRequest) ProtoReflect() protoreflect.Message {
+func (x *ReplicatedFrame) ProtoReflect() protoreflect.Message {
 	mi := &file_internal_proto_can_can_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -306,39 +366,78 @@ func (x *GetMessageCountRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetMessageCountRequest.ProtoReflect.Descriptor instead.
-func (*GetMessageCountRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use ReplicatedFrame.ProtoReflect.Descriptor instead.
+func (*ReplicatedFrame) Descriptor() ([]byte, []int) {
 	return file_internal_proto_can_can_proto_rawDescGZIP(), []int{4}
 }
 
-func (x *GetMessageCountRequest) GetFilter() *QueryFilter {
+func (x *ReplicatedFrame) GetSequence() uint64 {
 	if x != nil {
-		return x.Filter
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *ReplicatedFrame) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *ReplicatedFrame) GetInterface() string {
+	if x != nil {
+		return x.Interface
+	}
+	return ""
+}
+
+func (x *ReplicatedFrame) GetCanId() uint32 {
+	if x != nil {
+		return x.CanId
+	}
+	return 0
+}
+
+func (x *ReplicatedFrame) GetData() []byte {
+	if x != nil {
+		return x.Data
 	}
 	return nil
 }
 
-type GetMessageCountResponse struct {
+func (x *ReplicatedFrame) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+type ReplicationBatch struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Count         uint64                 `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	DeviceId      string                 `protobuf:"bytes,1,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`
+	Site          string                 `protobuf:"bytes,2,opt,name=site,proto3" json:"site,omitempty"`
+	HwRevision    string                 `protobuf:"bytes,3,opt,name=hw_revision,json=hwRevision,proto3" json:"hw_revision,omitempty"`
+	StoreId       string                 `protobuf:"bytes,4,opt,name=store_id,json=storeId,proto3" json:"store_id,omitempty"` // Edge store the sequences belong to
+	Frames        []*ReplicatedFrame     `protobuf:"bytes,5,rep,name=frames,proto3" json:"frames,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetMessageCountResponse) Reset() {
-	*x = GetMessageCountResponse{}
+func (x *ReplicationBatch) Reset() {
+	*x = ReplicationBatch{}
 	mi := &file_internal_proto_can_can_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetMessageCountResponse) String() string {
+func (x *ReplicationBatch) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetMessageCountResponse) ProtoMessage() {}
+func (*ReplicationBatch) ProtoMessage() {}
 
-func (x *GetMessageCountResponse) ProtoReflect() protoreflect.Message {
+func (x *ReplicationBatch) ProtoReflect() protoreflect.Message {
 	mi := &file_internal_proto_can_can_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -350,40 +449,71 @@ func (x *GetMessageCountResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetMessageCountResponse.ProtoReflect.Descriptor instead.
-func (*GetMessageCountResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use ReplicationBatch.ProtoReflect.Descriptor instead.
+func (*ReplicationBatch) Descriptor() ([]byte, []int) {
 	return file_internal_proto_can_can_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *GetMessageCountResponse) GetCount() uint64 {
+func (x *ReplicationBatch) GetDeviceId() string {
 	if x != nil {
-		return x.Count
+		return x.DeviceId
 	}
-	return 0
+	return ""
+}
+
+func (x *ReplicationBatch) GetSite() string {
+	if x != nil {
+		return x.Site
+	}
+	return ""
+}
+
+func (x *ReplicationBatch) GetHwRevision() string {
+	if x != nil {
+		return x.HwRevision
+	}
+	return ""
+}
+
+func (x *ReplicationBatch) GetStoreId() string {
+	if x != nil {
+		return x.StoreId
+	}
+	return ""
+}
+
+func (x *ReplicationBatch) GetFrames() []*ReplicatedFrame {
+	if x != nil {
+		return x.Frames
+	}
+	return nil
 }
 
-// GetUniqueCANIDs request/response
-type GetUniqueCANIDsRequest struct {
+type ReplicationAck struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Filter        *QueryFilter           `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	AckedSequence uint64                 `protobuf:"varint,1,opt,name=acked_sequence,json=ackedSequence,proto3" json:"acked_sequence,omitempty"` // Highest sequence of the store written centrally
+	Frames        uint64                 `protobuf:"varint,2,opt,name=frames,proto3" json:"frames,omitempty"`                                    // Frames written by this stream
+	Duplicates    uint64                 `protobuf:"varint,3,opt,name=duplicates,proto3" json:"duplicates,omitempty"`                            // Frames skipped as already written
+	Rejected      uint64                 `protobuf:"varint,4,opt,name=rejected,proto3" json:"rejected,omitempty"`                                // Invalid frames, acknowledged but not written
+	Errors        []string               `protobuf:"bytes,5,rep,name=errors,proto3" json:"errors,omitempty"`                                     // Reasons of the first rejections
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetUniqueCANIDsRequest) Reset() {
-	*x = GetUniqueCANIDsRequest{}
+func (x *ReplicationAck) Reset() {
+	*x = ReplicationAck{}
 	mi := &file_internal_proto_can_can_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetUniqueCANIDsRequest) String() string {
+func (x *ReplicationAck) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetUniqueCANIDsRequest) ProtoMessage() {}
+func (*ReplicationAck) ProtoMessage() {}
 
-func (x *GetUniqueCANIDsRequest) ProtoReflect() protoreflect.Message {
+func (x *ReplicationAck) ProtoReflect() protoreflect.Message {
 	mi := &file_internal_proto_can_can_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -395,40 +525,70 @@ func (x *GetUniqueCANIDsRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetUniqueCANIDsRequest.ProtoReflect.Descriptor instead.
-func (*GetUniqueCANIDsRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use ReplicationAck.ProtoReflect.Descriptor instead.
+func (*ReplicationAck) Descriptor() ([]byte, []int) {
 	return file_internal_proto_can_can_proto_rawDescGZIP(), []int{6}
 }
 
-func (x *GetUniqueCANIDsRequest) GetFilter() *QueryFilter {
+func (x *ReplicationAck) GetAckedSequence() uint64 {
 	if x != nil {
-		return x.Filter
+		return x.AckedSequence
+	}
+	return 0
+}
+
+func (x *ReplicationAck) GetFrames() uint64 {
+	if x != nil {
+		return x.Frames
+	}
+	return 0
+}
+
+func (x *ReplicationAck) GetDuplicates() uint64 {
+	if x != nil {
+		return x.Duplicates
+	}
+	return 0
+}
+
+func (x *ReplicationAck) GetRejected() uint64 {
+	if x != nil {
+		return x.Rejected
+	}
+	return 0
+}
+
+func (x *ReplicationAck) GetErrors() []string {
+	if x != nil {
+		return x.Errors
 	}
 	return nil
 }
 
-type CANIDInfo struct {
+// Frame ingestion from remote producers
+type IngestFrame struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	CanId         uint32                 `protobuf:"varint,1,opt,name=can_id,json=canId,proto3" json:"can_id,omitempty"`
-	CanIdHex      string                 `protobuf:"bytes,2,opt,name=can_id_hex,json=canIdHex,proto3" json:"can_id_hex,omitempty"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=timestamp,proto3,oneof" json:"timestamp,omitempty"` // Receive time, default: server receive time
+	CanId         uint32                 `protobuf:"varint,2,opt,name=can_id,json=canId,proto3" json:"can_id,omitempty"` // SocketCAN CAN ID, including the EFF/RTR/ERR flags
+	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`                 // Up to 8 bytes
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CANIDInfo) Reset() {
-	*x = CANIDInfo{}
+func (x *IngestFrame) Reset() {
+	*x = IngestFrame{}
 	mi := &file_internal_proto_can_can_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CANIDInfo) String() string {
+func (x *IngestFrame) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CANIDInfo) ProtoMessage() {}
+func (*IngestFrame) ProtoMessage() {}
 
-func (x *CANIDInfo) ProtoReflect() protoreflect.Message {
+func (x *IngestFrame) ProtoReflect() protoreflect.Message {
 	mi := &file_internal_proto_can_can_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -440,46 +600,58 @@ func (x *CANIDInfo) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CANIDInfo.ProtoReflect.Descriptor instead.
-func (*CANIDInfo) Descriptor() ([]byte, []int) {
+// Deprecated: Use IngestFrame.ProtoReflect.Descriptor instead.
+func (*IngestFrame) Descriptor() ([]byte, []int) {
 	return file_internal_proto_can_can_proto_rawDescGZIP(), []int{7}
 }
 
-func (x *CANIDInfo) GetCanId() uint32 {
+func (x *IngestFrame) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *IngestFrame) GetCanId() uint32 {
 	if x != nil {
 		return x.CanId
 	}
 	return 0
 }
 
-func (x *CANIDInfo) GetCanIdHex() string {
+func (x *IngestFrame) GetData() []byte {
 	if x != nil {
-		return x.CanIdHex
+		return x.Data
 	}
-	return ""
+	return nil
 }
 
-type GetUniqueCANIDsResponse struct {
+type IngestBatch struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	CanIds        []*CANIDInfo           `protobuf:"bytes,1,rep,name=can_ids,json=canIds,proto3" json:"can_ids,omitempty"`
+	DeviceId      string                 `protobuf:"bytes,1,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`
+	Interface     string                 `protobuf:"bytes,2,opt,name=interface,proto3" json:"interface,omitempty"`
+	Site          string                 `protobuf:"bytes,3,opt,name=site,proto3" json:"site,omitempty"`
+	HwRevision    string                 `protobuf:"bytes,4,opt,name=hw_revision,json=hwRevision,proto3" json:"hw_revision,omitempty"`
+	SessionId     string                 `protobuf:"bytes,5,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"` // Optional recording session of the frames
+	Frames        []*IngestFrame         `protobuf:"bytes,6,rep,name=frames,proto3" json:"frames,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetUniqueCANIDsResponse) Reset() {
-	*x = GetUniqueCANIDsResponse{}
+func (x *IngestBatch) Reset() {
+	*x = IngestBatch{}
 	mi := &file_internal_proto_can_can_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetUniqueCANIDsResponse) String() string {
+func (x *IngestBatch) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetUniqueCANIDsResponse) ProtoMessage() {}
+func (*IngestBatch) ProtoMessage() {}
 
-func (x *GetUniqueCANIDsResponse) ProtoReflect() protoreflect.Message {
+func (x *IngestBatch) ProtoReflect() protoreflect.Message {
 	mi := &file_internal_proto_can_can_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -491,40 +663,77 @@ func (x *GetUniqueCANIDsResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetUniqueCANIDsResponse.ProtoReflect.Descriptor instead.
-func (*GetUniqueCANIDsResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use IngestBatch.ProtoReflect.Descriptor instead.
+func (*IngestBatch) Descriptor() ([]byte, []int) {
 	return file_internal_proto_can_can_proto_rawDescGZIP(), []int{8}
 }
 
-func (x *GetUniqueCANIDsResponse) GetCanIds() []*CANIDInfo {
+func (x *IngestBatch) GetDeviceId() string {
+	if x != nil {
+		return x.DeviceId
+	}
+	return ""
+}
+
+func (x *IngestBatch) GetInterface() string {
+	if x != nil {
+		return x.Interface
+	}
+	return ""
+}
+
+func (x *IngestBatch) GetSite() string {
+	if x != nil {
+		return x.Site
+	}
+	return ""
+}
+
+func (x *IngestBatch) GetHwRevision() string {
+	if x != nil {
+		return x.HwRevision
+	}
+	return ""
+}
+
+func (x *IngestBatch) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *IngestBatch) GetFrames() []*IngestFrame {
 	if x != nil {
-		return x.CanIds
+		return x.Frames
 	}
 	return nil
 }
 
-// GetStatsByCANID request/response
-type GetStatsByCANIDRequest struct {
+type IngestSummary struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Filter        *QueryFilter           `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	Batches       uint64                 `protobuf:"varint,1,opt,name=batches,proto3" json:"batches,omitempty"`
+	Accepted      uint64                 `protobuf:"varint,2,opt,name=accepted,proto3" json:"accepted,omitempty"` // Frames queued for writing
+	Rejected      uint64                 `protobuf:"varint,3,opt,name=rejected,proto3" json:"rejected,omitempty"` // Invalid frames, not written
+	Errors        []string               `protobuf:"bytes,4,rep,name=errors,proto3" json:"errors,omitempty"`      // Reasons of the first rejections
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetStatsByCANIDRequest) Reset() {
-	*x = GetStatsByCANIDRequest{}
+func (x *IngestSummary) Reset() {
+	*x = IngestSummary{}
 	mi := &file_internal_proto_can_can_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetStatsByCANIDRequest) String() string {
+func (x *IngestSummary) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetStatsByCANIDRequest) ProtoMessage() {}
+func (*IngestSummary) ProtoMessage() {}
 
-func (x *GetStatsByCANIDRequest) ProtoReflect() protoreflect.Message {
+func (x *IngestSummary) ProtoReflect() protoreflect.Message {
 	mi := &file_internal_proto_can_can_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -536,43 +745,65 @@ func (x *GetStatsByCANIDRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetStatsByCANIDRequest.ProtoReflect.Descriptor instead.
-func (*GetStatsByCANIDRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use IngestSummary.ProtoReflect.Descriptor instead.
+func (*IngestSummary) Descriptor() ([]byte, []int) {
 	return file_internal_proto_can_can_proto_rawDescGZIP(), []int{9}
 }
 
-func (x *GetStatsByCANIDRequest) GetFilter() *QueryFilter {
+func (x *IngestSummary) GetBatches() uint64 {
 	if x != nil {
-		return x.Filter
+		return x.Batches
+	}
+	return 0
+}
+
+func (x *IngestSummary) GetAccepted() uint64 {
+	if x != nil {
+		return x.Accepted
+	}
+	return 0
+}
+
+func (x *IngestSummary) GetRejected() uint64 {
+	if x != nil {
+		return x.Rejected
+	}
+	return 0
+}
+
+func (x *IngestSummary) GetErrors() []string {
+	if x != nil {
+		return x.Errors
 	}
 	return nil
 }
 
-type CANIDStats struct {
+// Frame transmission
+type SendFrameRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	CanId         uint32                 `protobuf:"varint,1,opt,name=can_id,json=canId,proto3" json:"can_id,omitempty"`
-	CanIdHex      string                 `protobuf:"bytes,2,opt,name=can_id_hex,json=canIdHex,proto3" json:"can_id_hex,omitempty"`
-	MessageCount  uint64                 `protobuf:"varint,3,opt,name=message_count,json=messageCount,proto3" json:"message_count,omitempty"`
-	FirstSeen     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=first_seen,json=firstSeen,proto3" json:"first_seen,omitempty"`
-	LastSeen      *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=last_seen,json=lastSeen,proto3" json:"last_seen,omitempty"`
+	Interface     string                 `protobuf:"bytes,1,opt,name=interface,proto3" json:"interface,omitempty"`                   // Must be listed in CAN_TX_INTERFACES
+	CanId         uint32                 `protobuf:"varint,2,opt,name=can_id,json=canId,proto3" json:"can_id,omitempty"`             // SocketCAN CAN ID, including the EFF/RTR flags
+	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`                             // Up to 8 bytes
+	User          string                 `protobuf:"bytes,4,opt,name=user,proto3" json:"user,omitempty"`                             // Ignored, the frame is recorded with the operator of the authorization metadata
+	TimeoutMs     uint32                 `protobuf:"varint,5,opt,name=timeout_ms,json=timeoutMs,proto3" json:"timeout_ms,omitempty"` // Wait for the confirmation, default 1000
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CANIDStats) Reset() {
-	*x = CANIDStats{}
+func (x *SendFrameRequest) Reset() {
+	*x = SendFrameRequest{}
 	mi := &file_internal_proto_can_can_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CANIDStats) String() string {
+func (x *SendFrameRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CANIDStats) ProtoMessage() {}
+func (*SendFrameRequest) ProtoMessage() {}
 
-func (x *CANIDStats) ProtoReflect() protoreflect.Message {
+func (x *SendFrameRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_internal_proto_can_can_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -584,67 +815,73 @@ func (x *CANIDStats) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CANIDStats.ProtoReflect.Descriptor instead.
-func (*CANIDStats) Descriptor() ([]byte, []int) {
+// Deprecated: Use SendFrameRequest.ProtoReflect.Descriptor instead.
+func (*SendFrameRequest) Descriptor() ([]byte, []int) {
 	return file_internal_proto_can_can_proto_rawDescGZIP(), []int{10}
 }
 
-func (x *CANIDStats) GetCanId() uint32 {
+func (x *SendFrameRequest) GetInterface() string {
 	if x != nil {
-		return x.CanId
+		return x.Interface
 	}
-	return 0
+	return ""
 }
 
-func (x *CANIDStats) GetCanIdHex() string {
+func (x *SendFrameRequest) GetCanId() uint32 {
 	if x != nil {
-		return x.CanIdHex
+		return x.CanId
 	}
-	return ""
+	return 0
 }
 
-func (x *CANIDStats) GetMessageCount() uint64 {
+func (x *SendFrameRequest) GetData() []byte {
 	if x != nil {
-		return x.MessageCount
+		return x.Data
 	}
-	return 0
+	return nil
 }
 
-func (x *CANIDStats) GetFirstSeen() *timestamppb.Timestamp {
+func (x *SendFrameRequest) GetUser() string {
 	if x != nil {
-		return x.FirstSeen
+		return x.User
 	}
-	return nil
+	return ""
 }
 
-func (x *CANIDStats) GetLastSeen() *timestamppb.Timestamp {
+func (x *SendFrameRequest) GetTimeoutMs() uint32 {
 	if x != nil {
-		return x.LastSeen
+		return x.TimeoutMs
 	}
-	return nil
+	return 0
 }
 
-type GetStatsByCANIDResponse struct {
+type SendFrameResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Stats         []*CANIDStats          `protobuf:"bytes,1,rep,name=stats,proto3" json:"stats,omitempty"`
+	Interface     string                 `protobuf:"bytes,1,opt,name=interface,proto3" json:"interface,omitempty"`
+	CanId         uint32                 `protobuf:"varint,2,opt,name=can_id,json=canId,proto3" json:"can_id,omitempty"`
+	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	Confirmation  string                 `protobuf:"bytes,4,opt,name=confirmation,proto3" json:"confirmation,omitempty"` // echo (sent on the bus) or loopback (queued to a driver without echo)
+	TxTimestamp   *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=tx_timestamp,json=txTimestamp,proto3" json:"tx_timestamp,omitempty"`
+	LatencyUs     int64                  `protobuf:"varint,6,opt,name=latency_us,json=latencyUs,proto3" json:"latency_us,omitempty"`
+	Logged        bool                   `protobuf:"varint,7,opt,name=logged,proto3" json:"logged,omitempty"` // Operator recorded in a frame_sent event, the frame is recorded by the CAN Reader
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetStatsByCANIDResponse) Reset() {
-	*x = GetStatsByCANIDResponse{}
+func (x *SendFrameResponse) Reset() {
+	*x = SendFrameResponse{}
 	mi := &file_internal_proto_can_can_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetStatsByCANIDResponse) String() string {
+func (x *SendFrameResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetStatsByCANIDResponse) ProtoMessage() {}
+func (*SendFrameResponse) ProtoMessage() {}
 
-func (x *GetStatsByCANIDResponse) ProtoReflect() protoreflect.Message {
+func (x *SendFrameResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_internal_proto_can_can_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -656,43 +893,88 @@ func (x *GetStatsByCANIDResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetStatsByCANIDResponse.ProtoReflect.Descriptor instead.
-func (*GetStatsByCANIDResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use SendFrameResponse.ProtoReflect.Descriptor instead.
+func (*SendFrameResponse) Descriptor() ([]byte, []int) {
 	return file_internal_proto_can_can_proto_rawDescGZIP(), []int{11}
 }
 
-func (x *GetStatsByCANIDResponse) GetStats() []*CANIDStats {
+func (x *SendFrameResponse) GetInterface() string {
 	if x != nil {
-		return x.Stats
+		return x.Interface
+	}
+	return ""
+}
+
+func (x *SendFrameResponse) GetCanId() uint32 {
+	if x != nil {
+		return x.CanId
+	}
+	return 0
+}
+
+func (x *SendFrameResponse) GetData() []byte {
+	if x != nil {
+		return x.Data
 	}
 	return nil
 }
 
-// GetCANopenMessages request/response
-type GetCANopenMessagesRequest struct {
+func (x *SendFrameResponse) GetConfirmation() string {
+	if x != nil {
+		return x.Confirmation
+	}
+	return ""
+}
+
+func (x *SendFrameResponse) GetTxTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.TxTimestamp
+	}
+	return nil
+}
+
+func (x *SendFrameResponse) GetLatencyUs() int64 {
+	if x != nil {
+		return x.LatencyUs
+	}
+	return 0
+}
+
+func (x *SendFrameResponse) GetLogged() bool {
+	if x != nil {
+		return x.Logged
+	}
+	return false
+}
+
+// Cyclic transmission
+type CreateCyclicJobRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Filter        *QueryFilter           `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
-	MessageType   string                 `protobuf:"bytes,2,opt,name=message_type,json=messageType,proto3" json:"message_type,omitempty"` // nmt, sync, emcy, pdo, sdo, heartbeat
-	NodeId        *uint32                `protobuf:"varint,3,opt,name=node_id,json=nodeId,proto3,oneof" json:"node_id,omitempty"`
-	PdoMappings   map[string]string      `protobuf:"bytes,4,rep,name=pdo_mappings,json=pdoMappings,proto3" json:"pdo_mappings,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // key: tpdo1/rpdo1, value: field mapping string
+	Interface     string                 `protobuf:"bytes,1,opt,name=interface,proto3" json:"interface,omitempty"`                 // Must be listed in CAN_TX_INTERFACES
+	CanId         uint32                 `protobuf:"varint,2,opt,name=can_id,json=canId,proto3" json:"can_id,omitempty"`           // SocketCAN CAN ID, including the EFF/RTR flags
+	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`                           // Up to 8 bytes
+	PeriodMs      float64                `protobuf:"fixed64,4,opt,name=period_ms,json=periodMs,proto3" json:"period_ms,omitempty"` // 0.1 to 3600000
+	Mode          string                 `protobuf:"bytes,5,opt,name=mode,proto3" json:"mode,omitempty"`                           // bcm (default, timer when CAN_BCM is unavailable) or timer
+	Paused        bool                   `protobuf:"varint,6,opt,name=paused,proto3" json:"paused,omitempty"`                      // Create without transmitting
+	User          string                 `protobuf:"bytes,7,opt,name=user,proto3" json:"user,omitempty"`                           // Ignored, the job is created for the operator of the authorization metadata
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetCANopenMessagesRequest) Reset() {
-	*x = GetCANopenMessagesRequest{}
+func (x *CreateCyclicJobRequest) Reset() {
+	*x = CreateCyclicJobRequest{}
 	mi := &file_internal_proto_can_can_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetCANopenMessagesRequest) String() string {
+func (x *CreateCyclicJobRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetCANopenMessagesRequest) ProtoMessage() {}
+func (*CreateCyclicJobRequest) ProtoMessage() {}
 
-func (x *GetCANopenMessagesRequest) ProtoReflect() protoreflect.Message {
+func (x *CreateCyclicJobRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_internal_proto_can_can_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -704,67 +986,84 @@ func (x *GetCANopenMessagesRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetCANopenMessagesRequest.ProtoReflect.Descriptor instead.
-func (*GetCANopenMessagesRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use CreateCyclicJobRequest.ProtoReflect.Descriptor instead.
+func (*CreateCyclicJobRequest) Descriptor() ([]byte, []int) {
 	return file_internal_proto_can_can_proto_rawDescGZIP(), []int{12}
 }
 
-func (x *GetCANopenMessagesRequest) GetFilter() *QueryFilter {
+func (x *CreateCyclicJobRequest) GetInterface() string {
 	if x != nil {
-		return x.Filter
+		return x.Interface
+	}
+	return ""
+}
+
+func (x *CreateCyclicJobRequest) GetCanId() uint32 {
+	if x != nil {
+		return x.CanId
+	}
+	return 0
+}
+
+func (x *CreateCyclicJobRequest) GetData() []byte {
+	if x != nil {
+		return x.Data
 	}
 	return nil
 }
 
-func (x *GetCANopenMessagesRequest) GetMessageType() string {
+func (x *CreateCyclicJobRequest) GetPeriodMs() float64 {
 	if x != nil {
-		return x.MessageType
+		return x.PeriodMs
+	}
+	return 0
+}
+
+func (x *CreateCyclicJobRequest) GetMode() string {
+	if x != nil {
+		return x.Mode
 	}
 	return ""
 }
 
-func (x *GetCANopenMessagesRequest) GetNodeId() uint32 {
-	if x != nil && x.NodeId != nil {
-		return *x.NodeId
+func (x *CreateCyclicJobRequest) GetPaused() bool {
+	if x != nil {
+		return x.Paused
 	}
-	return 0
+	return false
 }
 
-func (x *GetCANopenMessagesRequest) GetPdoMappings() map[string]string {
+func (x *CreateCyclicJobRequest) GetUser() string {
 	if x != nil {
-		return x.PdoMappings
+		return x.User
 	}
-	return nil
+	return ""
 }
 
-type CANopenMessage struct {
+type UpdateCyclicJobRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
-	Interface     string                 `protobuf:"bytes,2,opt,name=interface,proto3" json:"interface,omitempty"`
-	CanId         uint32                 `protobuf:"varint,3,opt,name=can_id,json=canId,proto3" json:"can_id,omitempty"`
-	CanIdHex      string                 `protobuf:"bytes,4,opt,name=can_id_hex,json=canIdHex,proto3" json:"can_id_hex,omitempty"`
-	Data          []byte                 `protobuf:"bytes,5,opt,name=data,proto3" json:"data,omitempty"`
-	MessageType   string                 `protobuf:"bytes,6,opt,name=message_type,json=messageType,proto3" json:"message_type,omitempty"`
-	NodeId        uint32                 `protobuf:"varint,7,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
-	ParsedData    map[string]string      `protobuf:"bytes,8,rep,name=parsed_data,json=parsedData,proto3" json:"parsed_data,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // parsed PDO data if available
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Data          []byte                 `protobuf:"bytes,2,opt,name=data,proto3,oneof" json:"data,omitempty"` // Applied from the next transmission without restarting the cycle
+	PeriodMs      *float64               `protobuf:"fixed64,3,opt,name=period_ms,json=periodMs,proto3,oneof" json:"period_ms,omitempty"`
+	Paused        *bool                  `protobuf:"varint,4,opt,name=paused,proto3,oneof" json:"paused,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CANopenMessage) Reset() {
-	*x = CANopenMessage{}
+func (x *UpdateCyclicJobRequest) Reset() {
+	*x = UpdateCyclicJobRequest{}
 	mi := &file_internal_proto_can_can_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CANopenMessage) String() string {
+func (x *UpdateCyclicJobRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CANopenMessage) ProtoMessage() {}
+func (*UpdateCyclicJobRequest) ProtoMessage() {}
 
-func (x *CANopenMessage) ProtoReflect() protoreflect.Message {
+func (x *UpdateCyclicJobRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_internal_proto_can_can_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -776,88 +1075,60 @@ func (x *CANopenMessage) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CANopenMessage.ProtoReflect.Descriptor instead.
-func (*CANopenMessage) Descriptor() ([]byte, []int) {
+// Deprecated: Use UpdateCyclicJobRequest.ProtoReflect.Descriptor instead.
+func (*UpdateCyclicJobRequest) Descriptor() ([]byte, []int) {
 	return file_internal_proto_can_can_proto_rawDescGZIP(), []int{13}
 }
 
-func (x *CANopenMessage) GetTimestamp() *timestamppb.Timestamp {
-	if x != nil {
-		return x.Timestamp
-	}
-	return nil
-}
-
-func (x *CANopenMessage) GetInterface() string {
-	if x != nil {
-		return x.Interface
-	}
-	return ""
-}
-
-func (x *CANopenMessage) GetCanId() uint32 {
-	if x != nil {
-		return x.CanId
-	}
-	return 0
-}
-
-func (x *CANopenMessage) GetCanIdHex() string {
+func (x *UpdateCyclicJobRequest) GetId() string {
 	if x != nil {
-		return x.CanIdHex
+		return x.Id
 	}
 	return ""
 }
 
-func (x *CANopenMessage) GetData() []byte {
+func (x *UpdateCyclicJobRequest) GetData() []byte {
 	if x != nil {
 		return x.Data
 	}
 	return nil
 }
 
-func (x *CANopenMessage) GetMessageType() string {
-	if x != nil {
-		return x.MessageType
-	}
-	return ""
-}
-
-func (x *CANopenMessage) GetNodeId() uint32 {
-	if x != nil {
-		return x.NodeId
+func (x *UpdateCyclicJobRequest) GetPeriodMs() float64 {
+	if x != nil && x.PeriodMs != nil {
+		return *x.PeriodMs
 	}
 	return 0
 }
 
-func (x *CANopenMessage) GetParsedData() map[string]string {
-	if x != nil {
-		return x.ParsedData
+func (x *UpdateCyclicJobRequest) GetPaused() bool {
+	if x != nil && x.Paused != nil {
+		return *x.Paused
 	}
-	return nil
+	return false
 }
 
-type GetCANopenMessagesResponse struct {
+type DeleteCyclicJobRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Messages      []*CANopenMessage      `protobuf:"bytes,1,rep,name=messages,proto3" json:"messages,omitempty"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetCANopenMessagesResponse) Reset() {
-	*x = GetCANopenMessagesResponse{}
+func (x *DeleteCyclicJobRequest) Reset() {
+	*x = DeleteCyclicJobRequest{}
 	mi := &file_internal_proto_can_can_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetCANopenMessagesResponse) String() string {
+func (x *DeleteCyclicJobRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetCANopenMessagesResponse) ProtoMessage() {}
+func (*DeleteCyclicJobRequest) ProtoMessage() {}
 
-func (x *GetCANopenMessagesResponse) ProtoReflect() protoreflect.Message {
+func (x *DeleteCyclicJobRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_internal_proto_can_can_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -869,40 +1140,38 @@ func (x *GetCANopenMessagesResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetCANopenMessagesResponse.ProtoReflect.Descriptor instead.
-func (*GetCANopenMessagesResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use DeleteCyclicJobRequest.ProtoReflect.Descriptor instead.
+func (*DeleteCyclicJobRequest) Descriptor() ([]byte, []int) {
 	return file_internal_proto_can_can_proto_rawDescGZIP(), []int{14}
 }
 
-func (x *GetCANopenMessagesResponse) GetMessages() []*CANopenMessage {
+func (x *DeleteCyclicJobRequest) GetId() string {
 	if x != nil {
-		return x.Messages
+		return x.Id
 	}
-	return nil
+	return ""
 }
 
-// GetCANopenStats request/response
-type GetCANopenStatsRequest struct {
+type ListCyclicJobsRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Filter        *QueryFilter           `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetCANopenStatsRequest) Reset() {
-	*x = GetCANopenStatsRequest{}
+func (x *ListCyclicJobsRequest) Reset() {
+	*x = ListCyclicJobsRequest{}
 	mi := &file_internal_proto_can_can_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetCANopenStatsRequest) String() string {
+func (x *ListCyclicJobsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetCANopenStatsRequest) ProtoMessage() {}
+func (*ListCyclicJobsRequest) ProtoMessage() {}
 
-func (x *GetCANopenStatsRequest) ProtoReflect() protoreflect.Message {
+func (x *ListCyclicJobsRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_internal_proto_can_can_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -914,42 +1183,32 @@ func (x *GetCANopenStatsRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetCANopenStatsRequest.ProtoReflect.Descriptor instead.
-func (*GetCANopenStatsRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use ListCyclicJobsRequest.ProtoReflect.Descriptor instead.
+func (*ListCyclicJobsRequest) Descriptor() ([]byte, []int) {
 	return file_internal_proto_can_can_proto_rawDescGZIP(), []int{15}
 }
 
-func (x *GetCANopenStatsRequest) GetFilter() *QueryFilter {
-	if x != nil {
-		return x.Filter
-	}
-	return nil
-}
-
-type CANopenMessageTypeStats struct {
+type ListCyclicJobsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	MessageType   string                 `protobuf:"bytes,1,opt,name=message_type,json=messageType,proto3" json:"message_type,omitempty"`
-	MessageCount  uint64                 `protobuf:"varint,2,opt,name=message_count,json=messageCount,proto3" json:"message_count,omitempty"`
-	FirstSeen     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=first_seen,json=firstSeen,proto3" json:"first_seen,omitempty"`
-	LastSeen      *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=last_seen,json=lastSeen,proto3" json:"last_seen,omitempty"`
+	Jobs          []*CyclicJob           `protobuf:"bytes,1,rep,name=jobs,proto3" json:"jobs,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CANopenMessageTypeStats) Reset() {
-	*x = CANopenMessageTypeStats{}
+func (x *ListCyclicJobsResponse) Reset() {
+	*x = ListCyclicJobsResponse{}
 	mi := &file_internal_proto_can_can_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CANopenMessageTypeStats) String() string {
+func (x *ListCyclicJobsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CANopenMessageTypeStats) ProtoMessage() {}
+func (*ListCyclicJobsResponse) ProtoMessage() {}
 
-func (x *CANopenMessageTypeStats) ProtoReflect() protoreflect.Message {
+func (x *ListCyclicJobsResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_internal_proto_can_can_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -961,88 +1220,171 @@ func (x *CANopenMessageTypeStats) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CANopenMessageTypeStats.ProtoReflect.Descriptor instead.
-func (*CANopenMessageTypeStats) Descriptor() ([]byte, []int) {
+// Deprecated: Use ListCyclicJobsResponse.ProtoReflect.Descriptor instead.
+func (*ListCyclicJobsResponse) Descriptor() ([]byte, []int) {
 	return file_internal_proto_can_can_proto_rawDescGZIP(), []int{16}
 }
 
-func (x *CANopenMessageTypeStats) GetMessageType() string {
+func (x *ListCyclicJobsResponse) GetJobs() []*CyclicJob {
 	if x != nil {
-		return x.MessageType
+		return x.Jobs
+	}
+	return nil
+}
+
+type CyclicJob struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Interface      string                 `protobuf:"bytes,2,opt,name=interface,proto3" json:"interface,omitempty"`
+	CanId          uint32                 `protobuf:"varint,3,opt,name=can_id,json=canId,proto3" json:"can_id,omitempty"`
+	Data           []byte                 `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
+	PeriodMs       float64                `protobuf:"fixed64,5,opt,name=period_ms,json=periodMs,proto3" json:"period_ms,omitempty"`
+	Mode           string                 `protobuf:"bytes,6,opt,name=mode,proto3" json:"mode,omitempty"`   // bcm or timer
+	State          string                 `protobuf:"bytes,7,opt,name=state,proto3" json:"state,omitempty"` // running or paused
+	User           string                 `protobuf:"bytes,8,opt,name=user,proto3" json:"user,omitempty"`
+	CreatedAt      *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	Frames         uint64                 `protobuf:"varint,10,opt,name=frames,proto3" json:"frames,omitempty"` // Transmitted frames
+	LastTx         *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=last_tx,json=lastTx,proto3,oneof" json:"last_tx,omitempty"`
+	MeanPeriodMs   float64                `protobuf:"fixed64,12,opt,name=mean_period_ms,json=meanPeriodMs,proto3" json:"mean_period_ms,omitempty"`
+	JitterMs       float64                `protobuf:"fixed64,13,opt,name=jitter_ms,json=jitterMs,proto3" json:"jitter_ms,omitempty"`                     // Standard deviation of the period
+	MaxDeviationMs float64                `protobuf:"fixed64,14,opt,name=max_deviation_ms,json=maxDeviationMs,proto3" json:"max_deviation_ms,omitempty"` // Largest deviation from period_ms
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CyclicJob) Reset() {
+	*x = CyclicJob{}
+	mi := &file_internal_proto_can_can_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CyclicJob) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CyclicJob) ProtoMessage() {}
+
+func (x *CyclicJob) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_can_can_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CyclicJob.ProtoReflect.Descriptor instead.
+func (*CyclicJob) Descriptor() ([]byte, []int) {
+	return file_internal_proto_can_can_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *CyclicJob) GetId() string {
+	if x != nil {
+		return x.Id
 	}
 	return ""
 }
 
-func (x *CANopenMessageTypeStats) GetMessageCount() uint64 {
+func (x *CyclicJob) GetInterface() string {
 	if x != nil {
-		return x.MessageCount
+		return x.Interface
 	}
-	return 0
+	return ""
 }
 
-func (x *CANopenMessageTypeStats) GetFirstSeen() *timestamppb.Timestamp {
+func (x *CyclicJob) GetCanId() uint32 {
 	if x != nil {
-		return x.FirstSeen
+		return x.CanId
 	}
-	return nil
+	return 0
 }
 
-func (x *CANopenMessageTypeStats) GetLastSeen() *timestamppb.Timestamp {
+func (x *CyclicJob) GetData() []byte {
 	if x != nil {
-		return x.LastSeen
+		return x.Data
 	}
 	return nil
 }
 
-type GetCANopenStatsResponse struct {
-	state         protoimpl.MessageState     `protogen:"open.v1"`
-	Stats         []*CANopenMessageTypeStats `protobuf:"bytes,1,rep,name=stats,proto3" json:"stats,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *CyclicJob) GetPeriodMs() float64 {
+	if x != nil {
+		return x.PeriodMs
+	}
+	return 0
 }
 
-func (x *GetCANopenStatsResponse) Reset() {
-	*x = GetCANopenStatsResponse{}
-	mi := &file_internal_proto_can_can_proto_msgTypes[17]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *CyclicJob) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
 }
 
-func (x *GetCANopenStatsResponse) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *CyclicJob) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
 }
 
-func (*GetCANopenStatsResponse) ProtoMessage() {}
+func (x *CyclicJob) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
 
-func (x *GetCANopenStatsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_proto_can_can_proto_msgTypes[17]
+func (x *CyclicJob) GetCreatedAt() *timestamppb.Timestamp {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.CreatedAt
 	}
-	return mi.MessageOf(x)
+	return nil
 }
 
-// Deprecated: Use GetCANopenStatsResponse.ProtoReflect.Descriptor instead.
-func (*GetCANopenStatsResponse) Descriptor() ([]byte, []int) {
-	return file_internal_proto_can_can_proto_rawDescGZIP(), []int{17}
+func (x *CyclicJob) GetFrames() uint64 {
+	if x != nil {
+		return x.Frames
+	}
+	return 0
 }
 
-func (x *GetCANopenStatsResponse) GetStats() []*CANopenMessageTypeStats {
+func (x *CyclicJob) GetLastTx() *timestamppb.Timestamp {
 	if x != nil {
-		return x.Stats
+		return x.LastTx
 	}
 	return nil
 }
 
+func (x *CyclicJob) GetMeanPeriodMs() float64 {
+	if x != nil {
+		return x.MeanPeriodMs
+	}
+	return 0
+}
+
+func (x *CyclicJob) GetJitterMs() float64 {
+	if x != nil {
+		return x.JitterMs
+	}
+	return 0
+}
+
+func (x *CyclicJob) GetMaxDeviationMs() float64 {
+	if x != nil {
+		return x.MaxDeviationMs
+	}
+	return 0
+}
+
 var File_internal_proto_can_can_proto protoreflect.FileDescriptor
 
 const file_internal_proto_can_can_proto_rawDesc = "" +
 	"\n" +
-	"\x1cinternal/proto/can/can.proto\x12\x05proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\x98\x02\n" +
+	"\x1cinternal/proto/can/can.proto\x12\x05proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xb5\x02\n" +
 	"\vQueryFilter\x12>\n" +
 	"\n" +
 	"start_time\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampH\x00R\tstartTime\x88\x01\x01\x12:\n" +
@@ -1050,47 +1392,11 @@ const file_internal_proto_can_can_proto_rawDesc = "" +
 	"\x06can_id\x18\x03 \x01(\rH\x02R\x05canId\x88\x01\x01\x12\x1c\n" +
 	"\tinterface\x18\x04 \x01(\tR\tinterface\x12\x14\n" +
 	"\x05limit\x18\x05 \x01(\x05R\x05limit\x12\x16\n" +
-	"\x06offset\x18\x06 \x01(\x05R\x06offsetB\r\n" +
+	"\x06offset\x18\x06 \x01(\x05R\x06offset\x12\x1b\n" +
+	"\tdevice_id\x18\a \x01(\tR\bdeviceIdB\r\n" +
 	"\v_start_timeB\v\n" +
 	"\t_end_timeB\t\n" +
-	"\a_can_id\"\xad\x01\n" +
-	"\n" +
-	"CANMessage\x128\n" +
-	"\ttimestamp\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x1c\n" +
-	"\tinterface\x18\x02 \x01(\tR\tinterface\x12\x15\n" +
-	"\x06can_id\x18\x03 \x01(\rR\x05canId\x12\x1c\n" +
-	"\n" +
-	"can_id_hex\x18\x04 \x01(\tR\bcanIdHex\x12\x12\n" +
-	"\x04data\x18\x05 \x01(\fR\x04data\"@\n" +
-	"\x12GetMessagesRequest\x12*\n" +
-	"\x06filter\x18\x01 \x01(\v2\x12.proto.QueryFilterR\x06filter\"D\n" +
-	"\x13GetMessagesResponse\x12-\n" +
-	"\bmessages\x18\x01 \x03(\v2\x11.proto.CANMessageR\bmessages\"D\n" +
-	"\x16GetMessageCountRequest\x12*\n" +
-	"\x06filter\x18\x01 \x01(\v2\x12.proto.QueryFilterR\x06filter\"/\n" +
-	"\x17GetMessageCountResponse\x12\x14\n" +
-	"\x05count\x18\x01 \x01(\x04R\x05count\"D\n" +
-	"\x16GetUniqueCANIDsRequest\x12*\n" +
-	"\x06filter\x18\x01 \x01(\v2\x12.proto.QueryFilterR\x06filter\"@\n" +
-	"\tCANIDInfo\x12\x15\n" +
-	"\x06can_id\x18\x01 \x01(\rR\x05canId\x12\x1c\n" +
-	"\n" +
-	"can_id_hex\x18\x02 \x01(\tR\bcanIdHex\"D\n" +
-	"\x17GetUniqueCANIDsResponse\x12)\n" +
-	"\acan_ids\x18\x01 \x03(\v2\x10.proto.CANIDInfoR\x06canIds\"D\n" +
-	"\x16GetStatsByCANIDRequest\x12*\n" +
-	"\x06filter\x18\x01 \x01(\v2\x12.proto.QueryFilterR\x06filter\"\xda\x01\n" +
-	"\n" +
-	"CANIDStats\x12\x15\n" +
-	"\x06can_id\x18\x01 \x01(\rR\x05canId\x12\x1c\n" +
-	"\n" +
-	"can_id_hex\x18\x02 \x01(\tR\bcanIdHex\x12#\n" +
-	"\rmessage_count\x18\x03 \x01(\x04R\fmessageCount\x129\n" +
-	"\n" +
-	"first_seen\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tfirstSeen\x127\n" +
-	"\tlast_seen\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\blastSeen\"B\n" +
-	"\x17GetStatsByCANIDResponse\x12'\n" +
-	"\x05stats\x18\x01 \x03(\v2\x11.proto.CANIDStatsR\x05stats\"\xaa\x02\n" +
+	"\a_can_id\"\xaa\x02\n" +
 	"\x19GetCANopenMessagesRequest\x12*\n" +
 	"\x06filter\x18\x01 \x01(\v2\x12.proto.QueryFilterR\x06filter\x12!\n" +
 	"\fmessage_type\x18\x02 \x01(\tR\vmessageType\x12\x1c\n" +
@@ -1116,25 +1422,117 @@ const file_internal_proto_can_can_proto_rawDesc = "" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
 	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"O\n" +
 	"\x1aGetCANopenMessagesResponse\x121\n" +
-	"\bmessages\x18\x01 \x03(\v2\x15.proto.CANopenMessageR\bmessages\"D\n" +
-	"\x16GetCANopenStatsRequest\x12*\n" +
-	"\x06filter\x18\x01 \x01(\v2\x12.proto.QueryFilterR\x06filter\"\xd5\x01\n" +
-	"\x17CANopenMessageTypeStats\x12!\n" +
-	"\fmessage_type\x18\x01 \x01(\tR\vmessageType\x12#\n" +
-	"\rmessage_count\x18\x02 \x01(\x04R\fmessageCount\x129\n" +
+	"\bmessages\x18\x01 \x03(\v2\x15.proto.CANopenMessageR\bmessages\"\xcf\x01\n" +
+	"\x0fReplicatedFrame\x12\x1a\n" +
+	"\bsequence\x18\x01 \x01(\x04R\bsequence\x128\n" +
+	"\ttimestamp\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x1c\n" +
+	"\tinterface\x18\x03 \x01(\tR\tinterface\x12\x15\n" +
+	"\x06can_id\x18\x04 \x01(\rR\x05canId\x12\x12\n" +
+	"\x04data\x18\x05 \x01(\fR\x04data\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x06 \x01(\tR\tsessionId\"\xaf\x01\n" +
+	"\x10ReplicationBatch\x12\x1b\n" +
+	"\tdevice_id\x18\x01 \x01(\tR\bdeviceId\x12\x12\n" +
+	"\x04site\x18\x02 \x01(\tR\x04site\x12\x1f\n" +
+	"\vhw_revision\x18\x03 \x01(\tR\n" +
+	"hwRevision\x12\x19\n" +
+	"\bstore_id\x18\x04 \x01(\tR\astoreId\x12.\n" +
+	"\x06frames\x18\x05 \x03(\v2\x16.proto.ReplicatedFrameR\x06frames\"\xa3\x01\n" +
+	"\x0eReplicationAck\x12%\n" +
+	"\x0eacked_sequence\x18\x01 \x01(\x04R\rackedSequence\x12\x16\n" +
+	"\x06frames\x18\x02 \x01(\x04R\x06frames\x12\x1e\n" +
+	"\n" +
+	"duplicates\x18\x03 \x01(\x04R\n" +
+	"duplicates\x12\x1a\n" +
+	"\brejected\x18\x04 \x01(\x04R\brejected\x12\x16\n" +
+	"\x06errors\x18\x05 \x03(\tR\x06errors\"\x85\x01\n" +
+	"\vIngestFrame\x12=\n" +
+	"\ttimestamp\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampH\x00R\ttimestamp\x88\x01\x01\x12\x15\n" +
+	"\x06can_id\x18\x02 \x01(\rR\x05canId\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04dataB\f\n" +
+	"\n" +
+	"_timestamp\"\xc8\x01\n" +
+	"\vIngestBatch\x12\x1b\n" +
+	"\tdevice_id\x18\x01 \x01(\tR\bdeviceId\x12\x1c\n" +
+	"\tinterface\x18\x02 \x01(\tR\tinterface\x12\x12\n" +
+	"\x04site\x18\x03 \x01(\tR\x04site\x12\x1f\n" +
+	"\vhw_revision\x18\x04 \x01(\tR\n" +
+	"hwRevision\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x05 \x01(\tR\tsessionId\x12*\n" +
+	"\x06frames\x18\x06 \x03(\v2\x12.proto.IngestFrameR\x06frames\"y\n" +
+	"\rIngestSummary\x12\x18\n" +
+	"\abatches\x18\x01 \x01(\x04R\abatches\x12\x1a\n" +
+	"\baccepted\x18\x02 \x01(\x04R\baccepted\x12\x1a\n" +
+	"\brejected\x18\x03 \x01(\x04R\brejected\x12\x16\n" +
+	"\x06errors\x18\x04 \x03(\tR\x06errors\"\x8e\x01\n" +
+	"\x10SendFrameRequest\x12\x1c\n" +
+	"\tinterface\x18\x01 \x01(\tR\tinterface\x12\x15\n" +
+	"\x06can_id\x18\x02 \x01(\rR\x05canId\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\x12\x12\n" +
+	"\x04user\x18\x04 \x01(\tR\x04user\x12\x1d\n" +
+	"\n" +
+	"timeout_ms\x18\x05 \x01(\rR\ttimeoutMs\"\xf6\x01\n" +
+	"\x11SendFrameResponse\x12\x1c\n" +
+	"\tinterface\x18\x01 \x01(\tR\tinterface\x12\x15\n" +
+	"\x06can_id\x18\x02 \x01(\rR\x05canId\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\x12\"\n" +
+	"\fconfirmation\x18\x04 \x01(\tR\fconfirmation\x12=\n" +
+	"\ftx_timestamp\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\vtxTimestamp\x12\x1d\n" +
+	"\n" +
+	"latency_us\x18\x06 \x01(\x03R\tlatencyUs\x12\x16\n" +
+	"\x06logged\x18\a \x01(\bR\x06logged\"\xbe\x01\n" +
+	"\x16CreateCyclicJobRequest\x12\x1c\n" +
+	"\tinterface\x18\x01 \x01(\tR\tinterface\x12\x15\n" +
+	"\x06can_id\x18\x02 \x01(\rR\x05canId\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\x12\x1b\n" +
+	"\tperiod_ms\x18\x04 \x01(\x01R\bperiodMs\x12\x12\n" +
+	"\x04mode\x18\x05 \x01(\tR\x04mode\x12\x16\n" +
+	"\x06paused\x18\x06 \x01(\bR\x06paused\x12\x12\n" +
+	"\x04user\x18\a \x01(\tR\x04user\"\xa2\x01\n" +
+	"\x16UpdateCyclicJobRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\x04data\x18\x02 \x01(\fH\x00R\x04data\x88\x01\x01\x12 \n" +
+	"\tperiod_ms\x18\x03 \x01(\x01H\x01R\bperiodMs\x88\x01\x01\x12\x1b\n" +
+	"\x06paused\x18\x04 \x01(\bH\x02R\x06paused\x88\x01\x01B\a\n" +
+	"\x05_dataB\f\n" +
+	"\n" +
+	"_period_msB\t\n" +
+	"\a_paused\"(\n" +
+	"\x16DeleteCyclicJobRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x17\n" +
+	"\x15ListCyclicJobsRequest\">\n" +
+	"\x16ListCyclicJobsResponse\x12$\n" +
+	"\x04jobs\x18\x01 \x03(\v2\x10.proto.CyclicJobR\x04jobs\"\xc5\x03\n" +
+	"\tCyclicJob\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1c\n" +
+	"\tinterface\x18\x02 \x01(\tR\tinterface\x12\x15\n" +
+	"\x06can_id\x18\x03 \x01(\rR\x05canId\x12\x12\n" +
+	"\x04data\x18\x04 \x01(\fR\x04data\x12\x1b\n" +
+	"\tperiod_ms\x18\x05 \x01(\x01R\bperiodMs\x12\x12\n" +
+	"\x04mode\x18\x06 \x01(\tR\x04mode\x12\x14\n" +
+	"\x05state\x18\a \x01(\tR\x05state\x12\x12\n" +
+	"\x04user\x18\b \x01(\tR\x04user\x129\n" +
+	"\n" +
+	"created_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12\x16\n" +
+	"\x06frames\x18\n" +
+	" \x01(\x04R\x06frames\x128\n" +
+	"\alast_tx\x18\v \x01(\v2\x1a.google.protobuf.TimestampH\x00R\x06lastTx\x88\x01\x01\x12$\n" +
+	"\x0emean_period_ms\x18\f \x01(\x01R\fmeanPeriodMs\x12\x1b\n" +
+	"\tjitter_ms\x18\r \x01(\x01R\bjitterMs\x12(\n" +
+	"\x10max_deviation_ms\x18\x0e \x01(\x01R\x0emaxDeviationMsB\n" +
 	"\n" +
-	"first_seen\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tfirstSeen\x127\n" +
-	"\tlast_seen\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\blastSeen\"O\n" +
-	"\x17GetCANopenStatsResponse\x124\n" +
-	"\x05stats\x18\x01 \x03(\v2\x1e.proto.CANopenMessageTypeStatsR\x05stats2\xf5\x03\n" +
+	"\b_last_tx2\xc3\x04\n" +
 	"\n" +
-	"canService\x12D\n" +
-	"\vGetMessages\x12\x19.proto.GetMessagesRequest\x1a\x1a.proto.GetMessagesResponse\x12P\n" +
-	"\x0fGetMessageCount\x12\x1d.proto.GetMessageCountRequest\x1a\x1e.proto.GetMessageCountResponse\x12P\n" +
-	"\x0fGetUniqueCANIDs\x12\x1d.proto.GetUniqueCANIDsRequest\x1a\x1e.proto.GetUniqueCANIDsResponse\x12P\n" +
-	"\x0fGetStatsByCANID\x12\x1d.proto.GetStatsByCANIDRequest\x1a\x1e.proto.GetStatsByCANIDResponse\x12Y\n" +
-	"\x12GetCANopenMessages\x12 .proto.GetCANopenMessagesRequest\x1a!.proto.GetCANopenMessagesResponse\x12P\n" +
-	"\x0fGetCANopenStats\x12\x1d.proto.GetCANopenStatsRequest\x1a\x1e.proto.GetCANopenStatsResponseB\"Z can-db-writer/internal/proto/canb\x06proto3"
+	"canService\x12Y\n" +
+	"\x12GetCANopenMessages\x12 .proto.GetCANopenMessagesRequest\x1a!.proto.GetCANopenMessagesResponse\x12C\n" +
+	"\x0fReplicateFrames\x12\x17.proto.ReplicationBatch\x1a\x15.proto.ReplicationAck(\x01\x12:\n" +
+	"\fIngestFrames\x12\x12.proto.IngestBatch\x1a\x14.proto.IngestSummary(\x01\x12>\n" +
+	"\tSendFrame\x12\x17.proto.SendFrameRequest\x1a\x18.proto.SendFrameResponse\x12B\n" +
+	"\x0fCreateCyclicJob\x12\x1d.proto.CreateCyclicJobRequest\x1a\x10.proto.CyclicJob\x12B\n" +
+	"\x0fUpdateCyclicJob\x12\x1d.proto.UpdateCyclicJobRequest\x1a\x10.proto.CyclicJob\x12B\n" +
+	"\x0fDeleteCyclicJob\x12\x1d.proto.DeleteCyclicJobRequest\x1a\x10.proto.CyclicJob\x12M\n" +
+	"\x0eListCyclicJobs\x12\x1c.proto.ListCyclicJobsRequest\x1a\x1d.proto.ListCyclicJobsResponseB\"Z can-db-writer/internal/proto/canb\x06proto3"
 
 var (
 	file_internal_proto_can_can_proto_rawDescOnce sync.Once
@@ -1151,23 +1549,23 @@ func file_internal_proto_can_can_proto_rawDescGZIP() []byte {
 var file_internal_proto_can_can_proto_msgTypes = make([]protoimpl.MessageInfo, 20)
 var file_internal_proto_can_can_proto_goTypes = []any{
 	(*QueryFilter)(nil),                // 0: proto.QueryFilter
-	(*CANMessage)(nil),                 // 1: proto.CANMessage
-	(*GetMessagesRequest)(nil),         // 2: proto.GetMessagesRequest
-	(*GetMessagesResponse)(nil),        // 3: proto.GetMessagesResponse
-	(*GetMessageCountRequest)(nil),     // 4: proto.GetMessageCountRequest
-	(*GetMessageCountResponse)(nil),    // 5: proto.GetMessageCountResponse
-	(*GetUniqueCANIDsRequest)(nil),     // 6: proto.GetUniqueCANIDsRequest
-	(*CANIDInfo)(nil),                  // 7: proto.CANIDInfo
-	(*GetUniqueCANIDsResponse)(nil),    // 8: proto.GetUniqueCANIDsResponse
-	(*GetStatsByCANIDRequest)(nil),     // 9: proto.GetStatsByCANIDRequest
-	(*CANIDStats)(nil),                 // 10: proto.CANIDStats
-	(*GetStatsByCANIDResponse)(nil),    // 11: proto.GetStatsByCANIDResponse
-	(*GetCANopenMessagesRequest)(nil),  // 12: proto.GetCANopenMessagesRequest
-	(*CANopenMessage)(nil),             // 13: proto.CANopenMessage
-	(*GetCANopenMessagesResponse)(nil), // 14: proto.GetCANopenMessagesResponse
-	(*GetCANopenStatsRequest)(nil),     // 15: proto.GetCANopenStatsRequest
-	(*CANopenMessageTypeStats)(nil),    // 16: proto.CANopenMessageTypeStats
-	(*GetCANopenStatsResponse)(nil),    // 17: proto.GetCANopenStatsResponse
+	(*GetCANopenMessagesRequest)(nil),  // 1: proto.GetCANopenMessagesRequest
+	(*CANopenMessage)(nil),             // 2: proto.CANopenMessage
+	(*GetCANopenMessagesResponse)(nil), // 3: proto.GetCANopenMessagesResponse
+	(*ReplicatedFrame)(nil),            // 4: proto.ReplicatedFrame
+	(*ReplicationBatch)(nil),           // 5: proto.ReplicationBatch
+	(*ReplicationAck)(nil),             // 6: proto.ReplicationAck
+	(*IngestFrame)(nil),                // 7: proto.IngestFrame
+	(*IngestBatch)(nil),                // 8: proto.IngestBatch
+	(*IngestSummary)(nil),              // 9: proto.IngestSummary
+	(*SendFrameRequest)(nil),           // 10: proto.SendFrameRequest
+	(*SendFrameResponse)(nil),          // 11: proto.SendFrameResponse
+	(*CreateCyclicJobRequest)(nil),     // 12: proto.CreateCyclicJobRequest
+	(*UpdateCyclicJobRequest)(nil),     // 13: proto.UpdateCyclicJobRequest
+	(*DeleteCyclicJobRequest)(nil),     // 14: proto.DeleteCyclicJobRequest
+	(*ListCyclicJobsRequest)(nil),      // 15: proto.ListCyclicJobsRequest
+	(*ListCyclicJobsResponse)(nil),     // 16: proto.ListCyclicJobsResponse
+	(*CyclicJob)(nil),                  // 17: proto.CyclicJob
 	nil,                                // 18: proto.GetCANopenMessagesRequest.PdoMappingsEntry
 	nil,                                // 19: proto.CANopenMessage.ParsedDataEntry
 	(*timestamppb.Timestamp)(nil),      // 20: google.protobuf.Timestamp
@@ -1175,42 +1573,40 @@ var file_internal_proto_can_can_proto_goTypes = []any{
 var file_internal_proto_can_can_proto_depIdxs = []int32{
 	20, // 0: proto.QueryFilter.start_time:type_name -> google.protobuf.Timestamp
 	20, // 1: proto.QueryFilter.end_time:type_name -> google.protobuf.Timestamp
-	20, // 2: proto.CANMessage.timestamp:type_name -> google.protobuf.Timestamp
-	0,  // 3: proto.GetMessagesRequest.filter:type_name -> proto.QueryFilter
-	1,  // 4: proto.GetMessagesResponse.messages:type_name -> proto.CANMessage
-	0,  // 5: proto.GetMessageCountRequest.filter:type_name -> proto.QueryFilter
-	0,  // 6: proto.GetUniqueCANIDsRequest.filter:type_name -> proto.QueryFilter
-	7,  // 7: proto.GetUniqueCANIDsResponse.can_ids:type_name -> proto.CANIDInfo
-	0,  // 8: proto.GetStatsByCANIDRequest.filter:type_name -> proto.QueryFilter
-	20, // 9: proto.CANIDStats.first_seen:type_name -> google.protobuf.Timestamp
-	20, // 10: proto.CANIDStats.last_seen:type_name -> google.protobuf.Timestamp
-	10, // 11: proto.GetStatsByCANIDResponse.stats:type_name -> proto.CANIDStats
-	0,  // 12: proto.GetCANopenMessagesRequest.filter:type_name -> proto.QueryFilter
-	18, // 13: proto.GetCANopenMessagesRequest.pdo_mappings:type_name -> proto.GetCANopenMessagesRequest.PdoMappingsEntry
-	20, // 14: proto.CANopenMessage.timestamp:type_name -> google.protobuf.Timestamp
-	19, // 15: proto.CANopenMessage.parsed_data:type_name -> proto.CANopenMessage.ParsedDataEntry
-	13, // 16: proto.GetCANopenMessagesResponse.messages:type_name -> proto.CANopenMessage
-	0,  // 17: proto.GetCANopenStatsRequest.filter:type_name -> proto.QueryFilter
-	20, // 18: proto.CANopenMessageTypeStats.first_seen:type_name -> google.protobuf.Timestamp
-	20, // 19: proto.CANopenMessageTypeStats.last_seen:type_name -> google.protobuf.Timestamp
-	16, // 20: proto.GetCANopenStatsResponse.stats:type_name -> proto.CANopenMessageTypeStats
-	2,  // 21: proto.canService.GetMessages:input_type -> proto.GetMessagesRequest
-	4,  // 22: proto.canService.GetMessageCount:input_type -> proto.GetMessageCountRequest
-	6,  // 23: proto.canService.GetUniqueCANIDs:input_type -> proto.GetUniqueCANIDsRequest
-	9,  // 24: proto.canService.GetStatsByCANID:input_type -> proto.GetStatsByCANIDRequest
-	12, // 25: proto.canService.GetCANopenMessages:input_type -> proto.GetCANopenMessagesRequest
-	15, // 26: proto.canService.GetCANopenStats:input_type -> proto.GetCANopenStatsRequest
-	3,  // 27: proto.canService.GetMessages:output_type -> proto.GetMessagesResponse
-	5,  // 28: proto.canService.GetMessageCount:output_type -> proto.GetMessageCountResponse
-	8,  // 29: proto.canService.GetUniqueCANIDs:output_type -> proto.GetUniqueCANIDsResponse
-	11, // 30: proto.canService.GetStatsByCANID:output_type -> proto.GetStatsByCANIDResponse
-	14, // 31: proto.canService.GetCANopenMessages:output_type -> proto.GetCANopenMessagesResponse
-	17, // 32: proto.canService.GetCANopenStats:output_type -> proto.GetCANopenStatsResponse
-	27, // [27:33] is the sub-list for method output_type
-	21, // [21:27] is the sub-list for method input_type
-	21, // [21:21] is the sub-list for extension type_name
-	21, // [21:21] is the sub-list for extension extendee
-	0,  // [0:21] is the sub-list for field type_name
+	0,  // 2: proto.GetCANopenMessagesRequest.filter:type_name -> proto.QueryFilter
+	18, // 3: proto.GetCANopenMessagesRequest.pdo_mappings:type_name -> proto.GetCANopenMessagesRequest.PdoMappingsEntry
+	20, // 4: proto.CANopenMessage.timestamp:type_name -> google.protobuf.Timestamp
+	19, // 5: proto.CANopenMessage.parsed_data:type_name -> proto.CANopenMessage.ParsedDataEntry
+	2,  // 6: proto.GetCANopenMessagesResponse.messages:type_name -> proto.CANopenMessage
+	20, // 7: proto.ReplicatedFrame.timestamp:type_name -> google.protobuf.Timestamp
+	4,  // 8: proto.ReplicationBatch.frames:type_name -> proto.ReplicatedFrame
+	20, // 9: proto.IngestFrame.timestamp:type_name -> google.protobuf.Timestamp
+	7,  // 10: proto.IngestBatch.frames:type_name -> proto.IngestFrame
+	20, // 11: proto.SendFrameResponse.tx_timestamp:type_name -> google.protobuf.Timestamp
+	17, // 12: proto.ListCyclicJobsResponse.jobs:type_name -> proto.CyclicJob
+	20, // 13: proto.CyclicJob.created_at:type_name -> google.protobuf.Timestamp
+	20, // 14: proto.CyclicJob.last_tx:type_name -> google.protobuf.Timestamp
+	1,  // 15: proto.canService.GetCANopenMessages:input_type -> proto.GetCANopenMessagesRequest
+	5,  // 16: proto.canService.ReplicateFrames:input_type -> proto.ReplicationBatch
+	8,  // 17: proto.canService.IngestFrames:input_type -> proto.IngestBatch
+	10, // 18: proto.canService.SendFrame:input_type -> proto.SendFrameRequest
+	12, // 19: proto.canService.CreateCyclicJob:input_type -> proto.CreateCyclicJobRequest
+	13, // 20: proto.canService.UpdateCyclicJob:input_type -> proto.UpdateCyclicJobRequest
+	14, // 21: proto.canService.DeleteCyclicJob:input_type -> proto.DeleteCyclicJobRequest
+	15, // 22: proto.canService.ListCyclicJobs:input_type -> proto.ListCyclicJobsRequest
+	3,  // 23: proto.canService.GetCANopenMessages:output_type -> proto.GetCANopenMessagesResponse
+	6,  // 24: proto.canService.ReplicateFrames:output_type -> proto.ReplicationAck
+	9,  // 25: proto.canService.IngestFrames:output_type -> proto.IngestSummary
+	11, // 26: proto.canService.SendFrame:output_type -> proto.SendFrameResponse
+	17, // 27: proto.canService.CreateCyclicJob:output_type -> proto.CyclicJob
+	17, // 28: proto.canService.UpdateCyclicJob:output_type -> proto.CyclicJob
+	17, // 29: proto.canService.DeleteCyclicJob:output_type -> proto.CyclicJob
+	16, // 30: proto.canService.ListCyclicJobs:output_type -> proto.ListCyclicJobsResponse
+	23, // [23:31] is the sub-list for method output_type
+	15, // [15:23] is the sub-list for method input_type
+	15, // [15:15] is the sub-list for extension type_name
+	15, // [15:15] is the sub-list for extension extendee
+	0,  // [0:15] is the sub-list for field type_name
 }
 
 func init() { file_internal_proto_can_can_proto_init() }
@@ -1219,7 +1615,10 @@ func file_internal_proto_can_can_proto_init() {
 		return
 	}
 	file_internal_proto_can_can_proto_msgTypes[0].OneofWrappers = []any{}
-	file_internal_proto_can_can_proto_msgTypes[12].OneofWrappers = []any{}
+	file_internal_proto_can_can_proto_msgTypes[1].OneofWrappers = []any{}
+	file_internal_proto_can_can_proto_msgTypes[7].OneofWrappers = []any{}
+	file_internal_proto_can_can_proto_msgTypes[13].OneofWrappers = []any{}
+	file_internal_proto_can_can_proto_msgTypes[17].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
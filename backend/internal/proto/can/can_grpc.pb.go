@@ -19,30 +19,33 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	CanService_GetMessages_FullMethodName        = "/proto.canService/GetMessages"
-	CanService_GetMessageCount_FullMethodName    = "/proto.canService/GetMessageCount"
-	CanService_GetUniqueCANIDs_FullMethodName    = "/proto.canService/GetUniqueCANIDs"
-	CanService_GetStatsByCANID_FullMethodName    = "/proto.canService/GetStatsByCANID"
 	CanService_GetCANopenMessages_FullMethodName = "/proto.canService/GetCANopenMessages"
-	CanService_GetCANopenStats_FullMethodName    = "/proto.canService/GetCANopenStats"
+	CanService_ReplicateFrames_FullMethodName    = "/proto.canService/ReplicateFrames"
+	CanService_IngestFrames_FullMethodName       = "/proto.canService/IngestFrames"
+	CanService_SendFrame_FullMethodName          = "/proto.canService/SendFrame"
+	CanService_CreateCyclicJob_FullMethodName    = "/proto.canService/CreateCyclicJob"
+	CanService_UpdateCyclicJob_FullMethodName    = "/proto.canService/UpdateCyclicJob"
+	CanService_DeleteCyclicJob_FullMethodName    = "/proto.canService/DeleteCyclicJob"
+	CanService_ListCyclicJobs_FullMethodName     = "/proto.canService/ListCyclicJobs"
 )
 
 // CanServiceClient is the client API for CanService service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type CanServiceClient interface {
-	// Get CAN messages with optional filters
-	GetMessages(ctx context.Context, in *GetMessagesRequest, opts ...grpc.CallOption) (*GetMessagesResponse, error)
-	// Get message count with optional filters
-	GetMessageCount(ctx context.Context, in *GetMessageCountRequest, opts ...grpc.CallOption) (*GetMessageCountResponse, error)
-	// Get unique CAN IDs
-	GetUniqueCANIDs(ctx context.Context, in *GetUniqueCANIDsRequest, opts ...grpc.CallOption) (*GetUniqueCANIDsResponse, error)
-	// Get statistics grouped by CAN ID
-	GetStatsByCANID(ctx context.Context, in *GetStatsByCANIDRequest, opts ...grpc.CallOption) (*GetStatsByCANIDResponse, error)
 	// Get CANopen messages classified by message type
 	GetCANopenMessages(ctx context.Context, in *GetCANopenMessagesRequest, opts ...grpc.CallOption) (*GetCANopenMessagesResponse, error)
-	// Get CANopen statistics grouped by message type
-	GetCANopenStats(ctx context.Context, in *GetCANopenStatsRequest, opts ...grpc.CallOption) (*GetCANopenStatsResponse, error)
+	// Replicate the frames stored by an edge can-reader; acknowledges the frames stored centrally
+	ReplicateFrames(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ReplicationBatch, ReplicationAck], error)
+	// Ingest frames of remote producers (gateways, simulators, other loggers) through the batched writer
+	IngestFrames(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[IngestBatch, IngestSummary], error)
+	// Transmit a single frame and wait for its echo or loopback confirmation
+	SendFrame(ctx context.Context, in *SendFrameRequest, opts ...grpc.CallOption) (*SendFrameResponse, error)
+	// Cyclic transmission jobs, timed by the kernel Broadcast Manager or a user-space timer
+	CreateCyclicJob(ctx context.Context, in *CreateCyclicJobRequest, opts ...grpc.CallOption) (*CyclicJob, error)
+	UpdateCyclicJob(ctx context.Context, in *UpdateCyclicJobRequest, opts ...grpc.CallOption) (*CyclicJob, error)
+	DeleteCyclicJob(ctx context.Context, in *DeleteCyclicJobRequest, opts ...grpc.CallOption) (*CyclicJob, error)
+	ListCyclicJobs(ctx context.Context, in *ListCyclicJobsRequest, opts ...grpc.CallOption) (*ListCyclicJobsResponse, error)
 }
 
 type canServiceClient struct {
@@ -53,60 +56,86 @@ func NewCanServiceClient(cc grpc.ClientConnInterface) CanServiceClient {
 	return &canServiceClient{cc}
 }
 
-func (c *canServiceClient) GetMessages(ctx context.Context, in *GetMessagesRequest, opts ...grpc.CallOption) (*GetMessagesResponse, error) {
+func (c *canServiceClient) GetCANopenMessages(ctx context.Context, in *GetCANopenMessagesRequest, opts ...grpc.CallOption) (*GetCANopenMessagesResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	out := new(GetMessagesResponse)
-	err := c.cc.Invoke(ctx, CanService_GetMessages_FullMethodName, in, out, cOpts...)
+	out := new(GetCANopenMessagesResponse)
+	err := c.cc.Invoke(ctx, CanService_GetCANopenMessages_FullMethodName, in, out, cOpts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *canServiceClient) GetMessageCount(ctx context.Context, in *GetMessageCountRequest, opts ...grpc.CallOption) (*GetMessageCountResponse, error) {
+func (c *canServiceClient) ReplicateFrames(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ReplicationBatch, ReplicationAck], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CanService_ServiceDesc.Streams[0], CanService_ReplicateFrames_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ReplicationBatch, ReplicationAck]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CanService_ReplicateFramesClient = grpc.ClientStreamingClient[ReplicationBatch, ReplicationAck]
+
+func (c *canServiceClient) IngestFrames(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[IngestBatch, IngestSummary], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CanService_ServiceDesc.Streams[1], CanService_IngestFrames_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[IngestBatch, IngestSummary]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CanService_IngestFramesClient = grpc.ClientStreamingClient[IngestBatch, IngestSummary]
+
+func (c *canServiceClient) SendFrame(ctx context.Context, in *SendFrameRequest, opts ...grpc.CallOption) (*SendFrameResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	out := new(GetMessageCountResponse)
-	err := c.cc.Invoke(ctx, CanService_GetMessageCount_FullMethodName, in, out, cOpts...)
+	out := new(SendFrameResponse)
+	err := c.cc.Invoke(ctx, CanService_SendFrame_FullMethodName, in, out, cOpts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *canServiceClient) GetUniqueCANIDs(ctx context.Context, in *GetUniqueCANIDsRequest, opts ...grpc.CallOption) (*GetUniqueCANIDsResponse, error) {
+func (c *canServiceClient) CreateCyclicJob(ctx context.Context, in *CreateCyclicJobRequest, opts ...grpc.CallOption) (*CyclicJob, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	out := new(GetUniqueCANIDsResponse)
-	err := c.cc.Invoke(ctx, CanService_GetUniqueCANIDs_FullMethodName, in, out, cOpts...)
+	out := new(CyclicJob)
+	err := c.cc.Invoke(ctx, CanService_CreateCyclicJob_FullMethodName, in, out, cOpts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *canServiceClient) GetStatsByCANID(ctx context.Context, in *GetStatsByCANIDRequest, opts ...grpc.CallOption) (*GetStatsByCANIDResponse, error) {
+func (c *canServiceClient) UpdateCyclicJob(ctx context.Context, in *UpdateCyclicJobRequest, opts ...grpc.CallOption) (*CyclicJob, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	out := new(GetStatsByCANIDResponse)
-	err := c.cc.Invoke(ctx, CanService_GetStatsByCANID_FullMethodName, in, out, cOpts...)
+	out := new(CyclicJob)
+	err := c.cc.Invoke(ctx, CanService_UpdateCyclicJob_FullMethodName, in, out, cOpts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *canServiceClient) GetCANopenMessages(ctx context.Context, in *GetCANopenMessagesRequest, opts ...grpc.CallOption) (*GetCANopenMessagesResponse, error) {
+func (c *canServiceClient) DeleteCyclicJob(ctx context.Context, in *DeleteCyclicJobRequest, opts ...grpc.CallOption) (*CyclicJob, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	out := new(GetCANopenMessagesResponse)
-	err := c.cc.Invoke(ctx, CanService_GetCANopenMessages_FullMethodName, in, out, cOpts...)
+	out := new(CyclicJob)
+	err := c.cc.Invoke(ctx, CanService_DeleteCyclicJob_FullMethodName, in, out, cOpts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *canServiceClient) GetCANopenStats(ctx context.Context, in *GetCANopenStatsRequest, opts ...grpc.CallOption) (*GetCANopenStatsResponse, error) {
+func (c *canServiceClient) ListCyclicJobs(ctx context.Context, in *ListCyclicJobsRequest, opts ...grpc.CallOption) (*ListCyclicJobsResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	out := new(GetCANopenStatsResponse)
-	err := c.cc.Invoke(ctx, CanService_GetCANopenStats_FullMethodName, in, out, cOpts...)
+	out := new(ListCyclicJobsResponse)
+	err := c.cc.Invoke(ctx, CanService_ListCyclicJobs_FullMethodName, in, out, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -117,18 +146,19 @@ func (c *canServiceClient) GetCANopenStats(ctx context.Context, in *GetCANopenSt
 // All implementations must embed UnimplementedCanServiceServer
 // for forward compatibility.
 type CanServiceServer interface {
-	// Get CAN messages with optional filters
-	GetMessages(context.Context, *GetMessagesRequest) (*GetMessagesResponse, error)
-	// Get message count with optional filters
-	GetMessageCount(context.Context, *GetMessageCountRequest) (*GetMessageCountResponse, error)
-	// Get unique CAN IDs
-	GetUniqueCANIDs(context.Context, *GetUniqueCANIDsRequest) (*GetUniqueCANIDsResponse, error)
-	// Get statistics grouped by CAN ID
-	GetStatsByCANID(context.Context, *GetStatsByCANIDRequest) (*GetStatsByCANIDResponse, error)
 	// Get CANopen messages classified by message type
 	GetCANopenMessages(context.Context, *GetCANopenMessagesRequest) (*GetCANopenMessagesResponse, error)
-	// Get CANopen statistics grouped by message type
-	GetCANopenStats(context.Context, *GetCANopenStatsRequest) (*GetCANopenStatsResponse, error)
+	// Replicate the frames stored by an edge can-reader; acknowledges the frames stored centrally
+	ReplicateFrames(grpc.ClientStreamingServer[ReplicationBatch, ReplicationAck]) error
+	// Ingest frames of remote producers (gateways, simulators, other loggers) through the batched writer
+	IngestFrames(grpc.ClientStreamingServer[IngestBatch, IngestSummary]) error
+	// Transmit a single frame and wait for its echo or loopback confirmation
+	SendFrame(context.Context, *SendFrameRequest) (*SendFrameResponse, error)
+	// Cyclic transmission jobs, timed by the kernel Broadcast Manager or a user-space timer
+	CreateCyclicJob(context.Context, *CreateCyclicJobRequest) (*CyclicJob, error)
+	UpdateCyclicJob(context.Context, *UpdateCyclicJobRequest) (*CyclicJob, error)
+	DeleteCyclicJob(context.Context, *DeleteCyclicJobRequest) (*CyclicJob, error)
+	ListCyclicJobs(context.Context, *ListCyclicJobsRequest) (*ListCyclicJobsResponse, error)
 	mustEmbedUnimplementedCanServiceServer()
 }
 
@@ -139,23 +169,29 @@ type CanServiceServer interface {
 // pointer dereference when methods are called.
 type UnimplementedCanServiceServer struct{}
 
-func (UnimplementedCanServiceServer) GetMessages(context.Context, *GetMessagesRequest) (*GetMessagesResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "method GetMessages not implemented")
+func (UnimplementedCanServiceServer) GetCANopenMessages(context.Context, *GetCANopenMessagesRequest) (*GetCANopenMessagesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetCANopenMessages not implemented")
 }
-func (UnimplementedCanServiceServer) GetMessageCount(context.Context, *GetMessageCountRequest) (*GetMessageCountResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "method GetMessageCount not implemented")
+func (UnimplementedCanServiceServer) ReplicateFrames(grpc.ClientStreamingServer[ReplicationBatch, ReplicationAck]) error {
+	return status.Error(codes.Unimplemented, "method ReplicateFrames not implemented")
 }
-func (UnimplementedCanServiceServer) GetUniqueCANIDs(context.Context, *GetUniqueCANIDsRequest) (*GetUniqueCANIDsResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "method GetUniqueCANIDs not implemented")
+func (UnimplementedCanServiceServer) IngestFrames(grpc.ClientStreamingServer[IngestBatch, IngestSummary]) error {
+	return status.Error(codes.Unimplemented, "method IngestFrames not implemented")
 }
-func (UnimplementedCanServiceServer) GetStatsByCANID(context.Context, *GetStatsByCANIDRequest) (*GetStatsByCANIDResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "method GetStatsByCANID not implemented")
+func (UnimplementedCanServiceServer) SendFrame(context.Context, *SendFrameRequest) (*SendFrameResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method SendFrame not implemented")
 }
-func (UnimplementedCanServiceServer) GetCANopenMessages(context.Context, *GetCANopenMessagesRequest) (*GetCANopenMessagesResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "method GetCANopenMessages not implemented")
+func (UnimplementedCanServiceServer) CreateCyclicJob(context.Context, *CreateCyclicJobRequest) (*CyclicJob, error) {
+	return nil, status.Error(codes.Unimplemented, "method CreateCyclicJob not implemented")
+}
+func (UnimplementedCanServiceServer) UpdateCyclicJob(context.Context, *UpdateCyclicJobRequest) (*CyclicJob, error) {
+	return nil, status.Error(codes.Unimplemented, "method UpdateCyclicJob not implemented")
+}
+func (UnimplementedCanServiceServer) DeleteCyclicJob(context.Context, *DeleteCyclicJobRequest) (*CyclicJob, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeleteCyclicJob not implemented")
 }
-func (UnimplementedCanServiceServer) GetCANopenStats(context.Context, *GetCANopenStatsRequest) (*GetCANopenStatsResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "method GetCANopenStats not implemented")
+func (UnimplementedCanServiceServer) ListCyclicJobs(context.Context, *ListCyclicJobsRequest) (*ListCyclicJobsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListCyclicJobs not implemented")
 }
 func (UnimplementedCanServiceServer) mustEmbedUnimplementedCanServiceServer() {}
 func (UnimplementedCanServiceServer) testEmbeddedByValue()                    {}
@@ -178,110 +214,124 @@ func RegisterCanServiceServer(s grpc.ServiceRegistrar, srv CanServiceServer) {
 	s.RegisterService(&CanService_ServiceDesc, srv)
 }
 
-func _CanService_GetMessages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetMessagesRequest)
+func _CanService_GetCANopenMessages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCANopenMessagesRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(CanServiceServer).GetMessages(ctx, in)
+		return srv.(CanServiceServer).GetCANopenMessages(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: CanService_GetMessages_FullMethodName,
+		FullMethod: CanService_GetCANopenMessages_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(CanServiceServer).GetMessages(ctx, req.(*GetMessagesRequest))
+		return srv.(CanServiceServer).GetCANopenMessages(ctx, req.(*GetCANopenMessagesRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _CanService_GetMessageCount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetMessageCountRequest)
+func _CanService_ReplicateFrames_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(CanServiceServer).ReplicateFrames(&grpc.GenericServerStream[ReplicationBatch, ReplicationAck]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CanService_ReplicateFramesServer = grpc.ClientStreamingServer[ReplicationBatch, ReplicationAck]
+
+func _CanService_IngestFrames_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(CanServiceServer).IngestFrames(&grpc.GenericServerStream[IngestBatch, IngestSummary]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CanService_IngestFramesServer = grpc.ClientStreamingServer[IngestBatch, IngestSummary]
+
+func _CanService_SendFrame_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendFrameRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(CanServiceServer).GetMessageCount(ctx, in)
+		return srv.(CanServiceServer).SendFrame(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: CanService_GetMessageCount_FullMethodName,
+		FullMethod: CanService_SendFrame_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(CanServiceServer).GetMessageCount(ctx, req.(*GetMessageCountRequest))
+		return srv.(CanServiceServer).SendFrame(ctx, req.(*SendFrameRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _CanService_GetUniqueCANIDs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetUniqueCANIDsRequest)
+func _CanService_CreateCyclicJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateCyclicJobRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(CanServiceServer).GetUniqueCANIDs(ctx, in)
+		return srv.(CanServiceServer).CreateCyclicJob(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: CanService_GetUniqueCANIDs_FullMethodName,
+		FullMethod: CanService_CreateCyclicJob_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(CanServiceServer).GetUniqueCANIDs(ctx, req.(*GetUniqueCANIDsRequest))
+		return srv.(CanServiceServer).CreateCyclicJob(ctx, req.(*CreateCyclicJobRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _CanService_GetStatsByCANID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetStatsByCANIDRequest)
+func _CanService_UpdateCyclicJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateCyclicJobRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(CanServiceServer).GetStatsByCANID(ctx, in)
+		return srv.(CanServiceServer).UpdateCyclicJob(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: CanService_GetStatsByCANID_FullMethodName,
+		FullMethod: CanService_UpdateCyclicJob_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(CanServiceServer).GetStatsByCANID(ctx, req.(*GetStatsByCANIDRequest))
+		return srv.(CanServiceServer).UpdateCyclicJob(ctx, req.(*UpdateCyclicJobRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _CanService_GetCANopenMessages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetCANopenMessagesRequest)
+func _CanService_DeleteCyclicJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteCyclicJobRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(CanServiceServer).GetCANopenMessages(ctx, in)
+		return srv.(CanServiceServer).DeleteCyclicJob(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: CanService_GetCANopenMessages_FullMethodName,
+		FullMethod: CanService_DeleteCyclicJob_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(CanServiceServer).GetCANopenMessages(ctx, req.(*GetCANopenMessagesRequest))
+		return srv.(CanServiceServer).DeleteCyclicJob(ctx, req.(*DeleteCyclicJobRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _CanService_GetCANopenStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetCANopenStatsRequest)
+func _CanService_ListCyclicJobs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCyclicJobsRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(CanServiceServer).GetCANopenStats(ctx, in)
+		return srv.(CanServiceServer).ListCyclicJobs(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: CanService_GetCANopenStats_FullMethodName,
+		FullMethod: CanService_ListCyclicJobs_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(CanServiceServer).GetCANopenStats(ctx, req.(*GetCANopenStatsRequest))
+		return srv.(CanServiceServer).ListCyclicJobs(ctx, req.(*ListCyclicJobsRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -294,30 +344,41 @@ var CanService_ServiceDesc = grpc.ServiceDesc{
 	HandlerType: (*CanServiceServer)(nil),
 	Methods: []grpc.MethodDesc{
 		{
-			MethodName: "GetMessages",
-			Handler:    _CanService_GetMessages_Handler,
+			MethodName: "GetCANopenMessages",
+			Handler:    _CanService_GetCANopenMessages_Handler,
 		},
 		{
-			MethodName: "GetMessageCount",
-			Handler:    _CanService_GetMessageCount_Handler,
+			MethodName: "SendFrame",
+			Handler:    _CanService_SendFrame_Handler,
 		},
 		{
-			MethodName: "GetUniqueCANIDs",
-			Handler:    _CanService_GetUniqueCANIDs_Handler,
+			MethodName: "CreateCyclicJob",
+			Handler:    _CanService_CreateCyclicJob_Handler,
 		},
 		{
-			MethodName: "GetStatsByCANID",
-			Handler:    _CanService_GetStatsByCANID_Handler,
+			MethodName: "UpdateCyclicJob",
+			Handler:    _CanService_UpdateCyclicJob_Handler,
 		},
 		{
-			MethodName: "GetCANopenMessages",
-			Handler:    _CanService_GetCANopenMessages_Handler,
+			MethodName: "DeleteCyclicJob",
+			Handler:    _CanService_DeleteCyclicJob_Handler,
+		},
+		{
+			MethodName: "ListCyclicJobs",
+			Handler:    _CanService_ListCyclicJobs_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ReplicateFrames",
+			Handler:       _CanService_ReplicateFrames_Handler,
+			ClientStreams: true,
 		},
 		{
-			MethodName: "GetCANopenStats",
-			Handler:    _CanService_GetCANopenStats_Handler,
+			StreamName:    "IngestFrames",
+			Handler:       _CanService_IngestFrames_Handler,
+			ClientStreams: true,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "internal/proto/can/can.proto",
 }
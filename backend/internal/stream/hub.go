@@ -0,0 +1,159 @@
+package stream
+
+import (
+	"can-db-writer/internal/models"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// Hub fans out live frames and events to WebSocket subscribers
+type Hub struct {
+	mu      sync.RWMutex
+	clients map[*client]struct{}
+}
+
+// client is a single live stream subscriber
+type client struct {
+	types map[string]bool // Subscribed message types, empty = all
+	send  chan []byte
+}
+
+// NewHub creates a new live stream hub
+func NewHub() *Hub {
+	return &Hub{
+		clients: make(map[*client]struct{}),
+	}
+}
+
+// Publish sends a message to every subscriber of its type
+// Slow subscribers drop messages instead of blocking the caller.
+func (h *Hub) Publish(msg models.StreamMessage) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if len(h.clients) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		fmt.Printf("Failed to marshal stream message: %v\n", err)
+		return
+	}
+
+	for c := range h.clients {
+		if len(c.types) > 0 && !c.types[msg.Type] {
+			continue
+		}
+		select {
+		case c.send <- payload:
+		default:
+		}
+	}
+}
+
+// PublishFrame publishes a received CAN frame
+func (h *Hub) PublishFrame(msg models.CANMessage) {
+	h.Publish(models.NewFrameStreamMessage(msg))
+}
+
+// PublishEvent publishes a detected event
+func (h *Hub) PublishEvent(event models.CANEvent) {
+	h.Publish(models.NewEventStreamMessage(event))
+}
+
+// PublishSniffer publishes the payload changes of the past sniffer interval
+func (h *Hub) PublishSniffer(changes []models.PayloadChanges) {
+	h.Publish(models.NewSnifferStreamMessage(changes))
+}
+
+// PublishAlert publishes an alert notification
+func (h *Hub) PublishAlert(alert models.Alert) {
+	h.Publish(models.NewAlertStreamMessage(alert))
+}
+
+// subscribe registers a new subscriber
+func (h *Hub) subscribe(types []string) *client {
+	c := &client{
+		types: make(map[string]bool),
+		send:  make(chan []byte, 1000),
+	}
+	for _, t := range types {
+		if t = strings.TrimSpace(t); t != "" {
+			c.types[t] = true
+		}
+	}
+
+	h.mu.Lock()
+	h.clients[c] = struct{}{}
+	h.mu.Unlock()
+
+	return c
+}
+
+// unsubscribe removes a subscriber
+func (h *Hub) unsubscribe(c *client) {
+	h.mu.Lock()
+	delete(h.clients, c)
+	h.mu.Unlock()
+}
+
+// Handler returns the WebSocket handler for the live stream
+// GET /ws?types=frame,event,sniffer,alert (types is optional, default: all)
+func (h *Hub) Handler() http.Handler {
+	return websocket.Server{
+		// Accept any origin, like the CORS policy of the HTTP API
+		Handshake: func(config *websocket.Config, r *http.Request) error {
+			return nil
+		},
+		Handler: h.serveClient,
+	}
+}
+
+// serveClient streams messages to a WebSocket client until it disconnects
+func (h *Hub) serveClient(ws *websocket.Conn) {
+	defer ws.Close()
+
+	var types []string
+	if typesStr := ws.Request().URL.Query().Get("types"); typesStr != "" {
+		types = strings.Split(typesStr, ",")
+	}
+
+	c := h.subscribe(types)
+	defer h.unsubscribe(c)
+
+	log.Printf("Live stream client connected: %s", ws.Request().RemoteAddr)
+
+	// Detect disconnects by reading until the client goes away
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		var discard string
+		for {
+			if err := websocket.Message.Receive(ws, &discard); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case payload := <-c.send:
+			ws.SetWriteDeadline(time.Now().Add(5 * time.Second))
+			if err := websocket.Message.Send(ws, string(payload)); err != nil {
+				log.Printf("Live stream client %s disconnected: %v", ws.Request().RemoteAddr, err)
+				return
+			}
+		case <-closed:
+			log.Printf("Live stream client disconnected: %s", ws.Request().RemoteAddr)
+			return
+		}
+	}
+}
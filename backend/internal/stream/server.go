@@ -0,0 +1,51 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Server serves the live stream over HTTP/WebSocket
+type Server struct {
+	server *http.Server
+	hub    *Hub
+}
+
+// NewServer creates a live stream server on the given port
+func NewServer(port int, hub *Hub) *Server {
+	mux := http.NewServeMux()
+	mux.Handle("/ws", hub.Handler())
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"healthy"}`))
+	})
+
+	return &Server{
+		server: &http.Server{
+			Addr:    fmt.Sprintf(":%d", port),
+			Handler: mux,
+			// No ReadTimeout: it would also apply to the hijacked WebSocket connections
+			ReadHeaderTimeout: 10 * time.Second,
+			IdleTimeout:       60 * time.Second,
+		},
+		hub: hub,
+	}
+}
+
+// Start starts the live stream server
+func (s *Server) Start() error {
+	log.Printf("Live stream available at ws://localhost%s/ws", s.server.Addr)
+	if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// Stop gracefully stops the live stream server
+func (s *Server) Stop(ctx context.Context) error {
+	log.Println("Stopping live stream server...")
+	return s.server.Shutdown(ctx)
+}